@@ -0,0 +1,40 @@
+package main
+
+import (
+	"myshop/internal/model"
+	"myshop/internal/service"
+	"myshop/pkg/utils"
+	"testing"
+)
+
+func TestCreateAdmin(t *testing.T) {
+	a := newTestApp(t)
+	args := []string{"-username", "root", "-email", " Root@Example.com ", "-password", "secret123"}
+	if err := runCreateAdmin(a, args); err != nil {
+		t.Fatal(err)
+	}
+
+	var user model.User
+	if err := a.db.Where("username = ?", "root").First(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != model.RoleAdmin || user.Email != "root@example.com" || user.EmailVerifiedAt == nil {
+		t.Fatalf("user = %+v", user)
+	}
+	if !utils.CheckPassword("secret123", user.Password) {
+		t.Fatal("password not set")
+	}
+
+	var logs int64
+	a.db.Model(&model.AuditLog{}).Where("resource_type = ? AND auth_method = ?", model.AuditResourceUser, service.AuthMethodCLI).Count(&logs)
+	if logs != 1 {
+		t.Fatalf("got %d audit logs, want 1", logs)
+	}
+
+	if err := runCreateAdmin(a, args); err != service.ErrUserExists {
+		t.Fatalf("err = %v, want ErrUserExists", err)
+	}
+	if err := runCreateAdmin(a, []string{"-username", "root2", "-password", "123"}); err == nil {
+		t.Fatal("expected error for short password")
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"os"
+	"time"
+)
+
+// 导出数据的类型
+const (
+	dataTypeProducts = "products"
+	dataTypeOrders   = "orders"
+)
+
+// exportBatchSize 导出时每批读取的记录数
+const exportBatchSize = 500
+
+// dataFile 导出文件格式，import读取同样的格式
+type dataFile struct {
+	Type       string          `json:"type"`
+	ExportedAt time.Time       `json:"exported_at"`
+	Products   []model.Product `json:"products,omitempty"`
+	Orders     []model.Order   `json:"orders,omitempty"`
+}
+
+// runExport 执行export子命令，导出商品或订单数据为JSON
+// 订单包含收货人姓名、电话和地址，导出文件应按个人信息妥善保管
+func runExport(a *app, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	dataType := fs.String("type", dataTypeProducts, "导出的数据类型: products/orders")
+	output := fs.String("o", "-", "输出文件，-表示标准输出")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dataType != dataTypeProducts && *dataType != dataTypeOrders {
+		return fmt.Errorf("不支持的数据类型: %s", *dataType)
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	data := dataFile{Type: *dataType, ExportedAt: time.Now()}
+	switch *dataType {
+	case dataTypeProducts:
+		productRepo := repository.NewProductRepository(a.db)
+		var lastID uint
+		for {
+			products, err := productRepo.ListAfter(ctx, lastID, exportBatchSize)
+			if err != nil {
+				return err
+			}
+			if len(products) == 0 {
+				break
+			}
+			data.Products = append(data.Products, products...)
+			lastID = products[len(products)-1].ID
+		}
+	case dataTypeOrders:
+		orderRepo := repository.NewOrderRepository(a.db)
+		var lastID uint
+		for {
+			orders, err := orderRepo.ListAfter(ctx, lastID, exportBatchSize)
+			if err != nil {
+				return err
+			}
+			if len(orders) == 0 {
+				break
+			}
+			data.Orders = append(data.Orders, orders...)
+			lastID = orders[len(orders)-1].ID
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return err
+	}
+
+	if *output != "-" {
+		fmt.Printf("已导出%d个商品、%d个订单到%s\n", len(data.Products), len(data.Orders), *output)
+	}
+	return nil
+}
+
+// runImport 执行import子命令，从export导出的文件导入商品
+// 按商品ID匹配，已存在的商品更新，不存在的按原ID创建，便于在环境之间复制商品目录；
+// 按原ID创建后调整自增序列，之后新建的商品不会与导入的ID冲突
+// 订单关联用户和库存，只支持导出不支持导入
+func runImport(a *app, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	input := fs.String("i", "", "输入文件，-表示标准输入")
+	dryRun := fs.Bool("dry-run", false, "只检查数据并输出将要执行的操作，不写入数据库")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" {
+		return errors.New("缺少输入文件，使用 -i 指定")
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var data dataFile
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return fmt.Errorf("解析导入文件失败: %w", err)
+	}
+	if data.Type != dataTypeProducts {
+		return fmt.Errorf("不支持导入的数据类型: %s，只能导入products", data.Type)
+	}
+	for i, p := range data.Products {
+		if p.Name == "" || !p.Price.IsPositive() || p.Stock < 0 {
+			return fmt.Errorf("第%d个商品数据无效: 名称不能为空，价格和库存不能为负数", i+1)
+		}
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+
+	catalog, err := a.catalog()
+	if err != nil {
+		return err
+	}
+	productRepo := repository.NewProductRepository(a.db)
+	ctx := cliContext()
+
+	var created, updated int
+	for i := range data.Products {
+		product := data.Products[i]
+		exists := false
+		if product.ID != 0 {
+			_, err := productRepo.GetByID(ctx, product.ID)
+			exists = err == nil
+		}
+		if *dryRun {
+			if exists {
+				updated++
+			} else {
+				created++
+			}
+			continue
+		}
+
+		if exists {
+			err = catalog.Update(ctx, &product)
+			updated++
+		} else {
+			err = catalog.Create(ctx, &product)
+			created++
+		}
+		if err != nil {
+			return fmt.Errorf("导入商品%s失败: %w", product.Name, err)
+		}
+	}
+
+	if *dryRun {
+		fmt.Printf("检查通过，将创建%d个商品、更新%d个商品\n", created, updated)
+		return nil
+	}
+	if created > 0 {
+		if err := resetIDSequence(a, "products"); err != nil {
+			return fmt.Errorf("调整商品ID序列失败: %w", err)
+		}
+	}
+	fmt.Printf("已创建%d个商品、更新%d个商品\n", created, updated)
+	return nil
+}
+
+// resetIDSequence 把PostgreSQL表的自增序列调整到当前最大ID之后
+// 显式指定ID插入不会推进PostgreSQL的序列；MySQL和SQLite的自增值会自动调整，无需处理
+func resetIDSequence(a *app, table string) error {
+	if a.cfg.Database.Driver != "postgres" {
+		return nil
+	}
+	return a.db.Exec("SELECT setval(pg_get_serial_sequence(?, 'id'), COALESCE((SELECT MAX(id) FROM "+table+"), 0) + 1, false)", table).Error
+}
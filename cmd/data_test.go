@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"myshop/internal/config"
+	"myshop/internal/migrations"
+	"myshop/internal/model"
+	"myshop/pkg/migrate"
+	"myshop/pkg/money"
+	"myshop/pkg/utils"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestApp 创建使用内存数据库并已执行全部迁移的app
+func newTestApp(t *testing.T) *app {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	m, err := migrate.New(db, migrations.All())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return &app{cfg: &config.Config{Database: config.DatabaseConfig{Driver: "sqlite", Path: config.SQLiteMemory}}, db: db}
+}
+
+func TestSeedIsIdempotent(t *testing.T) {
+	a := newTestApp(t)
+	for i := 0; i < 2; i++ {
+		if err := runSeed(a, nil); err != nil {
+			t.Fatalf("第%d次seed失败: %v", i+1, err)
+		}
+	}
+
+	var users, products, orders int64
+	a.db.Model(&model.User{}).Count(&users)
+	a.db.Model(&model.Product{}).Count(&products)
+	a.db.Model(&model.Order{}).Count(&orders)
+	if users != int64(len(seedUsers)) || products != int64(len(seedProducts)) || orders != int64(len(seedOrders)) {
+		t.Fatalf("got %d users, %d products, %d orders", users, products, orders)
+	}
+
+	var admin model.User
+	a.db.Where("username = ?", "demo_admin").First(&admin)
+	if admin.Role != model.RoleAdmin {
+		t.Fatalf("demo_admin role = %q", admin.Role)
+	}
+	if utils.CheckPassword(defaultSeedPassword, admin.Password) {
+		t.Fatal("演示管理员不应使用默认密码")
+	}
+}
+
+func TestSeedRequiresDevDatabase(t *testing.T) {
+	a := newTestApp(t)
+	a.cfg.Database.Driver = "postgres"
+	if err := runSeed(a, nil); err == nil {
+		t.Fatal("向非sqlite数据库写入演示数据应被拒绝")
+	}
+	var users int64
+	a.db.Model(&model.User{}).Count(&users)
+	if users != 0 {
+		t.Fatalf("拒绝后不应写入数据, users = %d", users)
+	}
+	if err := runSeed(a, []string{"-force-dev"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImportProducts(t *testing.T) {
+	src := newTestApp(t)
+	if err := runSeed(src, nil); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "products.json")
+	if err := runExport(src, []string{"-type", "products", "-o", file}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 修改导出文件中的一个商品，导入到已有该商品的库时应更新
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data dataFile
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Products) != len(seedProducts) {
+		t.Fatalf("exported %d products, want %d", len(data.Products), len(seedProducts))
+	}
+	data.Products[0].Price = money.MustParse("1", "CNY")
+	raw, _ = json.Marshal(data)
+	if err := os.WriteFile(file, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := newTestApp(t)
+	if err := runImport(dst, []string{"-i", file, "-dry-run"}); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	dst.db.Model(&model.Product{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("dry run wrote %d products", count)
+	}
+
+	if err := runImport(dst, []string{"-i", file}); err != nil {
+		t.Fatal(err)
+	}
+	if err := runImport(src, []string{"-i", file}); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range []*app{src, dst} {
+		var products []model.Product
+		a.db.Order("id").Find(&products)
+		if len(products) != len(seedProducts) {
+			t.Fatalf("got %d products, want %d", len(products), len(seedProducts))
+		}
+		if products[0].ID != data.Products[0].ID || products[0].Price.String() != "1.00" {
+			t.Fatalf("first product = %+v", products[0])
+		}
+	}
+
+	// 导入后新建的商品不能与导入的ID冲突
+	product := &model.Product{Name: "新商品", Price: money.MustParse("1", "CNY"), Status: 1}
+	if err := dst.db.Create(product).Error; err != nil {
+		t.Fatal(err)
+	}
+	if product.ID <= data.Products[len(data.Products)-1].ID {
+		t.Fatalf("new product id = %d", product.ID)
+	}
+}
+
+func TestImportRejectsOrders(t *testing.T) {
+	a := newTestApp(t)
+	file := filepath.Join(t.TempDir(), "orders.json")
+	if err := os.WriteFile(file, []byte(`{"type":"orders","orders":[]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runImport(a, []string{"-i", file}); err == nil {
+		t.Fatal("expected error importing orders")
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"myshop/internal/handler"
+	"myshop/internal/model"
+	"myshop/pkg/money"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testClient 调用完整路由的测试客户端，登录后自动携带访问令牌
+type testClient struct {
+	t        *testing.T
+	router   http.Handler
+	token    string
+	apiKey   string // 不为空时通过X-API-Key请求头认证，代替访问令牌
+	currency string // 不为空时通过X-Currency请求头指定币种
+}
+
+// newTestClient 使用内存数据库、内存缓存和内存邮件组装与serve相同的路由
+func newTestClient(t *testing.T) (*testClient, *app) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	a := newTestApp(t)
+	a.cfg.Shop.ExchangeRates = map[string]string{"USD": "0.125"}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	r, err := newRouter(ctx, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testClient{t: t, router: r}, a
+}
+
+// do 发送JSON请求，返回状态码并把响应体解码到out
+func (c *testClient) do(method, path string, body, out interface{}) int {
+	c.t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &reader)
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.currency != "" {
+		req.Header.Set("X-Currency", c.currency)
+	}
+	w := httptest.NewRecorder()
+	c.router.ServeHTTP(w, req)
+	if out != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			c.t.Fatalf("%s %s: 解析响应失败: %v, body: %s", method, path, err, w.Body.String())
+		}
+	}
+	return w.Code
+}
+
+// mustDo 发送请求并要求返回wantStatus
+func (c *testClient) mustDo(method, path string, body, out interface{}, wantStatus int) {
+	c.t.Helper()
+	if status := c.do(method, path, body, out); status != wantStatus {
+		c.t.Fatalf("%s %s: status = %d, want %d", method, path, status, wantStatus)
+	}
+}
+
+// login 注册并登录一个普通用户
+func (c *testClient) login(username string) {
+	c.t.Helper()
+	credentials := map[string]string{"username": username, "password": "password123"}
+	c.mustDo(http.MethodPost, "/api/user/register", credentials, nil, http.StatusOK)
+	var resp struct {
+		Token string `json:"token"`
+	}
+	c.mustDo(http.MethodPost, "/api/user/login", credentials, &resp, http.StatusOK)
+	if resp.Token == "" {
+		c.t.Fatal("login returned no token")
+	}
+	c.token = resp.Token
+}
+
+func TestPlaceOrderOverHTTP(t *testing.T) {
+	client, a := newTestClient(t)
+	product := &model.Product{Name: "iPhone 15", Price: money.MustParse("5999", "CNY"), Stock: 5, Status: 1}
+	if err := a.db.Create(product).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	client.login("alice")
+	order := map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": product.ID, "quantity": 2}},
+	}
+	// 没有收货地址时不能下单
+	client.mustDo(http.MethodPost, "/api/orders", order, nil, http.StatusBadRequest)
+
+	client.mustDo(http.MethodPost, "/api/user/addresses", map[string]string{
+		"name": "张三", "phone": "13800138000", "province": "广东省", "city": "深圳市", "detail": "科技园南区1栋101",
+	}, nil, http.StatusOK)
+
+	var created struct {
+		Data model.Order `json:"data"`
+	}
+	client.mustDo(http.MethodPost, "/api/orders", order, &created, http.StatusOK)
+	if created.Data.ID == 0 || created.Data.TotalPrice.String() != "11998.00" || created.Data.ShippingAddress.Name != "张三" {
+		t.Fatalf("created order = %+v", created.Data)
+	}
+	if item := created.Data.Items[0]; item.Price.String() != "5999.00" || item.ProductName != "iPhone 15" {
+		t.Fatalf("created item = %+v", item)
+	}
+
+	var detail struct {
+		Data model.Product `json:"data"`
+	}
+	client.mustDo(http.MethodGet, fmt.Sprintf("/api/products/%d", product.ID), nil, &detail, http.StatusOK)
+	if detail.Data.Stock != 3 {
+		t.Fatalf("stock = %d, want 3", detail.Data.Stock)
+	}
+
+	// 库存不足
+	client.mustDo(http.MethodPost, "/api/orders", map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": product.ID, "quantity": 4}},
+	}, nil, http.StatusConflict)
+
+	// 使用优惠券下单，兑换码不存在时不能下单
+	coupon := &model.Coupon{Code: "TEN", Name: "立减10元", Type: model.CouponTypeFixed, AmountOff: money.MustParse("10", "CNY")}
+	if err := a.db.Create(coupon).Error; err != nil {
+		t.Fatal(err)
+	}
+	client.mustDo(http.MethodPost, "/api/orders", map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": product.ID, "quantity": 1}}, "coupon_codes": []string{"NOPE"},
+	}, nil, http.StatusBadRequest)
+	client.mustDo(http.MethodPost, "/api/orders", map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": product.ID, "quantity": 1}}, "coupon_codes": []string{"ten"},
+	}, &created, http.StatusOK)
+	if created.Data.TotalPrice.String() != "5989.00" || len(created.Data.Discounts) != 1 || created.Data.Discounts[0].Code != "TEN" {
+		t.Fatalf("created order = %+v", created.Data)
+	}
+	var preview struct {
+		Data handler.OrderPreviewResponse `json:"data"`
+	}
+	client.mustDo(http.MethodPost, "/api/orders/preview", map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": product.ID, "quantity": 1}}, "coupon_codes": []string{"TEN"},
+	}, &preview, http.StatusOK)
+	if preview.Data.TotalPrice.String() != "5989.00" || preview.Data.Lines[0].Discount.String() != "10.00" {
+		t.Fatalf("preview = %+v", preview.Data)
+	}
+
+	// 按美元展示价格并以美元下单，订单锁定下单时的汇率
+	client.currency = "USD"
+	var display struct {
+		Data         map[string]interface{} `json:"data"`
+		Currency     string                 `json:"currency"`
+		ExchangeRate string                 `json:"exchange_rate"`
+	}
+	client.mustDo(http.MethodGet, fmt.Sprintf("/api/products/%d", product.ID), nil, &display, http.StatusOK)
+	if display.Currency != "USD" || display.ExchangeRate != "0.125" || display.Data["display_price"] != "749.88" {
+		t.Fatalf("display = %+v", display)
+	}
+	client.mustDo(http.MethodPost, "/api/orders", map[string]interface{}{
+		"items": []map[string]interface{}{{"product_id": product.ID, "quantity": 1}},
+	}, &created, http.StatusOK)
+	if created.Data.PayCurrency != "USD" || created.Data.ExchangeRate != "0.125" ||
+		created.Data.TotalPrice.String() != "5999.00" || created.Data.PayTotal.String() != "749.88" {
+		t.Fatalf("created order = %+v", created.Data)
+	}
+	client.currency = "EUR"
+	client.mustDo(http.MethodGet, "/api/products", nil, nil, http.StatusBadRequest)
+	client.currency = ""
+
+	// 其他用户看不到该订单
+	other := &testClient{t: t, router: client.router}
+	other.login("bob")
+	var list struct {
+		Total int64 `json:"total"`
+	}
+	other.mustDo(http.MethodGet, "/api/orders", nil, &list, http.StatusOK)
+	if list.Total != 0 {
+		t.Fatalf("bob sees %d orders", list.Total)
+	}
+}
+
+// TestPlaceOrderWithAPIKey API Key不能访问地址簿，下单时直接填写收货地址
+func TestPlaceOrderWithAPIKey(t *testing.T) {
+	client, a := newTestClient(t)
+	product := &model.Product{Name: "iPhone 15", Price: money.MustParse("5999", "CNY"), Stock: 5, Status: 1}
+	if err := a.db.Create(product).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	client.login("carol")
+	var key struct {
+		Data handler.CreateAPIKeyResponse `json:"data"`
+	}
+	client.mustDo(http.MethodPost, "/api/user/api-keys", map[string]interface{}{
+		"name": "下单脚本", "scopes": []string{model.ScopeOrdersWrite},
+	}, &key, http.StatusOK)
+	bot := &testClient{t: t, router: client.router, apiKey: key.Data.Key}
+
+	items := []map[string]interface{}{{"product_id": product.ID, "quantity": 1}}
+	address := map[string]string{
+		"name": "张三", "phone": "13800138000", "province": "广东省", "city": "深圳市", "detail": "科技园南区1栋101",
+	}
+	bot.mustDo(http.MethodGet, "/api/user/addresses", nil, nil, http.StatusForbidden)
+	bot.mustDo(http.MethodPost, "/api/orders", map[string]interface{}{"items": items}, nil, http.StatusBadRequest)
+	bot.mustDo(http.MethodPost, "/api/orders", map[string]interface{}{
+		"items": items, "address_id": 1, "shipping_address": address,
+	}, nil, http.StatusBadRequest)
+
+	var created struct {
+		Data model.Order `json:"data"`
+	}
+	bot.mustDo(http.MethodPost, "/api/orders", map[string]interface{}{"items": items, "shipping_address": address}, &created, http.StatusOK)
+	if created.Data.ShippingAddress.Name != "张三" || created.Data.AddressID != 0 {
+		t.Fatalf("created order = %+v", created.Data)
+	}
+	var addresses int64
+	a.db.Model(&model.Address{}).Count(&addresses)
+	if addresses != 0 {
+		t.Fatalf("直接填写的地址不应保存到地址簿, addresses = %d", addresses)
+	}
+}
+
+// TestAdminRequiresMFAOnScopedRoutes 要求管理员两步验证时，未通过两步验证的管理员也不能通过按权限范围授权的接口管理商品和订单
+func TestAdminRequiresMFAOnScopedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := newTestApp(t)
+	a.cfg.Security.TwoFactor.EnforceAdmin = true
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	r, err := newRouter(ctx, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &testClient{t: t, router: r}
+
+	credentials := map[string]string{"username": "root", "password": "password123"}
+	client.mustDo(http.MethodPost, "/api/user/register", credentials, nil, http.StatusOK)
+	if err := a.db.Model(&model.User{}).Where("username = ?", "root").Update("role", model.RoleAdmin).Error; err != nil {
+		t.Fatal(err)
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	client.mustDo(http.MethodPost, "/api/user/login", credentials, &resp, http.StatusOK)
+	client.token = resp.Token
+
+	product := map[string]interface{}{"name": "iPhone 15", "price": "5999", "stock": 5}
+	client.mustDo(http.MethodPost, "/api/products", product, nil, http.StatusForbidden)
+	client.mustDo(http.MethodGet, "/api/orders/all", nil, nil, http.StatusForbidden)
+	client.mustDo(http.MethodGet, "/api/admin/audit-logs", nil, nil, http.StatusForbidden)
+	// 仍可以查看自己的信息并设置两步验证
+	client.mustDo(http.MethodGet, "/api/user/info", nil, nil, http.StatusOK)
+	client.mustDo(http.MethodPost, "/api/user/2fa/enroll", nil, nil, http.StatusOK)
+}
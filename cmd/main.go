@@ -1,20 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	_ "myshop/docs" // 导入swagger文档
 	"myshop/internal/config"
-	"myshop/internal/handler"
-	"myshop/internal/model"
+	"myshop/internal/migrations"
 	"myshop/internal/repository"
 	"myshop/internal/service"
-	"myshop/pkg/middleware"
+	"myshop/pkg/migrate"
+	"myshop/pkg/money"
+	"os"
+	"time"
 
-	"github.com/gin-gonic/gin"
-	files "github.com/swaggo/files" // 修改这行
-	ginSwagger "github.com/swaggo/gin-swagger"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -27,79 +31,183 @@ import (
 // @in header
 // @name Authorization
 // @description 在请求头中添加 Authorization: Bearer {token} 进行身份验证
+// @securityDefinitions.apikey ApiKey
+// @in header
+// @name X-API-Key
+// @description 服务账号在请求头中添加 X-API-Key: {key} 进行身份验证
 func main() {
-	// 初始化数据库连接
-	db, err := gorm.Open(mysql.Open("root:123456@tcp(localhost:3306)/myshop?charset=utf8mb4&parseTime=True&loc=Local"))
+	configPath := flag.String("config", "config.yaml", "配置文件路径")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// 未指定子命令时启动HTTP服务
+	name, args := "serve", flag.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", name)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	a, err := newApp(*configPath)
 	if err != nil {
-		log.Fatal("数据库连接失败:", err)
+		log.Fatal(err)
+	}
+	if err := cmd.run(a, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatalf("%s失败: %v", cmd.desc, err)
+	}
+}
+
+const usage = `用法: myshop [-config config.yaml] <命令> [参数]
+
+命令:
+  serve          启动HTTP服务，未指定命令时默认执行
+  migrate        执行数据库迁移，见 myshop migrate
+  seed           写入本地开发用的演示商品、用户和订单
+  create-admin   创建管理员账号
+  reindex        重建商品缓存
+  export         导出商品或订单数据为JSON
+  import         从JSON导入商品数据
+
+各命令的参数使用 myshop <命令> -h 查看
+
+全局参数:`
+
+// command 子命令
+type command struct {
+	desc string
+	run  func(a *app, args []string) error
+}
+
+var commands = map[string]command{
+	"serve":        {"启动服务", runServe},
+	"migrate":      {"数据库迁移", runMigrate},
+	"seed":         {"写入演示数据", runSeed},
+	"create-admin": {"创建管理员", runCreateAdmin},
+	"reindex":      {"重建商品缓存", runReindex},
+	"export":       {"导出数据", runExport},
+	"import":       {"导入数据", runImport},
+}
+
+// app 各子命令共用的配置和数据库连接
+type app struct {
+	configPath string
+	cfg        *config.Config
+	db         *gorm.DB
+}
+
+// newApp 加载配置
+func newApp(configPath string) (*app, error) {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	if err := money.SetDefaultCurrency(cfg.Shop.Currency); err != nil {
+		return nil, fmt.Errorf("本位币配置错误: %w", err)
 	}
+	return &app{configPath: configPath, cfg: cfg}, nil
+}
+
+// connect 连接数据库并检查数据库结构是否为最新
+// 子命令解析完参数后再调用，查看帮助时不需要数据库
+func (a *app) connect() error {
+	if err := a.openDB(); err != nil {
+		return err
+	}
+	return checkSchema(a.db)
+}
 
-	// 自动迁移数据库表
-	err = db.AutoMigrate(
-		&model.User{},
-		&model.Product{},
-		&model.Order{},
-		&model.OrderItem{},
-	)
+// openDB 连接数据库，不检查数据库结构，只用于执行迁移
+func (a *app) openDB() error {
+	if a.db != nil {
+		return nil
+	}
+	db, err := newDatabase(a.cfg.Database)
 	if err != nil {
-		log.Fatal("数据库迁移失败:", err)
-	}
-
-	// 初始化各层依赖
-	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo)
-	userHandler := handler.NewUserHandler(userService)
-
-	productRepo := repository.NewProductRepository(db)
-	productService := service.NewProductService(productRepo)
-	productHandler := handler.NewProductHandler(productService)
-
-	orderRepo := repository.NewOrderRepository(db)
-	orderService := service.NewOrderService(orderRepo, productRepo)
-	orderHandler := handler.NewOrderHandler(orderService)
-
-	// 初始化路由
-	r := gin.Default()
-
-	// API路由
-	api := r.Group("/api")
-	{
-		// 用户相关路由
-		api.POST("/user/register", userHandler.Register)
-		api.POST("/user/login", userHandler.Login)
-
-		// 商品相关路由
-		api.GET("/products", productHandler.List)
-		api.GET("/products/:id", productHandler.GetByID)
-
-		// 需要认证的路由
-		auth := api.Group("/", middleware.Auth())
-		{
-			// 用户
-			auth.GET("/user/info", userHandler.GetInfo)
-
-			// 商品管理
-			auth.POST("/products", productHandler.Create)
-			auth.PUT("/products/:id", productHandler.Update)
-			auth.DELETE("/products/:id", productHandler.Delete)
-
-			// 订单管理
-			auth.POST("/orders", orderHandler.Create)
-			auth.GET("/orders/:id", orderHandler.GetByID)
-			auth.GET("/orders", orderHandler.GetUserOrders)
+		return fmt.Errorf("数据库连接失败: %w", err)
+	}
+	a.db = db
+
+	// 内存数据库每次启动都是空的，直接执行全部迁移
+	if a.cfg.Database.IsMemory() {
+		m, err := migrate.New(db, migrations.All())
+		if err != nil {
+			return err
+		}
+		if _, err := m.Up(); err != nil {
+			return fmt.Errorf("初始化内存数据库失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// newDatabase 根据配置连接数据库
+func newDatabase(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch cfg.Driver {
+	case "", "mysql":
+		dialector = mysql.Open(cfg.GetDSN())
+	case "postgres":
+		dialector = postgres.Open(cfg.GetDSN())
+	case "sqlite":
+		if cfg.Path == "" {
+			return nil, errors.New("使用sqlite时必须配置database.path")
 		}
+		dialector = sqlite.Open(cfg.GetDSN())
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", cfg.Driver)
 	}
-	// 添加swagger路由
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(files.Handler))
 
-	// 加载配置
-	config, err := config.LoadConfig("config.yaml")
+	db, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
-		log.Fatalf("加载配置失败: %v", err)
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
 	}
 
-	// 启动服务器
-	if err := r.Run(fmt.Sprintf(":%d", config.Server.Port)); err != nil {
-		log.Fatal("服务器启动失败:", err)
+	if cfg.Driver == "sqlite" {
+		// SQLite同一时间只允许一个写入者，使用单个连接避免database is locked；
+		// 内存数据库随连接关闭而销毁，这个连接不能被回收
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		return db, nil
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Second)
+	}
+	return db, nil
+}
+
+// cliContext 返回命令行操作使用的context，审计日志中记为cli操作
+func cliContext() context.Context {
+	return service.WithActor(context.Background(), service.Actor{AuthMethod: service.AuthMethodCLI})
+}
+
+// catalog 创建带缓存的商品服务，写操作记录审计日志并清除缓存
+func (a *app) catalog() (*service.CachedProductService, error) {
+	appCache, err := newCache(a.cfg.Cache, a.cfg.Redis)
+	if err != nil {
+		return nil, fmt.Errorf("初始化缓存失败: %w", err)
 	}
+	audit := service.NewAuditService(repository.NewAuditLogRepository(a.db))
+	products := service.NewProductService(repository.NewTxManager(a.db), repository.NewProductRepository(a.db), audit)
+	return service.NewCachedProductService(products, appCache), nil
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"myshop/internal/config"
+	"myshop/internal/model"
+	"testing"
+)
+
+func TestConnectMemoryDatabase(t *testing.T) {
+	a := &app{cfg: &config.Config{Database: config.DatabaseConfig{Driver: "sqlite", Path: config.SQLiteMemory}}}
+	// 内存数据库连接时自动执行迁移，之后的结构检查应该通过
+	if err := a.connect(); err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := a.db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := seedDemo(a, defaultSeedPassword); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	a.db.Model(&model.Product{}).Count(&count)
+	if count != int64(len(seedProducts)) {
+		t.Fatalf("got %d products, want %d", count, len(seedProducts))
+	}
+}
+
+func TestNewDatabaseRejectsUnknownDriver(t *testing.T) {
+	if _, err := newDatabase(config.DatabaseConfig{Driver: "oracle"}); err == nil {
+		t.Fatal("expected error for unknown driver")
+	}
+	if _, err := newDatabase(config.DatabaseConfig{Driver: "sqlite"}); err == nil {
+		t.Fatal("expected error for sqlite without path")
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"myshop/internal/migrations"
+	"myshop/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+const migrateUsage = `用法: myshop migrate <up|down|status>
+  up             执行全部未执行的迁移
+  down [-steps N] 回滚最近执行的N个迁移，默认1个
+  status         查看迁移执行状态`
+
+// runMigrate 执行migrate子命令
+func runMigrate(a *app, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("缺少迁移命令\n%s", migrateUsage)
+	}
+
+	if err := a.openDB(); err != nil {
+		return err
+	}
+	m, err := migrate.New(a.db, migrations.All())
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := m.Up()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已执行%d个迁移\n", n)
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "回滚的迁移数量")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		n, err := m.Down(*steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已回滚%d个迁移\n", n)
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "未执行"
+			if s.Applied {
+				applied = "已执行 " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%6d  %-32s %s\n", s.Version, s.Name, applied)
+		}
+	default:
+		return fmt.Errorf("未知的迁移命令: %s\n%s", args[0], migrateUsage)
+	}
+	return nil
+}
+
+// checkSchema 检查数据库结构是否为最新，未执行迁移时拒绝启动服务
+func checkSchema(db *gorm.DB) error {
+	m, err := migrate.New(db, migrations.All())
+	if err != nil {
+		return err
+	}
+	pending, err := m.Check()
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return fmt.Errorf("数据库结构落后%d个版本，请先执行 myshop migrate up", pending)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+)
+
+// reindexBatchSize 每批处理的商品数量
+const reindexBatchSize = 500
+
+// runReindex 执行reindex子命令，清除全部商品缓存并按需预热上架商品的详情缓存
+// 直接修改数据库或导入数据后使用，只有redis缓存在多个进程间共享，memory缓存下没有意义
+func runReindex(a *app, args []string) error {
+	fs := flag.NewFlagSet("reindex", flag.ContinueOnError)
+	warm := fs.Bool("warm", true, "清除后预热上架商品的详情缓存")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if a.cfg.Cache.Driver != "redis" {
+		fmt.Println("当前使用进程内memory缓存，服务重启即会重建，无需执行reindex")
+		return nil
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+
+	catalog, err := a.catalog()
+	if err != nil {
+		return err
+	}
+	productRepo := repository.NewProductRepository(a.db)
+	ctx := context.Background()
+
+	var cleared, warmed int
+	var lastID uint
+	for {
+		products, err := productRepo.ListAfter(ctx, lastID, reindexBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(products) == 0 {
+			break
+		}
+
+		ids := make([]uint, len(products))
+		for i, p := range products {
+			ids[i] = p.ID
+		}
+		catalog.Invalidate(ids...)
+		cleared += len(ids)
+
+		if *warm {
+			for _, p := range products {
+				if p.Status != model.ProductStatusOnSale {
+					continue
+				}
+				if _, err := catalog.GetByID(p.ID); err != nil {
+					return fmt.Errorf("预热商品%d失败: %w", p.ID, err)
+				}
+				warmed++
+			}
+		}
+		lastID = products[len(products)-1].ID
+	}
+
+	fmt.Printf("已清除%d个商品的缓存，预热%d个\n", cleared, warmed)
+	return nil
+}
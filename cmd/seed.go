@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/internal/service"
+	"myshop/pkg/money"
+	"myshop/pkg/utils"
+	"time"
+)
+
+// seedUser 演示用户
+type seedUser struct {
+	username string
+	role     string
+	address  model.ShippingAddress
+}
+
+var seedUsers = []seedUser{
+	{username: "demo_admin", role: model.RoleAdmin},
+	{username: "alice", role: model.RoleUser, address: model.ShippingAddress{
+		Name: "张三", Phone: "13800138000", Province: "广东省", City: "深圳市", District: "南山区",
+		Detail: "科技园南区1栋101", PostalCode: "518000",
+	}},
+	{username: "bob", role: model.RoleUser, address: model.ShippingAddress{
+		Name: "李四", Phone: "13900139000", Province: "浙江省", City: "杭州市", District: "西湖区",
+		Detail: "文三路90号", PostalCode: "310000",
+	}},
+}
+
+var seedProducts = []model.Product{
+	{Name: "iPhone 15", Description: "6.1英寸超视网膜XDR显示屏，A16仿生芯片", Price: money.MustParse("5999", "CNY"), Stock: 100, Status: 1, CategoryID: 1},
+	{Name: "iPhone 15 Pro", Description: "钛金属设计，A17 Pro芯片", Price: money.MustParse("7999", "CNY"), Stock: 50, Status: 1, CategoryID: 1},
+	{Name: "小米14", Description: "骁龙8 Gen 3，徕卡光学镜头", Price: money.MustParse("3999", "CNY"), Stock: 200, Status: 1, CategoryID: 1},
+	{Name: "MacBook Air 13", Description: "M3芯片，8GB内存，256GB存储", Price: money.MustParse("8999", "CNY"), Stock: 30, Status: 1, CategoryID: 2},
+	{Name: "ThinkPad X1 Carbon", Description: "14英寸轻薄商务笔记本", Price: money.MustParse("9999", "CNY"), Stock: 20, Status: 1, CategoryID: 2},
+	{Name: "AirPods Pro", Description: "主动降噪无线耳机", Price: money.MustParse("1899", "CNY"), Stock: 300, Status: 1, CategoryID: 3},
+	{Name: "索尼WH-1000XM5", Description: "头戴式降噪耳机", Price: money.MustParse("2499", "CNY"), Stock: 80, Status: 1, CategoryID: 3},
+	{Name: "iPad 第九代", Description: "已停产的旧款平板，用于演示下架商品", Price: money.MustParse("2299", "CNY"), Stock: 0, Status: 2, CategoryID: 4},
+}
+
+// seedOrder 演示订单，items为商品在seedProducts中的下标和数量
+type seedOrder struct {
+	username string
+	items    [][2]int
+	status   int
+}
+
+var seedOrders = []seedOrder{
+	{username: "alice", items: [][2]int{{0, 1}, {5, 1}}, status: model.OrderStatusCompleted},
+	{username: "alice", items: [][2]int{{3, 1}}, status: model.OrderStatusPaid},
+	{username: "bob", items: [][2]int{{2, 2}}, status: model.OrderStatusShipped},
+	{username: "bob", items: [][2]int{{6, 1}, {5, 2}}, status: model.OrderStatusPending},
+}
+
+// defaultSeedPassword 演示普通用户的默认密码，演示管理员使用随机密码
+const defaultSeedPassword = "password123"
+
+// runSeed 执行seed子命令，写入本地开发用的演示商品、用户和订单
+func runSeed(a *app, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	password := fs.String("password", defaultSeedPassword, "演示普通用户的登录密码")
+	forceDev := fs.Bool("force-dev", false, "确认目标是开发环境，允许向sqlite以外的数据库写入演示数据")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkSeedTarget(a, *forceDev); err != nil {
+		return err
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	return seedDemo(a, *password)
+}
+
+// checkSeedTarget 演示数据包含管理员账号，默认只允许写入sqlite开发数据库，避免误写入生产环境
+func checkSeedTarget(a *app, forceDev bool) error {
+	if a.cfg.Database.Driver == "sqlite" || forceDev {
+		return nil
+	}
+	return errors.New("演示数据只能写入sqlite开发数据库，确认目标是开发环境时使用 -force-dev")
+}
+
+// seedDemo 写入演示数据，演示用户已存在时视为已写入过，不重复写入
+// 普通用户使用password，管理员使用随机生成的密码，只在输出中显示一次
+func seedDemo(a *app, password string) error {
+	ctx := cliContext()
+	userRepo := repository.NewUserRepository(a.db)
+	if _, err := userRepo.GetByUsername(ctx, seedUsers[0].username); err == nil {
+		fmt.Println("演示数据已存在，跳过")
+		return nil
+	}
+
+	catalog, err := a.catalog()
+	if err != nil {
+		return err
+	}
+	audit := service.NewAuditService(repository.NewAuditLogRepository(a.db))
+	addresses := service.NewAddressService(repository.NewAddressRepository(a.db))
+	rates, err := newRates(a.cfg.Shop)
+	if err != nil {
+		return err
+	}
+	currency := service.NewCurrencyService(rates, userRepo)
+	txManager := repository.NewTxManager(a.db)
+	promotions := service.NewPromotionService(txManager, repository.NewPromotionRepository(a.db), audit)
+	coupons := service.NewCouponService(txManager, repository.NewCouponRepository(a.db), audit)
+	orders := service.NewOrderService(txManager, repository.NewOrderRepository(a.db), repository.NewProductRepository(a.db),
+		catalog, addresses, currency, promotions, coupons, audit)
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	adminPassword, err := utils.RandomToken(12)
+	if err != nil {
+		return err
+	}
+	hashedAdminPassword, err := utils.HashPassword(adminPassword)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	userIDs := make(map[string]uint, len(seedUsers))
+	for _, su := range seedUsers {
+		userPassword := hashedPassword
+		if su.role == model.RoleAdmin {
+			userPassword = hashedAdminPassword
+		}
+		user := &model.User{
+			Username:        su.username,
+			Password:        userPassword,
+			Role:            su.role,
+			Email:           su.username + "@example.com",
+			EmailVerifiedAt: &now,
+			Nickname:        su.username,
+		}
+		if err := userRepo.Create(ctx, user); err != nil {
+			return fmt.Errorf("创建用户%s失败: %w", su.username, err)
+		}
+		userIDs[su.username] = user.ID
+
+		if su.address.Name != "" {
+			if err := addresses.Create(ctx, user.ID, &model.Address{ShippingAddress: su.address}); err != nil {
+				return fmt.Errorf("创建用户%s的收货地址失败: %w", su.username, err)
+			}
+		}
+	}
+
+	productIDs := make([]uint, len(seedProducts))
+	for i := range seedProducts {
+		product := seedProducts[i]
+		// 演示价格的数值按配置的本位币解释
+		product.Price = product.Price.In(money.DefaultCurrency())
+		if err := catalog.Create(ctx, &product); err != nil {
+			return fmt.Errorf("创建商品%s失败: %w", product.Name, err)
+		}
+		productIDs[i] = product.ID
+	}
+
+	for _, so := range seedOrders {
+		order := &model.Order{UserID: userIDs[so.username]}
+		for _, item := range so.items {
+			order.Items = append(order.Items, model.OrderItem{ProductID: productIDs[item[0]], Quantity: item[1]})
+		}
+		if err := orders.Create(ctx, order, nil); err != nil {
+			return fmt.Errorf("创建用户%s的订单失败: %w", so.username, err)
+		}
+		// 按支付、发货、完成的顺序依次变更到目标状态
+		for status := model.OrderStatusPaid; status <= so.status; status++ {
+			if err := orders.UpdateStatus(ctx, order.ID, status); err != nil {
+				return fmt.Errorf("更新订单状态失败: %w", err)
+			}
+		}
+	}
+
+	fmt.Printf("已写入%d个用户、%d个商品、%d个订单，演示用户密码: %s\n",
+		len(seedUsers), len(seedProducts), len(seedOrders), password)
+	fmt.Printf("演示管理员%s的密码: %s（只显示这一次）\n", seedUsers[0].username, adminPassword)
+	return nil
+}
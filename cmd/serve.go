@@ -0,0 +1,422 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"myshop/internal/config"
+	"myshop/internal/handler"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/internal/service"
+	"myshop/pkg/cache"
+	"myshop/pkg/mailer"
+	"myshop/pkg/middleware"
+	"myshop/pkg/money"
+	"myshop/pkg/utils"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	files "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// shutdownTimeout 关闭服务器时等待进行中请求完成的最长时间
+const shutdownTimeout = 10 * time.Second
+
+// runServe 执行serve子命令，启动HTTP服务
+func runServe(a *app, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	seed := fs.Bool("seed", false, "启动前写入演示数据，配合内存数据库使用")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *seed {
+		if err := checkSeedTarget(a, false); err != nil {
+			return err
+		}
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	if *seed {
+		if err := seedDemo(a, defaultSeedPassword); err != nil {
+			return fmt.Errorf("写入演示数据失败: %w", err)
+		}
+	}
+
+	// 收到退出信号时停止接收新请求，并结束后台任务
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	r, err := newRouter(ctx, a)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Addr: fmt.Sprintf(":%d", a.cfg.Server.Port), Handler: r}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("服务器启动失败: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Println("正在关闭服务器...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("关闭服务器失败: %w", err)
+	}
+	return nil
+}
+
+// newRouter 按配置组装各层依赖并注册路由，a需已连接数据库
+// 后台匿名化任务随ctx取消而退出
+func newRouter(ctx context.Context, a *app) (*gin.Engine, error) {
+	config, db := a.cfg, a.db
+
+	// 初始化缓存
+	appCache, err := newCache(config.Cache, config.Redis)
+	if err != nil {
+		return nil, fmt.Errorf("初始化缓存失败: %w", err)
+	}
+	denylist := utils.NewTokenDenylist(appCache)
+
+	// 初始化令牌签名密钥
+	keyRing, err := loadKeyRing(config.Security.Token)
+	if err != nil {
+		return nil, fmt.Errorf("加载签名密钥失败: %w", err)
+	}
+	utils.InitJWT(utils.JWTOptions{
+		KeyRing:   keyRing,
+		Issuer:    config.Security.Token.Issuer,
+		Audience:  config.Security.Token.Audience,
+		AccessTTL: config.Security.Token.AccessTTL,
+	})
+	jwksHandler := handler.NewJWKSHandler(keyRing)
+
+	// 初始化邮件发送
+	mail, err := newMailer(config.Mail)
+	if err != nil {
+		return nil, fmt.Errorf("初始化邮件发送失败: %w", err)
+	}
+
+	// 初始化各层依赖
+	txManager := repository.NewTxManager(db)
+	auditService := service.NewAuditService(repository.NewAuditLogRepository(db))
+	if err := auditService.RecordConfig(ctx, a.configPath, config, []byte(config.Security.AuditSecret)); err != nil {
+		log.Printf("记录配置变更失败: %v", err)
+	}
+	auditHandler := handler.NewAuditHandler(auditService)
+
+	userRepo := repository.NewUserRepository(db)
+	securityEventRepo := repository.NewSecurityEventRepository(db)
+	loginGuard := service.NewLoginGuard(appCache, securityEventRepo, config.Security.Login)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	sessionService := service.NewSessionService(sessionRepo, refreshTokenRepo, securityEventRepo, appCache)
+	tokenService := service.NewTokenService(userRepo, refreshTokenRepo, sessionService, securityEventRepo, denylist, config.Security.Token.RefreshTTL)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	twoFactorService := service.NewTwoFactorService(userRepo, recoveryCodeRepo, appCache, tokenService, loginGuard, config.Security.TwoFactor)
+	userTokenRepo := repository.NewUserTokenRepository(db)
+	accountService, err := service.NewAccountService(txManager, userRepo, userTokenRepo, sessionService, securityEventRepo, mail, appCache, config.Security.Account)
+	if err != nil {
+		return nil, fmt.Errorf("初始化账号服务失败: %w", err)
+	}
+	userService := service.NewUserService(txManager, userRepo, loginGuard, tokenService, twoFactorService, accountService, sessionService, auditService)
+	userHandler := handler.NewUserHandler(userService, tokenService, twoFactorService, accountService, sessionService, config.Security.Cookie)
+
+	identityRepo := repository.NewIdentityRepository(db)
+	oidcService := service.NewOIDCService(userRepo, identityRepo, appCache, tokenService, twoFactorService, config.Security.OIDC)
+	oidcHandler := handler.NewOIDCHandler(oidcService, config.Security.Cookie)
+
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, userService)
+
+	rates, err := newRates(config.Shop)
+	if err != nil {
+		return nil, fmt.Errorf("加载汇率失败: %w", err)
+	}
+	currencyService := service.NewCurrencyService(rates, userRepo)
+	currencyHandler := handler.NewCurrencyHandler(currencyService)
+
+	productRepo := repository.NewProductRepository(db)
+	productService := service.NewCachedProductService(service.NewProductService(txManager, productRepo, auditService), appCache)
+	productHandler := handler.NewProductHandler(productService, currencyService)
+
+	addressRepo := repository.NewAddressRepository(db)
+	addressService := service.NewAddressService(addressRepo)
+	addressHandler := handler.NewAddressHandler(addressService)
+
+	promotionService := service.NewPromotionService(txManager, repository.NewPromotionRepository(db), auditService)
+	promotionHandler := handler.NewPromotionHandler(promotionService)
+
+	couponService := service.NewCouponService(txManager, repository.NewCouponRepository(db), auditService)
+	couponHandler := handler.NewCouponHandler(couponService)
+
+	orderRepo := repository.NewOrderRepository(db)
+	orderService := service.NewOrderService(txManager, orderRepo, productRepo, productService, addressService,
+		currencyService, promotionService, couponService, auditService)
+	orderHandler := handler.NewOrderHandler(orderService)
+
+	privacyRepo := repository.NewPrivacyRepository(db)
+	privacyService := service.NewPrivacyService(txManager, userRepo, privacyRepo, addressRepo, orderRepo, identityRepo,
+		sessionService, twoFactorService, securityEventRepo, appCache, config.Security.Privacy)
+	privacyHandler := handler.NewPrivacyHandler(privacyService, config.Security.Cookie)
+	// 后台匿名化宽限期已结束的注销账号
+	go privacyService.RunAnonymizer(ctx)
+
+	// 认证器链：Bearer令牌优先，其次是浏览器Cookie，最后是服务账号的API Key
+	authMiddleware := middleware.Auth(
+		middleware.NewBearerAuthenticator(denylist, sessionService, model.RoleScopes),
+		middleware.NewCookieAuthenticator(config.Security.Cookie.Name, denylist, sessionService, model.RoleScopes),
+		middleware.NewAPIKeyAuthenticator(apiKeyValidator{apiKeyService}),
+	)
+
+	// 初始化路由
+	r := gin.Default()
+	r.Use(middleware.RequestID())
+
+	// API路由
+	api := r.Group("/api")
+	{
+		// 用户相关路由
+		api.POST("/user/register", userHandler.Register)
+		api.POST("/user/login", userHandler.Login)
+		api.POST("/user/login/2fa", userHandler.LoginTwoFactor)
+		api.POST("/user/refresh", userHandler.Refresh)
+		api.POST("/user/email/verify", userHandler.VerifyEmail)
+		api.POST("/user/password/forgot", userHandler.ForgotPassword)
+		api.POST("/user/password/reset", userHandler.ResetPassword)
+
+		// 第三方登录
+		api.GET("/auth/oidc/providers", oidcHandler.Providers)
+		api.GET("/auth/oidc/:provider/login", oidcHandler.Login)
+		api.GET("/auth/oidc/:provider/callback", oidcHandler.Callback)
+
+		// 商品相关路由
+		api.GET("/products", productHandler.List)
+		api.GET("/products/:id", productHandler.GetByID)
+		api.GET("/currencies", currencyHandler.List)
+
+		// 需要认证、但不要求两步验证的路由，未启用两步验证的管理员登录后在这里完成设置
+		setup := api.Group("/", authMiddleware)
+		{
+			setup.GET("/user/info", userHandler.GetInfo)
+			setup.POST("/user/logout", userHandler.Logout)
+
+			// 两步验证，只允许登录用户本人操作
+			twoFactor := setup.Group("/user/2fa", middleware.DenyAPIKey())
+			{
+				twoFactor.POST("/enroll", userHandler.EnrollTwoFactor)
+				twoFactor.POST("/verify", userHandler.VerifyTwoFactor)
+				twoFactor.POST("/disable", userHandler.DisableTwoFactor)
+				twoFactor.POST("/recovery-codes", userHandler.RegenerateRecoveryCodes)
+			}
+		}
+
+		// 按配置要求管理员身份的登录用户在登录时通过两步验证，覆盖商品管理等按权限范围授权的接口；
+		// API Key主体不带角色，不受此限制
+		adminMFA := func(c *gin.Context) { c.Next() }
+		if config.Security.TwoFactor.EnforceAdmin {
+			adminMFA = middleware.RequireMFAForRole(model.RoleAdmin)
+		}
+
+		// 需要认证的路由
+		auth := api.Group("/", authMiddleware, adminMFA)
+		{
+			// 用户
+			auth.PUT("/user/profile", middleware.DenyAPIKey(), userHandler.UpdateProfile)
+			auth.PUT("/user/email", middleware.DenyAPIKey(), userHandler.ChangeEmail)
+			auth.POST("/user/email/verify/send", middleware.DenyAPIKey(), userHandler.SendVerification)
+			auth.PUT("/user/password", middleware.DenyAPIKey(), userHandler.ChangePassword)
+			auth.GET("/user/export", middleware.DenyAPIKey(), privacyHandler.Export)
+			auth.DELETE("/user", middleware.DenyAPIKey(), privacyHandler.DeleteAccount)
+
+			// 登录会话管理，只允许登录用户本人操作
+			sessions := auth.Group("/user/sessions", middleware.DenyAPIKey())
+			{
+				sessions.GET("", userHandler.ListSessions)
+				sessions.DELETE("", userHandler.RevokeOtherSessions)
+				sessions.DELETE("/:id", userHandler.RevokeSession)
+			}
+
+			// 收货地址，只允许登录用户本人操作
+			addresses := auth.Group("/user/addresses", middleware.DenyAPIKey())
+			{
+				addresses.GET("", addressHandler.List)
+				addresses.POST("", addressHandler.Create)
+				addresses.PUT("/:id", addressHandler.Update)
+				addresses.PUT("/:id/default", addressHandler.SetDefault)
+				addresses.DELETE("/:id", addressHandler.Delete)
+			}
+
+			// 第三方账号关联，只允许登录用户本人操作
+			identities := auth.Group("/user/identities", middleware.DenyAPIKey())
+			{
+				identities.GET("", oidcHandler.ListIdentities)
+				identities.POST("/:provider", oidcHandler.Link)
+			}
+
+			// API Key管理，只允许登录用户本人操作
+			keys := auth.Group("/user/api-keys", middleware.DenyAPIKey())
+			{
+				keys.POST("", apiKeyHandler.Create)
+				keys.GET("", apiKeyHandler.List)
+				keys.DELETE("/:id", apiKeyHandler.Revoke)
+			}
+
+			// 商品管理
+			auth.POST("/products", middleware.RequireScope(model.ScopeProductsWrite), productHandler.Create)
+			auth.PUT("/products/:id", middleware.RequireScope(model.ScopeProductsWrite), productHandler.Update)
+			auth.DELETE("/products/:id", middleware.RequireScope(model.ScopeProductsWrite), productHandler.Delete)
+
+			// 订单管理
+			auth.POST("/orders", middleware.RequireScope(model.ScopeOrdersWrite), orderHandler.Create)
+			auth.POST("/orders/preview", middleware.RequireScope(model.ScopeOrdersRead), orderHandler.Preview)
+			auth.GET("/orders/all", middleware.RequireScope(model.ScopeOrdersReadAll), orderHandler.List)
+			auth.GET("/orders/:id", middleware.RequireScope(model.ScopeOrdersRead), orderHandler.GetByID)
+			auth.GET("/orders", middleware.RequireScope(model.ScopeOrdersRead), orderHandler.GetUserOrders)
+		}
+
+		// 管理员路由，按配置要求管理员登录时通过两步验证
+		admin := api.Group("/admin", authMiddleware, middleware.RequireRole(model.RoleAdmin), adminMFA)
+		{
+			admin.POST("/users/:id/unlock", userHandler.Unlock)
+			admin.PUT("/users/:id/role", userHandler.ChangeRole)
+			admin.GET("/security-events", userHandler.ListSecurityEvents)
+			admin.GET("/audit-logs", auditHandler.List)
+			admin.PUT("/orders/:id/status", orderHandler.UpdateStatus)
+			admin.POST("/coupons", couponHandler.Create)
+			admin.GET("/coupons", couponHandler.List)
+			admin.GET("/coupons/:id", couponHandler.GetByID)
+			admin.PUT("/coupons/:id", couponHandler.Update)
+			admin.DELETE("/coupons/:id", couponHandler.Delete)
+			admin.POST("/promotions", promotionHandler.Create)
+			admin.GET("/promotions", promotionHandler.List)
+			admin.GET("/promotions/:id", promotionHandler.GetByID)
+			admin.PUT("/promotions/:id", promotionHandler.Update)
+			admin.DELETE("/promotions/:id", promotionHandler.Delete)
+			admin.POST("/service-accounts", apiKeyHandler.CreateServiceAccount)
+			admin.POST("/users/:id/api-keys", apiKeyHandler.AdminCreate)
+			admin.GET("/users/:id/api-keys", apiKeyHandler.AdminList)
+			admin.DELETE("/api-keys/:id", apiKeyHandler.AdminRevoke)
+		}
+	}
+	// 公开验签公钥
+	r.GET("/.well-known/jwks.json", jwksHandler.Get)
+
+	// 添加swagger路由
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(files.Handler))
+
+	return r, nil
+}
+
+// securityCachePrefixes 保存安全状态的缓存键前缀：令牌吊销列表、登录失败计数和锁定、限流窗口、
+// 两步验证挑战和已用时间步、第三方登录state。内存缓存容量不足时不能淘汰这些键
+var securityCachePrefixes = []string{"jwt:deny:", "login:", "ratelimit:", "2fa:", "oidc:state:"}
+
+// apiKeyValidator 把API Key的校验结果转换为认证主体，实现middleware.APIKeyValidator
+type apiKeyValidator struct {
+	keys *service.APIKeyService
+}
+
+func (v apiKeyValidator) ValidateAPIKey(ctx context.Context, key string) (*middleware.Principal, error) {
+	identity, err := v.keys.ValidateAPIKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &middleware.Principal{UserID: identity.UserID, Scopes: identity.Scopes, ExpiresAt: identity.ExpiresAt}, nil
+}
+
+// newCache 根据配置创建缓存
+func newCache(cfg config.CacheConfig, redisCfg config.RedisConfig) (cache.Cache, error) {
+	switch cfg.Driver {
+	case "", "memory":
+		return cache.NewMemoryCache(cache.MemoryOptions{
+			MaxEntries:      cfg.MaxEntries,
+			CleanupInterval: cfg.CleanupInterval,
+			PinnedPrefixes:  securityCachePrefixes,
+		}), nil
+	case "redis":
+		return cache.NewRedisCache(cache.RedisOptions{
+			Addr:         fmt.Sprintf("%s:%d", redisCfg.Host, redisCfg.Port),
+			Password:     redisCfg.Password,
+			DB:           redisCfg.DB,
+			PoolSize:     redisCfg.PoolSize,
+			MinIdleConns: redisCfg.MinIdleConns,
+			Prefix:       cfg.Prefix,
+		})
+	default:
+		return nil, fmt.Errorf("不支持的缓存驱动: %s", cfg.Driver)
+	}
+}
+
+// newRates 根据配置创建汇率来源，配置了汇率文件时从文件读取
+// 汇率以本位币为基准，需在设置本位币之后调用
+func newRates(cfg config.ShopConfig) (money.RateProvider, error) {
+	base := money.DefaultCurrency()
+	if cfg.ExchangeRatesFile != "" {
+		rates, err := money.NewFileRates(cfg.ExchangeRatesFile)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := rates.Rate(base, base); err != nil {
+			return nil, fmt.Errorf("汇率文件缺少本位币%s: %w", base, err)
+		}
+		return rates, nil
+	}
+	return money.NewStaticRates(base, cfg.ExchangeRates)
+}
+
+// newMailer 根据配置创建邮件发送器
+func newMailer(cfg config.MailConfig) (mailer.Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return mailer.NewSMTPMailer(cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.From)
+	case "file":
+		return mailer.NewFileOutbox(cfg.OutboxFile), nil
+	case "", "memory":
+		return mailer.NewMemoryOutbox(), nil
+	default:
+		return nil, fmt.Errorf("不支持的邮件驱动: %s", cfg.Driver)
+	}
+}
+
+// loadKeyRing 根据配置加载签名密钥环
+// 未配置密钥时生成临时密钥，重启后之前签发的令牌全部失效
+func loadKeyRing(cfg config.TokenConfig) (*utils.KeyRing, error) {
+	ring := utils.NewKeyRing()
+
+	if len(cfg.Keys) == 0 {
+		log.Println("未配置令牌签名密钥，使用临时生成的Ed25519密钥，仅适用于开发环境")
+		key, err := utils.GenerateEd25519Key("dev-" + time.Now().Format("20060102150405"))
+		if err != nil {
+			return nil, err
+		}
+		if err := ring.Add(key); err != nil {
+			return nil, err
+		}
+		return ring, ring.Use(key.ID)
+	}
+
+	for _, kc := range cfg.Keys {
+		key, err := utils.LoadSigningKey(kc.Kid, kc.Alg, kc.PrivateKeyFile, kc.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := ring.Add(key); err != nil {
+			return nil, err
+		}
+	}
+	return ring, ring.Use(cfg.SigningKey)
+}
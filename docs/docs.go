@@ -15,14 +15,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/orders": {
-            "get": {
+        "/admin/api-keys/{id}": {
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "获取当前用户的订单列表",
+                "description": "吊销任意用户的API Key（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -30,117 +30,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "订单管理"
+                    "API Key"
                 ],
-                "summary": "获取用户订单列表",
+                "summary": "吊销任意API Key",
                 "parameters": [
                     {
                         "type": "integer",
-                        "default": 1,
-                        "description": "页码",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "每页数量",
-                        "name": "page_size",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "订单列表",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/handler.ListResponse"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/model.Order"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            },
-            "post": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
-                ],
-                "description": "创建新订单",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "订单管理"
-                ],
-                "summary": "创建订单",
-                "parameters": [
-                    {
-                        "description": "订单信息",
-                        "name": "order",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.Order"
-                        }
+                        "description": "API Key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "创建成功",
+                        "description": "吊销成功",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "参数错误",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handler.Response"
                         }
                     },
-                    "401": {
-                        "description": "未授权",
+                    "403": {
+                        "description": "权限不足",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "库存不足",
+                    "404": {
+                        "description": "API Key不存在",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/orders/{id}": {
+        "/admin/audit-logs": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "获取订单详细信息",
+                "description": "按条件分页查询商品、订单状态、用户角色和运行配置的变更记录（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -148,48 +79,130 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "订单管理"
+                    "用户管理"
                 ],
-                "summary": "获取订单详情",
+                "summary": "查询审计日志",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "订单ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "操作者用户ID",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "create",
+                            "update",
+                            "delete"
+                        ],
+                        "type": "string",
+                        "description": "动作",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "product",
+                            "order",
+                            "user",
+                            "config"
+                        ],
+                        "type": "string",
+                        "description": "资源类型",
+                        "name": "resource_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "资源ID",
+                        "name": "resource_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "请求ID",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "起始时间(RFC3339)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "截止时间(RFC3339)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量，最多100",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "审计日志列表",
                         "schema": {
-                            "$ref": "#/definitions/model.Order"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.PageResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.AuditLog"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "404": {
-                        "description": "订单不存在",
+                    "400": {
+                        "description": "参数错误",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "权限不足",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/products": {
+        "/admin/coupons": {
             "get": {
-                "description": "获取商品列表，支持分页",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "Bearer": []
+                    }
                 ],
+                "description": "分页获取优惠券，新创建的在前（需要管理员权限）",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "商品管理"
+                    "优惠券"
                 ],
-                "summary": "获取商品列表",
+                "summary": "获取优惠券列表",
                 "parameters": [
                     {
                         "type": "integer",
@@ -208,11 +221,11 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "商品列表",
+                        "description": "优惠券列表",
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/handler.ListResponse"
+                                    "$ref": "#/definitions/handler.PageResponse"
                                 },
                                 {
                                     "type": "object",
@@ -220,13 +233,19 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/model.Product"
+                                                "$ref": "#/definitions/model.Coupon"
                                             }
                                         }
                                     }
                                 }
                             ]
                         }
+                    },
+                    "403": {
+                        "description": "权限不足",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
                     }
                 }
             },
@@ -236,7 +255,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "创建新商品（需要管理员权限）",
+                "description": "创建按比例或固定金额减免的优惠券，可设置最低消费、使用次数、有效期、适用商品或分类以及能否叠加（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -244,17 +263,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "商品管理"
+                    "优惠券"
                 ],
-                "summary": "创建商品",
+                "summary": "创建优惠券",
                 "parameters": [
                     {
-                        "description": "商品信息",
+                        "description": "优惠券",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.CreateProductRequest"
+                            "$ref": "#/definitions/handler.CouponRequest"
                         }
                     }
                 ],
@@ -270,7 +289,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/handler.ProductResponse"
+                                            "$ref": "#/definitions/model.Coupon"
                                         }
                                     }
                                 }
@@ -278,13 +297,13 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "参数错误",
+                        "description": "参数错误或兑换码已存在",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "未授权",
+                    "403": {
+                        "description": "权限不足",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
@@ -292,23 +311,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/products/{id}": {
+        "/admin/coupons/{id}": {
             "get": {
-                "description": "根据ID获取商品详情",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "Bearer": []
+                    }
                 ],
+                "description": "获取优惠券及已使用次数（需要管理员权限）",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "商品管理"
+                    "优惠券"
                 ],
-                "summary": "获取商品详情",
+                "summary": "获取优惠券详情",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "商品ID",
+                        "description": "优惠券ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -316,16 +337,27 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "优惠券",
                         "schema": {
-                            "$ref": "#/definitions/model.Product"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Coupon"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "404": {
-                        "description": "商品不存在",
+                        "description": "优惠券不存在",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
@@ -336,7 +368,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "更新商品信息",
+                "description": "修改优惠券，兑换码和已使用次数不能修改；已下单的订单不受影响（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -344,47 +376,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "商品管理"
+                    "优惠券"
                 ],
-                "summary": "更新商品",
+                "summary": "修改优惠券",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "商品ID",
+                        "description": "优惠券ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "商品信息",
-                        "name": "product",
+                        "description": "优惠券",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.Product"
+                            "$ref": "#/definitions/handler.CouponRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "更新成功",
+                        "description": "修改成功",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Coupon"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "参数错误或商品不存在",
+                        "description": "参数错误",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "参数错误或商品不存在",
+                        "description": "优惠券不存在",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
@@ -395,21 +436,18 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "删除指定商品",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "删除优惠券，已使用的订单不受影响，兑换码不能再用于新的优惠券（需要管理员权限）",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "商品管理"
+                    "优惠券"
                 ],
-                "summary": "删除商品",
+                "summary": "删除优惠券",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "商品ID",
+                        "description": "优惠券ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -419,28 +457,26 @@ const docTemplate = `{
                     "200": {
                         "description": "删除成功",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handler.Response"
                         }
                     },
                     "404": {
-                        "description": "商品不存在",
+                        "description": "优惠券不存在",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/user/info": {
-            "get": {
+        "/admin/orders/{id}/status": {
+            "put": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "获取当前登录用户的信息（仅返回ID和用户名）",
+                "description": "修改订单状态（需要管理员权限），变更记录在审计日志中。待支付可改为已支付或已取消，已支付可改为已发货或已取消，已发货可改为已完成；取消订单时归还库存和使用的优惠券",
                 "consumes": [
                     "application/json"
                 ],
@@ -448,34 +484,120 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户管理"
+                    "订单管理"
+                ],
+                "summary": "修改订单状态",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "订单ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "订单状态",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdateOrderStatusRequest"
+                        }
+                    }
                 ],
-                "summary": "获取用户信息",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "修改成功",
                         "schema": {
-                            "$ref": "#/definitions/handler.UserInfo"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "未授权",
+                    "400": {
+                        "description": "参数错误或不允许的状态变更",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "服务器错误",
+                    "404": {
+                        "description": "订单不存在",
                         "schema": {
-                            "$ref": "#/definitions/handler.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/user/login": {
+        "/admin/promotions": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "分页获取促销，新创建的在前（需要管理员权限）",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "促销"
+                ],
+                "summary": "获取促销列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "促销列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.PageResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Promotion"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "权限不足",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "使用用户名和密码登录获取token",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "创建下单时自动应用的促销：买X送Y(buy_x_get_y)、阶梯满减(tiered)或组合价(bundle)（需要管理员权限）\n多个促销按优先级从高到低计算，同一件商品只参与一个买赠或组合促销；满减按之前优惠后的金额计算门槛",
                 "consumes": [
                     "application/json"
                 ],
@@ -483,25 +605,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户管理"
+                    "促销"
                 ],
-                "summary": "用户登录",
+                "summary": "创建促销",
                 "parameters": [
                     {
-                        "description": "用户名和密码",
+                        "description": "促销",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.LoginRequest"
+                            "$ref": "#/definitions/handler.PromotionRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "创建成功",
                         "schema": {
-                            "$ref": "#/definitions/handler.LoginResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Promotion"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -510,8 +644,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "用户名或密码错误",
+                    "403": {
+                        "description": "权限不足",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
@@ -519,9 +653,64 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/register": {
-            "post": {
-                "description": "创建新用户账号（仅需用户名和密码）",
+        "/admin/promotions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "获取促销及促销商品和满减档位（需要管理员权限）",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "促销"
+                ],
+                "summary": "获取促销详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "促销ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "促销",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Promotion"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "促销不存在",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "修改促销，已下单的订单不受影响（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -529,128 +718,4418 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户管理"
+                    "促销"
                 ],
-                "summary": "用户注册",
+                "summary": "修改促销",
                 "parameters": [
                     {
-                        "description": "用户名和密码",
+                        "type": "integer",
+                        "description": "促销ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "促销",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.RegisterRequest"
+                            "$ref": "#/definitions/handler.PromotionRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "修改成功",
                         "schema": {
-                            "$ref": "#/definitions/handler.RegisterResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Promotion"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "参数错误或用户名已存在",
+                        "description": "参数错误",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "服务器错误",
+                    "404": {
+                        "description": "促销不存在",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "删除促销，已下单的订单不受影响（需要管理员权限）",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "促销"
+                ],
+                "summary": "删除促销",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "促销ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除成功",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "促销不存在",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handler.CreateProductRequest": {
-            "type": "object",
-            "required": [
-                "name",
-                "price",
-                "stock"
-            ],
-            "properties": {
-                "description": {
-                    "type": "string",
-                    "example": "最新款iPhone"
-                },
-                "name": {
-                    "type": "string",
-                    "example": "iPhone 15"
-                },
-                "price": {
-                    "type": "number",
-                    "example": 6999
-                },
-                "stock": {
+        },
+        "/admin/security-events": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "按条件分页查询登录失败、锁定等安全事件（需要管理员权限）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户管理"
+                ],
+                "summary": "查询安全事件",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "用户名",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "客户端IP",
+                        "name": "ip",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "login_failed",
+                            "login_success",
+                            "account_locked",
+                            "ip_blocked",
+                            "login_blocked",
+                            "account_unlock"
+                        ],
+                        "type": "string",
+                        "description": "事件类型",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "起始时间(RFC3339)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "截止时间(RFC3339)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "安全事件列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.PageResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.SecurityEvent"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "权限不足",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/service-accounts": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "创建不能使用密码登录、只能通过API Key访问的服务账号（需要管理员权限）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Key"
+                ],
+                "summary": "创建服务账号",
+                "parameters": [
+                    {
+                        "description": "服务账号用户名",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateServiceAccountRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "创建成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handler.UserInfo"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误或用户名已存在",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "权限不足",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "获取指定用户或服务账号的API Key列表（需要管理员权限）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Key"
+                ],
+                "summary": "获取用户的API Key列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "API Key列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.APIKey"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "权限不足",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "为指定用户或服务账号创建API Key，可授予任意权限（需要管理员权限）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Key"
+                ],
+                "summary": "为用户创建API Key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "名称、权限范围和有效期",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "创建成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handler.CreateAPIKeyResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误或权限范围无效",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "权限不足",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/role": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "在普通用户和管理员之间切换用户角色（需要管理员权限），用户需要重新登录，变更记录在审计日志中",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户管理"
+                ],
+                "summary": "修改用户角色",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "新角色",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ChangeRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误或不允许修改",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "权限不足",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/unlock": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "解除因登录失败次数过多导致的账号锁定（需要管理员权限）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户管理"
+                ],
+                "summary": "解锁用户",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "解锁成功",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "无效的用户ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "权限不足",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/providers": {
+            "get": {
+                "description": "获取已配置的第三方登录提供方，用于展示登录按钮",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "第三方登录"
+                ],
+                "summary": "获取第三方登录方式",
+                "responses": {
+                    "200": {
+                        "description": "提供方列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/service.OIDCProviderInfo"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/callback": {
+            "get": {
+                "description": "身份提供方登录完成后的回调地址，必须由发起登录或关联的浏览器访问（校验oidc_state Cookie）。登录流程返回token，首次登录自动创建账号；关联流程返回关联结果",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "第三方登录"
+                ],
+                "summary": "第三方登录回调",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "提供方标识",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "授权码",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "状态参数",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "登录已超时或被拒绝",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "第三方登录验证失败",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "第三方账号或邮箱已被其他用户使用",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/login": {
+            "get": {
+                "description": "跳转到第三方身份提供方进行登录，完成后回调 /auth/oidc/{provider}/callback",
+                "tags": [
+                    "第三方登录"
+                ],
+                "summary": "第三方登录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "google",
+                        "description": "提供方标识",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "跳转到身份提供方"
+                    },
+                    "404": {
+                        "description": "不支持的登录方式",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "身份提供方不可用",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/currencies": {
+            "get": {
+                "description": "获取本位币和可以通过X-Currency请求头选择的展示和支付币种",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "商品管理"
+                ],
+                "summary": "获取可选币种",
+                "responses": {
+                    "200": {
+                        "description": "币种列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handler.CurrencyListResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/orders": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "获取当前用户的订单列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "订单管理"
+                ],
+                "summary": "获取用户订单列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "订单列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ListResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Order"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "创建新订单，收货地址从地址簿中选择(address_id)，未指定时使用默认地址；也可以直接填写收货地址(shipping_address)，API Key调用方不能访问地址簿，应使用这种方式\n订单保存地址、商品名称和单价快照\n支付币种由X-Currency请求头指定，未指定时使用用户资料中的偏好币种；订单锁定下单时的汇率和应付金额(PayTotal)\n自动应用当前生效的促销，可以再同时使用最多3张可叠加的优惠券(coupon_codes)，优惠明细保存在订单的Discounts中",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "订单管理"
+                ],
+                "summary": "创建订单",
+                "parameters": [
+                    {
+                        "description": "订单信息",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateOrderRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "支付币种",
+                        "name": "X-Currency",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "创建成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误、收货地址无效、商品已下架、不支持的币种或优惠券不可用",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "库存不足或优惠券使用次数已达上限",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/all": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "获取所有用户的订单列表，需要orders:read_all权限，供管理员和ERP等服务账号使用",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "订单管理"
+                ],
+                "summary": "获取全部订单列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "订单列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ListResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Order"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "权限不足",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/preview": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "按与创建订单相同的规则计算金额：先自动应用当前生效的促销（买赠、满减、组合价），再应用优惠券\n返回每个商品行分摊的优惠和优惠明细，不创建订单、不扣减库存、不占用优惠券；支付币种由X-Currency请求头指定",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "订单管理"
+                ],
+                "summary": "订单试算",
+                "parameters": [
+                    {
+                        "description": "商品和优惠券",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.PreviewOrderRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "支付币种",
+                        "name": "X-Currency",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "试算结果，data为OrderPreviewResponse",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误、商品已下架、不支持的币种或优惠券不可用",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "库存不足或优惠券使用次数已达上限",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "获取订单详细信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "订单管理"
+                ],
+                "summary": "获取订单详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "订单ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Order"
+                        }
+                    },
+                    "404": {
+                        "description": "订单不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/products": {
+            "get": {
+                "description": "获取商品列表，支持分页；display_price为按X-Currency指定币种换算的展示价格",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "商品管理"
+                ],
+                "summary": "获取商品列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "展示币种，默认本位币",
+                        "name": "X-Currency",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "商品列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ListResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/handler.ProductView"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "不支持的币种",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "创建新商品（需要管理员权限）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "商品管理"
+                ],
+                "summary": "创建商品",
+                "parameters": [
+                    {
+                        "description": "商品信息",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateProductRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "创建成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handler.ProductResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}": {
+            "get": {
+                "description": "根据ID获取商品详情；display_price为按X-Currency指定币种换算的展示价格",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "商品管理"
+                ],
+                "summary": "获取商品详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "商品ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "展示币种，默认本位币",
+                        "name": "X-Currency",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ProductView"
+                        }
+                    },
+                    "400": {
+                        "description": "不支持的币种",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "商品不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "更新商品信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "商品管理"
+                ],
+                "summary": "更新商品",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "商品ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "商品信息",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Product"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误或商品不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "参数错误或商品不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    },
+                    {
+                        "ApiKey": []
+                    }
+                ],
+                "description": "删除指定商品",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "商品管理"
+                ],
+                "summary": "删除商品",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "商品ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "商品不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "重新认证后注销当前账号：校验密码或两步验证码，也可以在刚登录（含第三方登录）后直接注销。注销后立即退出全部设备并吊销API Key，宽限期结束后个人信息被匿名化，订单保留用于对账",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账号安全"
+                ],
+                "summary": "注销账号",
+                "parameters": [
+                    {
+                        "description": "当前密码或两步验证码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.DeleteAccountRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "注销成功",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误、密码或验证码错误",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "需要重新认证",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "尝试过于频繁",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/disable": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "提交当前验证码关闭两步验证，原有恢复码同时作废",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "两步验证"
+                ],
+                "summary": "关闭两步验证",
+                "parameters": [
+                    {
+                        "description": "验证码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "关闭成功",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "验证码错误或未启用",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "生成TOTP密钥并返回otpauth URI，用验证器App扫描后调用 /user/2fa/verify 完成开通",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "两步验证"
+                ],
+                "summary": "开通两步验证",
+                "responses": {
+                    "200": {
+                        "description": "密钥和URI",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handler.TwoFactorEnrollResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "已启用两步验证",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "提交当前验证码重新生成恢复码，原有恢复码全部作废",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "两步验证"
+                ],
+                "summary": "重新生成恢复码",
+                "parameters": [
+                    {
+                        "description": "验证码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "新的恢复码",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "验证码错误或未启用",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/verify": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "提交验证器App中的验证码以启用两步验证，返回的恢复码只显示这一次",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "两步验证"
+                ],
+                "summary": "确认开通两步验证",
+                "parameters": [
+                    {
+                        "description": "验证码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "恢复码",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "验证码错误或未开始开通",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/addresses": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "获取当前用户的收货地址，默认地址在前",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "收货地址"
+                ],
+                "summary": "获取收货地址列表",
+                "responses": {
+                    "200": {
+                        "description": "收货地址列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Address"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "新增收货地址，第一个地址自动设为默认地址",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "收货地址"
+                ],
+                "summary": "新增收货地址",
+                "parameters": [
+                    {
+                        "description": "收货地址",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.AddressRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "新增成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Address"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误或地址数量已达上限",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/addresses/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "修改收货地址内容，已创建订单中的收货地址不受影响",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "收货地址"
+                ],
+                "summary": "修改收货地址",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "地址ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "收货地址",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.AddressRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Address"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "地址不存在",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "删除收货地址，删除默认地址时最近添加的地址成为新的默认地址",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "收货地址"
+                ],
+                "summary": "删除收货地址",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "地址ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除成功",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "地址不存在",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/addresses/{id}/default": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "将指定地址设为默认地址，下单未指定地址时使用",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "收货地址"
+                ],
+                "summary": "设为默认地址",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "地址ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "设置成功",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "地址不存在",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "获取当前用户的API Key列表，不包含明文",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Key"
+                ],
+                "summary": "获取API Key列表",
+                "responses": {
+                    "200": {
+                        "description": "API Key列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.APIKey"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "为当前用户创建API Key，只能授予自己已有的权限；明文只在响应中出现一次",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Key"
+                ],
+                "summary": "创建API Key",
+                "parameters": [
+                    {
+                        "description": "名称、权限范围和有效期",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "创建成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handler.CreateAPIKeyResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误或权限范围无效",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "吊销当前用户的API Key，立即失效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Key"
+                ],
+                "summary": "吊销API Key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API Key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "吊销成功",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "API Key不存在",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/email": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "设置或修改当前用户的邮箱，修改后需要重新验证，验证邮件发送到新邮箱",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账号安全"
+                ],
+                "summary": "修改邮箱",
+                "parameters": [
+                    {
+                        "description": "新邮箱",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ChangeEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "验证邮件已发送",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误或邮箱已被使用",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "发送过于频繁",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/email/verify": {
+            "post": {
+                "description": "使用验证邮件中的令牌完成邮箱验证，令牌只能使用一次",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账号安全"
+                ],
+                "summary": "验证邮箱",
+                "parameters": [
+                    {
+                        "description": "验证令牌",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.TokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "验证成功",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "令牌无效、已使用或已过期",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/email/verify/send": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "向当前用户的邮箱重新发送验证邮件，每分钟最多一次",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账号安全"
+                ],
+                "summary": "发送邮箱验证邮件",
+                "responses": {
+                    "200": {
+                        "description": "验证邮件已发送",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "未设置邮箱或邮箱已验证",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "发送过于频繁",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/export": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "以JSON文件下载当前用户的个人资料、收货地址、订单和关联的第三方账号",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账号安全"
+                ],
+                "summary": "导出个人数据",
+                "responses": {
+                    "200": {
+                        "description": "个人数据",
+                        "schema": {
+                            "$ref": "#/definitions/service.UserExport"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/identities": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "获取当前用户关联的全部第三方账号",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "第三方登录"
+                ],
+                "summary": "获取已关联的第三方账号",
+                "responses": {
+                    "200": {
+                        "description": "第三方账号列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Identity"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/identities/{provider}": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "为当前用户关联第三方账号，返回身份提供方的授权地址并写入oidc_state Cookie，前端在同一浏览器中跳转后在回调中完成关联",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "第三方登录"
+                ],
+                "summary": "关联第三方账号",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "提供方标识",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "授权地址",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handler.LinkIdentityResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "不支持的登录方式",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/info": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "获取当前登录用户的个人资料和邮箱验证状态",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户管理"
+                ],
+                "summary": "获取用户信息",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.UserInfo"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/login": {
+            "post": {
+                "description": "使用用户名和密码登录获取token；启用两步验证的用户返回挑战令牌，需调用 /user/login/2fa 完成登录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户管理"
+                ],
+                "summary": "用户登录",
+                "parameters": [
+                    {
+                        "description": "用户名和密码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "用户名或密码错误",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "失败后需要等待的秒数，等待结束前的尝试返回429"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "失败次数过多，账号或IP被临时锁定",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "失败后需要等待的秒数，等待结束前的尝试返回429"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/user/login/2fa": {
+            "post": {
+                "description": "使用登录接口返回的挑战令牌和验证器App中的验证码（或一次性恢复码）换取token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "两步验证"
+                ],
+                "summary": "两步登录",
+                "parameters": [
+                    {
+                        "description": "挑战令牌和验证码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.TwoFactorLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "验证码错误或挑战已失效",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "失败后需要等待的秒数，等待结束前的尝试返回429"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "失败次数过多，账号或IP被临时锁定",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "失败后需要等待的秒数，等待结束前的尝试返回429"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/user/logout": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "吊销当前访问令牌和登录会话，该次登录派生的全部刷新令牌一并失效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户管理"
+                ],
+                "summary": "退出登录",
+                "parameters": [
+                    {
+                        "description": "刷新令牌",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LogoutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "退出成功",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "校验当前密码后设置新密码，成功后其他设备上的会话全部失效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账号安全"
+                ],
+                "summary": "修改密码",
+                "parameters": [
+                    {
+                        "description": "当前密码和新密码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误或当前密码错误",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "尝试过于频繁",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password/forgot": {
+            "post": {
+                "description": "向已验证的邮箱发送重置密码邮件；无论邮箱是否存在都返回相同结果",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账号安全"
+                ],
+                "summary": "忘记密码",
+                "parameters": [
+                    {
+                        "description": "注册邮箱",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "如果邮箱存在，重置邮件已发送",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "请求过于频繁",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password/reset": {
+            "post": {
+                "description": "使用重置邮件中的令牌设置新密码，成功后其他设备需要重新登录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账号安全"
+                ],
+                "summary": "重置密码",
+                "parameters": [
+                    {
+                        "description": "重置令牌和新密码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "重置成功",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误、令牌无效或已过期",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/profile": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "修改昵称、手机号、头像、邮箱和偏好币种，未提供的字段保持不变；修改邮箱后需要重新验证",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户管理"
+                ],
+                "summary": "修改个人资料",
+                "parameters": [
+                    {
+                        "description": "个人资料",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdateProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handler.UserInfo"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误、邮箱已被使用或不支持的币种",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/refresh": {
+            "post": {
+                "description": "使用刷新令牌换取新的访问令牌，刷新令牌每次使用后轮换，旧令牌立即失效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户管理"
+                ],
+                "summary": "刷新令牌",
+                "parameters": [
+                    {
+                        "description": "刷新令牌",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "刷新令牌无效或已失效",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/register": {
+            "post": {
+                "description": "创建新用户账号，邮箱可选，填写后发送验证邮件",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户管理"
+                ],
+                "summary": "用户注册",
+                "parameters": [
+                    {
+                        "description": "用户名、密码和邮箱",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RegisterResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误或用户名、邮箱已存在",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "获取当前用户所有有效的登录会话，包括设备、IP和最后活跃时间",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账号安全"
+                ],
+                "summary": "获取登录会话列表",
+                "responses": {
+                    "200": {
+                        "description": "会话列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/handler.SessionInfo"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "吊销当前会话以外的全部会话，其他设备需要重新登录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账号安全"
+                ],
+                "summary": "吊销其他登录会话",
+                "responses": {
+                    "200": {
+                        "description": "吊销的会话数量",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "integer"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "吊销当前用户的指定会话，该设备上的访问令牌和刷新令牌立即失效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账号安全"
+                ],
+                "summary": "吊销登录会话",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "会话ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "吊销成功",
+                        "schema": {
+                            "$ref": "#/definitions/handler.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "无效的会话ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "会话不存在",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handler.AddressRequest": {
+            "type": "object",
+            "required": [
+                "city",
+                "detail",
+                "name",
+                "phone",
+                "province"
+            ],
+            "properties": {
+                "city": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "深圳市"
+                },
+                "detail": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "科技园南区1栋101"
+                },
+                "district": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "南山区"
+                },
+                "is_default": {
+                    "description": "仅新增时有效，修改默认地址请使用设为默认接口",
+                    "type": "boolean",
+                    "example": false
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "张三"
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 20,
+                    "example": "13800138000"
+                },
+                "postal_code": {
+                    "type": "string",
+                    "maxLength": 10,
+                    "example": "518000"
+                },
+                "province": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "广东省"
+                }
+            }
+        },
+        "handler.ChangeEmailRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "test@example.com"
+                }
+            }
+        },
+        "handler.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "new_password",
+                "old_password"
+            ],
+            "properties": {
+                "new_password": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 6,
+                    "example": "newpassword123"
+                },
+                "old_password": {
+                    "type": "string",
+                    "example": "password123"
+                }
+            }
+        },
+        "handler.ChangeRoleRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "admin"
+                    ],
+                    "example": "admin"
+                }
+            }
+        },
+        "handler.CouponRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "type"
+            ],
+            "properties": {
+                "amount_off": {
+                    "description": "减免金额，fixed类型",
+                    "type": "string",
+                    "example": "20.00"
+                },
+                "code": {
+                    "description": "兑换码，不区分大小写，仅创建时有效",
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "NEWYEAR20"
+                },
+                "disabled": {
+                    "description": "停用",
+                    "type": "boolean",
+                    "example": false
+                },
+                "ends_at": {
+                    "description": "失效时间，为空表示长期有效",
+                    "type": "string",
+                    "example": "2024-02-01T00:00:00+08:00"
+                },
+                "max_discount": {
+                    "description": "percent类型的减免上限，为0表示不限",
+                    "type": "string",
+                    "example": "0"
+                },
+                "min_spend": {
+                    "description": "最低消费，按适用商品原价合计计算",
+                    "type": "string",
+                    "example": "200.00"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "新年满200减20"
+                },
+                "per_user_limit": {
+                    "description": "每人使用次数，为0表示不限",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1
+                },
+                "percent_off": {
+                    "description": "减免比例，percent类型",
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 0,
+                    "example": 0
+                },
+                "scopes": {
+                    "description": "适用范围，为空表示全部商品",
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "$ref": "#/definitions/handler.CouponScopeRequest"
+                    }
+                },
+                "stackable": {
+                    "description": "能否与其他可叠加的优惠券同时使用",
+                    "type": "boolean",
+                    "example": true
+                },
+                "starts_at": {
+                    "description": "生效时间，为空表示立即生效",
+                    "type": "string",
+                    "example": "2024-01-01T00:00:00+08:00"
+                },
+                "total_limit": {
+                    "description": "总使用次数，为0表示不限",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1000
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "percent",
+                        "fixed"
+                    ],
+                    "example": "fixed"
+                }
+            }
+        },
+        "handler.CouponScopeRequest": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "product_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handler.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_in_days": {
+                    "description": "0表示永不过期",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 90
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "ERP同步"
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "products:write",
+                        "orders:read_all"
+                    ]
+                }
+            }
+        },
+        "handler.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/model.APIKey"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "msk_Xr3v9c..."
+                }
+            }
+        },
+        "handler.CreateOrderItemRequest": {
+            "type": "object",
+            "required": [
+                "product_id",
+                "quantity"
+            ],
+            "properties": {
+                "product_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "quantity": {
+                    "type": "integer",
+                    "maximum": 999,
+                    "minimum": 1,
+                    "example": 2
+                }
+            }
+        },
+        "handler.CreateOrderRequest": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "address_id": {
+                    "description": "地址簿中的地址ID，为0时使用默认地址",
+                    "type": "integer",
+                    "example": 0
+                },
+                "coupon_codes": {
+                    "description": "使用的优惠券兑换码，不区分大小写",
+                    "type": "array",
+                    "maxItems": 3,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "NEWYEAR20"
+                    ]
+                },
+                "items": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/handler.CreateOrderItemRequest"
+                    }
+                },
+                "shipping_address": {
+                    "description": "直接填写的收货地址，不保存到地址簿，不能与address_id同时指定",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handler.OrderAddressRequest"
+                        }
+                    ]
+                }
+            }
+        },
+        "handler.CreateProductRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "stock"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "最新款iPhone"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "iPhone 15"
+                },
+                "price": {
+                    "description": "十进制字符串或数字，小数位数不超过本位币精度",
+                    "type": "string",
+                    "example": "6999.00"
+                },
+                "stock": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 100
+                }
+            }
+        },
+        "handler.CreateServiceAccountRequest": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "username": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 3,
+                    "example": "erp-sync"
+                }
+            }
+        },
+        "handler.CurrencyListResponse": {
+            "type": "object",
+            "properties": {
+                "base": {
+                    "description": "本位币，商品定价和结算的币种",
+                    "type": "string",
+                    "example": "CNY"
+                },
+                "currencies": {
+                    "description": "可以通过X-Currency请求头选择的币种",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "CNY",
+                        "EUR",
+                        "JPY",
+                        "USD"
+                    ]
+                }
+            }
+        },
+        "handler.DeleteAccountRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "password123"
+                }
+            }
+        },
+        "handler.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "message": {
+                    "type": "string",
+                    "example": "参数错误"
+                }
+            }
+        },
+        "handler.ForgotPasswordRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "test@example.com"
+                }
+            }
+        },
+        "handler.LinkIdentityResponse": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "type": "string",
+                    "example": "https://accounts.google.com/o/oauth2/v2/auth?client_id=..."
+                }
+            }
+        },
+        "handler.ListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handler.LoginRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "password123"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "testuser"
+                }
+            }
+        },
+        "handler.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "challenge_token": {
+                    "type": "string",
+                    "example": "Zk9wY2x1..."
+                },
+                "expires_in": {
+                    "type": "integer",
+                    "example": 900
+                },
+                "refresh_token": {
+                    "type": "string",
+                    "example": "0cM2k3yGvJ7q..."
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIs..."
+                },
+                "two_factor_required": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "handler.LogoutRequest": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string",
+                    "example": "0cM2k3yGvJ7q..."
+                }
+            }
+        },
+        "handler.OrderAddressRequest": {
+            "type": "object",
+            "required": [
+                "city",
+                "detail",
+                "name",
+                "phone",
+                "province"
+            ],
+            "properties": {
+                "city": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "深圳市"
+                },
+                "detail": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "科技园南区1栋101"
+                },
+                "district": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "南山区"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "张三"
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 20,
+                    "example": "13800138000"
+                },
+                "postal_code": {
+                    "type": "string",
+                    "maxLength": 10,
+                    "example": "518000"
+                },
+                "province": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "广东省"
+                }
+            }
+        },
+        "handler.PageResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "data": {},
+                "message": {
+                    "type": "string",
+                    "example": "success"
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "page_size": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "handler.PreviewOrderRequest": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "coupon_codes": {
+                    "description": "使用的优惠券兑换码，不区分大小写",
+                    "type": "array",
+                    "maxItems": 3,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "NEWYEAR20"
+                    ]
+                },
+                "items": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/handler.CreateOrderItemRequest"
+                    }
+                }
+            }
+        },
+        "handler.ProductResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-12-20T10:00:00Z"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "最新款iPhone"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "iPhone 15"
+                },
+                "price": {
+                    "type": "string",
+                    "example": "6999.00"
+                },
+                "stock": {
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "handler.ProductView": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-12-20T10:00:00Z"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "最新款iPhone"
+                },
+                "display_price": {
+                    "type": "string",
+                    "example": "830.26"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "iPhone 15"
+                },
+                "price": {
+                    "description": "本位币价格",
+                    "type": "string",
+                    "example": "6999.00"
+                },
+                "status": {
+                    "description": "1: 上架 2: 下架",
+                    "type": "integer",
+                    "example": 1
+                },
+                "stock": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2023-12-20T10:00:00Z"
+                }
+            }
+        },
+        "handler.PromotionItemRequest": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "product_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 0
+                }
+            }
+        },
+        "handler.PromotionRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "type"
+            ],
+            "properties": {
+                "bundle_price": {
+                    "description": "每组的组合价",
+                    "type": "string",
+                    "example": "0"
+                },
+                "buy_quantity": {
+                    "description": "买赠需购买的件数",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 2
+                },
+                "disabled": {
+                    "description": "停用",
+                    "type": "boolean",
+                    "example": false
+                },
+                "ends_at": {
+                    "description": "失效时间，为空表示长期有效",
+                    "type": "string",
+                    "example": "2024-02-01T00:00:00+08:00"
+                },
+                "exclusive": {
+                    "description": "独占：之前已有促销生效时不参与，生效后不再计算其他促销",
+                    "type": "boolean",
+                    "example": false
+                },
+                "free_quantity": {
+                    "description": "买赠免费的件数",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 1
+                },
+                "items": {
+                    "description": "买赠和满减的适用范围，为空表示全部商品；组合的商品和数量",
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "$ref": "#/definitions/handler.PromotionItemRequest"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "耳机买二送一"
+                },
+                "priority": {
+                    "description": "优先级，大的先计算",
+                    "type": "integer",
+                    "example": 10
+                },
+                "starts_at": {
+                    "description": "生效时间，为空表示立即生效",
+                    "type": "string",
+                    "example": "2024-01-01T00:00:00+08:00"
+                },
+                "tiers": {
+                    "description": "满减档位",
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "$ref": "#/definitions/handler.PromotionTierRequest"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "buy_x_get_y",
+                        "tiered",
+                        "bundle"
+                    ],
+                    "example": "buy_x_get_y"
+                }
+            }
+        },
+        "handler.PromotionTierRequest": {
+            "type": "object",
+            "properties": {
+                "amount_off": {
+                    "type": "string",
+                    "example": "50.00"
+                },
+                "threshold": {
+                    "type": "string",
+                    "example": "500.00"
+                }
+            }
+        },
+        "handler.RefreshRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string",
+                    "example": "0cM2k3yGvJ7q..."
+                }
+            }
+        },
+        "handler.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "description": "可选，填写后发送验证邮件",
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "test@example.com"
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 6,
+                    "example": "password123"
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 3,
+                    "example": "testuser"
+                }
+            }
+        },
+        "handler.RegisterResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "注册成功"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handler.ResetPasswordRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 6,
+                    "example": "newpassword123"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJwIjoicmVzZXRfcGFzc3dvcmQi..."
+                }
+            }
+        },
+        "handler.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "data": {},
+                "message": {
+                    "type": "string",
+                    "example": "success"
+                }
+            }
+        },
+        "handler.SessionInfo": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "登录时间",
+                    "type": "string"
+                },
+                "current": {
+                    "description": "是否为发起请求的会话",
+                    "type": "boolean",
+                    "example": true
+                },
+                "device": {
+                    "description": "由User-Agent解析出的设备描述",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "过期时间，随刷新令牌轮换顺延",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "主键",
+                    "type": "integer"
+                },
+                "ip": {
+                    "description": "登录IP",
+                    "type": "string"
+                },
+                "last_active_at": {
+                    "description": "最后活跃时间",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "description": "吊销时间，为空表示仍然有效",
+                    "type": "string"
+                },
+                "user_agent": {
+                    "description": "登录时的User-Agent",
+                    "type": "string"
+                }
+            }
+        },
+        "handler.TokenRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "eyJwIjoidmVyaWZ5X2VtYWlsIi..."
+                }
+            }
+        },
+        "handler.TwoFactorCodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "handler.TwoFactorEnrollResponse": {
+            "type": "object",
+            "properties": {
+                "secret": {
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXP"
+                },
+                "uri": {
+                    "type": "string",
+                    "example": "otpauth://totp/MyShop:testuser?secret=JBSWY3DPEHPK3PXP\u0026issuer=MyShop"
+                }
+            }
+        },
+        "handler.TwoFactorLoginRequest": {
+            "type": "object",
+            "required": [
+                "challenge_token"
+            ],
+            "properties": {
+                "challenge_token": {
+                    "type": "string",
+                    "example": "Zk9wY2x1..."
+                },
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "recovery_code": {
+                    "type": "string",
+                    "example": "abcde-fghij"
+                }
+            }
+        },
+        "handler.UpdateOrderStatusRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "status": {
+                    "description": "1待支付 2已支付 3已发货 4已完成 5已取消",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "handler.UpdateProfileRequest": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "https://cdn.example.com/avatar/1.png"
+                },
+                "currency": {
+                    "description": "偏好币种，空字符串表示使用本位币",
+                    "type": "string",
+                    "example": "USD"
+                },
+                "email": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "test@example.com"
+                },
+                "nickname": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "小明"
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 20,
+                    "example": "13800138000"
+                }
+            }
+        },
+        "handler.UserInfo": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "type": "string",
+                    "example": "https://cdn.example.com/avatar/1.png"
+                },
+                "currency": {
+                    "description": "偏好币种，为空时使用本位币",
+                    "type": "string",
+                    "example": "USD"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "test@example.com"
+                },
+                "email_verified": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "nickname": {
+                    "type": "string",
+                    "example": "小明"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "13800138000"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "testuser"
+                }
+            }
+        },
+        "model.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "创建时间",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "过期时间，为空表示永不过期",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "主键",
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "description": "最近使用时间",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "名称，便于辨认用途",
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "明文前缀，可公开展示",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "description": "吊销时间",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "权限范围",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "description": "所属用户或服务账号",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Address": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "description": "市",
+                    "type": "string",
+                    "example": "深圳市"
+                },
+                "created_at": {
+                    "description": "创建时间",
+                    "type": "string"
+                },
+                "detail": {
+                    "description": "详细地址",
+                    "type": "string",
+                    "example": "科技园南区1栋101"
+                },
+                "district": {
+                    "description": "区县",
+                    "type": "string",
+                    "example": "南山区"
+                },
+                "id": {
+                    "description": "地址ID，主键",
+                    "type": "integer"
+                },
+                "is_default": {
+                    "description": "是否为默认地址",
+                    "type": "boolean"
+                },
+                "name": {
+                    "description": "收货人",
+                    "type": "string",
+                    "example": "张三"
+                },
+                "phone": {
+                    "description": "联系电话",
+                    "type": "string",
+                    "example": "13800138000"
+                },
+                "postal_code": {
+                    "description": "邮政编码",
+                    "type": "string",
+                    "example": "518000"
+                },
+                "province": {
+                    "description": "省",
+                    "type": "string",
+                    "example": "广东省"
+                },
+                "updated_at": {
+                    "description": "更新时间",
+                    "type": "string"
+                }
+            }
+        },
+        "model.AuditChange": {
+            "type": "object",
+            "properties": {
+                "after": {},
+                "before": {}
+            }
+        },
+        "model.AuditLog": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "动作",
+                    "type": "string"
+                },
+                "actor_id": {
+                    "description": "操作者用户ID，系统操作为0",
+                    "type": "integer"
+                },
+                "actor_role": {
+                    "description": "操作者角色",
+                    "type": "string"
+                },
+                "auth_method": {
+                    "description": "认证方式，系统操作为system，命令行操作为cli",
+                    "type": "string"
+                },
+                "changes": {
+                    "description": "字段变更",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/model.AuditChange"
+                    }
+                },
+                "created_at": {
+                    "description": "操作时间",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "主键",
+                    "type": "integer"
+                },
+                "ip": {
+                    "description": "客户端IP",
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "请求ID，与访问日志关联",
+                    "type": "string"
+                },
+                "resource_id": {
+                    "description": "资源ID",
+                    "type": "string"
+                },
+                "resource_type": {
+                    "description": "资源类型",
+                    "type": "string"
+                }
+            }
+        },
+        "model.Coupon": {
+            "type": "object",
+            "properties": {
+                "amount_off": {
+                    "description": "减免金额，fixed类型",
+                    "type": "string",
+                    "example": "20.00"
+                },
+                "code": {
+                    "description": "兑换码，大写",
+                    "type": "string",
+                    "example": "NEWYEAR20"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "disabled": {
+                    "description": "停用后不能再使用，已使用的不受影响",
+                    "type": "boolean",
+                    "example": false
+                },
+                "ends_at": {
+                    "description": "失效时间，为空表示长期有效",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "max_discount": {
+                    "description": "percent类型的减免上限，为0表示不限",
+                    "type": "string",
+                    "example": "0.00"
+                },
+                "min_spend": {
+                    "description": "适用商品原价合计达到该金额才能使用，为0表示不限",
+                    "type": "string",
+                    "example": "200.00"
+                },
+                "name": {
+                    "description": "名称，显示在订单优惠明细中",
+                    "type": "string",
+                    "example": "新年满200减20"
+                },
+                "per_user_limit": {
+                    "description": "每个用户可使用次数，为0表示不限",
+                    "type": "integer",
+                    "example": 1
+                },
+                "percent_off": {
+                    "description": "减免比例(1-100)，percent类型",
+                    "type": "integer",
+                    "example": 0
+                },
+                "scopes": {
+                    "description": "适用范围",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CouponScope"
+                    }
+                },
+                "stackable": {
+                    "description": "能否与其他可叠加的优惠券同时使用",
+                    "type": "boolean",
+                    "example": true
+                },
+                "starts_at": {
+                    "description": "生效时间，为空表示立即生效",
+                    "type": "string"
+                },
+                "total_limit": {
+                    "description": "全部用户合计可使用次数，为0表示不限",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "type": {
+                    "description": "类型 percent/fixed",
+                    "type": "string",
+                    "example": "fixed"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "used_count": {
+                    "description": "已使用次数，下单时递增，取消订单时归还",
                     "type": "integer",
-                    "minimum": 0,
-                    "example": 100
+                    "example": 0
                 }
             }
         },
-        "handler.ErrorResponse": {
+        "model.CouponScope": {
             "type": "object",
             "properties": {
-                "code": {
+                "category_id": {
+                    "type": "integer"
+                },
+                "product_id": {
                     "type": "integer",
-                    "example": 400
+                    "example": 1
+                }
+            }
+        },
+        "model.Identity": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "关联时间",
+                    "type": "string"
                 },
-                "message": {
-                    "type": "string",
-                    "example": "参数错误"
+                "email": {
+                    "description": "提供方返回的邮箱",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "主键",
+                    "type": "integer"
+                },
+                "last_login_at": {
+                    "description": "最近一次通过该身份登录的时间",
+                    "type": "string"
+                },
+                "provider": {
+                    "description": "身份提供方标识",
+                    "type": "string"
                 }
             }
         },
-        "handler.ListResponse": {
+        "model.Order": {
             "type": "object",
             "properties": {
-                "data": {},
-                "page": {
+                "addressID": {
+                    "description": "下单时选择的地址簿地址ID，仅作记录",
                     "type": "integer"
                 },
-                "page_size": {
+                "createdAt": {
+                    "description": "创建时间",
+                    "type": "string"
+                },
+                "discountTotal": {
+                    "description": "优惠合计，本位币",
+                    "type": "string"
+                },
+                "discounts": {
+                    "description": "优惠明细，一对多关系",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.OrderDiscount"
+                    }
+                },
+                "exchangeRate": {
+                    "description": "下单时本位币到支付币种的汇率，之后汇率变化不影响订单",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "订单ID，主键",
                     "type": "integer"
                 },
-                "total": {
+                "items": {
+                    "description": "订单项，一对多关系",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.OrderItem"
+                    }
+                },
+                "orderNo": {
+                    "description": "订单号，唯一索引",
+                    "type": "string"
+                },
+                "payCurrency": {
+                    "description": "支付币种，下单时确定",
+                    "type": "string"
+                },
+                "payTotal": {
+                    "description": "按下单时的汇率换算的应付金额，支付币种",
+                    "type": "string"
+                },
+                "shippingAddress": {
+                    "description": "收货地址快照，创建订单时从地址簿复制，之后不再修改",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ShippingAddress"
+                        }
+                    ]
+                },
+                "status": {
+                    "description": "订单状态，默认1（待支付）",
+                    "type": "integer"
+                },
+                "totalPrice": {
+                    "description": "订单总价，本位币，已扣除优惠",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "description": "更新时间",
+                    "type": "string"
+                },
+                "userID": {
+                    "description": "用户ID，外键",
                     "type": "integer"
                 }
             }
         },
-        "handler.LoginRequest": {
+        "model.OrderDiscount": {
             "type": "object",
-            "required": [
-                "password",
-                "username"
-            ],
             "properties": {
-                "password": {
-                    "type": "string",
-                    "example": "password123"
+                "amount": {
+                    "description": "优惠金额，本位币",
+                    "type": "string"
                 },
-                "username": {
-                    "type": "string",
-                    "example": "testuser"
+                "code": {
+                    "description": "优惠券兑换码，促销为空",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "优惠说明，下单时的促销或优惠券名称",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "主键",
+                    "type": "integer"
+                },
+                "orderID": {
+                    "description": "订单ID，外键",
+                    "type": "integer"
+                },
+                "source": {
+                    "description": "优惠来源 promotion/coupon",
+                    "type": "string"
+                },
+                "sourceID": {
+                    "description": "促销或优惠券ID",
+                    "type": "integer"
                 }
             }
         },
-        "handler.LoginResponse": {
+        "model.OrderItem": {
             "type": "object",
             "properties": {
-                "token": {
-                    "type": "string",
-                    "example": "eyJhbGciOiJIUzI1NiIs..."
+                "id": {
+                    "description": "订单项ID，主键",
+                    "type": "integer"
+                },
+                "orderID": {
+                    "description": "订单ID，外键",
+                    "type": "integer"
+                },
+                "price": {
+                    "description": "下单时的商品单价快照",
+                    "type": "string"
+                },
+                "productID": {
+                    "description": "商品ID，外键",
+                    "type": "integer"
+                },
+                "productName": {
+                    "description": "下单时的商品名称快照",
+                    "type": "string"
+                },
+                "quantity": {
+                    "description": "购买数量",
+                    "type": "integer"
                 }
             }
         },
-        "handler.ProductResponse": {
+        "model.Product": {
             "type": "object",
             "properties": {
+                "category_id": {
+                    "type": "integer"
+                },
                 "created_at": {
                     "type": "string",
                     "example": "2023-12-20T10:00:00Z"
@@ -668,147 +5147,288 @@ const docTemplate = `{
                     "example": "iPhone 15"
                 },
                 "price": {
-                    "type": "number",
-                    "example": 6999
+                    "description": "本位币价格",
+                    "type": "string",
+                    "example": "6999.00"
+                },
+                "status": {
+                    "description": "1: 上架 2: 下架",
+                    "type": "integer",
+                    "example": 1
                 },
                 "stock": {
                     "type": "integer",
                     "example": 100
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2023-12-20T10:00:00Z"
                 }
             }
         },
-        "handler.RegisterRequest": {
+        "model.Promotion": {
             "type": "object",
-            "required": [
-                "password",
-                "username"
-            ],
             "properties": {
-                "password": {
+                "bundle_price": {
+                    "description": "每组的组合价，bundle类型",
                     "type": "string",
-                    "maxLength": 32,
-                    "minLength": 6,
-                    "example": "password123"
+                    "example": "0"
                 },
-                "username": {
+                "buy_quantity": {
+                    "description": "买赠需购买的件数",
+                    "type": "integer",
+                    "example": 2
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "disabled": {
+                    "description": "停用",
+                    "type": "boolean",
+                    "example": false
+                },
+                "ends_at": {
+                    "description": "失效时间，为空表示长期有效",
+                    "type": "string"
+                },
+                "exclusive": {
+                    "description": "独占：之前已有促销生效时不参与，生效后不再计算其他促销",
+                    "type": "boolean",
+                    "example": false
+                },
+                "free_quantity": {
+                    "description": "买赠免费的件数",
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "items": {
+                    "description": "买赠和满减的适用范围，为空表示全部商品；组合的商品和数量",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PromotionItem"
+                    }
+                },
+                "name": {
+                    "description": "名称，显示在订单优惠明细中",
                     "type": "string",
-                    "maxLength": 32,
-                    "minLength": 3,
-                    "example": "testuser"
+                    "example": "耳机买二送一"
+                },
+                "priority": {
+                    "description": "优先级，大的先计算",
+                    "type": "integer",
+                    "example": 10
+                },
+                "starts_at": {
+                    "description": "生效时间，为空表示立即生效",
+                    "type": "string"
+                },
+                "tiers": {
+                    "description": "满减档位，tiered类型",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PromotionTier"
+                    }
+                },
+                "type": {
+                    "description": "类型 buy_x_get_y/tiered/bundle",
+                    "type": "string",
+                    "example": "buy_x_get_y"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "handler.RegisterResponse": {
+        "model.PromotionItem": {
             "type": "object",
             "properties": {
-                "message": {
-                    "type": "string",
-                    "example": "注册成功"
+                "category_id": {
+                    "type": "integer"
                 },
-                "user_id": {
+                "product_id": {
                     "type": "integer",
                     "example": 1
+                },
+                "quantity": {
+                    "description": "组合内的数量",
+                    "type": "integer"
                 }
             }
         },
-        "handler.Response": {
+        "model.PromotionTier": {
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "integer",
-                    "example": 200
+                "amount_off": {
+                    "description": "减免金额",
+                    "type": "string",
+                    "example": "50.00"
                 },
-                "data": {},
-                "message": {
+                "threshold": {
+                    "description": "门槛金额",
                     "type": "string",
-                    "example": "success"
+                    "example": "500.00"
                 }
             }
         },
-        "handler.UserInfo": {
+        "model.SecurityEvent": {
             "type": "object",
             "properties": {
+                "created_at": {
+                    "description": "发生时间",
+                    "type": "string"
+                },
+                "detail": {
+                    "description": "附加说明",
+                    "type": "string"
+                },
                 "id": {
-                    "type": "integer",
-                    "example": 1
+                    "description": "事件ID，主键",
+                    "type": "integer"
+                },
+                "ip": {
+                    "description": "客户端IP",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "事件类型",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "关联用户ID，未知用户为0",
+                    "type": "integer"
                 },
                 "username": {
-                    "type": "string",
-                    "example": "testuser"
+                    "description": "尝试登录的用户名",
+                    "type": "string"
                 }
             }
         },
-        "model.Order": {
-            "type": "object"
-        },
-        "model.OrderItem": {
+        "model.ShippingAddress": {
             "type": "object",
             "properties": {
-                "id": {
-                    "description": "订单项ID，主键",
-                    "type": "integer"
+                "city": {
+                    "description": "市",
+                    "type": "string",
+                    "example": "深圳市"
                 },
-                "orderID": {
-                    "description": "订单ID，外键",
-                    "type": "integer"
+                "detail": {
+                    "description": "详细地址",
+                    "type": "string",
+                    "example": "科技园南区1栋101"
                 },
-                "price": {
-                    "description": "商品单价",
-                    "type": "number"
+                "district": {
+                    "description": "区县",
+                    "type": "string",
+                    "example": "南山区"
                 },
-                "productID": {
-                    "description": "商品ID，外键",
-                    "type": "integer"
+                "name": {
+                    "description": "收货人",
+                    "type": "string",
+                    "example": "张三"
                 },
-                "quantity": {
-                    "description": "购买数量",
-                    "type": "integer"
+                "phone": {
+                    "description": "联系电话",
+                    "type": "string",
+                    "example": "13800138000"
+                },
+                "postal_code": {
+                    "description": "邮政编码",
+                    "type": "string",
+                    "example": "518000"
+                },
+                "province": {
+                    "description": "省",
+                    "type": "string",
+                    "example": "广东省"
                 }
             }
         },
-        "model.Product": {
+        "service.OIDCProviderInfo": {
             "type": "object",
             "properties": {
-                "category_id": {
-                    "type": "integer"
-                },
-                "created_at": {
+                "display_name": {
                     "type": "string",
-                    "example": "2023-12-20T10:00:00Z"
+                    "example": "Google"
                 },
-                "description": {
+                "name": {
                     "type": "string",
-                    "example": "最新款iPhone"
+                    "example": "google"
+                }
+            }
+        },
+        "service.ProfileExport": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "email_verified_at": {
+                    "type": "string"
                 },
                 "id": {
-                    "type": "integer",
-                    "example": 1
+                    "type": "integer"
                 },
-                "name": {
-                    "type": "string",
-                    "example": "iPhone 15"
+                "nickname": {
+                    "type": "string"
                 },
-                "price": {
-                    "type": "number",
-                    "example": 6999
+                "phone": {
+                    "type": "string"
                 },
-                "status": {
-                    "description": "1: 上架 2: 下架",
-                    "type": "integer",
-                    "example": 1
+                "two_factor_enabled": {
+                    "type": "boolean"
                 },
-                "stock": {
-                    "type": "integer",
-                    "example": 100
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.UserExport": {
+            "type": "object",
+            "properties": {
+                "addresses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Address"
+                    }
                 },
-                "updated_at": {
-                    "type": "string",
-                    "example": "2023-12-20T10:00:00Z"
+                "exported_at": {
+                    "type": "string"
+                },
+                "identities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Identity"
+                    }
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Order"
+                    }
+                },
+                "profile": {
+                    "$ref": "#/definitions/service.ProfileExport"
                 }
             }
         }
     },
     "securityDefinitions": {
+        "ApiKey": {
+            "description": "服务账号在请求头中添加 X-API-Key: {key} 进行身份验证",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
         "Bearer": {
             "description": "在请求头中添加 Authorization: Bearer {token} 进行身份验证",
             "type": "apiKey",
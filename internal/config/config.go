@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,25 +12,43 @@ type Config struct {
 	Server   ServerConfig   `mapstructure:"server"`
 	Database DatabaseConfig `mapstructure:"database"`
 	Redis    RedisConfig    `mapstructure:"redis"`
+	Cache    CacheConfig    `mapstructure:"cache"`
 	Log      LogConfig      `mapstructure:"log"`
+	Security SecurityConfig `mapstructure:"security"`
+	Mail     MailConfig     `mapstructure:"mail"`
+	Shop     ShopConfig     `mapstructure:"shop"`
+}
+
+// ShopConfig 商城业务配置
+type ShopConfig struct {
+	// Currency 本位币，商品价格和订单金额的币种，默认CNY
+	// 数据库中的金额不带币种，已有数据后修改本位币不会换算已有金额
+	Currency string `mapstructure:"currency"`
+	// ExchangeRates 本位币到各展示币种的汇率，如USD: "0.1384"表示1本位币兑换0.1384美元
+	// 汇率以字符串书写，避免按浮点数解析
+	ExchangeRates map[string]string `mapstructure:"exchange_rates"`
+	// ExchangeRatesFile 汇率文件，格式为{"base": "CNY", "rates": {"USD": "0.1384"}}
+	// 配置后代替exchange_rates，文件修改后自动使用新汇率，无需重启
+	ExchangeRatesFile string `mapstructure:"exchange_rates_file"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port      int    `mapstructure:"port"`
-	Mode      string `mapstructure:"mode"`
-	JWTSecret string `mapstructure:"jwt_secret"`
+	Port int    `mapstructure:"port"`
+	Mode string `mapstructure:"mode"`
 }
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
-	Driver          string `mapstructure:"driver"`
+	Driver          string `mapstructure:"driver"` // mysql/postgres/sqlite
 	Host            string `mapstructure:"host"`
 	Port            int    `mapstructure:"port"`
 	Username        string `mapstructure:"username"`
 	Password        string `mapstructure:"password"`
 	DBName          string `mapstructure:"dbname"`
-	Charset         string `mapstructure:"charset"`
+	Charset         string `mapstructure:"charset"` // mysql字符集
+	SSLMode         string `mapstructure:"sslmode"` // postgres的sslmode，默认disable
+	Path            string `mapstructure:"path"`    // sqlite数据库文件路径，:memory:表示内存数据库
 	MaxIdleConns    int    `mapstructure:"max_idle_conns"`
 	MaxOpenConns    int    `mapstructure:"max_open_conns"`
 	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime"`
@@ -45,6 +64,15 @@ type RedisConfig struct {
 	MinIdleConns int    `mapstructure:"min_idle_conns"`
 }
 
+// CacheConfig 缓存配置
+type CacheConfig struct {
+	Driver string `mapstructure:"driver"` // memory/redis，多实例部署时应使用redis共享限流、会话等状态
+	Prefix string `mapstructure:"prefix"` // redis键前缀
+
+	MaxEntries      int           `mapstructure:"max_entries"`      // memory缓存的最大条目数，超出时淘汰最久未使用的条目，吊销、锁定等安全状态不受影响
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"` // memory缓存清理过期条目的间隔
+}
+
 // LogConfig 日志配置
 type LogConfig struct {
 	Level      string `mapstructure:"level"`
@@ -55,10 +83,112 @@ type LogConfig struct {
 	Compress   bool   `mapstructure:"compress"`
 }
 
+// SecurityConfig 安全配置
+type SecurityConfig struct {
+	Login     LoginSecurityConfig `mapstructure:"login"`
+	Token     TokenConfig         `mapstructure:"token"`
+	Cookie    CookieConfig        `mapstructure:"cookie"`
+	TwoFactor TwoFactorConfig     `mapstructure:"two_factor"`
+	Account   AccountConfig       `mapstructure:"account"`
+	OIDC      OIDCConfig          `mapstructure:"oidc"`
+	Privacy   PrivacyConfig       `mapstructure:"privacy"`
+
+	// AuditSecret 审计日志中密码、密钥类配置摘要的HMAC密钥，各实例和重启前后需保持一致
+	// 为空时只记录是否设置，无法发现这类配置的修改
+	AuditSecret string `mapstructure:"audit_secret"`
+}
+
+// LoginSecurityConfig 登录防暴力破解配置
+type LoginSecurityConfig struct {
+	MaxUserFailures int           `mapstructure:"max_user_failures"` // 同一用户名失败次数上限
+	MaxIPFailures   int           `mapstructure:"max_ip_failures"`   // 同一IP失败次数上限
+	FailureWindow   time.Duration `mapstructure:"failure_window"`    // 失败计数窗口，从第一次失败开始计算
+	LockoutDuration time.Duration `mapstructure:"lockout_duration"`  // 锁定时长
+	BaseDelay       time.Duration `mapstructure:"base_delay"`        // 渐进延迟基数
+	MaxDelay        time.Duration `mapstructure:"max_delay"`         // 渐进延迟上限
+}
+
+// TokenConfig 令牌配置
+type TokenConfig struct {
+	AccessTTL  time.Duration      `mapstructure:"access_ttl"`  // 访问令牌有效期
+	RefreshTTL time.Duration      `mapstructure:"refresh_ttl"` // 刷新令牌有效期
+	Issuer     string             `mapstructure:"issuer"`      // 签发者(iss)
+	Audience   string             `mapstructure:"audience"`    // 受众(aud)
+	SigningKey string             `mapstructure:"signing_key"` // 当前用于签名的密钥ID
+	Keys       []SigningKeyConfig `mapstructure:"keys"`        // 全部密钥，未作为签名密钥的仅用于验签
+}
+
+// CookieConfig 浏览器访问令牌Cookie配置
+type CookieConfig struct {
+	Name   string `mapstructure:"name"`   // Cookie名称
+	Domain string `mapstructure:"domain"` // Cookie域名，为空时使用请求域名
+	Secure bool   `mapstructure:"secure"` // 仅通过HTTPS发送
+}
+
+// TwoFactorConfig 两步验证配置
+type TwoFactorConfig struct {
+	Issuer       string        `mapstructure:"issuer"`        // 验证器App中显示的服务名称
+	EnforceAdmin bool          `mapstructure:"enforce_admin"` // 管理员登录时未通过两步验证则只能设置两步验证，不能访问其他需要认证的接口
+	ChallengeTTL time.Duration `mapstructure:"challenge_ttl"` // 密码验证通过后等待输入验证码的时长
+}
+
+// AccountConfig 邮箱验证与找回密码配置
+type AccountConfig struct {
+	TokenSecret string        `mapstructure:"token_secret"` // 邮件链接令牌的HMAC密钥
+	VerifyTTL   time.Duration `mapstructure:"verify_ttl"`   // 邮箱验证链接有效期
+	ResetTTL    time.Duration `mapstructure:"reset_ttl"`    // 重置密码链接有效期
+	BaseURL     string        `mapstructure:"base_url"`     // 前端地址，用于拼接邮件中的链接
+}
+
+// PrivacyConfig 账号注销与个人数据配置
+type PrivacyConfig struct {
+	DeletionGrace     time.Duration `mapstructure:"deletion_grace"`     // 注销后保留个人信息的宽限期，期满后匿名化
+	AnonymizeInterval time.Duration `mapstructure:"anonymize_interval"` // 后台匿名化任务的执行间隔
+	ReauthWindow      time.Duration `mapstructure:"reauth_window"`      // 不输入密码或验证码时，注销账号要求最近一次登录不早于该时长
+}
+
+// OIDCConfig 第三方登录配置
+type OIDCConfig struct {
+	StateTTL  time.Duration        `mapstructure:"state_ttl"` // 跳转到身份提供方后完成登录的时限
+	Providers []OIDCProviderConfig `mapstructure:"providers"` // 身份提供方列表
+}
+
+// OIDCProviderConfig OpenID Connect身份提供方配置
+type OIDCProviderConfig struct {
+	Name         string   `mapstructure:"name"`          // 提供方标识，出现在登录和回调地址中
+	DisplayName  string   `mapstructure:"display_name"`  // 登录按钮上显示的名称
+	Issuer       string   `mapstructure:"issuer"`        // 签发者地址，用于自动发现端点和公钥
+	ClientID     string   `mapstructure:"client_id"`     // 客户端ID
+	ClientSecret string   `mapstructure:"client_secret"` // 客户端密钥，公开客户端可为空
+	RedirectURL  string   `mapstructure:"redirect_url"`  // 回调地址，需与提供方登记的一致
+	Scopes       []string `mapstructure:"scopes"`        // 额外申请的权限，openid总是包含
+}
+
+// SigningKeyConfig 签名密钥配置
+type SigningKeyConfig struct {
+	Kid            string `mapstructure:"kid"`              // 密钥ID
+	Alg            string `mapstructure:"alg"`              // 算法：RS256/EdDSA
+	PrivateKeyFile string `mapstructure:"private_key_file"` // PEM私钥文件
+	PublicKeyFile  string `mapstructure:"public_key_file"`  // PEM公钥文件，只验签的旧密钥只需提供公钥
+}
+
+// MailConfig 邮件配置
+type MailConfig struct {
+	Driver     string `mapstructure:"driver"`      // smtp/file/memory
+	Host       string `mapstructure:"host"`        // SMTP服务器
+	Port       int    `mapstructure:"port"`        // SMTP端口
+	Username   string `mapstructure:"username"`    // SMTP用户名
+	Password   string `mapstructure:"password"`    // SMTP密码
+	From       string `mapstructure:"from"`        // 发件人
+	OutboxFile string `mapstructure:"outbox_file"` // file驱动的输出文件
+}
+
 // LoadConfig 加载配置
 func LoadConfig(configPath string) (*Config, error) {
 	viper.SetConfigFile(configPath)
 	viper.AutomaticEnv()
+	viper.SetDefault("security.cookie.name", "access_token")
+	viper.SetDefault("shop.currency", "CNY")
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("读取配置文件失败: %w", err)
@@ -72,14 +202,44 @@ func LoadConfig(configPath string) (*Config, error) {
 	return &config, nil
 }
 
+// SQLiteMemory 内存SQLite数据库的路径
+const SQLiteMemory = ":memory:"
+
 // GetDSN 获取数据库连接字符串
 func (c *DatabaseConfig) GetDSN() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
-		c.Username,
-		c.Password,
-		c.Host,
-		c.Port,
-		c.DBName,
-		c.Charset,
-	)
+	switch c.Driver {
+	case "postgres":
+		sslMode := c.SSLMode
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			c.Host,
+			c.Port,
+			c.Username,
+			c.Password,
+			c.DBName,
+			sslMode,
+		)
+	case "sqlite":
+		if c.IsMemory() {
+			return c.Path
+		}
+		// 写入冲突时等待而不是立即返回database is locked
+		return c.Path + "?_busy_timeout=5000&_journal_mode=WAL"
+	default:
+		return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
+			c.Username,
+			c.Password,
+			c.Host,
+			c.Port,
+			c.DBName,
+			c.Charset,
+		)
+	}
+}
+
+// IsMemory 是否使用内存SQLite数据库，进程退出后数据全部丢失
+func (c *DatabaseConfig) IsMemory() bool {
+	return c.Driver == "sqlite" && c.Path == SQLiteMemory
 }
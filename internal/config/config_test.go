@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestGetDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  DatabaseConfig
+		want string
+	}{
+		{"mysql", DatabaseConfig{Driver: "mysql", Host: "db", Port: 3306, Username: "u", Password: "p", DBName: "shop", Charset: "utf8mb4"},
+			"u:p@tcp(db:3306)/shop?charset=utf8mb4&parseTime=True&loc=Local"},
+		{"postgres", DatabaseConfig{Driver: "postgres", Host: "db", Port: 5432, Username: "u", Password: "p", DBName: "shop"},
+			"host=db port=5432 user=u password=p dbname=shop sslmode=disable"},
+		{"postgres sslmode", DatabaseConfig{Driver: "postgres", Host: "db", Port: 5432, Username: "u", Password: "p", DBName: "shop", SSLMode: "require"},
+			"host=db port=5432 user=u password=p dbname=shop sslmode=require"},
+		{"sqlite file", DatabaseConfig{Driver: "sqlite", Path: "./myshop.db"}, "./myshop.db?_busy_timeout=5000&_journal_mode=WAL"},
+		{"sqlite memory", DatabaseConfig{Driver: "sqlite", Path: SQLiteMemory}, ":memory:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.GetDSN(); got != tt.want {
+				t.Fatalf("GetDSN() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"myshop/internal/service"
+	"myshop/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// accountError 将邮箱验证、找回密码相关错误转换为响应
+func accountError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case service.ErrRateLimited:
+		c.JSON(429, ErrorResponse{Code: 429, Message: "操作过于频繁，请稍后再试"})
+	case service.ErrInvalidToken:
+		c.JSON(400, ErrorResponse{Code: 400, Message: "链接无效或已使用"})
+	case service.ErrTokenExpired:
+		c.JSON(400, ErrorResponse{Code: 400, Message: "链接已过期，请重新获取"})
+	case service.ErrEmailExists:
+		c.JSON(400, ErrorResponse{Code: 400, Message: "邮箱已被使用"})
+	case service.ErrEmailNotSet:
+		c.JSON(400, ErrorResponse{Code: 400, Message: "尚未设置邮箱"})
+	case service.ErrEmailVerified:
+		c.JSON(400, ErrorResponse{Code: 400, Message: "邮箱已验证"})
+	case service.ErrUnsupportedCurrency:
+		c.JSON(400, ErrorResponse{Code: 400, Message: "不支持的币种"})
+	case service.ErrUserNotFound:
+		c.JSON(404, ErrorResponse{Code: 404, Message: "用户不存在"})
+	default:
+		c.JSON(500, ErrorResponse{Code: 500, Message: fallback})
+	}
+}
+
+// ChangeEmailRequest 修改邮箱请求结构
+type ChangeEmailRequest struct {
+	Email string `json:"email" binding:"required,email,max=128" example:"test@example.com"`
+}
+
+// @Summary 修改邮箱
+// @Description 设置或修改当前用户的邮箱，修改后需要重新验证，验证邮件发送到新邮箱
+// @Tags 账号安全
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body ChangeEmailRequest true "新邮箱"
+// @Success 200 {object} Response "验证邮件已发送"
+// @Failure 400 {object} ErrorResponse "参数错误或邮箱已被使用"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Failure 429 {object} ErrorResponse "发送过于频繁"
+// @Router /user/email [put]
+func (h *UserHandler) ChangeEmail(c *gin.Context) {
+	var req ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: 邮箱格式不正确"})
+		return
+	}
+
+	if err := h.accountService.ChangeEmail(c.Request.Context(), middleware.CurrentUserID(c), req.Email); err != nil {
+		accountError(c, err, "修改邮箱失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "验证邮件已发送，请查收"})
+}
+
+// @Summary 发送邮箱验证邮件
+// @Description 向当前用户的邮箱重新发送验证邮件，每分钟最多一次
+// @Tags 账号安全
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response "验证邮件已发送"
+// @Failure 400 {object} ErrorResponse "未设置邮箱或邮箱已验证"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Failure 429 {object} ErrorResponse "发送过于频繁"
+// @Router /user/email/verify/send [post]
+func (h *UserHandler) SendVerification(c *gin.Context) {
+	if err := h.accountService.SendVerification(c.Request.Context(), middleware.CurrentUserID(c)); err != nil {
+		accountError(c, err, "发送验证邮件失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "验证邮件已发送，请查收"})
+}
+
+// TokenRequest 邮件链接中的令牌
+type TokenRequest struct {
+	Token string `json:"token" binding:"required" example:"eyJwIjoidmVyaWZ5X2VtYWlsIi..."`
+}
+
+// @Summary 验证邮箱
+// @Description 使用验证邮件中的令牌完成邮箱验证，令牌只能使用一次
+// @Tags 账号安全
+// @Accept json
+// @Produce json
+// @Param request body TokenRequest true "验证令牌"
+// @Success 200 {object} Response "验证成功"
+// @Failure 400 {object} ErrorResponse "令牌无效、已使用或已过期"
+// @Router /user/email/verify [post]
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	var req TokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误"})
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		accountError(c, err, "验证邮箱失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "邮箱验证成功"})
+}
+
+// ForgotPasswordRequest 忘记密码请求结构
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"test@example.com"`
+}
+
+// @Summary 忘记密码
+// @Description 向已验证的邮箱发送重置密码邮件；无论邮箱是否存在都返回相同结果
+// @Tags 账号安全
+// @Accept json
+// @Produce json
+// @Param request body ForgotPasswordRequest true "注册邮箱"
+// @Success 200 {object} Response "如果邮箱存在，重置邮件已发送"
+// @Failure 400 {object} ErrorResponse "参数错误"
+// @Failure 429 {object} ErrorResponse "请求过于频繁"
+// @Router /user/password/forgot [post]
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: 邮箱格式不正确"})
+		return
+	}
+
+	if err := h.accountService.ForgotPassword(c.Request.Context(), req.Email, c.ClientIP()); err != nil {
+		accountError(c, err, "发送重置邮件失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "如果该邮箱已注册并验证，重置密码邮件已发送"})
+}
+
+// ResetPasswordRequest 重置密码请求结构
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required" example:"eyJwIjoicmVzZXRfcGFzc3dvcmQi..."`
+	Password string `json:"password" binding:"required,min=6,max=32" example:"newpassword123"`
+}
+
+// @Summary 重置密码
+// @Description 使用重置邮件中的令牌设置新密码，成功后其他设备需要重新登录
+// @Tags 账号安全
+// @Accept json
+// @Produce json
+// @Param request body ResetPasswordRequest true "重置令牌和新密码"
+// @Success 200 {object} Response "重置成功"
+// @Failure 400 {object} ErrorResponse "参数错误、令牌无效或已过期"
+// @Router /user/password/reset [post]
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: 密码长度6-32位"})
+		return
+	}
+
+	if err := h.accountService.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		accountError(c, err, "重置密码失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "密码已重置，请使用新密码登录"})
+}
+
+// ChangePasswordRequest 修改密码请求结构
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required" example:"password123"`
+	NewPassword string `json:"new_password" binding:"required,min=6,max=32" example:"newpassword123"`
+}
+
+// @Summary 修改密码
+// @Description 校验当前密码后设置新密码，成功后其他设备上的会话全部失效
+// @Tags 账号安全
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body ChangePasswordRequest true "当前密码和新密码"
+// @Success 200 {object} Response "修改成功"
+// @Failure 400 {object} ErrorResponse "参数错误或当前密码错误"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Failure 429 {object} ErrorResponse "尝试过于频繁"
+// @Router /user/password [put]
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: 新密码长度6-32位"})
+		return
+	}
+
+	principal, _ := middleware.GetPrincipal(c)
+	err := h.accountService.ChangePassword(c.Request.Context(), principal.UserID, principal.SessionID, req.OldPassword, req.NewPassword)
+	if err != nil {
+		if err == service.ErrWrongPassword {
+			c.JSON(400, ErrorResponse{Code: 400, Message: "当前密码错误"})
+			return
+		}
+		accountError(c, err, "修改密码失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "密码修改成功，其他设备需要重新登录"})
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"myshop/internal/model"
+	"myshop/internal/service"
+	"myshop/pkg/middleware"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AddressHandler struct {
+	addressService *service.AddressService
+}
+
+func NewAddressHandler(addressService *service.AddressService) *AddressHandler {
+	return &AddressHandler{addressService: addressService}
+}
+
+// AddressRequest 收货地址请求结构
+type AddressRequest struct {
+	Name       string `json:"name" binding:"required,max=32" example:"张三"`
+	Phone      string `json:"phone" binding:"required,max=20" example:"13800138000"`
+	Province   string `json:"province" binding:"required,max=32" example:"广东省"`
+	City       string `json:"city" binding:"required,max=32" example:"深圳市"`
+	District   string `json:"district" binding:"max=32" example:"南山区"`
+	Detail     string `json:"detail" binding:"required,max=255" example:"科技园南区1栋101"`
+	PostalCode string `json:"postal_code" binding:"max=10" example:"518000"`
+	IsDefault  bool   `json:"is_default" example:"false"` // 仅新增时有效，修改默认地址请使用设为默认接口
+}
+
+func (r *AddressRequest) content() model.ShippingAddress {
+	return model.ShippingAddress{
+		Name:       r.Name,
+		Phone:      r.Phone,
+		Province:   r.Province,
+		City:       r.City,
+		District:   r.District,
+		Detail:     r.Detail,
+		PostalCode: r.PostalCode,
+	}
+}
+
+// @Summary 获取收货地址列表
+// @Description 获取当前用户的收货地址，默认地址在前
+// @Tags 收货地址
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=[]model.Address} "收货地址列表"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Router /user/addresses [get]
+func (h *AddressHandler) List(c *gin.Context) {
+	addresses, err := h.addressService.List(c.Request.Context(), middleware.CurrentUserID(c))
+	if err != nil {
+		c.JSON(500, ErrorResponse{Code: 500, Message: "获取收货地址失败"})
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "success", Data: addresses})
+}
+
+// @Summary 新增收货地址
+// @Description 新增收货地址，第一个地址自动设为默认地址
+// @Tags 收货地址
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body AddressRequest true "收货地址"
+// @Success 200 {object} Response{data=model.Address} "新增成功"
+// @Failure 400 {object} ErrorResponse "参数错误或地址数量已达上限"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Router /user/addresses [post]
+func (h *AddressHandler) Create(c *gin.Context) {
+	var req AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: 收货人、电话、省、市和详细地址必填"})
+		return
+	}
+
+	address := &model.Address{ShippingAddress: req.content(), IsDefault: req.IsDefault}
+	if err := h.addressService.Create(c.Request.Context(), middleware.CurrentUserID(c), address); err != nil {
+		if err == service.ErrAddressLimit {
+			c.JSON(400, ErrorResponse{Code: 400, Message: "收货地址数量已达上限"})
+			return
+		}
+		c.JSON(500, ErrorResponse{Code: 500, Message: "新增收货地址失败"})
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "新增成功", Data: address})
+}
+
+// @Summary 修改收货地址
+// @Description 修改收货地址内容，已创建订单中的收货地址不受影响
+// @Tags 收货地址
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "地址ID"
+// @Param request body AddressRequest true "收货地址"
+// @Success 200 {object} Response{data=model.Address} "修改成功"
+// @Failure 400 {object} ErrorResponse "参数错误"
+// @Failure 404 {object} ErrorResponse "地址不存在"
+// @Router /user/addresses/{id} [put]
+func (h *AddressHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的地址ID"})
+		return
+	}
+	var req AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: 收货人、电话、省、市和详细地址必填"})
+		return
+	}
+
+	address, err := h.addressService.Update(c.Request.Context(), middleware.CurrentUserID(c), uint(id), req.content())
+	if err != nil {
+		addressError(c, err, "修改收货地址失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "修改成功", Data: address})
+}
+
+// @Summary 设为默认地址
+// @Description 将指定地址设为默认地址，下单未指定地址时使用
+// @Tags 收货地址
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "地址ID"
+// @Success 200 {object} Response "设置成功"
+// @Failure 404 {object} ErrorResponse "地址不存在"
+// @Router /user/addresses/{id}/default [put]
+func (h *AddressHandler) SetDefault(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的地址ID"})
+		return
+	}
+
+	if err := h.addressService.SetDefault(c.Request.Context(), middleware.CurrentUserID(c), uint(id)); err != nil {
+		addressError(c, err, "设置默认地址失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "设置成功"})
+}
+
+// @Summary 删除收货地址
+// @Description 删除收货地址，删除默认地址时最近添加的地址成为新的默认地址
+// @Tags 收货地址
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "地址ID"
+// @Success 200 {object} Response "删除成功"
+// @Failure 404 {object} ErrorResponse "地址不存在"
+// @Router /user/addresses/{id} [delete]
+func (h *AddressHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的地址ID"})
+		return
+	}
+
+	if err := h.addressService.Delete(c.Request.Context(), middleware.CurrentUserID(c), uint(id)); err != nil {
+		addressError(c, err, "删除收货地址失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "删除成功"})
+}
+
+func addressError(c *gin.Context, err error, fallback string) {
+	if err == service.ErrAddressNotFound {
+		c.JSON(404, ErrorResponse{Code: 404, Message: "地址不存在"})
+		return
+	}
+	c.JSON(500, ErrorResponse{Code: 500, Message: fallback})
+}
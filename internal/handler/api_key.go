@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"myshop/internal/model"
+	"myshop/internal/service"
+	"myshop/pkg/middleware"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+	userService   *service.UserService
+}
+
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService, userService *service.UserService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService, userService: userService}
+}
+
+// CreateAPIKeyRequest 创建API Key请求
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=64" example:"ERP同步"`
+	Scopes        []string `json:"scopes" binding:"required,min=1" example:"products:write,orders:read_all"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"gte=0" example:"90"` // 0表示永不过期
+}
+
+// CreateAPIKeyResponse 创建API Key响应，明文密钥只返回这一次
+type CreateAPIKeyResponse struct {
+	Key    string       `json:"key" example:"msk_Xr3v9c..."`
+	APIKey model.APIKey `json:"api_key"`
+}
+
+// @Summary 创建API Key
+// @Description 为当前用户创建API Key，只能授予自己已有的权限；明文只在响应中出现一次
+// @Tags API Key
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body CreateAPIKeyRequest true "名称、权限范围和有效期"
+// @Success 200 {object} Response{data=CreateAPIKeyResponse} "创建成功"
+// @Failure 400 {object} ErrorResponse "参数错误或权限范围无效"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Router /user/api-keys [post]
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	h.create(c, middleware.CurrentUserID(c), false)
+}
+
+// @Summary 获取API Key列表
+// @Description 获取当前用户的API Key列表，不包含明文
+// @Tags API Key
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=[]model.APIKey} "API Key列表"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Router /user/api-keys [get]
+func (h *APIKeyHandler) List(c *gin.Context) {
+	h.list(c, middleware.CurrentUserID(c))
+}
+
+// @Summary 吊销API Key
+// @Description 吊销当前用户的API Key，立即失效
+// @Tags API Key
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "API Key ID"
+// @Success 200 {object} Response "吊销成功"
+// @Failure 404 {object} ErrorResponse "API Key不存在"
+// @Router /user/api-keys/{id} [delete]
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	h.revoke(c, false)
+}
+
+// CreateServiceAccountRequest 创建服务账号请求
+type CreateServiceAccountRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=32" example:"erp-sync"`
+}
+
+// @Summary 创建服务账号
+// @Description 创建不能使用密码登录、只能通过API Key访问的服务账号（需要管理员权限）
+// @Tags API Key
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body CreateServiceAccountRequest true "服务账号用户名"
+// @Success 200 {object} Response{data=UserInfo} "创建成功"
+// @Failure 400 {object} ErrorResponse "参数错误或用户名已存在"
+// @Failure 403 {object} ErrorResponse "权限不足"
+// @Router /admin/service-accounts [post]
+func (h *APIKeyHandler) CreateServiceAccount(c *gin.Context) {
+	var req CreateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: 用户名长度3-32位"})
+		return
+	}
+
+	user, err := h.userService.CreateServiceAccount(c.Request.Context(), req.Username)
+	if err != nil {
+		if err == service.ErrUserExists {
+			c.JSON(400, ErrorResponse{Code: 400, Message: "用户名已存在"})
+			return
+		}
+		c.JSON(500, ErrorResponse{Code: 500, Message: "创建服务账号失败"})
+		return
+	}
+
+	c.JSON(200, Response{
+		Code:    200,
+		Message: "创建成功",
+		Data:    UserInfo{ID: user.ID, Username: user.Username},
+	})
+}
+
+// @Summary 为用户创建API Key
+// @Description 为指定用户或服务账号创建API Key，可授予任意权限（需要管理员权限）
+// @Tags API Key
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Param request body CreateAPIKeyRequest true "名称、权限范围和有效期"
+// @Success 200 {object} Response{data=CreateAPIKeyResponse} "创建成功"
+// @Failure 400 {object} ErrorResponse "参数错误或权限范围无效"
+// @Failure 403 {object} ErrorResponse "权限不足"
+// @Failure 404 {object} ErrorResponse "用户不存在"
+// @Router /admin/users/{id}/api-keys [post]
+func (h *APIKeyHandler) AdminCreate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的用户ID"})
+		return
+	}
+	h.create(c, uint(id), true)
+}
+
+// @Summary 获取用户的API Key列表
+// @Description 获取指定用户或服务账号的API Key列表（需要管理员权限）
+// @Tags API Key
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Success 200 {object} Response{data=[]model.APIKey} "API Key列表"
+// @Failure 403 {object} ErrorResponse "权限不足"
+// @Router /admin/users/{id}/api-keys [get]
+func (h *APIKeyHandler) AdminList(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的用户ID"})
+		return
+	}
+	h.list(c, uint(id))
+}
+
+// @Summary 吊销任意API Key
+// @Description 吊销任意用户的API Key（需要管理员权限）
+// @Tags API Key
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "API Key ID"
+// @Success 200 {object} Response "吊销成功"
+// @Failure 403 {object} ErrorResponse "权限不足"
+// @Failure 404 {object} ErrorResponse "API Key不存在"
+// @Router /admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) AdminRevoke(c *gin.Context) {
+	h.revoke(c, true)
+}
+
+func (h *APIKeyHandler) create(c *gin.Context, ownerID uint, byAdmin bool) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误"})
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	key, plain, err := h.apiKeyService.Create(c.Request.Context(), ownerID, req.Name, req.Scopes, expiresAt, byAdmin)
+	if err != nil {
+		switch err {
+		case service.ErrUserNotFound:
+			c.JSON(404, ErrorResponse{Code: 404, Message: "用户不存在"})
+		case service.ErrInvalidScope:
+			c.JSON(400, ErrorResponse{Code: 400, Message: "权限范围无效或超出所有者的权限"})
+		case service.ErrInvalidExpiry:
+			c.JSON(400, ErrorResponse{Code: 400, Message: "过期时间无效"})
+		default:
+			c.JSON(500, ErrorResponse{Code: 500, Message: "创建API Key失败"})
+		}
+		return
+	}
+
+	c.JSON(200, Response{
+		Code:    200,
+		Message: "创建成功，请妥善保存密钥，之后将无法再次查看",
+		Data:    CreateAPIKeyResponse{Key: plain, APIKey: *key},
+	})
+}
+
+func (h *APIKeyHandler) list(c *gin.Context, ownerID uint) {
+	keys, err := h.apiKeyService.List(c.Request.Context(), ownerID)
+	if err != nil {
+		c.JSON(500, ErrorResponse{Code: 500, Message: "获取API Key列表失败"})
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "success", Data: keys})
+}
+
+func (h *APIKeyHandler) revoke(c *gin.Context, byAdmin bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的API Key ID"})
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(c.Request.Context(), middleware.CurrentUserID(c), uint(id), byAdmin); err != nil {
+		if err == service.ErrAPIKeyNotFound {
+			c.JSON(404, ErrorResponse{Code: 404, Message: "API Key不存在"})
+			return
+		}
+		c.JSON(500, ErrorResponse{Code: 500, Message: "吊销API Key失败"})
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "吊销成功"})
+}
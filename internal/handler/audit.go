@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"myshop/internal/repository"
+	"myshop/internal/service"
+	"myshop/pkg/middleware"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// auditContext 从请求中提取操作者信息，供业务层记录审计日志
+func auditContext(c *gin.Context) context.Context {
+	actor := service.Actor{
+		IP:        c.ClientIP(),
+		RequestID: middleware.GetRequestID(c),
+	}
+	if principal, ok := middleware.GetPrincipal(c); ok {
+		actor.UserID = principal.UserID
+		actor.Role = strings.Join(principal.Roles, ",")
+		actor.AuthMethod = string(principal.Method)
+	}
+	return service.WithActor(c.Request.Context(), actor)
+}
+
+// @Summary 查询审计日志
+// @Description 按条件分页查询商品、订单状态、用户角色和运行配置的变更记录（需要管理员权限）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param actor_id query int false "操作者用户ID"
+// @Param action query string false "动作" Enums(create, update, delete)
+// @Param resource_type query string false "资源类型" Enums(product, order, user, config)
+// @Param resource_id query string false "资源ID"
+// @Param request_id query string false "请求ID"
+// @Param since query string false "起始时间(RFC3339)"
+// @Param until query string false "截止时间(RFC3339)"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量，最多100" default(20)
+// @Success 200 {object} PageResponse{data=[]model.AuditLog} "审计日志列表"
+// @Failure 400 {object} ErrorResponse "参数错误"
+// @Failure 403 {object} ErrorResponse "权限不足"
+// @Router /admin/audit-logs [get]
+func (h *AuditHandler) List(c *gin.Context) {
+	actorID, _ := strconv.ParseUint(c.Query("actor_id"), 10, 32)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	page, pageSize = service.NormalizeAuditPage(page, pageSize)
+
+	filter := repository.AuditLogFilter{
+		ActorID:      uint(actorID),
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resource_type"),
+		ResourceID:   c.Query("resource_id"),
+		RequestID:    c.Query("request_id"),
+	}
+	var err error
+	if v := c.Query("since"); v != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: since格式应为RFC3339"})
+			return
+		}
+	}
+	if v := c.Query("until"); v != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: until格式应为RFC3339"})
+			return
+		}
+	}
+
+	logs, total, err := h.auditService.List(c.Request.Context(), filter, page, pageSize)
+	if err != nil {
+		c.JSON(500, ErrorResponse{Code: 500, Message: "查询审计日志失败"})
+		return
+	}
+
+	c.JSON(200, PageResponse{
+		Code:     200,
+		Message:  "success",
+		Data:     logs,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	})
+}
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"errors"
+	"myshop/internal/model"
+	"myshop/internal/service"
+	"myshop/pkg/money"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CouponHandler struct {
+	couponService *service.CouponService
+}
+
+func NewCouponHandler(couponService *service.CouponService) *CouponHandler {
+	return &CouponHandler{couponService: couponService}
+}
+
+// CouponRequest 创建或修改优惠券请求结构
+type CouponRequest struct {
+	Code         string               `json:"code" binding:"max=32" example:"NEWYEAR20"` // 兑换码，不区分大小写，仅创建时有效
+	Name         string               `json:"name" binding:"required,max=64" example:"新年满200减20"`
+	Type         string               `json:"type" binding:"required,oneof=percent fixed" example:"fixed"`
+	PercentOff   int                  `json:"percent_off" binding:"min=0,max=100" example:"0"` // 减免比例，percent类型
+	AmountOff    money.Amount         `json:"amount_off" swaggertype:"string" example:"20.00"` // 减免金额，fixed类型
+	MaxDiscount  money.Amount         `json:"max_discount" swaggertype:"string" example:"0"`   // percent类型的减免上限，为0表示不限
+	MinSpend     money.Amount         `json:"min_spend" swaggertype:"string" example:"200.00"` // 最低消费，按适用商品原价合计计算
+	Stackable    bool                 `json:"stackable" example:"true"`                        // 能否与其他可叠加的优惠券同时使用
+	TotalLimit   int                  `json:"total_limit" binding:"min=0" example:"1000"`      // 总使用次数，为0表示不限
+	PerUserLimit int                  `json:"per_user_limit" binding:"min=0" example:"1"`      // 每人使用次数，为0表示不限
+	StartsAt     *time.Time           `json:"starts_at" example:"2024-01-01T00:00:00+08:00"`   // 生效时间，为空表示立即生效
+	EndsAt       *time.Time           `json:"ends_at" example:"2024-02-01T00:00:00+08:00"`     // 失效时间，为空表示长期有效
+	Disabled     bool                 `json:"disabled" example:"false"`                        // 停用
+	Scopes       []CouponScopeRequest `json:"scopes" binding:"max=100,dive"`                   // 适用范围，为空表示全部商品
+}
+
+// CouponScopeRequest 优惠券适用范围，商品ID和分类ID二选一
+type CouponScopeRequest struct {
+	ProductID  uint `json:"product_id" example:"1"`
+	CategoryID uint `json:"category_id" example:"0"`
+}
+
+func (r *CouponRequest) coupon() *model.Coupon {
+	coupon := &model.Coupon{
+		Code:         r.Code,
+		Name:         strings.TrimSpace(r.Name),
+		Type:         r.Type,
+		PercentOff:   r.PercentOff,
+		AmountOff:    r.AmountOff,
+		MaxDiscount:  r.MaxDiscount,
+		MinSpend:     r.MinSpend,
+		Stackable:    r.Stackable,
+		TotalLimit:   r.TotalLimit,
+		PerUserLimit: r.PerUserLimit,
+		StartsAt:     r.StartsAt,
+		EndsAt:       r.EndsAt,
+		Disabled:     r.Disabled,
+		Scopes:       make([]model.CouponScope, len(r.Scopes)),
+	}
+	for i, scope := range r.Scopes {
+		coupon.Scopes[i] = model.CouponScope{ProductID: scope.ProductID, CategoryID: scope.CategoryID}
+	}
+	return coupon
+}
+
+// couponError 输出优惠券管理接口的错误响应
+func couponError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCoupon):
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: " + strings.TrimPrefix(err.Error(), service.ErrInvalidCoupon.Error()+": ")})
+	case errors.Is(err, service.ErrCouponCodeExists):
+		c.JSON(400, ErrorResponse{Code: 400, Message: "兑换码已存在"})
+	case errors.Is(err, service.ErrCouponNotFound):
+		c.JSON(404, ErrorResponse{Code: 404, Message: "优惠券不存在"})
+	default:
+		c.JSON(500, ErrorResponse{Code: 500, Message: fallback})
+	}
+}
+
+// couponCheckoutError 输出下单时使用优惠券失败的错误响应，err不是优惠券错误时返回false
+func couponCheckoutError(c *gin.Context, err error) bool {
+	var status int
+	var message string
+	switch {
+	case errors.Is(err, service.ErrCouponNotFound):
+		status, message = 400, "优惠券不存在"
+	case errors.Is(err, service.ErrCouponExpired):
+		status, message = 400, "优惠券不在有效期内"
+	case errors.Is(err, service.ErrCouponNotApplicable):
+		status, message = 400, "优惠券不适用于所选商品"
+	case errors.Is(err, service.ErrCouponMinSpend):
+		status, message = 400, "未达到优惠券的最低消费金额"
+	case errors.Is(err, service.ErrCouponNotStackable):
+		status, message = 400, "优惠券不能同时使用"
+	case errors.Is(err, service.ErrCouponUserLimit):
+		status, message = 409, "已达到该优惠券的使用次数上限"
+	case errors.Is(err, service.ErrCouponExhausted):
+		status, message = 409, "优惠券已被用完"
+	default:
+		return false
+	}
+	c.JSON(status, gin.H{"error": message, "detail": err.Error()})
+	return true
+}
+
+// @Summary 创建优惠券
+// @Description 创建按比例或固定金额减免的优惠券，可设置最低消费、使用次数、有效期、适用商品或分类以及能否叠加（需要管理员权限）
+// @Tags 优惠券
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body CouponRequest true "优惠券"
+// @Success 200 {object} Response{data=model.Coupon} "创建成功"
+// @Failure 400 {object} ErrorResponse "参数错误或兑换码已存在"
+// @Failure 403 {object} ErrorResponse "权限不足"
+// @Router /admin/coupons [post]
+func (h *CouponHandler) Create(c *gin.Context) {
+	var req CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误"})
+		return
+	}
+
+	coupon := req.coupon()
+	if err := h.couponService.Create(auditContext(c), coupon); err != nil {
+		couponError(c, err, "创建优惠券失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "创建成功", Data: coupon})
+}
+
+// @Summary 获取优惠券列表
+// @Description 分页获取优惠券，新创建的在前（需要管理员权限）
+// @Tags 优惠券
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Success 200 {object} PageResponse{data=[]model.Coupon} "优惠券列表"
+// @Failure 403 {object} ErrorResponse "权限不足"
+// @Router /admin/coupons [get]
+func (h *CouponHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	coupons, total, err := h.couponService.List(c.Request.Context(), page, pageSize)
+	if err != nil {
+		c.JSON(500, ErrorResponse{Code: 500, Message: "获取优惠券列表失败"})
+		return
+	}
+
+	c.JSON(200, PageResponse{Code: 200, Message: "success", Data: coupons, Total: total, Page: page, PageSize: pageSize})
+}
+
+// @Summary 获取优惠券详情
+// @Description 获取优惠券及已使用次数（需要管理员权限）
+// @Tags 优惠券
+// @Produce json
+// @Security Bearer
+// @Param id path int true "优惠券ID"
+// @Success 200 {object} Response{data=model.Coupon} "优惠券"
+// @Failure 404 {object} ErrorResponse "优惠券不存在"
+// @Router /admin/coupons/{id} [get]
+func (h *CouponHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的优惠券ID"})
+		return
+	}
+
+	coupon, err := h.couponService.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		couponError(c, err, "获取优惠券失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "success", Data: coupon})
+}
+
+// @Summary 修改优惠券
+// @Description 修改优惠券，兑换码和已使用次数不能修改；已下单的订单不受影响（需要管理员权限）
+// @Tags 优惠券
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "优惠券ID"
+// @Param request body CouponRequest true "优惠券"
+// @Success 200 {object} Response{data=model.Coupon} "修改成功"
+// @Failure 400 {object} ErrorResponse "参数错误"
+// @Failure 404 {object} ErrorResponse "优惠券不存在"
+// @Router /admin/coupons/{id} [put]
+func (h *CouponHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的优惠券ID"})
+		return
+	}
+	var req CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误"})
+		return
+	}
+
+	coupon := req.coupon()
+	coupon.ID = uint(id)
+	if err := h.couponService.Update(auditContext(c), coupon); err != nil {
+		couponError(c, err, "修改优惠券失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "修改成功", Data: coupon})
+}
+
+// @Summary 删除优惠券
+// @Description 删除优惠券，已使用的订单不受影响，兑换码不能再用于新的优惠券（需要管理员权限）
+// @Tags 优惠券
+// @Produce json
+// @Security Bearer
+// @Param id path int true "优惠券ID"
+// @Success 200 {object} Response "删除成功"
+// @Failure 404 {object} ErrorResponse "优惠券不存在"
+// @Router /admin/coupons/{id} [delete]
+func (h *CouponHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的优惠券ID"})
+		return
+	}
+
+	if err := h.couponService.Delete(auditContext(c), uint(id)); err != nil {
+		couponError(c, err, "删除优惠券失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "删除成功"})
+}
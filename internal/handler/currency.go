@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"myshop/internal/service"
+	"myshop/pkg/money"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currencyHeader 指定展示和支付币种的请求头，未指定时使用用户资料中的偏好币种或本位币
+const currencyHeader = "X-Currency"
+
+type CurrencyHandler struct {
+	currencyService *service.CurrencyService
+}
+
+func NewCurrencyHandler(currencyService *service.CurrencyService) *CurrencyHandler {
+	return &CurrencyHandler{currencyService: currencyService}
+}
+
+// CurrencyListResponse 可选币种列表
+type CurrencyListResponse struct {
+	Base       string   `json:"base" example:"CNY"`                   // 本位币，商品定价和结算的币种
+	Currencies []string `json:"currencies" example:"CNY,EUR,JPY,USD"` // 可以通过X-Currency请求头选择的币种
+}
+
+// @Summary 获取可选币种
+// @Description 获取本位币和可以通过X-Currency请求头选择的展示和支付币种
+// @Tags 商品管理
+// @Produce json
+// @Success 200 {object} Response{data=CurrencyListResponse} "币种列表"
+// @Router /currencies [get]
+func (h *CurrencyHandler) List(c *gin.Context) {
+	c.JSON(200, Response{Code: 200, Message: "success", Data: CurrencyListResponse{
+		Base:       money.DefaultCurrency(),
+		Currencies: h.currencyService.Supported(),
+	}})
+}
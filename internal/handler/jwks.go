@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"myshop/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	keyRing *utils.KeyRing
+}
+
+func NewJWKSHandler(keyRing *utils.KeyRing) *JWKSHandler {
+	return &JWKSHandler{keyRing: keyRing}
+}
+
+// Get 以JWKS格式公开验签公钥，供其他服务校验本服务签发的令牌
+// 挂载在 /.well-known/jwks.json，不在 /api 路径下
+func (h *JWKSHandler) Get(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(200, h.keyRing.JWKS())
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"myshop/internal/config"
+	"myshop/internal/model"
+	"myshop/internal/service"
+	"myshop/pkg/middleware"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCHandler struct {
+	oidcService *service.OIDCService
+	cookie      config.CookieConfig
+}
+
+func NewOIDCHandler(oidcService *service.OIDCService, cookie config.CookieConfig) *OIDCHandler {
+	return &OIDCHandler{oidcService: oidcService, cookie: cookie}
+}
+
+// oidcStateCookie 保存授权请求绑定值的Cookie名称
+const oidcStateCookie = "oidc_state"
+
+// setOIDCStateCookie 把授权请求绑定到当前浏览器，回调时校验
+func (h *OIDCHandler) setOIDCStateCookie(c *gin.Context, req *service.OIDCAuthRequest) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, req.Binding, int(req.TTL.Seconds()), "/", h.cookie.Domain, h.cookie.Secure, true)
+}
+
+// oidcError 将第三方登录相关错误转换为响应
+func oidcError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrOIDCProviderNotFound:
+		c.JSON(404, ErrorResponse{Code: 404, Message: "不支持的登录方式"})
+	case service.ErrOIDCProviderUnavailable:
+		c.JSON(503, ErrorResponse{Code: 503, Message: "第三方登录暂时不可用，请稍后再试"})
+	case service.ErrInvalidOIDCState:
+		c.JSON(400, ErrorResponse{Code: 400, Message: "登录已超时或链接已使用，请重新发起"})
+	case service.ErrOIDCExchange:
+		c.JSON(401, ErrorResponse{Code: 401, Message: "第三方登录验证失败"})
+	case service.ErrIdentityLinked:
+		c.JSON(409, ErrorResponse{Code: 409, Message: "该第三方账号已关联其他用户"})
+	case service.ErrEmailExists:
+		c.JSON(409, ErrorResponse{Code: 409, Message: "该邮箱已注册，请使用原账号登录后关联第三方账号"})
+	case service.ErrInvalidCredentials:
+		c.JSON(401, ErrorResponse{Code: 401, Message: "该账号不允许登录"})
+	default:
+		c.JSON(500, ErrorResponse{Code: 500, Message: "第三方登录失败"})
+	}
+}
+
+// @Summary 获取第三方登录方式
+// @Description 获取已配置的第三方登录提供方，用于展示登录按钮
+// @Tags 第三方登录
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response{data=[]service.OIDCProviderInfo} "提供方列表"
+// @Router /auth/oidc/providers [get]
+func (h *OIDCHandler) Providers(c *gin.Context) {
+	c.JSON(200, Response{Code: 200, Message: "success", Data: h.oidcService.Providers()})
+}
+
+// @Summary 第三方登录
+// @Description 跳转到第三方身份提供方进行登录，完成后回调 /auth/oidc/{provider}/callback
+// @Tags 第三方登录
+// @Param provider path string true "提供方标识" example(google)
+// @Success 302 "跳转到身份提供方"
+// @Failure 404 {object} ErrorResponse "不支持的登录方式"
+// @Failure 503 {object} ErrorResponse "身份提供方不可用"
+// @Router /auth/oidc/{provider}/login [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	req, err := h.oidcService.AuthURL(c.Param("provider"), 0)
+	if err != nil {
+		oidcError(c, err)
+		return
+	}
+
+	h.setOIDCStateCookie(c, req)
+	c.Redirect(302, req.URL)
+}
+
+// @Summary 第三方登录回调
+// @Description 身份提供方登录完成后的回调地址，必须由发起登录或关联的浏览器访问（校验oidc_state Cookie）。登录流程返回token，首次登录自动创建账号；关联流程返回关联结果
+// @Tags 第三方登录
+// @Produce json
+// @Param provider path string true "提供方标识"
+// @Param code query string true "授权码"
+// @Param state query string true "状态参数"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} ErrorResponse "登录已超时或被拒绝"
+// @Failure 401 {object} ErrorResponse "第三方登录验证失败"
+// @Failure 409 {object} ErrorResponse "第三方账号或邮箱已被其他用户使用"
+// @Router /auth/oidc/{provider}/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if reason := c.Query("error"); reason != "" {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "第三方登录被拒绝: " + reason})
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误"})
+		return
+	}
+
+	binding, _ := c.Cookie(oidcStateCookie)
+	c.SetCookie(oidcStateCookie, "", -1, "/", h.cookie.Domain, h.cookie.Secure, true)
+
+	result, err := h.oidcService.Callback(c.Request.Context(), c.Param("provider"), state, binding, code, clientInfo(c))
+	if err != nil {
+		oidcError(c, err)
+		return
+	}
+
+	if result.LinkedUserID != 0 {
+		c.JSON(200, Response{Code: 200, Message: "关联成功"})
+		return
+	}
+	if result.Login.ChallengeToken != "" {
+		c.JSON(200, LoginResponse{TwoFactorRequired: true, ChallengeToken: result.Login.ChallengeToken})
+		return
+	}
+
+	setTokenCookie(c, h.cookie, result.Login.Tokens.AccessToken, int(result.Login.Tokens.ExpiresIn))
+	c.JSON(200, newLoginResponse(result.Login.Tokens))
+}
+
+// LinkIdentityResponse 关联第三方账号响应结构
+type LinkIdentityResponse struct {
+	URL string `json:"url" example:"https://accounts.google.com/o/oauth2/v2/auth?client_id=..."`
+}
+
+// @Summary 关联第三方账号
+// @Description 为当前用户关联第三方账号，返回身份提供方的授权地址并写入oidc_state Cookie，前端在同一浏览器中跳转后在回调中完成关联
+// @Tags 第三方登录
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param provider path string true "提供方标识"
+// @Success 200 {object} Response{data=LinkIdentityResponse} "授权地址"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Failure 404 {object} ErrorResponse "不支持的登录方式"
+// @Router /user/identities/{provider} [post]
+func (h *OIDCHandler) Link(c *gin.Context) {
+	req, err := h.oidcService.AuthURL(c.Param("provider"), middleware.CurrentUserID(c))
+	if err != nil {
+		oidcError(c, err)
+		return
+	}
+
+	h.setOIDCStateCookie(c, req)
+	c.JSON(200, Response{Code: 200, Message: "success", Data: LinkIdentityResponse{URL: req.URL}})
+}
+
+// @Summary 获取已关联的第三方账号
+// @Description 获取当前用户关联的全部第三方账号
+// @Tags 第三方登录
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=[]model.Identity} "第三方账号列表"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Router /user/identities [get]
+func (h *OIDCHandler) ListIdentities(c *gin.Context) {
+	identities, err := h.oidcService.ListIdentities(c.Request.Context(), middleware.CurrentUserID(c))
+	if err != nil {
+		c.JSON(500, ErrorResponse{Code: 500, Message: "获取第三方账号失败"})
+		return
+	}
+	if identities == nil {
+		identities = []model.Identity{}
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "success", Data: identities})
+}
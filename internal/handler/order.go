@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"myshop/internal/model"
 	"myshop/internal/service"
+	"myshop/pkg/middleware"
+	"myshop/pkg/money"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -16,31 +19,87 @@ func NewOrderHandler(orderService *service.OrderService) *OrderHandler {
 	return &OrderHandler{orderService: orderService}
 }
 
+// CreateOrderRequest 创建订单请求结构
+type CreateOrderRequest struct {
+	AddressID       uint                     `json:"address_id" example:"0"` // 地址簿中的地址ID，为0时使用默认地址
+	ShippingAddress *OrderAddressRequest     `json:"shipping_address"`       // 直接填写的收货地址，不保存到地址簿，不能与address_id同时指定
+	Items           []CreateOrderItemRequest `json:"items" binding:"required,min=1,max=50,dive"`
+	CouponCodes     []string                 `json:"coupon_codes" binding:"max=3,dive,max=32" example:"NEWYEAR20"` // 使用的优惠券兑换码，不区分大小写
+}
+
+// OrderAddressRequest 下单时直接填写的收货地址，API Key调用方无法使用地址簿时使用
+type OrderAddressRequest struct {
+	Name       string `json:"name" binding:"required,max=32" example:"张三"`
+	Phone      string `json:"phone" binding:"required,max=20" example:"13800138000"`
+	Province   string `json:"province" binding:"required,max=32" example:"广东省"`
+	City       string `json:"city" binding:"required,max=32" example:"深圳市"`
+	District   string `json:"district" binding:"max=32" example:"南山区"`
+	Detail     string `json:"detail" binding:"required,max=255" example:"科技园南区1栋101"`
+	PostalCode string `json:"postal_code" binding:"max=10" example:"518000"`
+}
+
+func (r *OrderAddressRequest) content() model.ShippingAddress {
+	return model.ShippingAddress{
+		Name:       r.Name,
+		Phone:      r.Phone,
+		Province:   r.Province,
+		City:       r.City,
+		District:   r.District,
+		Detail:     r.Detail,
+		PostalCode: r.PostalCode,
+	}
+}
+
+// CreateOrderItemRequest 订单项请求结构，同一商品出现多次时合并数量
+type CreateOrderItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required" example:"1"`
+	Quantity  int  `json:"quantity" binding:"required,min=1,max=999" example:"2"`
+}
+
 // @Summary 创建订单
-// @Description 创建新订单
+// @Description 创建新订单，收货地址从地址簿中选择(address_id)，未指定时使用默认地址；也可以直接填写收货地址(shipping_address)，API Key调用方不能访问地址簿，应使用这种方式
+// @Description 订单保存地址、商品名称和单价快照
+// @Description 支付币种由X-Currency请求头指定，未指定时使用用户资料中的偏好币种；订单锁定下单时的汇率和应付金额(PayTotal)
+// @Description 自动应用当前生效的促销，可以再同时使用最多3张可叠加的优惠券(coupon_codes)，优惠明细保存在订单的Discounts中
 // @Tags 订单管理
 // @Accept json
 // @Produce json
 // @Security Bearer
-// @Param order body model.Order true "订单信息"
+// @Security ApiKey
+// @Param order body CreateOrderRequest true "订单信息"
+// @Param X-Currency header string false "支付币种"
 // @Success 200 {object} map[string]interface{} "创建成功"
-// @Failure 400 {object} map[string]interface{} "参数错误"
+// @Failure 400 {object} map[string]interface{} "参数错误、收货地址无效、商品已下架、不支持的币种或优惠券不可用"
 // @Failure 401 {object} map[string]interface{} "未授权"
-// @Failure 500 {object} map[string]interface{} "库存不足"
+// @Failure 409 {object} map[string]interface{} "库存不足或优惠券使用次数已达上限"
 // @Router /orders [post]
 func (h *OrderHandler) Create(c *gin.Context) {
-	var order model.Order
-	if err := c.ShouldBindJSON(&order); err != nil {
+	var req CreateOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": "参数错误"})
 		return
 	}
+	if req.ShippingAddress != nil && req.AddressID != 0 {
+		c.JSON(400, gin.H{"error": "address_id和shipping_address只能指定一个"})
+		return
+	}
 
-	// 从JWT中获取用户ID
-	userID, _ := c.Get("userID")
-	order.UserID = userID.(uint)
+	// 从认证主体中获取用户ID
+	order := model.Order{
+		UserID:      middleware.CurrentUserID(c),
+		AddressID:   req.AddressID,
+		PayCurrency: c.GetHeader(currencyHeader),
+		Items:       make([]model.OrderItem, len(req.Items)),
+	}
+	if req.ShippingAddress != nil {
+		order.ShippingAddress = req.ShippingAddress.content()
+	}
+	for i, item := range req.Items {
+		order.Items[i] = model.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
 
-	if err := h.orderService.Create(c.Request.Context(), &order); err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+	if err := h.orderService.Create(c.Request.Context(), &order, req.CouponCodes); err != nil {
+		checkoutError(c, err, "创建订单失败")
 		return
 	}
 
@@ -50,12 +109,124 @@ func (h *OrderHandler) Create(c *gin.Context) {
 	})
 }
 
+// PreviewOrderRequest 订单试算请求结构
+type PreviewOrderRequest struct {
+	Items       []CreateOrderItemRequest `json:"items" binding:"required,min=1,max=50,dive"`
+	CouponCodes []string                 `json:"coupon_codes" binding:"max=3,dive,max=32" example:"NEWYEAR20"` // 使用的优惠券兑换码，不区分大小写
+}
+
+// OrderPreviewResponse 订单试算结果，金额为本位币，PayTotal为支付币种
+type OrderPreviewResponse struct {
+	Lines         []OrderPreviewLine    `json:"lines"`
+	Subtotal      money.Amount          `json:"subtotal" swaggertype:"string" example:"299.97"`      // 原价合计
+	Discounts     []model.OrderDiscount `json:"discounts"`                                           // 优惠明细，按应用顺序，先促销后优惠券
+	DiscountTotal money.Amount          `json:"discount_total" swaggertype:"string" example:"99.99"` // 优惠合计
+	TotalPrice    money.Amount          `json:"total_price" swaggertype:"string" example:"199.98"`   // 扣除优惠后的总价
+	PayCurrency   string                `json:"pay_currency" example:"USD"`                          // 支付币种
+	ExchangeRate  string                `json:"exchange_rate" example:"0.125"`                       // 当前汇率，下单时重新锁定
+	PayTotal      money.Amount          `json:"pay_total" swaggertype:"string" example:"25.00"`      // 按当前汇率换算的应付金额
+}
+
+// OrderPreviewLine 订单试算的商品行
+type OrderPreviewLine struct {
+	ProductID   uint         `json:"product_id" example:"1"`
+	ProductName string       `json:"product_name" example:"无线耳机"`
+	Price       money.Amount `json:"price" swaggertype:"string" example:"99.99"`    // 单价
+	Quantity    int          `json:"quantity" example:"3"`                          // 数量
+	Amount      money.Amount `json:"amount" swaggertype:"string" example:"299.97"`  // 原价小计
+	Discount    money.Amount `json:"discount" swaggertype:"string" example:"99.99"` // 分摊到该行的优惠
+	Payable     money.Amount `json:"payable" swaggertype:"string" example:"199.98"` // 扣除优惠后的小计
+}
+
+// @Summary 订单试算
+// @Description 按与创建订单相同的规则计算金额：先自动应用当前生效的促销（买赠、满减、组合价），再应用优惠券
+// @Description 返回每个商品行分摊的优惠和优惠明细，不创建订单、不扣减库存、不占用优惠券；支付币种由X-Currency请求头指定
+// @Tags 订单管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Security ApiKey
+// @Param order body PreviewOrderRequest true "商品和优惠券"
+// @Param X-Currency header string false "支付币种"
+// @Success 200 {object} map[string]interface{} "试算结果，data为OrderPreviewResponse"
+// @Failure 400 {object} map[string]interface{} "参数错误、商品已下架、不支持的币种或优惠券不可用"
+// @Failure 401 {object} map[string]interface{} "未授权"
+// @Failure 409 {object} map[string]interface{} "库存不足或优惠券使用次数已达上限"
+// @Router /orders/preview [post]
+func (h *OrderHandler) Preview(c *gin.Context) {
+	var req PreviewOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "参数错误"})
+		return
+	}
+
+	order := model.Order{
+		UserID:      middleware.CurrentUserID(c),
+		PayCurrency: c.GetHeader(currencyHeader),
+		Items:       make([]model.OrderItem, len(req.Items)),
+	}
+	for i, item := range req.Items {
+		order.Items[i] = model.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+
+	quote, err := h.orderService.Preview(c.Request.Context(), &order, req.CouponCodes)
+	if err != nil {
+		checkoutError(c, err, "订单试算失败")
+		return
+	}
+
+	resp := OrderPreviewResponse{
+		Lines:         make([]OrderPreviewLine, len(quote.Lines)),
+		Subtotal:      quote.Subtotal,
+		Discounts:     quote.Discounts,
+		DiscountTotal: quote.DiscountTotal,
+		TotalPrice:    order.TotalPrice,
+		PayCurrency:   order.PayCurrency,
+		ExchangeRate:  order.ExchangeRate,
+		PayTotal:      order.PayTotal,
+	}
+	if resp.Discounts == nil {
+		resp.Discounts = []model.OrderDiscount{}
+	}
+	for i, line := range quote.Lines {
+		resp.Lines[i] = OrderPreviewLine{ProductID: line.ProductID, ProductName: line.Name, Price: line.UnitPrice,
+			Quantity: line.Quantity, Amount: line.Amount, Discount: line.Discount, Payable: line.Payable()}
+	}
+	c.JSON(200, gin.H{"data": resp})
+}
+
+// checkoutError 输出创建订单或订单试算失败的错误响应
+func checkoutError(c *gin.Context, err error, fallback string) {
+	if couponCheckoutError(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrAddressRequired):
+		c.JSON(400, gin.H{"error": "请先添加收货地址或在请求中填写收货地址"})
+	case errors.Is(err, service.ErrAddressNotFound):
+		c.JSON(400, gin.H{"error": "收货地址不存在"})
+	case errors.Is(err, service.ErrEmptyOrder), errors.Is(err, service.ErrInvalidQuantity):
+		c.JSON(400, gin.H{"error": "购买数量无效"})
+	case errors.Is(err, service.ErrOrderTooLarge):
+		c.JSON(400, gin.H{"error": "订单金额超出上限"})
+	case errors.Is(err, service.ErrUnsupportedCurrency):
+		c.JSON(400, gin.H{"error": "不支持的币种"})
+	case errors.Is(err, service.ErrProductUnavailable):
+		c.JSON(400, gin.H{"error": "商品不存在或已下架", "detail": err.Error()})
+	case errors.Is(err, service.ErrInsufficientStock):
+		c.JSON(409, gin.H{"error": "库存不足", "detail": err.Error()})
+	default:
+		c.JSON(500, gin.H{"error": fallback})
+	}
+}
+
 // @Summary 获取订单详情
 // @Description 获取订单详细信息
 // @Tags 订单管理
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Security ApiKey
 // @Param id path int true "订单ID"
 // @Success 200 {object} model.Order
 // @Failure 404 {object} map[string]interface{} "订单不存在"
@@ -67,12 +238,19 @@ func (h *OrderHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	order, err := h.orderService.GetByID(uint(id))
+	order, err := h.orderService.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
 		c.JSON(500, gin.H{"error": "获取订单失败"})
 		return
 	}
 
+	// 只能查看自己的订单，具备读取全部订单权限的主体除外
+	principal, _ := middleware.GetPrincipal(c)
+	if order.UserID != principal.UserID && !principal.HasScope(model.ScopeOrdersReadAll) {
+		c.JSON(404, gin.H{"error": "订单不存在"})
+		return
+	}
+
 	c.JSON(200, gin.H{"data": order})
 }
 
@@ -82,16 +260,47 @@ func (h *OrderHandler) GetByID(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Security ApiKey
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(10)
 // @Success 200 {object} ListResponse{data=[]model.Order} "订单列表"
 // @Router /orders [get]
 func (h *OrderHandler) GetUserOrders(c *gin.Context) {
-	userID, _ := c.Get("userID")
+	userID := middleware.CurrentUserID(c)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	orders, total, err := h.orderService.GetUserOrders(c.Request.Context(), userID, page, pageSize)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "获取订单列表失败"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"data":      orders,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// @Summary 获取全部订单列表
+// @Description 获取所有用户的订单列表，需要orders:read_all权限，供管理员和ERP等服务账号使用
+// @Tags 订单管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Security ApiKey
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Success 200 {object} ListResponse{data=[]model.Order} "订单列表"
+// @Failure 403 {object} map[string]interface{} "权限不足"
+// @Router /orders/all [get]
+func (h *OrderHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
 
-	orders, total, err := h.orderService.GetUserOrders(userID.(uint), page, pageSize)
+	orders, total, err := h.orderService.List(c.Request.Context(), page, pageSize)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "获取订单列表失败"})
 		return
@@ -109,3 +318,48 @@ func (h *OrderHandler) GetUserOrders(c *gin.Context) {
 func (h *OrderHandler) GetService() *service.OrderService {
 	return h.orderService
 }
+
+// UpdateOrderStatusRequest 修改订单状态请求结构
+type UpdateOrderStatusRequest struct {
+	Status int `json:"status" binding:"required" example:"3"` // 1待支付 2已支付 3已发货 4已完成 5已取消
+}
+
+// @Summary 修改订单状态
+// @Description 修改订单状态（需要管理员权限），变更记录在审计日志中。待支付可改为已支付或已取消，已支付可改为已发货或已取消，已发货可改为已完成；取消订单时归还库存和使用的优惠券
+// @Tags 订单管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "订单ID"
+// @Param request body UpdateOrderStatusRequest true "订单状态"
+// @Success 200 {object} map[string]interface{} "修改成功"
+// @Failure 400 {object} map[string]interface{} "参数错误或不允许的状态变更"
+// @Failure 404 {object} map[string]interface{} "订单不存在"
+// @Router /admin/orders/{id}/status [put]
+func (h *OrderHandler) UpdateStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的订单ID"})
+		return
+	}
+
+	var req UpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "参数错误"})
+		return
+	}
+
+	if err := h.orderService.UpdateStatus(auditContext(c), uint(id), req.Status); err != nil {
+		switch err {
+		case service.ErrInvalidOrderStatus:
+			c.JSON(400, gin.H{"error": "无效的订单状态"})
+		case service.ErrOrderNotFound:
+			c.JSON(404, gin.H{"error": "订单不存在"})
+		default:
+			c.JSON(500, gin.H{"error": "修改订单状态失败"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "修改成功"})
+}
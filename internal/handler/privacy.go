@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"fmt"
+	"myshop/internal/config"
+	"myshop/internal/service"
+	"myshop/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrivacyHandler struct {
+	privacyService *service.PrivacyService
+	cookie         config.CookieConfig
+}
+
+func NewPrivacyHandler(privacyService *service.PrivacyService, cookie config.CookieConfig) *PrivacyHandler {
+	return &PrivacyHandler{privacyService: privacyService, cookie: cookie}
+}
+
+// @Summary 导出个人数据
+// @Description 以JSON文件下载当前用户的个人资料、收货地址、订单和关联的第三方账号
+// @Tags 账号安全
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} service.UserExport "个人数据"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Router /user/export [get]
+func (h *PrivacyHandler) Export(c *gin.Context) {
+	export, err := h.privacyService.Export(c.Request.Context(), middleware.CurrentUserID(c))
+	if err != nil {
+		c.JSON(500, ErrorResponse{Code: 500, Message: "导出个人数据失败"})
+		return
+	}
+
+	filename := fmt.Sprintf("myshop-export-%d-%s.json", export.Profile.ID, export.ExportedAt.Format("20060102150405"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.IndentedJSON(200, export)
+}
+
+// DeleteAccountRequest 注销账号请求结构
+// 密码和两步验证码任选其一；都不提供时要求当前会话刚登录过
+type DeleteAccountRequest struct {
+	Password string `json:"password" example:"password123"`
+	Code     string `json:"code" example:"123456"`
+}
+
+// @Summary 注销账号
+// @Description 重新认证后注销当前账号：校验密码或两步验证码，也可以在刚登录（含第三方登录）后直接注销。注销后立即退出全部设备并吊销API Key，宽限期结束后个人信息被匿名化，订单保留用于对账
+// @Tags 账号安全
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body DeleteAccountRequest true "当前密码或两步验证码"
+// @Success 200 {object} Response "注销成功"
+// @Failure 400 {object} ErrorResponse "参数错误、密码或验证码错误"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Failure 403 {object} ErrorResponse "需要重新认证"
+// @Failure 429 {object} ErrorResponse "尝试过于频繁"
+// @Router /user [delete]
+func (h *PrivacyHandler) DeleteAccount(c *gin.Context) {
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误"})
+		return
+	}
+
+	var sessionID uint
+	if p, ok := middleware.GetPrincipal(c); ok {
+		sessionID = p.SessionID
+	}
+	if err := h.privacyService.DeleteAccount(c.Request.Context(), middleware.CurrentUserID(c), sessionID, req.Password, req.Code); err != nil {
+		switch err {
+		case service.ErrWrongPassword:
+			c.JSON(400, ErrorResponse{Code: 400, Message: "密码错误"})
+		case service.ErrInvalidTwoFactorCode:
+			c.JSON(400, ErrorResponse{Code: 400, Message: "验证码错误"})
+		case service.ErrReauthRequired:
+			c.JSON(403, ErrorResponse{Code: 403, Message: "请输入密码或验证码，或重新登录后再注销"})
+		case service.ErrRateLimited:
+			c.JSON(429, ErrorResponse{Code: 429, Message: "操作过于频繁，请稍后再试"})
+		case service.ErrUserNotFound:
+			c.JSON(404, ErrorResponse{Code: 404, Message: "用户不存在"})
+		default:
+			c.JSON(500, ErrorResponse{Code: 500, Message: "注销账号失败"})
+		}
+		return
+	}
+	setTokenCookie(c, h.cookie, "", -1)
+
+	c.JSON(200, Response{Code: 200, Message: "账号已注销"})
+}
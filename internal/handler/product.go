@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"myshop/internal/model"
 	"myshop/internal/service"
+	"myshop/pkg/money"
 	"strconv"
 	"time"
 
@@ -10,19 +12,20 @@ import (
 )
 
 type ProductHandler struct {
-	productService *service.ProductService
+	productService  *service.CachedProductService
+	currencyService *service.CurrencyService
 }
 
-func NewProductHandler(productService *service.ProductService) *ProductHandler {
-	return &ProductHandler{productService: productService}
+func NewProductHandler(productService *service.CachedProductService, currencyService *service.CurrencyService) *ProductHandler {
+	return &ProductHandler{productService: productService, currencyService: currencyService}
 }
 
 // CreateProductRequest 创建商品请求
 type CreateProductRequest struct {
-	Name        string  `json:"name" binding:"required" example:"iPhone 15"`
-	Description string  `json:"description" example:"最新款iPhone"`
-	Price       float64 `json:"price" binding:"required,gt=0" example:"6999.00"`
-	Stock       int     `json:"stock" binding:"required,gte=0" example:"100"`
+	Name        string       `json:"name" binding:"required" example:"iPhone 15"`
+	Description string       `json:"description" example:"最新款iPhone"`
+	Price       money.Amount `json:"price" swaggertype:"string" example:"6999.00"` // 十进制字符串或数字，小数位数不超过本位币精度
+	Stock       int          `json:"stock" binding:"required,gte=0" example:"100"`
 }
 
 // ProductResponse 商品响应
@@ -30,17 +33,47 @@ type ProductResponse struct {
 	ID          uint      `json:"id" example:"1"`
 	Name        string    `json:"name" example:"iPhone 15"`
 	Description string    `json:"description" example:"最新款iPhone"`
-	Price       float64   `json:"price" example:"6999.00"`
+	Price       string    `json:"price" example:"6999.00"`
 	Stock       int       `json:"stock" example:"100"`
 	CreatedAt   time.Time `json:"created_at" example:"2023-12-20T10:00:00Z"`
 }
 
+// ProductView 商品及按请求币种换算的展示价格
+// Price仍为本位币价格，下单时按本位币结算后再按下单时的汇率换算
+type ProductView struct {
+	model.Product
+	DisplayPrice money.Amount `json:"display_price" swaggertype:"string" example:"830.26"`
+}
+
+// displayRate 按请求头X-Currency获取本位币到展示币种的汇率
+// 商品接口不需要登录，不读取用户资料中的偏好币种，由客户端从用户信息中取得后放入请求头
+func (h *ProductHandler) displayRate(c *gin.Context) (money.Rate, bool) {
+	currency, err := h.currencyService.Resolve(c.Request.Context(), c.GetHeader(currencyHeader), 0)
+	if err == nil {
+		var rate money.Rate
+		if rate, err = h.currencyService.Quote(currency); err == nil {
+			return rate, true
+		}
+	}
+	if errors.Is(err, service.ErrUnsupportedCurrency) {
+		c.JSON(400, gin.H{"error": "不支持的币种"})
+	} else {
+		c.JSON(500, gin.H{"error": "获取汇率失败"})
+	}
+	return money.Rate{}, false
+}
+
+func newProductView(product model.Product, rate money.Rate) ProductView {
+	return ProductView{Product: product, DisplayPrice: service.Convert(product.Price, rate)}
+}
+
 // @Summary 创建商品
 // @Description 创建新商品（需要管理员权限）
 // @Tags 商品管理
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Security ApiKey
 // @Param request body CreateProductRequest true "商品信息"
 // @Success 200 {object} Response{data=ProductResponse} "创建成功"
 // @Failure 400 {object} ErrorResponse "参数错误"
@@ -60,7 +93,11 @@ func (h *ProductHandler) Create(c *gin.Context) {
 		Stock:       req.Stock,
 	}
 
-	if err := h.productService.Create(product); err != nil {
+	if err := h.productService.Create(auditContext(c), product); err != nil {
+		if err == service.ErrInvalidPrice {
+			c.JSON(400, ErrorResponse{Code: 400, Message: "商品价格必须大于0且小于100000000"})
+			return
+		}
 		c.JSON(500, ErrorResponse{Code: 500, Message: "创建商品失败"})
 		return
 	}
@@ -73,39 +110,55 @@ func (h *ProductHandler) Create(c *gin.Context) {
 }
 
 // @Summary 获取商品列表
-// @Description 获取商品列表，支持分页
+// @Description 获取商品列表，支持分页；display_price为按X-Currency指定币种换算的展示价格
 // @Tags 商品管理
 // @Accept json
 // @Produce json
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(10)
-// @Success 200 {object} ListResponse{data=[]model.Product} "商品列表"
+// @Param X-Currency header string false "展示币种，默认本位币"
+// @Success 200 {object} ListResponse{data=[]ProductView} "商品列表"
+// @Failure 400 {object} map[string]interface{} "不支持的币种"
 // @Router /products [get]
 func (h *ProductHandler) List(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
 
+	rate, ok := h.displayRate(c)
+	if !ok {
+		return
+	}
+
 	products, total, err := h.productService.List(page, pageSize)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "获取商品列表失败"})
 		return
 	}
 
+	views := make([]ProductView, len(products))
+	for i, product := range products {
+		views[i] = newProductView(product, rate)
+	}
+
 	c.JSON(200, gin.H{
-		"data":      products,
-		"total":     total,
-		"page":      page,
-		"page_size": pageSize,
+		"data":          views,
+		"total":         total,
+		"page":          page,
+		"page_size":     pageSize,
+		"currency":      rate.To,
+		"exchange_rate": rate.String(),
 	})
 }
 
 // @Summary 获取商品详情
-// @Description 根据ID获取商品详情
+// @Description 根据ID获取商品详情；display_price为按X-Currency指定币种换算的展示价格
 // @Tags 商品管理
 // @Accept json
 // @Produce json
 // @Param id path int true "商品ID"
-// @Success 200 {object} model.Product
+// @Param X-Currency header string false "展示币种，默认本位币"
+// @Success 200 {object} ProductView
+// @Failure 400 {object} map[string]interface{} "不支持的币种"
 // @Failure 404 {object} map[string]interface{} "商品不存在"
 // @Router /products/{id} [get]
 func (h *ProductHandler) GetByID(c *gin.Context) {
@@ -115,13 +168,26 @@ func (h *ProductHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	rate, ok := h.displayRate(c)
+	if !ok {
+		return
+	}
+
 	product, err := h.productService.GetByID(uint(id))
 	if err != nil {
+		if err == service.ErrProductNotFound {
+			c.JSON(404, gin.H{"error": "商品不存在"})
+			return
+		}
 		c.JSON(500, gin.H{"error": "获取商品失败"})
 		return
 	}
 
-	c.JSON(200, gin.H{"data": product})
+	c.JSON(200, gin.H{
+		"data":          newProductView(*product, rate),
+		"currency":      rate.To,
+		"exchange_rate": rate.String(),
+	})
 }
 
 // @Summary 更新商品
@@ -130,6 +196,7 @@ func (h *ProductHandler) GetByID(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Security ApiKey
 // @Param id path int true "商品ID"
 // @Param product body model.Product true "商品信息"
 // @Success 200 {object} map[string]interface{} "更新成功"
@@ -149,8 +216,15 @@ func (h *ProductHandler) Update(c *gin.Context) {
 	}
 
 	product.ID = uint(id)
-	if err := h.productService.Update(&product); err != nil {
-		c.JSON(500, gin.H{"error": "更新商品失败"})
+	if err := h.productService.Update(auditContext(c), &product); err != nil {
+		switch err {
+		case service.ErrProductNotFound:
+			c.JSON(404, gin.H{"error": "商品不存在"})
+		case service.ErrInvalidPrice:
+			c.JSON(400, gin.H{"error": "商品价格必须大于0且小于100000000"})
+		default:
+			c.JSON(500, gin.H{"error": "更新商品失败"})
+		}
 		return
 	}
 
@@ -163,6 +237,7 @@ func (h *ProductHandler) Update(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Security ApiKey
 // @Param id path int true "商品ID"
 // @Success 200 {object} map[string]interface{} "删除成功"
 // @Failure 404 {object} map[string]interface{} "商品不存在"
@@ -174,7 +249,11 @@ func (h *ProductHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.productService.Delete(uint(id)); err != nil {
+	if err := h.productService.Delete(auditContext(c), uint(id)); err != nil {
+		if err == service.ErrProductNotFound {
+			c.JSON(404, gin.H{"error": "商品不存在"})
+			return
+		}
 		c.JSON(500, gin.H{"error": "删除商品失败"})
 		return
 	}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"errors"
+	"myshop/internal/model"
+	"myshop/internal/service"
+	"myshop/pkg/money"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PromotionHandler struct {
+	promotionService *service.PromotionService
+}
+
+func NewPromotionHandler(promotionService *service.PromotionService) *PromotionHandler {
+	return &PromotionHandler{promotionService: promotionService}
+}
+
+// PromotionRequest 创建或修改促销请求结构
+type PromotionRequest struct {
+	Name         string                 `json:"name" binding:"required,max=64" example:"耳机买二送一"`
+	Type         string                 `json:"type" binding:"required,oneof=buy_x_get_y tiered bundle" example:"buy_x_get_y"`
+	Priority     int                    `json:"priority" example:"10"`                         // 优先级，大的先计算
+	Exclusive    bool                   `json:"exclusive" example:"false"`                     // 独占：之前已有促销生效时不参与，生效后不再计算其他促销
+	BuyQuantity  int                    `json:"buy_quantity" binding:"min=0" example:"2"`      // 买赠需购买的件数
+	FreeQuantity int                    `json:"free_quantity" binding:"min=0" example:"1"`     // 买赠免费的件数
+	BundlePrice  money.Amount           `json:"bundle_price" swaggertype:"string" example:"0"` // 每组的组合价
+	StartsAt     *time.Time             `json:"starts_at" example:"2024-01-01T00:00:00+08:00"` // 生效时间，为空表示立即生效
+	EndsAt       *time.Time             `json:"ends_at" example:"2024-02-01T00:00:00+08:00"`   // 失效时间，为空表示长期有效
+	Disabled     bool                   `json:"disabled" example:"false"`                      // 停用
+	Items        []PromotionItemRequest `json:"items" binding:"max=100,dive"`                  // 买赠和满减的适用范围，为空表示全部商品；组合的商品和数量
+	Tiers        []PromotionTierRequest `json:"tiers" binding:"max=10,dive"`                   // 满减档位
+}
+
+// PromotionItemRequest 促销商品，适用范围的商品ID和分类ID二选一，组合需指定商品ID和数量
+type PromotionItemRequest struct {
+	ProductID  uint `json:"product_id" example:"1"`
+	CategoryID uint `json:"category_id" example:"0"`
+	Quantity   int  `json:"quantity" binding:"min=0" example:"0"`
+}
+
+// PromotionTierRequest 满减档位
+type PromotionTierRequest struct {
+	Threshold money.Amount `json:"threshold" swaggertype:"string" example:"500.00"`
+	AmountOff money.Amount `json:"amount_off" swaggertype:"string" example:"50.00"`
+}
+
+func (r *PromotionRequest) promotion() *model.Promotion {
+	promotion := &model.Promotion{
+		Name:         r.Name,
+		Type:         r.Type,
+		Priority:     r.Priority,
+		Exclusive:    r.Exclusive,
+		BuyQuantity:  r.BuyQuantity,
+		FreeQuantity: r.FreeQuantity,
+		BundlePrice:  r.BundlePrice,
+		StartsAt:     r.StartsAt,
+		EndsAt:       r.EndsAt,
+		Disabled:     r.Disabled,
+		Items:        make([]model.PromotionItem, len(r.Items)),
+		Tiers:        make([]model.PromotionTier, len(r.Tiers)),
+	}
+	for i, item := range r.Items {
+		promotion.Items[i] = model.PromotionItem{ProductID: item.ProductID, CategoryID: item.CategoryID, Quantity: item.Quantity}
+	}
+	for i, tier := range r.Tiers {
+		promotion.Tiers[i] = model.PromotionTier{Threshold: tier.Threshold, AmountOff: tier.AmountOff}
+	}
+	return promotion
+}
+
+// promotionError 输出促销管理接口的错误响应
+func promotionError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidPromotion):
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: " + strings.TrimPrefix(err.Error(), service.ErrInvalidPromotion.Error()+": ")})
+	case errors.Is(err, service.ErrPromotionNotFound):
+		c.JSON(404, ErrorResponse{Code: 404, Message: "促销不存在"})
+	default:
+		c.JSON(500, ErrorResponse{Code: 500, Message: fallback})
+	}
+}
+
+// @Summary 创建促销
+// @Description 创建下单时自动应用的促销：买X送Y(buy_x_get_y)、阶梯满减(tiered)或组合价(bundle)（需要管理员权限）
+// @Description 多个促销按优先级从高到低计算，同一件商品只参与一个买赠或组合促销；满减按之前优惠后的金额计算门槛
+// @Tags 促销
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body PromotionRequest true "促销"
+// @Success 200 {object} Response{data=model.Promotion} "创建成功"
+// @Failure 400 {object} ErrorResponse "参数错误"
+// @Failure 403 {object} ErrorResponse "权限不足"
+// @Router /admin/promotions [post]
+func (h *PromotionHandler) Create(c *gin.Context) {
+	var req PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误"})
+		return
+	}
+
+	promotion := req.promotion()
+	if err := h.promotionService.Create(auditContext(c), promotion); err != nil {
+		promotionError(c, err, "创建促销失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "创建成功", Data: promotion})
+}
+
+// @Summary 获取促销列表
+// @Description 分页获取促销，新创建的在前（需要管理员权限）
+// @Tags 促销
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Success 200 {object} PageResponse{data=[]model.Promotion} "促销列表"
+// @Failure 403 {object} ErrorResponse "权限不足"
+// @Router /admin/promotions [get]
+func (h *PromotionHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	promotions, total, err := h.promotionService.List(c.Request.Context(), page, pageSize)
+	if err != nil {
+		c.JSON(500, ErrorResponse{Code: 500, Message: "获取促销列表失败"})
+		return
+	}
+
+	c.JSON(200, PageResponse{Code: 200, Message: "success", Data: promotions, Total: total, Page: page, PageSize: pageSize})
+}
+
+// @Summary 获取促销详情
+// @Description 获取促销及促销商品和满减档位（需要管理员权限）
+// @Tags 促销
+// @Produce json
+// @Security Bearer
+// @Param id path int true "促销ID"
+// @Success 200 {object} Response{data=model.Promotion} "促销"
+// @Failure 404 {object} ErrorResponse "促销不存在"
+// @Router /admin/promotions/{id} [get]
+func (h *PromotionHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的促销ID"})
+		return
+	}
+
+	promotion, err := h.promotionService.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		promotionError(c, err, "获取促销失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "success", Data: promotion})
+}
+
+// @Summary 修改促销
+// @Description 修改促销，已下单的订单不受影响（需要管理员权限）
+// @Tags 促销
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "促销ID"
+// @Param request body PromotionRequest true "促销"
+// @Success 200 {object} Response{data=model.Promotion} "修改成功"
+// @Failure 400 {object} ErrorResponse "参数错误"
+// @Failure 404 {object} ErrorResponse "促销不存在"
+// @Router /admin/promotions/{id} [put]
+func (h *PromotionHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的促销ID"})
+		return
+	}
+	var req PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误"})
+		return
+	}
+
+	promotion := req.promotion()
+	promotion.ID = uint(id)
+	if err := h.promotionService.Update(auditContext(c), promotion); err != nil {
+		promotionError(c, err, "修改促销失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "修改成功", Data: promotion})
+}
+
+// @Summary 删除促销
+// @Description 删除促销，已下单的订单不受影响（需要管理员权限）
+// @Tags 促销
+// @Produce json
+// @Security Bearer
+// @Param id path int true "促销ID"
+// @Success 200 {object} Response "删除成功"
+// @Failure 404 {object} ErrorResponse "促销不存在"
+// @Router /admin/promotions/{id} [delete]
+func (h *PromotionHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的促销ID"})
+		return
+	}
+
+	if err := h.promotionService.Delete(auditContext(c), uint(id)); err != nil {
+		promotionError(c, err, "删除促销失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "删除成功"})
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"myshop/internal/model"
+	"myshop/internal/service"
+	"myshop/pkg/middleware"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionInfo 登录会话信息
+type SessionInfo struct {
+	model.Session
+	Current bool `json:"current" example:"true"` // 是否为发起请求的会话
+}
+
+// @Summary 获取登录会话列表
+// @Description 获取当前用户所有有效的登录会话，包括设备、IP和最后活跃时间
+// @Tags 账号安全
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=[]SessionInfo} "会话列表"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Router /user/sessions [get]
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	principal, _ := middleware.GetPrincipal(c)
+	sessions, err := h.sessionService.List(c.Request.Context(), principal.UserID)
+	if err != nil {
+		c.JSON(500, ErrorResponse{Code: 500, Message: "获取会话列表失败"})
+		return
+	}
+
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		infos = append(infos, SessionInfo{Session: s, Current: s.ID == principal.SessionID})
+	}
+	c.JSON(200, Response{Code: 200, Message: "success", Data: infos})
+}
+
+// @Summary 吊销登录会话
+// @Description 吊销当前用户的指定会话，该设备上的访问令牌和刷新令牌立即失效
+// @Tags 账号安全
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "会话ID"
+// @Success 200 {object} Response "吊销成功"
+// @Failure 400 {object} ErrorResponse "无效的会话ID"
+// @Failure 404 {object} ErrorResponse "会话不存在"
+// @Router /user/sessions/{id} [delete]
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的会话ID"})
+		return
+	}
+
+	if err := h.sessionService.Revoke(c.Request.Context(), middleware.CurrentUserID(c), uint(id)); err != nil {
+		if err == service.ErrSessionNotFound {
+			c.JSON(404, ErrorResponse{Code: 404, Message: "会话不存在"})
+			return
+		}
+		c.JSON(500, ErrorResponse{Code: 500, Message: "吊销会话失败"})
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "吊销成功"})
+}
+
+// @Summary 吊销其他登录会话
+// @Description 吊销当前会话以外的全部会话，其他设备需要重新登录
+// @Tags 账号安全
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=int} "吊销的会话数量"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Router /user/sessions [delete]
+func (h *UserHandler) RevokeOtherSessions(c *gin.Context) {
+	principal, _ := middleware.GetPrincipal(c)
+	count, err := h.sessionService.RevokeOthers(c.Request.Context(), principal.UserID, principal.SessionID)
+	if err != nil {
+		c.JSON(500, ErrorResponse{Code: 500, Message: "吊销会话失败"})
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "吊销成功", Data: count})
+}
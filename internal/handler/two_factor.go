@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"errors"
+	"myshop/internal/service"
+	"myshop/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorLoginRequest 两步登录请求结构，验证码和恢复码二选一
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required" example:"Zk9wY2x1..."`
+	Code           string `json:"code" example:"123456"`
+	RecoveryCode   string `json:"recovery_code" example:"abcde-fghij"`
+}
+
+// @Summary 两步登录
+// @Description 使用登录接口返回的挑战令牌和验证器App中的验证码（或一次性恢复码）换取token
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorLoginRequest true "挑战令牌和验证码"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} ErrorResponse "参数错误"
+// @Failure 401 {object} ErrorResponse "验证码错误或挑战已失效"
+// @Failure 429 {object} ErrorResponse "失败次数过多，账号或IP被临时锁定"
+// @Header 401,429 {integer} Retry-After "失败后需要等待的秒数，等待结束前的尝试返回429"
+// @Router /user/login/2fa [post]
+func (h *UserHandler) LoginTwoFactor(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: 需要验证码或恢复码"})
+		return
+	}
+
+	pair, err := h.twoFactorService.VerifyLogin(c.Request.Context(), req.ChallengeToken, req.Code, req.RecoveryCode, clientInfo(c))
+	if err != nil {
+		setRetryAfter(c, err)
+		switch {
+		case errors.Is(err, service.ErrAccountLocked), errors.Is(err, service.ErrTooManyAttempts):
+			c.JSON(429, ErrorResponse{Code: 429, Message: "登录失败次数过多，请稍后再试"})
+		case errors.Is(err, service.ErrInvalidChallenge):
+			c.JSON(401, ErrorResponse{Code: 401, Message: "登录已超时，请重新输入用户名和密码"})
+		case errors.Is(err, service.ErrInvalidTwoFactorCode):
+			c.JSON(401, ErrorResponse{Code: 401, Message: "验证码错误"})
+		default:
+			c.JSON(500, ErrorResponse{Code: 500, Message: "登录失败"})
+		}
+		return
+	}
+
+	setTokenCookie(c, h.cookie, pair.AccessToken, int(pair.ExpiresIn))
+	c.JSON(200, newLoginResponse(pair))
+}
+
+// TwoFactorEnrollResponse 开通两步验证响应结构
+type TwoFactorEnrollResponse struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	URI    string `json:"uri" example:"otpauth://totp/MyShop:testuser?secret=JBSWY3DPEHPK3PXP&issuer=MyShop"`
+}
+
+// @Summary 开通两步验证
+// @Description 生成TOTP密钥并返回otpauth URI，用验证器App扫描后调用 /user/2fa/verify 完成开通
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} Response{data=TwoFactorEnrollResponse} "密钥和URI"
+// @Failure 400 {object} ErrorResponse "已启用两步验证"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Router /user/2fa/enroll [post]
+func (h *UserHandler) EnrollTwoFactor(c *gin.Context) {
+	secret, uri, err := h.twoFactorService.Enroll(c.Request.Context(), middleware.CurrentUserID(c))
+	if err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(200, Response{
+		Code:    200,
+		Message: "success",
+		Data:    TwoFactorEnrollResponse{Secret: secret, URI: uri},
+	})
+}
+
+// TwoFactorCodeRequest 验证码请求结构
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,len=6" example:"123456"`
+}
+
+// @Summary 确认开通两步验证
+// @Description 提交验证器App中的验证码以启用两步验证，返回的恢复码只显示这一次
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body TwoFactorCodeRequest true "验证码"
+// @Success 200 {object} Response{data=[]string} "恢复码"
+// @Failure 400 {object} ErrorResponse "验证码错误或未开始开通"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Router /user/2fa/verify [post]
+func (h *UserHandler) VerifyTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误"})
+		return
+	}
+
+	codes, err := h.twoFactorService.Activate(c.Request.Context(), middleware.CurrentUserID(c), req.Code)
+	if err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "两步验证已启用，请妥善保存恢复码", Data: codes})
+}
+
+// @Summary 关闭两步验证
+// @Description 提交当前验证码关闭两步验证，原有恢复码同时作废
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body TwoFactorCodeRequest true "验证码"
+// @Success 200 {object} Response "关闭成功"
+// @Failure 400 {object} ErrorResponse "验证码错误或未启用"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Router /user/2fa/disable [post]
+func (h *UserHandler) DisableTwoFactor(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误"})
+		return
+	}
+
+	if err := h.twoFactorService.Disable(c.Request.Context(), middleware.CurrentUserID(c), req.Code); err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "两步验证已关闭"})
+}
+
+// @Summary 重新生成恢复码
+// @Description 提交当前验证码重新生成恢复码，原有恢复码全部作废
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body TwoFactorCodeRequest true "验证码"
+// @Success 200 {object} Response{data=[]string} "新的恢复码"
+// @Failure 400 {object} ErrorResponse "验证码错误或未启用"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Router /user/2fa/recovery-codes [post]
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误"})
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(c.Request.Context(), middleware.CurrentUserID(c), req.Code)
+	if err != nil {
+		h.twoFactorError(c, err)
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "恢复码已重新生成，请妥善保存", Data: codes})
+}
+
+func (h *UserHandler) twoFactorError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrTwoFactorEnabled:
+		c.JSON(400, ErrorResponse{Code: 400, Message: "已启用两步验证"})
+	case service.ErrTwoFactorNotEnrolled:
+		c.JSON(400, ErrorResponse{Code: 400, Message: "未启用两步验证"})
+	case service.ErrInvalidTwoFactorCode:
+		c.JSON(400, ErrorResponse{Code: 400, Message: "验证码错误"})
+	case service.ErrUserNotFound:
+		c.JSON(404, ErrorResponse{Code: 404, Message: "用户不存在"})
+	default:
+		c.JSON(500, ErrorResponse{Code: 500, Message: "操作失败"})
+	}
+}
@@ -1,24 +1,67 @@
 package handler
 
 import (
+	"errors"
+	"math"
+	"myshop/internal/config"
 	"myshop/internal/model"
+	"myshop/internal/repository"
 	"myshop/internal/service"
+	"myshop/pkg/middleware"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type UserHandler struct {
-	userService *service.UserService
+	userService      *service.UserService
+	tokenService     *service.TokenService
+	twoFactorService *service.TwoFactorService
+	accountService   *service.AccountService
+	sessionService   *service.SessionService
+	cookie           config.CookieConfig
 }
 
-func NewUserHandler(userService *service.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
+func NewUserHandler(userService *service.UserService, tokenService *service.TokenService,
+	twoFactorService *service.TwoFactorService, accountService *service.AccountService,
+	sessionService *service.SessionService, cookie config.CookieConfig) *UserHandler {
+	return &UserHandler{
+		userService:      userService,
+		tokenService:     tokenService,
+		twoFactorService: twoFactorService,
+		accountService:   accountService,
+		sessionService:   sessionService,
+		cookie:           cookie,
+	}
+}
+
+// setTokenCookie 将访问令牌写入HTTP-only Cookie，供浏览器客户端使用
+// SameSite=Lax 阻止跨站POST携带Cookie，降低CSRF风险
+func setTokenCookie(c *gin.Context, cookie config.CookieConfig, token string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(cookie.Name, token, maxAge, "/", cookie.Domain, cookie.Secure, true)
+}
+
+// clientInfo 提取登录客户端信息，记录到登录会话中
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// setRetryAfter 登录失败需要等待时设置Retry-After响应头，单位为秒，不足1秒按1秒计
+func setRetryAfter(c *gin.Context, err error) {
+	var retry *service.RetryAfterError
+	if errors.As(err, &retry) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retry.After.Seconds()))))
+	}
 }
 
 // RegisterRequest 注册请求结构
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=32" example:"testuser"`
 	Password string `json:"password" binding:"required,min=6,max=32" example:"password123"`
+	Email    string `json:"email" binding:"omitempty,email,max=128" example:"test@example.com"` // 可选，填写后发送验证邮件
 }
 
 // RegisterResponse 注册响应结构
@@ -28,31 +71,36 @@ type RegisterResponse struct {
 }
 
 // @Summary 用户注册
-// @Description 创建新用户账号（仅需用户名和密码）
+// @Description 创建新用户账号，邮箱可选，填写后发送验证邮件
 // @Tags 用户管理
 // @Accept json
 // @Produce json
-// @Param request body RegisterRequest true "用户名和密码"
+// @Param request body RegisterRequest true "用户名、密码和邮箱"
 // @Success 200 {object} RegisterResponse
-// @Failure 400 {object} ErrorResponse "参数错误或用户名已存在"
+// @Failure 400 {object} ErrorResponse "参数错误或用户名、邮箱已存在"
 // @Failure 500 {object} ErrorResponse "服务器错误"
 // @Router /user/register [post]
 func (h *UserHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, ErrorResponse{Message: "参数错误: 用户名长度3-32位，密码长度6-32位"})
+		c.JSON(400, ErrorResponse{Message: "参数错误: 用户名长度3-32位，密码长度6-32位，邮箱格式需正确"})
 		return
 	}
 
 	user := &model.User{
 		Username: req.Username,
 		Password: req.Password,
+		Email:    req.Email,
 	}
 
-	if err := h.userService.Register(user); err != nil {
-		if err == service.ErrUserExists {
+	if err := h.userService.Register(c.Request.Context(), user); err != nil {
+		switch err {
+		case service.ErrUserExists:
 			c.JSON(400, ErrorResponse{Message: "用户名已存在"})
 			return
+		case service.ErrEmailExists:
+			c.JSON(400, ErrorResponse{Message: "邮箱已被使用"})
+			return
 		}
 		c.JSON(500, ErrorResponse{Message: "注册失败"})
 		return
@@ -71,12 +119,25 @@ type LoginRequest struct {
 }
 
 // LoginResponse 登录响应结构
+// 启用两步验证的用户只返回two_factor_required和challenge_token
 type LoginResponse struct {
-	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
+	Token             string `json:"token,omitempty" example:"eyJhbGciOiJIUzI1NiIs..."`
+	RefreshToken      string `json:"refresh_token,omitempty" example:"0cM2k3yGvJ7q..."`
+	ExpiresIn         int64  `json:"expires_in,omitempty" example:"900"`
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty" example:"false"`
+	ChallengeToken    string `json:"challenge_token,omitempty" example:"Zk9wY2x1..."`
+}
+
+func newLoginResponse(pair *service.TokenPair) LoginResponse {
+	return LoginResponse{
+		Token:        pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		ExpiresIn:    pair.ExpiresIn,
+	}
 }
 
 // @Summary 用户登录
-// @Description 使用用户名和密码登录获取token
+// @Description 使用用户名和密码登录获取token；启用两步验证的用户返回挑战令牌，需调用 /user/login/2fa 完成登录
 // @Tags 用户管理
 // @Accept json
 // @Produce json
@@ -84,6 +145,8 @@ type LoginResponse struct {
 // @Success 200 {object} LoginResponse
 // @Failure 400 {object} ErrorResponse "参数错误"
 // @Failure 401 {object} ErrorResponse "用户名或密码错误"
+// @Failure 429 {object} ErrorResponse "失败次数过多，账号或IP被临时锁定"
+// @Header 401,429 {integer} Retry-After "失败后需要等待的秒数，等待结束前的尝试返回429"
 // @Router /user/login [post]
 func (h *UserHandler) Login(c *gin.Context) {
 	var req LoginRequest
@@ -92,23 +155,123 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.userService.Login(req.Username, req.Password)
+	result, err := h.userService.Login(c.Request.Context(), req.Username, req.Password, clientInfo(c))
 	if err != nil {
-		c.JSON(401, ErrorResponse{Message: "用户名或密码错误"})
+		setRetryAfter(c, err)
+		switch {
+		case errors.Is(err, service.ErrAccountLocked), errors.Is(err, service.ErrTooManyAttempts):
+			c.JSON(429, ErrorResponse{Code: 429, Message: "登录失败次数过多，请稍后再试"})
+		default:
+			c.JSON(401, ErrorResponse{Message: "用户名或密码错误"})
+		}
+		return
+	}
+
+	if result.ChallengeToken != "" {
+		c.JSON(200, LoginResponse{TwoFactorRequired: true, ChallengeToken: result.ChallengeToken})
 		return
 	}
 
-	c.JSON(200, LoginResponse{Token: token})
+	setTokenCookie(c, h.cookie, result.Tokens.AccessToken, int(result.Tokens.ExpiresIn))
+	c.JSON(200, newLoginResponse(result.Tokens))
+}
+
+// RefreshRequest 刷新令牌请求结构
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required" example:"0cM2k3yGvJ7q..."`
+}
+
+// @Summary 刷新令牌
+// @Description 使用刷新令牌换取新的访问令牌，刷新令牌每次使用后轮换，旧令牌立即失效
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param request body RefreshRequest true "刷新令牌"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} ErrorResponse "参数错误"
+// @Failure 401 {object} ErrorResponse "刷新令牌无效或已失效"
+// @Router /user/refresh [post]
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Message: "参数错误"})
+		return
+	}
+
+	pair, err := h.tokenService.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidRefreshToken, service.ErrRefreshTokenReused:
+			c.JSON(401, ErrorResponse{Code: 401, Message: "刷新令牌无效或已失效，请重新登录"})
+		default:
+			c.JSON(500, ErrorResponse{Code: 500, Message: "刷新令牌失败"})
+		}
+		return
+	}
+
+	setTokenCookie(c, h.cookie, pair.AccessToken, int(pair.ExpiresIn))
+	c.JSON(200, newLoginResponse(pair))
+}
+
+// LogoutRequest 登出请求结构
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" example:"0cM2k3yGvJ7q..."`
+}
+
+// @Summary 退出登录
+// @Description 吊销当前访问令牌和登录会话，该次登录派生的全部刷新令牌一并失效
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body LogoutRequest false "刷新令牌"
+// @Success 200 {object} Response "退出成功"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Router /user/logout [post]
+func (h *UserHandler) Logout(c *gin.Context) {
+	var req LogoutRequest
+	// 请求体可选
+	_ = c.ShouldBindJSON(&req)
+
+	principal, _ := middleware.GetPrincipal(c)
+	if principal.TokenID != "" {
+		if err := h.tokenService.Logout(c.Request.Context(), principal.UserID, principal.SessionID, principal.TokenID, principal.ExpiresAt, req.RefreshToken); err != nil {
+			c.JSON(500, ErrorResponse{Code: 500, Message: "退出登录失败"})
+			return
+		}
+	}
+	setTokenCookie(c, h.cookie, "", -1)
+
+	c.JSON(200, Response{Code: 200, Message: "退出成功"})
 }
 
 // UserInfo 用户信息响应结构
 type UserInfo struct {
-	ID       uint   `json:"id" example:"1"`
-	Username string `json:"username" example:"testuser"`
+	ID            uint   `json:"id" example:"1"`
+	Username      string `json:"username" example:"testuser"`
+	Nickname      string `json:"nickname,omitempty" example:"小明"`
+	Phone         string `json:"phone,omitempty" example:"13800138000"`
+	AvatarURL     string `json:"avatar_url,omitempty" example:"https://cdn.example.com/avatar/1.png"`
+	Email         string `json:"email,omitempty" example:"test@example.com"`
+	EmailVerified bool   `json:"email_verified" example:"true"`
+	Currency      string `json:"currency,omitempty" example:"USD"` // 偏好币种，为空时使用本位币
+}
+
+func newUserInfo(user *model.User) UserInfo {
+	return UserInfo{
+		ID:            user.ID,
+		Username:      user.Username,
+		Nickname:      user.Nickname,
+		Phone:         user.Phone,
+		AvatarURL:     user.AvatarURL,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Currency:      user.Currency,
+	}
 }
 
 // @Summary 获取用户信息
-// @Description 获取当前登录用户的信息（仅返回ID和用户名）
+// @Description 获取当前登录用户的个人资料和邮箱验证状态
 // @Tags 用户管理
 // @Accept json
 // @Produce json
@@ -118,15 +281,192 @@ type UserInfo struct {
 // @Failure 500 {object} ErrorResponse "服务器错误"
 // @Router /user/info [get]
 func (h *UserHandler) GetInfo(c *gin.Context) {
-	userID, _ := c.Get("userID")
-	user, err := h.userService.GetByID(userID.(uint))
+	user, err := h.userService.GetByID(c.Request.Context(), middleware.CurrentUserID(c))
 	if err != nil {
 		c.JSON(500, ErrorResponse{Message: "获取用户信息失败"})
 		return
 	}
 
-	c.JSON(200, UserInfo{
-		ID:       user.ID,
-		Username: user.Username,
+	c.JSON(200, newUserInfo(user))
+}
+
+// UpdateProfileRequest 修改个人资料请求结构，未提供的字段保持不变
+type UpdateProfileRequest struct {
+	Nickname  *string `json:"nickname" binding:"omitempty,max=32" example:"小明"`
+	Phone     *string `json:"phone" binding:"omitempty,max=20" example:"13800138000"`
+	AvatarURL *string `json:"avatar_url" binding:"omitempty,url,max=255" example:"https://cdn.example.com/avatar/1.png"`
+	Email     *string `json:"email" binding:"omitempty,email,max=128" example:"test@example.com"`
+	Currency  *string `json:"currency" binding:"omitempty,len=3" example:"USD"` // 偏好币种，空字符串表示使用本位币
+}
+
+// @Summary 修改个人资料
+// @Description 修改昵称、手机号、头像、邮箱和偏好币种，未提供的字段保持不变；修改邮箱后需要重新验证
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body UpdateProfileRequest true "个人资料"
+// @Success 200 {object} Response{data=UserInfo} "修改成功"
+// @Failure 400 {object} ErrorResponse "参数错误、邮箱已被使用或不支持的币种"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Router /user/profile [put]
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: 昵称最多32位，头像需为有效地址，邮箱格式需正确"})
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(c.Request.Context(), middleware.CurrentUserID(c), service.ProfileUpdate{
+		Nickname:  req.Nickname,
+		Phone:     req.Phone,
+		AvatarURL: req.AvatarURL,
+		Email:     req.Email,
+		Currency:  req.Currency,
+	})
+	if err != nil {
+		accountError(c, err, "修改个人资料失败")
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "修改成功", Data: newUserInfo(user)})
+}
+
+// @Summary 解锁用户
+// @Description 解除因登录失败次数过多导致的账号锁定（需要管理员权限）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Success 200 {object} Response "解锁成功"
+// @Failure 400 {object} ErrorResponse "无效的用户ID"
+// @Failure 403 {object} ErrorResponse "权限不足"
+// @Failure 404 {object} ErrorResponse "用户不存在"
+// @Router /admin/users/{id}/unlock [post]
+func (h *UserHandler) Unlock(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的用户ID"})
+		return
+	}
+
+	operatorID := middleware.CurrentUserID(c)
+	if err := h.userService.Unlock(c.Request.Context(), uint(id), operatorID); err != nil {
+		if err == service.ErrUserNotFound {
+			c.JSON(404, ErrorResponse{Code: 404, Message: "用户不存在"})
+			return
+		}
+		c.JSON(500, ErrorResponse{Code: 500, Message: "解锁失败"})
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "解锁成功"})
+}
+
+// ChangeRoleRequest 修改用户角色请求结构
+type ChangeRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin" example:"admin"`
+}
+
+// @Summary 修改用户角色
+// @Description 在普通用户和管理员之间切换用户角色（需要管理员权限），用户需要重新登录，变更记录在审计日志中
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Param request body ChangeRoleRequest true "新角色"
+// @Success 200 {object} Response "修改成功"
+// @Failure 400 {object} ErrorResponse "参数错误或不允许修改"
+// @Failure 403 {object} ErrorResponse "权限不足"
+// @Failure 404 {object} ErrorResponse "用户不存在"
+// @Router /admin/users/{id}/role [put]
+func (h *UserHandler) ChangeRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "无效的用户ID"})
+		return
+	}
+
+	var req ChangeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: 角色只能是user或admin"})
+		return
+	}
+
+	if err := h.userService.ChangeRole(auditContext(c), uint(id), req.Role); err != nil {
+		switch err {
+		case service.ErrUserNotFound:
+			c.JSON(404, ErrorResponse{Code: 404, Message: "用户不存在"})
+		case service.ErrInvalidRole:
+			c.JSON(400, ErrorResponse{Code: 400, Message: "服务账号不能修改角色"})
+		case service.ErrChangeOwnRole:
+			c.JSON(400, ErrorResponse{Code: 400, Message: "不能修改自己的角色"})
+		default:
+			c.JSON(500, ErrorResponse{Code: 500, Message: "修改角色失败"})
+		}
+		return
+	}
+
+	c.JSON(200, Response{Code: 200, Message: "修改成功"})
+}
+
+// @Summary 查询安全事件
+// @Description 按条件分页查询登录失败、锁定等安全事件（需要管理员权限）
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param user_id query int false "用户ID"
+// @Param username query string false "用户名"
+// @Param ip query string false "客户端IP"
+// @Param type query string false "事件类型" Enums(login_failed, login_success, account_locked, ip_blocked, login_blocked, account_unlock)
+// @Param since query string false "起始时间(RFC3339)"
+// @Param until query string false "截止时间(RFC3339)"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} PageResponse{data=[]model.SecurityEvent} "安全事件列表"
+// @Failure 400 {object} ErrorResponse "参数错误"
+// @Failure 403 {object} ErrorResponse "权限不足"
+// @Router /admin/security-events [get]
+func (h *UserHandler) ListSecurityEvents(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 32)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	filter := repository.SecurityEventFilter{
+		UserID:   uint(userID),
+		Username: c.Query("username"),
+		IP:       c.Query("ip"),
+		Type:     c.Query("type"),
+	}
+	var err error
+	if v := c.Query("since"); v != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: since格式应为RFC3339"})
+			return
+		}
+	}
+	if v := c.Query("until"); v != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			c.JSON(400, ErrorResponse{Code: 400, Message: "参数错误: until格式应为RFC3339"})
+			return
+		}
+	}
+
+	events, total, err := h.userService.ListSecurityEvents(c.Request.Context(), filter, page, pageSize)
+	if err != nil {
+		c.JSON(500, ErrorResponse{Code: 500, Message: "查询安全事件失败"})
+		return
+	}
+
+	c.JSON(200, PageResponse{
+		Code:     200,
+		Message:  "success",
+		Data:     events,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
 	})
 }
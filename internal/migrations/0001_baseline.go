@@ -0,0 +1,236 @@
+package migrations
+
+import (
+	"myshop/pkg/migrate"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 基线迁移：引入版本化迁移之前由AutoMigrate创建的全部表
+// 使用AutoMigrate创建，对已有的数据库只补齐缺少的表和列，可以直接接管老数据库
+
+type baselineUser struct {
+	ID               uint   `gorm:"primarykey"`
+	Username         string `gorm:"uniqueIndex;size:32"`
+	Password         string `gorm:"size:128"`
+	Role             string `gorm:"size:16;default:user"`
+	Email            string `gorm:"size:128;index"`
+	EmailVerifiedAt  *time.Time
+	Nickname         string `gorm:"size:32"`
+	Phone            string `gorm:"size:20"`
+	AvatarURL        string `gorm:"size:255"`
+	TOTPSecret       string `gorm:"size:64"`
+	TwoFactorEnabled bool   `gorm:"default:false"`
+	AnonymizedAt     *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineProduct struct {
+	ID          uint    `gorm:"primarykey"`
+	Name        string  `gorm:"size:128;index"`
+	Description string  `gorm:"type:text"`
+	Price       float64 `gorm:"type:decimal(10,2)"`
+	Stock       int     `gorm:"default:0"`
+	Status      int     `gorm:"default:1"`
+	CategoryID  uint    `gorm:"index"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineProduct) TableName() string { return "products" }
+
+type baselineShippingAddress struct {
+	Name       string `gorm:"size:32"`
+	Phone      string `gorm:"size:20"`
+	Province   string `gorm:"size:32"`
+	City       string `gorm:"size:32"`
+	District   string `gorm:"size:32"`
+	Detail     string `gorm:"size:255"`
+	PostalCode string `gorm:"size:10"`
+}
+
+type baselineOrder struct {
+	ID              uint    `gorm:"primarykey"`
+	UserID          uint    `gorm:"index"`
+	OrderNo         string  `gorm:"uniqueIndex;size:32"`
+	Status          int     `gorm:"default:1"`
+	TotalPrice      float64 `gorm:"type:decimal(10,2)"`
+	AddressID       uint
+	ShippingAddress baselineShippingAddress `gorm:"embedded;embeddedPrefix:ship_"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineOrder) TableName() string { return "orders" }
+
+type baselineOrderItem struct {
+	ID        uint `gorm:"primarykey"`
+	OrderID   uint `gorm:"index"`
+	ProductID uint `gorm:"index"`
+	Quantity  int
+	Price     float64 `gorm:"type:decimal(10,2)"`
+}
+
+func (baselineOrderItem) TableName() string { return "order_items" }
+
+type baselineSecurityEvent struct {
+	ID        uint      `gorm:"primarykey"`
+	Type      string    `gorm:"size:32;index"`
+	UserID    uint      `gorm:"index"`
+	Username  string    `gorm:"size:32;index"`
+	IP        string    `gorm:"size:64;index"`
+	Detail    string    `gorm:"size:255"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (baselineSecurityEvent) TableName() string { return "security_events" }
+
+type baselineRefreshToken struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"index"`
+	FamilyID  string `gorm:"size:64;index"`
+	TokenHash string `gorm:"size:64;uniqueIndex"`
+	MFA       bool
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+func (baselineRefreshToken) TableName() string { return "refresh_tokens" }
+
+type baselineAPIKey struct {
+	ID         uint   `gorm:"primarykey"`
+	UserID     uint   `gorm:"index"`
+	Name       string `gorm:"size:64"`
+	Prefix     string `gorm:"size:16;index"`
+	KeyHash    string `gorm:"size:64;uniqueIndex"`
+	Scopes     string `gorm:"type:text"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (baselineAPIKey) TableName() string { return "api_keys" }
+
+type baselineRecoveryCode struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"index"`
+	CodeHash  string `gorm:"size:64"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (baselineRecoveryCode) TableName() string { return "recovery_codes" }
+
+type baselineUserToken struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"index"`
+	Purpose   string `gorm:"size:32"`
+	Email     string `gorm:"size:128"`
+	NonceHash string `gorm:"size:64;uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (baselineUserToken) TableName() string { return "user_tokens" }
+
+type baselineSession struct {
+	ID           uint   `gorm:"primarykey"`
+	UserID       uint   `gorm:"index"`
+	FamilyID     string `gorm:"size:64;uniqueIndex"`
+	Device       string `gorm:"size:64"`
+	IP           string `gorm:"size:64"`
+	UserAgent    string `gorm:"size:255"`
+	LastActiveAt time.Time
+	ExpiresAt    time.Time
+	RevokedAt    *time.Time
+	CreatedAt    time.Time
+}
+
+func (baselineSession) TableName() string { return "sessions" }
+
+type baselineIdentity struct {
+	ID          uint   `gorm:"primarykey"`
+	UserID      uint   `gorm:"index"`
+	Provider    string `gorm:"size:32;uniqueIndex:idx_identity_subject"`
+	Subject     string `gorm:"size:255;uniqueIndex:idx_identity_subject"`
+	Email       string `gorm:"size:128"`
+	LastLoginAt time.Time
+	CreatedAt   time.Time
+}
+
+func (baselineIdentity) TableName() string { return "identities" }
+
+type baselineAddress struct {
+	ID              uint                    `gorm:"primarykey"`
+	UserID          uint                    `gorm:"index"`
+	ShippingAddress baselineShippingAddress `gorm:"embedded"`
+	IsDefault       bool                    `gorm:"default:false"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineAddress) TableName() string { return "addresses" }
+
+type baselineAuditLog struct {
+	ID           uint      `gorm:"primarykey"`
+	ActorID      uint      `gorm:"index"`
+	ActorRole    string    `gorm:"size:64"`
+	AuthMethod   string    `gorm:"size:16"`
+	Action       string    `gorm:"size:32;index"`
+	ResourceType string    `gorm:"size:32;index:idx_audit_resource"`
+	ResourceID   string    `gorm:"size:64;index:idx_audit_resource"`
+	Changes      string    `gorm:"type:text"`
+	IP           string    `gorm:"size:64"`
+	RequestID    string    `gorm:"size:64;index"`
+	CreatedAt    time.Time `gorm:"index"`
+}
+
+func (baselineAuditLog) TableName() string { return "audit_logs" }
+
+func baselineTables() []interface{} {
+	return []interface{}{
+		&baselineUser{},
+		&baselineProduct{},
+		&baselineOrder{},
+		&baselineOrderItem{},
+		&baselineSecurityEvent{},
+		&baselineRefreshToken{},
+		&baselineAPIKey{},
+		&baselineRecoveryCode{},
+		&baselineUserToken{},
+		&baselineSession{},
+		&baselineIdentity{},
+		&baselineAddress{},
+		&baselineAuditLog{},
+	}
+}
+
+func init() {
+	register(migrate.Migration{
+		Version: 1,
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(baselineTables()...)
+		},
+		Down: func(tx *gorm.DB) error {
+			tables := baselineTables()
+			for i := len(tables) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(tables[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"myshop/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 订单项保存下单时的商品名称快照，商品改名或删除后订单中仍显示购买时的名称
+
+type orderItemV2 struct {
+	ProductName string `gorm:"size:128"`
+}
+
+func (orderItemV2) TableName() string { return "order_items" }
+
+func init() {
+	register(migrate.Migration{
+		Version: 2,
+		Name:    "order_item_product_name",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&orderItemV2{}, "ProductName") {
+				if err := tx.Migrator().AddColumn(&orderItemV2{}, "ProductName"); err != nil {
+					return err
+				}
+			}
+			// 已有订单项使用商品当前的名称，包括已删除的商品
+			return tx.Exec(`UPDATE order_items SET product_name = ` +
+				`(SELECT name FROM products WHERE products.id = order_items.product_id) ` +
+				`WHERE product_name IS NULL OR product_name = ''`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&orderItemV2{}, "ProductName")
+		},
+	})
+}
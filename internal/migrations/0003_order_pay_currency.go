@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"myshop/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 订单记录支付币种、下单时的汇率和换算后的应付金额，用户可以设置偏好币种
+
+type orderV3 struct {
+	PayCurrency  string `gorm:"size:3"`
+	ExchangeRate string `gorm:"size:24"`
+	PayTotal     string `gorm:"type:decimal(18,4)"`
+}
+
+func (orderV3) TableName() string { return "orders" }
+
+type userV3 struct {
+	Currency string `gorm:"size:3"`
+}
+
+func (userV3) TableName() string { return "users" }
+
+func init() {
+	register(migrate.Migration{
+		Version: 3,
+		Name:    "order_pay_currency",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range []string{"PayCurrency", "ExchangeRate", "PayTotal"} {
+				if !tx.Migrator().HasColumn(&orderV3{}, column) {
+					if err := tx.Migrator().AddColumn(&orderV3{}, column); err != nil {
+						return err
+					}
+				}
+			}
+			if !tx.Migrator().HasColumn(&userV3{}, "Currency") {
+				if err := tx.Migrator().AddColumn(&userV3{}, "Currency"); err != nil {
+					return err
+				}
+			}
+			// 已有订单按本位币支付，支付币种留空，读取时视为本位币
+			return tx.Exec(`UPDATE orders SET pay_total = total_price, exchange_rate = '1' ` +
+				`WHERE exchange_rate IS NULL OR exchange_rate = ''`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"PayCurrency", "ExchangeRate", "PayTotal"} {
+				if err := tx.Migrator().DropColumn(&orderV3{}, column); err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropColumn(&userV3{}, "Currency")
+		},
+	})
+}
@@ -0,0 +1,106 @@
+package migrations
+
+import (
+	"myshop/pkg/migrate"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 优惠券、适用范围、使用记录和订单优惠明细，订单记录优惠合计
+
+type couponV4 struct {
+	ID           uint   `gorm:"primarykey"`
+	Code         string `gorm:"uniqueIndex;size:32"`
+	Name         string `gorm:"size:64"`
+	Type         string `gorm:"size:16"`
+	PercentOff   int
+	AmountOff    string `gorm:"type:decimal(10,2)"`
+	MaxDiscount  string `gorm:"type:decimal(10,2)"`
+	MinSpend     string `gorm:"type:decimal(10,2)"`
+	Stackable    bool
+	TotalLimit   int
+	PerUserLimit int
+	UsedCount    int `gorm:"default:0"`
+	StartsAt     *time.Time
+	EndsAt       *time.Time
+	Disabled     bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+func (couponV4) TableName() string { return "coupons" }
+
+type couponScopeV4 struct {
+	ID         uint `gorm:"primarykey"`
+	CouponID   uint `gorm:"index"`
+	ProductID  uint
+	CategoryID uint
+}
+
+func (couponScopeV4) TableName() string { return "coupon_scopes" }
+
+type couponRedemptionV4 struct {
+	ID         uint `gorm:"primarykey"`
+	CouponID   uint `gorm:"index:idx_coupon_redemption_user"`
+	UserID     uint `gorm:"index:idx_coupon_redemption_user"`
+	OrderID    uint `gorm:"index"`
+	ReleasedAt *time.Time
+	CreatedAt  time.Time
+}
+
+func (couponRedemptionV4) TableName() string { return "coupon_redemptions" }
+
+type orderDiscountV4 struct {
+	ID          uint   `gorm:"primarykey"`
+	OrderID     uint   `gorm:"index"`
+	Source      string `gorm:"size:16"`
+	SourceID    uint
+	Code        string `gorm:"size:32"`
+	Description string `gorm:"size:128"`
+	Amount      string `gorm:"type:decimal(10,2)"`
+}
+
+func (orderDiscountV4) TableName() string { return "order_discounts" }
+
+type orderV4 struct {
+	DiscountTotal string `gorm:"type:decimal(10,2)"`
+}
+
+func (orderV4) TableName() string { return "orders" }
+
+func couponTables() []interface{} {
+	return []interface{}{&couponV4{}, &couponScopeV4{}, &couponRedemptionV4{}, &orderDiscountV4{}}
+}
+
+func init() {
+	register(migrate.Migration{
+		Version: 4,
+		Name:    "coupons",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(couponTables()...); err != nil {
+				return err
+			}
+			if !tx.Migrator().HasColumn(&orderV4{}, "DiscountTotal") {
+				if err := tx.Migrator().AddColumn(&orderV4{}, "DiscountTotal"); err != nil {
+					return err
+				}
+			}
+			// 已有订单没有优惠
+			return tx.Exec(`UPDATE orders SET discount_total = 0 WHERE discount_total IS NULL`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropColumn(&orderV4{}, "DiscountTotal"); err != nil {
+				return err
+			}
+			tables := couponTables()
+			for i := len(tables) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(tables[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,71 @@
+package migrations
+
+import (
+	"myshop/pkg/migrate"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 自动促销、促销商品和满减档位，促销优惠记录在订单优惠明细中，订单表不变
+
+type promotionV5 struct {
+	ID           uint   `gorm:"primarykey"`
+	Name         string `gorm:"size:64"`
+	Type         string `gorm:"size:16"`
+	Priority     int    `gorm:"index"`
+	Exclusive    bool
+	BuyQuantity  int
+	FreeQuantity int
+	BundlePrice  string `gorm:"type:decimal(10,2)"`
+	StartsAt     *time.Time
+	EndsAt       *time.Time
+	Disabled     bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+func (promotionV5) TableName() string { return "promotions" }
+
+type promotionItemV5 struct {
+	ID          uint `gorm:"primarykey"`
+	PromotionID uint `gorm:"index"`
+	ProductID   uint
+	CategoryID  uint
+	Quantity    int
+}
+
+func (promotionItemV5) TableName() string { return "promotion_items" }
+
+type promotionTierV5 struct {
+	ID          uint   `gorm:"primarykey"`
+	PromotionID uint   `gorm:"index"`
+	Threshold   string `gorm:"type:decimal(10,2)"`
+	AmountOff   string `gorm:"type:decimal(10,2)"`
+}
+
+func (promotionTierV5) TableName() string { return "promotion_tiers" }
+
+func promotionTables() []interface{} {
+	return []interface{}{&promotionV5{}, &promotionItemV5{}, &promotionTierV5{}}
+}
+
+func init() {
+	register(migrate.Migration{
+		Version: 5,
+		Name:    "promotions",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(promotionTables()...)
+		},
+		Down: func(tx *gorm.DB) error {
+			tables := promotionTables()
+			for i := len(tables) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(tables[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"fmt"
+	"myshop/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 非空邮箱全局唯一，由数据库保证，避免并发注册或修改邮箱时绕过应用层检查
+// 未填写邮箱的用户保存为空字符串，不参与唯一约束：
+// PostgreSQL和SQLite使用部分索引；MySQL不支持部分索引，改为在值为NULLIF(email, '')的虚拟生成列上建唯一索引，
+// 空字符串在生成列中为NULL，兼容MySQL 5.7
+// 唯一索引覆盖已注销但尚未匿名化的用户，宽限期内其邮箱不能被重新使用
+
+const (
+	usersEmailUniqueIndex  = "idx_users_email_unique"
+	usersEmailUniqueColumn = "email_key" // MySQL使用的生成列，模型中不映射
+)
+
+func init() {
+	register(migrate.Migration{
+		Version: 6,
+		Name:    "users_email_unique",
+		Up: func(tx *gorm.DB) error {
+			var duplicated []string
+			err := tx.Raw(`SELECT email FROM users WHERE email <> '' GROUP BY email HAVING COUNT(*) > 1`).
+				Scan(&duplicated).Error
+			if err != nil {
+				return err
+			}
+			if len(duplicated) > 0 {
+				return fmt.Errorf("以下邮箱被多个用户使用，请先处理后再执行迁移: %v", duplicated)
+			}
+
+			if tx.Dialector.Name() == "mysql" {
+				return tx.Exec(`ALTER TABLE users ADD COLUMN ` + usersEmailUniqueColumn +
+					` VARCHAR(128) GENERATED ALWAYS AS (NULLIF(email, '')) VIRTUAL, ` +
+					`ADD UNIQUE INDEX ` + usersEmailUniqueIndex + ` (` + usersEmailUniqueColumn + `)`).Error
+			}
+			return tx.Exec(`CREATE UNIQUE INDEX ` + usersEmailUniqueIndex + ` ON users (email) WHERE email <> ''`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() == "mysql" {
+				return tx.Exec(`ALTER TABLE users DROP INDEX ` + usersEmailUniqueIndex +
+					`, DROP COLUMN ` + usersEmailUniqueColumn).Error
+			}
+			return tx.Exec(`DROP INDEX ` + usersEmailUniqueIndex).Error
+		},
+	})
+}
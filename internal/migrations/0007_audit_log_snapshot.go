@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"encoding/json"
+	"myshop/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// 配置审计日志保存变更后的完整配置快照，启动时只读取最新一条比较，不再重放全部历史
+// 已有的配置审计日志按写入顺序重放一次，结果写入最新一条的快照
+
+type auditLogV7 struct {
+	ID       uint
+	Changes  string `gorm:"type:text"`
+	Snapshot string `gorm:"type:text"`
+}
+
+func (auditLogV7) TableName() string { return "audit_logs" }
+
+func init() {
+	register(migrate.Migration{
+		Version: 7,
+		Name:    "audit_log_snapshot",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&auditLogV7{}, "Snapshot") {
+				if err := tx.Migrator().AddColumn(&auditLogV7{}, "Snapshot"); err != nil {
+					return err
+				}
+			}
+
+			var history []auditLogV7
+			err := tx.Select("id", "changes").Where("resource_type = ?", "config").Order("id ASC").Find(&history).Error
+			if err != nil || len(history) == 0 {
+				return err
+			}
+			snapshot := make(map[string]interface{})
+			for _, entry := range history {
+				var changes map[string]struct {
+					After interface{} `json:"after"`
+				}
+				if err := json.Unmarshal([]byte(entry.Changes), &changes); err != nil {
+					return err
+				}
+				for field, change := range changes {
+					if change.After == nil {
+						delete(snapshot, field)
+					} else {
+						snapshot[field] = change.After
+					}
+				}
+			}
+			data, err := json.Marshal(snapshot)
+			if err != nil {
+				return err
+			}
+			return tx.Model(&auditLogV7{}).Where("id = ?", history[len(history)-1].ID).
+				Update("snapshot", string(data)).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&auditLogV7{}, "Snapshot")
+		},
+	})
+}
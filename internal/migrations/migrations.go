@@ -0,0 +1,17 @@
+// Package migrations 数据库结构的版本化迁移
+// 每个迁移一个文件，文件名以版本号开头；迁移中使用各自的结构体快照，
+// 不引用model包，保证模型之后的修改不会改变已发布迁移的行为
+package migrations
+
+import "myshop/pkg/migrate"
+
+var all []migrate.Migration
+
+func register(m migrate.Migration) {
+	all = append(all, m)
+}
+
+// All 返回全部迁移
+func All() []migrate.Migration {
+	return append([]migrate.Migration(nil), all...)
+}
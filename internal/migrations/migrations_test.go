@@ -0,0 +1,203 @@
+package migrations
+
+import (
+	"myshop/internal/model"
+	"myshop/pkg/migrate"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// models 全部需要持久化的模型，新增模型时需要同时加入这里和迁移中
+var models = []interface{}{
+	&model.User{},
+	&model.Product{},
+	&model.Order{},
+	&model.OrderItem{},
+	&model.SecurityEvent{},
+	&model.RefreshToken{},
+	&model.APIKey{},
+	&model.RecoveryCode{},
+	&model.UserToken{},
+	&model.Session{},
+	&model.Identity{},
+	&model.Address{},
+	&model.AuditLog{},
+	&model.Coupon{},
+	&model.CouponScope{},
+	&model.CouponRedemption{},
+	&model.Promotion{},
+	&model.PromotionItem{},
+	&model.PromotionTier{},
+	&model.OrderDiscount{},
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存数据库每个连接都是独立的库
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// TestMigrationsMatchModels 执行全部迁移后，模型的每个列和索引都应该存在
+// 修改模型却忘记新增迁移时这里会失败
+func TestMigrationsMatchModels(t *testing.T) {
+	db := newTestDB(t)
+	m, err := migrate.New(db, All())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mdl := range models {
+		s, err := schema.Parse(mdl, &sync.Map{}, db.NamingStrategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !db.Migrator().HasTable(s.Table) {
+			t.Errorf("缺少表%s", s.Table)
+			continue
+		}
+		for _, field := range s.Fields {
+			if field.DBName != "" && !db.Migrator().HasColumn(mdl, field.DBName) {
+				t.Errorf("表%s缺少列%s", s.Table, field.DBName)
+			}
+		}
+		for _, idx := range s.ParseIndexes() {
+			if !db.Migrator().HasIndex(mdl, idx.Name) {
+				t.Errorf("表%s缺少索引%s", s.Table, idx.Name)
+			}
+		}
+	}
+}
+
+func TestMigrationsUpDown(t *testing.T) {
+	db := newTestDB(t)
+	m, err := migrate.New(db, All())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pending, err := m.Check(); err != nil || pending != len(All()) {
+		t.Fatalf("Check() = %d, %v", pending, err)
+	}
+	if n, err := m.Up(); err != nil || n != len(All()) {
+		t.Fatalf("Up() = %d, %v", n, err)
+	}
+	if n, err := m.Up(); err != nil || n != 0 {
+		t.Fatalf("重复执行Up() = %d, %v", n, err)
+	}
+	if pending, err := m.Check(); err != nil || pending != 0 {
+		t.Fatalf("Check() = %d, %v", pending, err)
+	}
+
+	if n, err := m.Down(len(All())); err != nil || n != len(All()) {
+		t.Fatalf("Down() = %d, %v", n, err)
+	}
+	if db.Migrator().HasTable("users") {
+		t.Fatal("回滚后users表仍然存在")
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.Applied {
+			t.Fatalf("回滚后%d仍标记为已执行", s.Version)
+		}
+	}
+}
+
+// TestUsersEmailUniqueRejectsDuplicates 已有重复邮箱时迁移6应失败并保持未执行，空邮箱不算重复
+func TestUsersEmailUniqueRejectsDuplicates(t *testing.T) {
+	db := newTestDB(t)
+	before, err := migrate.New(db, All()[:5])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := before.Up(); err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []map[string]interface{}{
+		{"username": "alice", "email": "dup@example.com"},
+		{"username": "bob", "email": "dup@example.com"},
+		{"username": "carol", "email": ""},
+		{"username": "dave", "email": ""},
+	} {
+		if err := db.Table("users").Create(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := migrate.New(db, All()[:6])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err == nil {
+		t.Fatal("存在重复邮箱时迁移应失败")
+	}
+	if pending, _ := m.Check(); pending != 1 {
+		t.Fatalf("失败的迁移不应标记为已执行, 待执行%d个", pending)
+	}
+
+	if err := db.Table("users").Where("username = ?", "bob").Update("email", "bob@example.com").Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("处理重复邮箱后迁移失败: %v", err)
+	}
+	if err := db.Table("users").Create(map[string]interface{}{"username": "erin", "email": "bob@example.com"}).Error; err == nil {
+		t.Error("迁移后仍能写入重复邮箱")
+	}
+}
+
+// TestAuditLogSnapshotBackfill 迁移7重放已有的配置审计日志，结果写入最新一条的快照
+func TestAuditLogSnapshotBackfill(t *testing.T) {
+	db := newTestDB(t)
+	before, err := migrate.New(db, All()[:6])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := before.Up(); err != nil {
+		t.Fatal(err)
+	}
+	for _, changes := range []string{
+		`{"server.port":{"before":null,"after":8080},"redis.host":{"before":null,"after":"redis"}}`,
+		`{"server.port":{"before":8080,"after":9090},"redis.host":{"before":"redis","after":null}}`,
+	} {
+		entry := map[string]interface{}{"resource_type": "config", "resource_id": "config.yaml", "changes": changes}
+		if err := db.Table("audit_logs").Create(entry).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := migrate.New(db, All())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	var latest model.AuditLog
+	if err := db.Where("resource_type = ?", "config").Order("id DESC").First(&latest).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(latest.Snapshot) != 1 || latest.Snapshot["server.port"] != float64(9090) {
+		t.Fatalf("快照 = %v, 期望只有server.port=9090", latest.Snapshot)
+	}
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ShippingAddress 收货地址内容
+// 地址簿和订单共用，订单创建时复制一份作为快照，之后修改地址簿不影响历史订单
+type ShippingAddress struct {
+	Name       string `gorm:"size:32" json:"name" example:"张三"`            // 收货人
+	Phone      string `gorm:"size:20" json:"phone" example:"13800138000"`  // 联系电话
+	Province   string `gorm:"size:32" json:"province" example:"广东省"`       // 省
+	City       string `gorm:"size:32" json:"city" example:"深圳市"`           // 市
+	District   string `gorm:"size:32" json:"district" example:"南山区"`       // 区县
+	Detail     string `gorm:"size:255" json:"detail" example:"科技园南区1栋101"` // 详细地址
+	PostalCode string `gorm:"size:10" json:"postal_code" example:"518000"` // 邮政编码
+}
+
+// Address 地址簿中的收货地址
+type Address struct {
+	ID              uint           `gorm:"primarykey" json:"id"` // 地址ID，主键
+	UserID          uint           `gorm:"index" json:"-"`       // 所属用户ID
+	ShippingAddress                // 地址内容
+	IsDefault       bool           `gorm:"default:false" json:"is_default"` // 是否为默认地址
+	CreatedAt       time.Time      `json:"created_at"`                      // 创建时间
+	UpdatedAt       time.Time      `json:"updated_at"`                      // 更新时间
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`                  // 软删除时间
+}
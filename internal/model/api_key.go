@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// API权限范围常量
+const (
+	ScopeProductsWrite = "products:write"  // 创建、修改、删除商品
+	ScopeOrdersRead    = "orders:read"     // 读取自己的订单
+	ScopeOrdersWrite   = "orders:write"    // 创建订单
+	ScopeOrdersReadAll = "orders:read_all" // 读取全部用户的订单
+	ScopeAll           = "*"               // 全部权限，仅授予管理员角色
+)
+
+// AllScopes 可授予API Key的全部权限范围
+var AllScopes = []string{ScopeProductsWrite, ScopeOrdersRead, ScopeOrdersWrite, ScopeOrdersReadAll}
+
+// RoleScopes 各角色通过登录令牌获得的权限范围
+// 服务账号没有默认权限，只能通过API Key授予
+var RoleScopes = map[string][]string{
+	RoleAdmin:   {ScopeAll},
+	RoleUser:    {ScopeOrdersRead, ScopeOrdersWrite},
+	RoleService: {},
+}
+
+// APIKey API Key模型
+// 明文只在创建时返回一次，数据库只保存摘要和用于辨认的前缀
+type APIKey struct {
+	ID         uint       `gorm:"primarykey" json:"id"`                    // 主键
+	UserID     uint       `gorm:"index" json:"user_id"`                    // 所属用户或服务账号
+	Name       string     `gorm:"size:64" json:"name"`                     // 名称，便于辨认用途
+	Prefix     string     `gorm:"size:16;index" json:"prefix"`             // 明文前缀，可公开展示
+	KeyHash    string     `gorm:"size:64;uniqueIndex" json:"-"`            // 明文摘要
+	Scopes     []string   `gorm:"serializer:json;type:text" json:"scopes"` // 权限范围
+	ExpiresAt  *time.Time `json:"expires_at"`                              // 过期时间，为空表示永不过期
+	LastUsedAt *time.Time `json:"last_used_at"`                            // 最近使用时间
+	RevokedAt  *time.Time `json:"revoked_at"`                              // 吊销时间
+	CreatedAt  time.Time  `json:"created_at"`                              // 创建时间
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 审计动作常量
+const (
+	AuditActionCreate = "create" // 创建
+	AuditActionUpdate = "update" // 修改
+	AuditActionDelete = "delete" // 删除
+)
+
+// 审计资源类型常量
+const (
+	AuditResourceProduct   = "product"   // 商品
+	AuditResourceOrder     = "order"     // 订单
+	AuditResourceCoupon    = "coupon"    // 优惠券
+	AuditResourcePromotion = "promotion" // 促销
+	AuditResourceUser      = "user"      // 用户
+	AuditResourceConfig    = "config"    // 运行配置
+)
+
+// ErrAuditLogImmutable 审计日志只能追加，不能修改或删除
+var ErrAuditLogImmutable = errors.New("audit log is append-only")
+
+// AuditChange 单个字段的变更，创建时Before为空，删除时After为空
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditLog 审计日志模型
+// 记录敏感操作的操作者和变更内容，只追加写入
+type AuditLog struct {
+	ID           uint                   `gorm:"primarykey" json:"id"`                                  // 主键
+	ActorID      uint                   `gorm:"index" json:"actor_id"`                                 // 操作者用户ID，系统操作为0
+	ActorRole    string                 `gorm:"size:64" json:"actor_role"`                             // 操作者角色
+	AuthMethod   string                 `gorm:"size:16" json:"auth_method"`                            // 认证方式，系统操作为system，命令行操作为cli
+	Action       string                 `gorm:"size:32;index" json:"action"`                           // 动作
+	ResourceType string                 `gorm:"size:32;index:idx_audit_resource" json:"resource_type"` // 资源类型
+	ResourceID   string                 `gorm:"size:64;index:idx_audit_resource" json:"resource_id"`   // 资源ID
+	Changes      map[string]AuditChange `gorm:"serializer:json;type:text" json:"changes"`              // 字段变更
+	Snapshot     map[string]interface{} `gorm:"serializer:json;type:text" json:"-"`                    // 变更后的完整字段，只用于运行配置，下次启动时与之比较
+	IP           string                 `gorm:"size:64" json:"ip"`                                     // 客户端IP
+	RequestID    string                 `gorm:"size:64;index" json:"request_id"`                       // 请求ID，与访问日志关联
+	CreatedAt    time.Time              `gorm:"index" json:"created_at"`                               // 操作时间
+}
+
+// BeforeUpdate 拒绝修改审计日志
+func (l *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// BeforeDelete 拒绝删除审计日志
+func (l *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"myshop/pkg/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 优惠券类型常量
+const (
+	CouponTypePercent = "percent" // 按比例减免，PercentOff为15表示减免15%
+	CouponTypeFixed   = "fixed"   // 减免固定金额
+)
+
+// Coupon 优惠券
+// 适用范围为空时适用于全部商品，否则只有匹配任一范围的商品计入可优惠金额
+type Coupon struct {
+	ID           uint           `gorm:"primarykey" json:"id" example:"1"`
+	Code         string         `gorm:"uniqueIndex;size:32" json:"code" example:"NEWYEAR20"`                        // 兑换码，大写
+	Name         string         `gorm:"size:64" json:"name" example:"新年满200减20"`                                    // 名称，显示在订单优惠明细中
+	Type         string         `gorm:"size:16" json:"type" example:"fixed"`                                        // 类型 percent/fixed
+	PercentOff   int            `json:"percent_off" example:"0"`                                                    // 减免比例(1-100)，percent类型
+	AmountOff    money.Amount   `gorm:"type:decimal(10,2)" json:"amount_off" swaggertype:"string" example:"20.00"`  // 减免金额，fixed类型
+	MaxDiscount  money.Amount   `gorm:"type:decimal(10,2)" json:"max_discount" swaggertype:"string" example:"0.00"` // percent类型的减免上限，为0表示不限
+	MinSpend     money.Amount   `gorm:"type:decimal(10,2)" json:"min_spend" swaggertype:"string" example:"200.00"`  // 适用商品原价合计达到该金额才能使用，为0表示不限
+	Stackable    bool           `json:"stackable" example:"true"`                                                   // 能否与其他可叠加的优惠券同时使用
+	TotalLimit   int            `json:"total_limit" example:"1000"`                                                 // 全部用户合计可使用次数，为0表示不限
+	PerUserLimit int            `json:"per_user_limit" example:"1"`                                                 // 每个用户可使用次数，为0表示不限
+	UsedCount    int            `gorm:"default:0" json:"used_count" example:"0"`                                    // 已使用次数，下单时递增，取消订单时归还
+	StartsAt     *time.Time     `json:"starts_at"`                                                                  // 生效时间，为空表示立即生效
+	EndsAt       *time.Time     `json:"ends_at"`                                                                    // 失效时间，为空表示长期有效
+	Disabled     bool           `json:"disabled" example:"false"`                                                   // 停用后不能再使用，已使用的不受影响
+	Scopes       []CouponScope  `json:"scopes"`                                                                     // 适用范围
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// ActiveAt 优惠券在at时刻是否可以使用，不检查使用次数
+func (c *Coupon) ActiveAt(at time.Time) bool {
+	if c.Disabled {
+		return false
+	}
+	if c.StartsAt != nil && at.Before(*c.StartsAt) {
+		return false
+	}
+	return c.EndsAt == nil || at.Before(*c.EndsAt)
+}
+
+// Applies 优惠券是否适用于商品
+func (c *Coupon) Applies(productID, categoryID uint) bool {
+	if len(c.Scopes) == 0 {
+		return true
+	}
+	for _, scope := range c.Scopes {
+		if scope.ProductID != 0 && scope.ProductID == productID ||
+			scope.CategoryID != 0 && scope.CategoryID == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// CouponScope 优惠券适用范围，ProductID和CategoryID二选一
+type CouponScope struct {
+	ID         uint `gorm:"primarykey" json:"-"`
+	CouponID   uint `gorm:"index" json:"-"`
+	ProductID  uint `json:"product_id,omitempty" example:"1"`
+	CategoryID uint `json:"category_id,omitempty"`
+}
+
+// CouponRedemption 优惠券使用记录，每个订单使用的每张优惠券一条
+// 取消订单时标记归还，不再计入用户的使用次数
+type CouponRedemption struct {
+	ID         uint       `gorm:"primarykey"`
+	CouponID   uint       `gorm:"index:idx_coupon_redemption_user"`
+	UserID     uint       `gorm:"index:idx_coupon_redemption_user"`
+	OrderID    uint       `gorm:"index"`
+	ReleasedAt *time.Time // 归还时间，为空表示仍计入使用次数
+	CreatedAt  time.Time
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Identity 第三方身份模型
+// 记录用户在外部身份提供方的账号，一个用户可以关联多个提供方的身份，
+// 同一提供方的同一账号(Provider+Subject)只能关联一个用户
+type Identity struct {
+	ID          uint      `gorm:"primarykey" json:"id"`                                     // 主键
+	UserID      uint      `gorm:"index" json:"-"`                                           // 关联用户ID
+	Provider    string    `gorm:"size:32;uniqueIndex:idx_identity_subject" json:"provider"` // 身份提供方标识
+	Subject     string    `gorm:"size:255;uniqueIndex:idx_identity_subject" json:"-"`       // 提供方内的用户唯一标识(sub)
+	Email       string    `gorm:"size:128" json:"email"`                                    // 提供方返回的邮箱
+	LastLoginAt time.Time `json:"last_login_at"`                                            // 最近一次通过该身份登录的时间
+	CreatedAt   time.Time `json:"created_at"`                                               // 关联时间
+}
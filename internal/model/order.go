@@ -1,6 +1,7 @@
 package model
 
 import (
+	"myshop/pkg/money"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,24 +16,75 @@ const (
 	OrderStatusCancelled            // 已取消
 )
 
+// 订单优惠来源常量
+const (
+	DiscountSourcePromotion = "promotion" // 自动促销
+	DiscountSourceCoupon    = "coupon"    // 优惠券
+)
+
 // Order 订单模型
 type Order struct {
-	ID         uint           `gorm:"primarykey"`          // 订单ID，主键
-	UserID     uint           `gorm:"index"`               // 用户ID，外键
-	OrderNo    string         `gorm:"uniqueIndex;size:32"` // 订单号，唯一索引
-	Status     int            `gorm:"default:1"`           // 订单状态，默认1（待支付）
-	TotalPrice float64        `gorm:"type:decimal(10,2)"`  // 订单总价
-	Items      []OrderItem    // 订单项，一对多关系
-	CreatedAt  time.Time      // 创建时间
-	UpdatedAt  time.Time      // 更新时间
-	DeletedAt  gorm.DeletedAt `gorm:"index"` // 软删除时间
+	ID              uint            `gorm:"primarykey"`                                   // 订单ID，主键
+	UserID          uint            `gorm:"index"`                                        // 用户ID，外键
+	OrderNo         string          `gorm:"uniqueIndex;size:32"`                          // 订单号，唯一索引
+	Status          int             `gorm:"default:1"`                                    // 订单状态，默认1（待支付）
+	TotalPrice      money.Amount    `gorm:"type:decimal(10,2)" swaggertype:"string"`      // 订单总价，本位币，已扣除优惠
+	DiscountTotal   money.Amount    `gorm:"type:decimal(10,2)" swaggertype:"string"`      // 优惠合计，本位币
+	PayCurrency     string          `gorm:"size:3"`                                       // 支付币种，下单时确定
+	ExchangeRate    string          `gorm:"size:24"`                                      // 下单时本位币到支付币种的汇率，之后汇率变化不影响订单
+	PayTotal        money.Amount    `gorm:"-" swaggertype:"string"`                       // 按下单时的汇率换算的应付金额，支付币种
+	PayTotalValue   string          `gorm:"column:pay_total;type:decimal(18,4)" json:"-"` // PayTotal的存储形式，按支付币种的精度解析
+	AddressID       uint            // 下单时选择的地址簿地址ID，仅作记录
+	ShippingAddress ShippingAddress `gorm:"embedded;embeddedPrefix:ship_"` // 收货地址快照，创建订单时从地址簿复制，之后不再修改
+	Items           []OrderItem     // 订单项，一对多关系
+	Discounts       []OrderDiscount // 优惠明细，一对多关系
+	CreatedAt       time.Time       // 创建时间
+	UpdatedAt       time.Time       // 更新时间
+	DeletedAt       gorm.DeletedAt  `gorm:"index" json:"-"` // 软删除时间
+}
+
+// BeforeCreate 保存应付金额
+// 支付币种的精度可能与本位币不同，金额以十进制字符串保存，读取时再按支付币种解析
+func (o *Order) BeforeCreate(tx *gorm.DB) error {
+	o.PayTotalValue = o.PayTotal.String()
+	return nil
+}
+
+// AfterFind 按支付币种解析应付金额
+// 支持多币种之前的订单没有支付币种，按本位币支付
+func (o *Order) AfterFind(tx *gorm.DB) error {
+	if o.PayCurrency == "" {
+		o.PayCurrency = o.TotalPrice.Currency()
+	}
+	if o.PayTotalValue == "" {
+		o.PayTotal = money.Zero(o.PayCurrency)
+		return nil
+	}
+	payTotal, err := money.Parse(o.PayTotalValue, o.PayCurrency)
+	if err != nil {
+		return err
+	}
+	o.PayTotal = payTotal
+	return nil
 }
 
 // OrderItem 订单项模型
 type OrderItem struct {
-	ID        uint    `gorm:"primarykey"` // 订单项ID，主键
-	OrderID   uint    `gorm:"index"`      // 订单ID，外键
-	ProductID uint    `gorm:"index"`      // 商品ID，外键
-	Quantity  int     // 购买数量
-	Price     float64 `gorm:"type:decimal(10,2)"` // 商品单价
+	ID          uint         `gorm:"primarykey"` // 订单项ID，主键
+	OrderID     uint         `gorm:"index"`      // 订单ID，外键
+	ProductID   uint         `gorm:"index"`      // 商品ID，外键
+	ProductName string       `gorm:"size:128"`   // 下单时的商品名称快照
+	Quantity    int          // 购买数量
+	Price       money.Amount `gorm:"type:decimal(10,2)" swaggertype:"string"` // 下单时的商品单价快照
+}
+
+// OrderDiscount 订单优惠明细，创建订单时保存，之后修改或删除优惠券不影响已有订单
+type OrderDiscount struct {
+	ID          uint         `gorm:"primarykey"` // 主键
+	OrderID     uint         `gorm:"index"`      // 订单ID，外键
+	Source      string       `gorm:"size:16"`    // 优惠来源 promotion/coupon
+	SourceID    uint         // 促销或优惠券ID
+	Code        string       `gorm:"size:32"`                                 // 优惠券兑换码，促销为空
+	Description string       `gorm:"size:128"`                                // 优惠说明，下单时的促销或优惠券名称
+	Amount      money.Amount `gorm:"type:decimal(10,2)" swaggertype:"string"` // 优惠金额，本位币
 }
@@ -1,17 +1,24 @@
 package model
 
 import (
+	"myshop/pkg/money"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// 商品状态常量
+const (
+	ProductStatusOnSale  = 1 // 上架
+	ProductStatusOffSale = 2 // 下架
+)
+
 // Product 商品模型
 type Product struct {
 	ID          uint           `gorm:"primarykey" json:"id" example:"1"`
 	Name        string         `gorm:"size:128;index" json:"name" example:"iPhone 15"`
 	Description string         `gorm:"type:text" json:"description" example:"最新款iPhone"`
-	Price       float64        `gorm:"type:decimal(10,2)" json:"price" example:"6999.00"`
+	Price       money.Amount   `gorm:"type:decimal(10,2)" json:"price" swaggertype:"string" example:"6999.00"` // 本位币价格
 	Stock       int            `gorm:"default:0" json:"stock" example:"100"`
 	Status      int            `gorm:"default:1" json:"status" example:"1"` // 1: 上架 2: 下架
 	CategoryID  uint           `gorm:"index" json:"category_id"`
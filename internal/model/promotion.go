@@ -0,0 +1,79 @@
+package model
+
+import (
+	"myshop/pkg/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 促销类型常量
+const (
+	PromotionTypeBuyXGetY = "buy_x_get_y" // 买X送Y：适用商品每X+Y件中价格最低的Y件免费
+	PromotionTypeTiered   = "tiered"      // 阶梯满减：适用商品金额达到门槛减免对应金额，按达到的最高一档计算
+	PromotionTypeBundle   = "bundle"      // 组合价：指定商品按数量凑齐一组后按组合价计算
+)
+
+// Promotion 自动促销，下单时无需兑换码自动应用
+// 多个促销按优先级从高到低依次计算，优先级相同时按ID顺序；同一件商品只参与一个买赠或组合促销
+type Promotion struct {
+	ID           uint            `gorm:"primarykey" json:"id" example:"1"`
+	Name         string          `gorm:"size:64" json:"name" example:"耳机买二送一"`                                    // 名称，显示在订单优惠明细中
+	Type         string          `gorm:"size:16" json:"type" example:"buy_x_get_y"`                               // 类型 buy_x_get_y/tiered/bundle
+	Priority     int             `gorm:"index" json:"priority" example:"10"`                                      // 优先级，大的先计算
+	Exclusive    bool            `json:"exclusive" example:"false"`                                               // 独占：之前已有促销生效时不参与，生效后不再计算其他促销
+	BuyQuantity  int             `json:"buy_quantity" example:"2"`                                                // 买赠需购买的件数
+	FreeQuantity int             `json:"free_quantity" example:"1"`                                               // 买赠免费的件数
+	BundlePrice  money.Amount    `gorm:"type:decimal(10,2)" json:"bundle_price" swaggertype:"string" example:"0"` // 每组的组合价，bundle类型
+	StartsAt     *time.Time      `json:"starts_at"`                                                               // 生效时间，为空表示立即生效
+	EndsAt       *time.Time      `json:"ends_at"`                                                                 // 失效时间，为空表示长期有效
+	Disabled     bool            `json:"disabled" example:"false"`                                                // 停用
+	Items        []PromotionItem `json:"items"`                                                                   // 买赠和满减的适用范围，为空表示全部商品；组合的商品和数量
+	Tiers        []PromotionTier `json:"tiers"`                                                                   // 满减档位，tiered类型
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt  `gorm:"index" json:"-"`
+}
+
+// ActiveAt 促销在at时刻是否生效
+func (p *Promotion) ActiveAt(at time.Time) bool {
+	if p.Disabled {
+		return false
+	}
+	if p.StartsAt != nil && at.Before(*p.StartsAt) {
+		return false
+	}
+	return p.EndsAt == nil || at.Before(*p.EndsAt)
+}
+
+// Applies 商品是否在买赠或满减促销的适用范围内
+func (p *Promotion) Applies(productID, categoryID uint) bool {
+	if len(p.Items) == 0 {
+		return true
+	}
+	for _, item := range p.Items {
+		if item.ProductID != 0 && item.ProductID == productID ||
+			item.CategoryID != 0 && item.CategoryID == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// PromotionItem 促销商品
+// 买赠和满减为适用范围，ProductID和CategoryID二选一；组合为组合内的商品及每组的数量
+type PromotionItem struct {
+	ID          uint `gorm:"primarykey" json:"-"`
+	PromotionID uint `gorm:"index" json:"-"`
+	ProductID   uint `json:"product_id,omitempty" example:"1"`
+	CategoryID  uint `json:"category_id,omitempty"`
+	Quantity    int  `json:"quantity,omitempty"` // 组合内的数量
+}
+
+// PromotionTier 满减档位
+type PromotionTier struct {
+	ID          uint         `gorm:"primarykey" json:"-"`
+	PromotionID uint         `gorm:"index" json:"-"`
+	Threshold   money.Amount `gorm:"type:decimal(10,2)" json:"threshold" swaggertype:"string" example:"500.00"` // 门槛金额
+	AmountOff   money.Amount `gorm:"type:decimal(10,2)" json:"amount_off" swaggertype:"string" example:"50.00"` // 减免金额
+}
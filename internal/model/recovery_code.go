@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// RecoveryCode 两步验证恢复码
+// 验证器丢失时用于代替验证码登录，每个恢复码只能使用一次，只保存摘要
+type RecoveryCode struct {
+	ID        uint       `gorm:"primarykey"` // 主键
+	UserID    uint       `gorm:"index"`      // 所属用户ID
+	CodeHash  string     `gorm:"size:64"`    // 恢复码摘要
+	UsedAt    *time.Time // 使用时间，为空表示未使用
+	CreatedAt time.Time  // 创建时间
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// RefreshToken 刷新令牌模型
+// 只保存令牌的SHA-256摘要；每次刷新都会轮换出同一家族(FamilyID)下的新令牌，
+// 已轮换的旧令牌再次出现即视为泄露，整个家族随之吊销
+type RefreshToken struct {
+	ID        uint       `gorm:"primarykey"`          // 主键
+	UserID    uint       `gorm:"index"`               // 所属用户ID
+	FamilyID  string     `gorm:"size:64;index"`       // 令牌家族，同一次登录派生的令牌共享
+	TokenHash string     `gorm:"size:64;uniqueIndex"` // 令牌摘要
+	MFA       bool       // 登录时是否通过了两步验证，刷新后保持不变
+	ExpiresAt time.Time  // 过期时间
+	RevokedAt *time.Time // 吊销时间，为空表示仍然有效
+	CreatedAt time.Time  // 创建时间
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// 安全事件类型常量
+const (
+	SecurityEventLoginFailed    = "login_failed"    // 登录失败
+	SecurityEventLoginSuccess   = "login_success"   // 登录成功
+	SecurityEventAccountLocked  = "account_locked"  // 账号被临时锁定
+	SecurityEventIPBlocked      = "ip_blocked"      // IP被临时封禁
+	SecurityEventLoginBlocked   = "login_blocked"   // 锁定期间的登录尝试
+	SecurityEventUnlocked       = "account_unlock"  // 管理员解锁账号
+	SecurityEventTokenReuse     = "token_reuse"     // 已轮换的刷新令牌被重复使用
+	SecurityEventLogout         = "logout"          // 用户登出
+	SecurityEventPasswordReset  = "password_reset"  // 通过邮件重置密码
+	SecurityEventPasswordChange = "password_change" // 登录后修改密码
+	SecurityEventSessionRevoked = "session_revoked" // 用户吊销登录会话
+	SecurityEventAccountDeleted = "account_deleted" // 用户注销账号
+)
+
+// SecurityEvent 安全事件模型
+// 只追加写入，用于事后审查可疑的登录行为
+type SecurityEvent struct {
+	ID        uint      `gorm:"primarykey" json:"id"`          // 事件ID，主键
+	Type      string    `gorm:"size:32;index" json:"type"`     // 事件类型
+	UserID    uint      `gorm:"index" json:"user_id"`          // 关联用户ID，未知用户为0
+	Username  string    `gorm:"size:32;index" json:"username"` // 尝试登录的用户名
+	IP        string    `gorm:"size:64;index" json:"ip"`       // 客户端IP
+	Detail    string    `gorm:"size:255" json:"detail"`        // 附加说明
+	CreatedAt time.Time `gorm:"index" json:"created_at"`       // 发生时间
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Session 登录会话模型
+// 每次登录创建一个会话，与该次登录派生的刷新令牌家族一一对应；
+// 访问令牌携带会话ID，会话被吊销后其访问令牌和刷新令牌立即失效
+type Session struct {
+	ID           uint       `gorm:"primarykey" json:"id"`         // 主键
+	UserID       uint       `gorm:"index" json:"-"`               // 所属用户ID
+	FamilyID     string     `gorm:"size:64;uniqueIndex" json:"-"` // 对应的刷新令牌家族
+	Device       string     `gorm:"size:64" json:"device"`        // 由User-Agent解析出的设备描述
+	IP           string     `gorm:"size:64" json:"ip"`            // 登录IP
+	UserAgent    string     `gorm:"size:255" json:"user_agent"`   // 登录时的User-Agent
+	LastActiveAt time.Time  `json:"last_active_at"`               // 最后活跃时间
+	ExpiresAt    time.Time  `json:"expires_at"`                   // 过期时间，随刷新令牌轮换顺延
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`         // 吊销时间，为空表示仍然有效
+	CreatedAt    time.Time  `json:"created_at"`                   // 登录时间
+}
@@ -6,13 +6,30 @@ import (
 	"gorm.io/gorm"
 )
 
+// 用户角色常量
+const (
+	RoleUser    = "user"    // 普通用户
+	RoleAdmin   = "admin"   // 管理员
+	RoleService = "service" // 服务账号，只能通过API Key访问
+)
+
 // User 用户模型
 // 采用GORM标签定义数据库表结构
 type User struct {
-	ID        uint           `gorm:"primarykey"`          // 用户ID，主键
-	Username  string         `gorm:"uniqueIndex;size:32"` // 用户名，唯一索引，最大长度32
-	Password  string         `gorm:"size:128" json:"-"`   // 密码，最大长度128，json序列化时忽略
-	CreatedAt time.Time      // 创建时间，GORM自动维护
-	UpdatedAt time.Time      // 更新时间，GORM自动维护
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // 软删除时间，支持软删除
+	ID               uint           `gorm:"primarykey"`           // 用户ID，主键
+	Username         string         `gorm:"uniqueIndex;size:32"`  // 用户名，唯一索引，最大长度32
+	Password         string         `gorm:"size:128" json:"-"`    // 密码，最大长度128，json序列化时忽略
+	Role             string         `gorm:"size:16;default:user"` // 角色，默认普通用户
+	Email            string         `gorm:"size:128;index"`       // 邮箱，可为空，非空时全局唯一（唯一索引由迁移创建）
+	EmailVerifiedAt  *time.Time     // 邮箱验证时间，为空表示未验证
+	Nickname         string         `gorm:"size:32"`          // 昵称
+	Phone            string         `gorm:"size:20"`          // 手机号
+	AvatarURL        string         `gorm:"size:255"`         // 头像地址
+	Currency         string         `gorm:"size:3"`           // 偏好的展示和支付币种，为空时使用本位币
+	TOTPSecret       string         `gorm:"size:64" json:"-"` // 两步验证密钥，开通流程中即写入
+	TwoFactorEnabled bool           `gorm:"default:false"`    // 是否已启用两步验证
+	AnonymizedAt     *time.Time     `json:"-"`                // 注销后个人信息被匿名化的时间
+	CreatedAt        time.Time      // 创建时间，GORM自动维护
+	UpdatedAt        time.Time      // 更新时间，GORM自动维护
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"` // 软删除时间，支持软删除
 }
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// 一次性令牌用途常量
+const (
+	TokenPurposeVerifyEmail   = "verify_email"   // 验证邮箱
+	TokenPurposeResetPassword = "reset_password" // 重置密码
+)
+
+// UserToken 邮件链接中的一次性令牌
+// 令牌本身经过签名，数据库只记录随机数摘要用于保证只能使用一次
+type UserToken struct {
+	ID        uint       `gorm:"primarykey"`          // 主键
+	UserID    uint       `gorm:"index"`               // 所属用户ID
+	Purpose   string     `gorm:"size:32"`             // 用途
+	Email     string     `gorm:"size:128"`            // 发送时的邮箱，验证邮箱时要求与当前邮箱一致
+	NonceHash string     `gorm:"size:64;uniqueIndex"` // 随机数摘要
+	ExpiresAt time.Time  // 过期时间
+	UsedAt    *time.Time // 使用时间，为空表示未使用
+	CreatedAt time.Time  // 创建时间
+}
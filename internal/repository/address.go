@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"myshop/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AddressRepository 收货地址数据访问层
+type AddressRepository struct {
+	db *gorm.DB
+}
+
+// NewAddressRepository 创建收货地址仓储实例
+func NewAddressRepository(db *gorm.DB) *AddressRepository {
+	return &AddressRepository{db: db}
+}
+
+// Create 创建收货地址，设为默认时取消用户其他地址的默认标记
+func (r *AddressRepository) Create(ctx context.Context, address *model.Address) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, address.UserID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(address).Error
+	})
+}
+
+// GetByID 根据ID查询用户的收货地址
+func (r *AddressRepository) GetByID(ctx context.Context, userID, id uint) (*model.Address, error) {
+	var address model.Address
+	err := dbFrom(ctx, r.db).Where("user_id = ?", userID).First(&address, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// GetDefault 查询用户的默认收货地址
+func (r *AddressRepository) GetDefault(ctx context.Context, userID uint) (*model.Address, error) {
+	var address model.Address
+	err := dbFrom(ctx, r.db).Where("user_id = ? AND is_default = ?", userID, true).First(&address).Error
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// ListByUserID 查询用户的全部收货地址，默认地址在前
+func (r *AddressRepository) ListByUserID(ctx context.Context, userID uint) ([]model.Address, error) {
+	var addresses []model.Address
+	err := dbFrom(ctx, r.db).Where("user_id = ?", userID).
+		Order("is_default DESC, id DESC").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+// CountByUserID 统计用户的收货地址数量
+func (r *AddressRepository) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := dbFrom(ctx, r.db).Model(&model.Address{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// Update 更新收货地址内容
+func (r *AddressRepository) Update(ctx context.Context, address *model.Address) error {
+	return dbFrom(ctx, r.db).Model(address).Select("Name", "Phone", "Province", "City", "District", "Detail", "PostalCode").
+		Updates(address).Error
+}
+
+// SetDefault 设为默认地址，同一用户只有一个默认地址
+func (r *AddressRepository) SetDefault(ctx context.Context, userID, id uint) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := clearDefaultAddress(tx, userID); err != nil {
+			return err
+		}
+		return tx.Model(&model.Address{}).
+			Where("id = ? AND user_id = ?", id, userID).
+			Update("is_default", true).Error
+	})
+}
+
+// Delete 删除收货地址，删除的是默认地址时把最近添加的地址设为默认
+func (r *AddressRepository) Delete(ctx context.Context, address *model.Address) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(address).Error; err != nil {
+			return err
+		}
+		if !address.IsDefault {
+			return nil
+		}
+
+		var next model.Address
+		err := tx.Where("user_id = ?", address.UserID).Order("id DESC").First(&next).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+}
+
+func clearDefaultAddress(tx *gorm.DB, userID uint) error {
+	return tx.Model(&model.Address{}).
+		Where("user_id = ? AND is_default = ?", userID, true).
+		Update("is_default", false).Error
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"myshop/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository API Key数据访问层
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository 创建API Key仓储实例
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create 保存API Key
+func (r *APIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	return dbFrom(ctx, r.db).Create(key).Error
+}
+
+// GetByID 根据ID查询
+func (r *APIKeyRepository) GetByID(ctx context.Context, id uint) (*model.APIKey, error) {
+	var key model.APIKey
+	err := dbFrom(ctx, r.db).First(&key, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByHash 根据明文摘要查询
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := dbFrom(ctx, r.db).Where("key_hash = ?", hash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByUserID 获取用户的全部API Key，按创建时间倒序
+func (r *APIKeyRepository) ListByUserID(ctx context.Context, userID uint) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := dbFrom(ctx, r.db).Where("user_id = ?", userID).Order("id DESC").Find(&keys).Error
+	return keys, err
+}
+
+// Revoke 吊销API Key
+func (r *APIKeyRepository) Revoke(ctx context.Context, id uint) error {
+	return dbFrom(ctx, r.db).Model(&model.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+// TouchLastUsed 更新最近使用时间
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	return dbFrom(ctx, r.db).Model(&model.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", usedAt).Error
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"myshop/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogFilter 审计日志查询条件
+type AuditLogFilter struct {
+	ActorID      uint
+	Action       string
+	ResourceType string
+	ResourceID   string
+	RequestID    string
+	Since        time.Time
+	Until        time.Time
+}
+
+// AuditLogRepository 审计日志数据访问层，只提供写入和查询
+type AuditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository 创建审计日志仓储实例
+func NewAuditLogRepository(db *gorm.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// Create 记录审计日志
+func (r *AuditLogRepository) Create(ctx context.Context, log *model.AuditLog) error {
+	return dbFrom(ctx, r.db).Create(log).Error
+}
+
+// List 按条件分页查询审计日志，按时间倒序
+func (r *AuditLogRepository) List(ctx context.Context, filter AuditLogFilter, page, pageSize int) ([]model.AuditLog, int64, error) {
+	var logs []model.AuditLog
+	var total int64
+
+	query := dbFrom(ctx, r.db).Model(&model.AuditLog{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ResourceType != "" {
+		query = query.Where("resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceID != "" {
+		query = query.Where("resource_id = ?", filter.ResourceID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// Latest 查询某类资源最新的一条审计日志，没有时返回gorm.ErrRecordNotFound
+func (r *AuditLogRepository) Latest(ctx context.Context, resourceType string) (*model.AuditLog, error) {
+	var log model.AuditLog
+	err := dbFrom(ctx, r.db).Where("resource_type = ?", resourceType).Order("id DESC").First(&log).Error
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
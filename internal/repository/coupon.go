@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"myshop/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CouponRepository 优惠券数据访问层
+type CouponRepository struct {
+	db *gorm.DB
+}
+
+// NewCouponRepository 创建优惠券仓储实例
+func NewCouponRepository(db *gorm.DB) *CouponRepository {
+	return &CouponRepository{db: db}
+}
+
+// Create 创建优惠券及适用范围
+func (r *CouponRepository) Create(ctx context.Context, coupon *model.Coupon) error {
+	return dbFrom(ctx, r.db).Create(coupon).Error
+}
+
+// GetByID 根据ID获取优惠券及适用范围
+func (r *CouponRepository) GetByID(ctx context.Context, id uint) (*model.Coupon, error) {
+	var coupon model.Coupon
+	err := dbFrom(ctx, r.db).Preload("Scopes").First(&coupon, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// CodeExists 兑换码是否已被使用，包括已删除的优惠券
+func (r *CouponRepository) CodeExists(ctx context.Context, code string) (bool, error) {
+	var count int64
+	err := dbFrom(ctx, r.db).Unscoped().Model(&model.Coupon{}).Where("code = ?", code).Count(&count).Error
+	return count > 0, err
+}
+
+// ListByCodes 根据兑换码批量获取优惠券及适用范围，不存在的兑换码不返回
+func (r *CouponRepository) ListByCodes(ctx context.Context, codes []string) ([]model.Coupon, error) {
+	var coupons []model.Coupon
+	err := dbFrom(ctx, r.db).Preload("Scopes").Where("code IN ?", codes).Order("id ASC").Find(&coupons).Error
+	return coupons, err
+}
+
+// List 获取优惠券列表，新创建的在前
+func (r *CouponRepository) List(ctx context.Context, page, pageSize int) ([]model.Coupon, int64, error) {
+	var coupons []model.Coupon
+	var total int64
+	db := dbFrom(ctx, r.db)
+
+	if err := db.Model(&model.Coupon{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := db.Preload("Scopes").Order("id DESC").Offset(offset).Limit(pageSize).Find(&coupons).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return coupons, total, nil
+}
+
+// Update 更新优惠券并替换适用范围，不修改已使用次数
+func (r *CouponRepository) Update(ctx context.Context, coupon *model.Coupon) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(coupon).Select("*").Omit("Scopes", "UsedCount", "CreatedAt", "DeletedAt").Updates(coupon).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("coupon_id = ?", coupon.ID).Delete(&model.CouponScope{}).Error; err != nil {
+			return err
+		}
+		if len(coupon.Scopes) == 0 {
+			return nil
+		}
+		for i := range coupon.Scopes {
+			coupon.Scopes[i].ID = 0
+			coupon.Scopes[i].CouponID = coupon.ID
+		}
+		return tx.Create(&coupon.Scopes).Error
+	})
+}
+
+// Delete 删除优惠券（软删除），已下单的优惠明细和使用记录保留
+func (r *CouponRepository) Delete(ctx context.Context, id uint) error {
+	return dbFrom(ctx, r.db).Delete(&model.Coupon{}, id).Error
+}
+
+// IncrementUsage 已使用次数加1，达到总次数上限时返回ErrCouponExhausted
+// 在事务中调用时同时锁定优惠券行直到事务结束，同一优惠券的并发使用依次进行
+func (r *CouponRepository) IncrementUsage(ctx context.Context, id uint) error {
+	result := dbFrom(ctx, r.db).Model(&model.Coupon{}).
+		Where("id = ? AND (total_limit = 0 OR used_count < total_limit)", id).
+		UpdateColumn("used_count", gorm.Expr("used_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCouponExhausted
+	}
+	return nil
+}
+
+// CountRedemptions 统计用户使用某优惠券且未归还的次数
+// 先锁定优惠券行再计数：在事务中调用时锁持有到事务结束，同一优惠券的计数和写入使用记录依次进行，
+// 并发下单不会都读到未达上限的次数
+func (r *CouponRepository) CountRedemptions(ctx context.Context, couponID, userID uint) (int64, error) {
+	db := dbFrom(ctx, r.db)
+	var locked model.Coupon
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&locked, couponID).Error; err != nil {
+		return 0, err
+	}
+	var count int64
+	err := db.Model(&model.CouponRedemption{}).
+		Where("coupon_id = ? AND user_id = ? AND released_at IS NULL", couponID, userID).
+		Count(&count).Error
+	return count, err
+}
+
+// CreateRedemption 创建优惠券使用记录
+func (r *CouponRepository) CreateRedemption(ctx context.Context, redemption *model.CouponRedemption) error {
+	return dbFrom(ctx, r.db).Create(redemption).Error
+}
+
+// ReleaseByOrder 归还订单使用的优惠券：标记使用记录已归还并减少已使用次数
+// 已归还的记录不会重复归还，返回本次归还的使用记录数
+func (r *CouponRepository) ReleaseByOrder(ctx context.Context, orderID uint, at time.Time) (int, error) {
+	db := dbFrom(ctx, r.db)
+	var redemptions []model.CouponRedemption
+	if err := db.Where("order_id = ? AND released_at IS NULL", orderID).Find(&redemptions).Error; err != nil {
+		return 0, err
+	}
+	released := 0
+	for _, redemption := range redemptions {
+		result := db.Model(&model.CouponRedemption{}).
+			Where("id = ? AND released_at IS NULL", redemption.ID).
+			UpdateColumn("released_at", at)
+		if result.Error != nil {
+			return released, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		err := db.Unscoped().Model(&model.Coupon{}).
+			Where("id = ? AND used_count > 0", redemption.CouponID).
+			UpdateColumn("used_count", gorm.Expr("used_count - 1")).Error
+		if err != nil {
+			return released, err
+		}
+		released++
+	}
+	return released, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"myshop/internal/model"
+	"myshop/pkg/money"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCouponUsage 使用次数达到上限后不能再使用，归还后可以再次使用且不会重复归还
+func TestCouponUsage(t *testing.T) {
+	db := newTestDB(t)
+	coupons := NewCouponRepository(db)
+	ctx := context.Background()
+
+	coupon := &model.Coupon{Code: "ONCE", Name: "限用一次", Type: model.CouponTypeFixed,
+		AmountOff: money.MustParse("10", "CNY"), TotalLimit: 1,
+		Scopes: []model.CouponScope{{CategoryID: 3}}}
+	if err := coupons.Create(ctx, coupon); err != nil {
+		t.Fatal(err)
+	}
+	if err := coupons.IncrementUsage(ctx, coupon.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := coupons.IncrementUsage(ctx, coupon.ID); !errors.Is(err, ErrCouponExhausted) {
+		t.Fatalf("err = %v, want %v", err, ErrCouponExhausted)
+	}
+	if err := coupons.CreateRedemption(ctx, &model.CouponRedemption{CouponID: coupon.ID, UserID: 1, OrderID: 7}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []int{1, 0} {
+		released, err := coupons.ReleaseByOrder(ctx, 7, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if released != want {
+			t.Fatalf("release #%d = %d, want %d", i+1, released, want)
+		}
+	}
+	used, err := coupons.CountRedemptions(ctx, coupon.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := coupons.GetByID(ctx, coupon.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used != 0 || stored.UsedCount != 0 || len(stored.Scopes) != 1 {
+		t.Fatalf("after release: redemptions = %d, coupon = %+v", used, stored)
+	}
+	if err := coupons.IncrementUsage(ctx, coupon.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCouponRedemptionConcurrent 并发使用每人限用一次的优惠券，只有一次成功
+func TestCouponRedemptionConcurrent(t *testing.T) {
+	db := newTestDB(t)
+	tx := NewTxManager(db)
+	coupons := NewCouponRepository(db)
+	ctx := context.Background()
+
+	coupon := &model.Coupon{Code: "ONCEEACH", Name: "每人一次", Type: model.CouponTypeFixed,
+		AmountOff: money.MustParse("10", "CNY"), PerUserLimit: 1}
+	if err := coupons.Create(ctx, coupon); err != nil {
+		t.Fatal(err)
+	}
+
+	var redeemed int64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(orderID uint) {
+			defer wg.Done()
+			err := tx.WithinTx(ctx, func(ctx context.Context) error {
+				used, err := coupons.CountRedemptions(ctx, coupon.ID, 1)
+				if err != nil || used >= int64(coupon.PerUserLimit) {
+					return err
+				}
+				atomic.AddInt64(&redeemed, 1)
+				return coupons.CreateRedemption(ctx, &model.CouponRedemption{CouponID: coupon.ID, UserID: 1, OrderID: orderID})
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}(uint(i + 1))
+	}
+	wg.Wait()
+
+	used, err := coupons.CountRedemptions(ctx, coupon.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redeemed != 1 || used != 1 {
+		t.Fatalf("redeemed = %d, redemptions = %d, want 1", redeemed, used)
+	}
+	if _, err := coupons.CountRedemptions(ctx, 999, 1); err == nil {
+		t.Fatal("优惠券不存在时应返回错误")
+	}
+}
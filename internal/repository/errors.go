@@ -4,5 +4,8 @@ import "errors"
 
 var (
 	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrCouponExhausted   = errors.New("coupon usage limit reached")
+	ErrStatusChanged     = errors.New("status changed")
 	ErrRecordNotFound    = errors.New("record not found")
+	ErrEmailTaken        = errors.New("email already taken")
 )
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"myshop/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IdentityRepository 第三方身份数据访问层
+type IdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewIdentityRepository 创建第三方身份仓储实例
+func NewIdentityRepository(db *gorm.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// Create 关联第三方身份
+func (r *IdentityRepository) Create(ctx context.Context, identity *model.Identity) error {
+	return dbFrom(ctx, r.db).Create(identity).Error
+}
+
+// CreateWithUser 在同一事务中创建用户并关联第三方身份，任一步失败都不会留下没有身份的账号
+// 邮箱与其他用户重复时返回ErrEmailTaken
+func (r *IdentityRepository) CreateWithUser(ctx context.Context, user *model.User, identity *model.Identity) error {
+	db := dbFrom(ctx, r.db)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+	if err != nil {
+		return translateUserError(db, user, err)
+	}
+	return nil
+}
+
+// GetBySubject 根据提供方和提供方内的用户标识查询
+func (r *IdentityRepository) GetBySubject(ctx context.Context, provider, subject string) (*model.Identity, error) {
+	var identity model.Identity
+	err := dbFrom(ctx, r.db).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// ListByUserID 查询用户关联的全部第三方身份
+func (r *IdentityRepository) ListByUserID(ctx context.Context, userID uint) ([]model.Identity, error) {
+	var identities []model.Identity
+	err := dbFrom(ctx, r.db).Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return identities, err
+}
+
+// TouchLogin 更新最近登录时间和邮箱
+func (r *IdentityRepository) TouchLogin(ctx context.Context, id uint, email string) error {
+	return dbFrom(ctx, r.db).Model(&model.Identity{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_login_at": time.Now(),
+		"email":         email,
+	}).Error
+}
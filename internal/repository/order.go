@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"myshop/internal/model"
 
 	"gorm.io/gorm"
@@ -16,15 +17,15 @@ func NewOrderRepository(db *gorm.DB) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
-// Create 创建新订单
-func (r *OrderRepository) Create(order *model.Order) error {
-	return r.db.Create(order).Error
+// Create 创建订单及订单项、优惠明细
+func (r *OrderRepository) Create(ctx context.Context, order *model.Order) error {
+	return dbFrom(ctx, r.db).Create(order).Error
 }
 
 // GetByID 根据ID获取订单
-func (r *OrderRepository) GetByID(id uint) (*model.Order, error) {
+func (r *OrderRepository) GetByID(ctx context.Context, id uint) (*model.Order, error) {
 	var order model.Order
-	err := r.db.Preload("Items").First(&order, id).Error
+	err := dbFrom(ctx, r.db).Preload("Items").Preload("Discounts").First(&order, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -32,17 +33,18 @@ func (r *OrderRepository) GetByID(id uint) (*model.Order, error) {
 }
 
 // GetByUserID 获取用户的订单列表
-func (r *OrderRepository) GetByUserID(userID uint, page, pageSize int) ([]model.Order, int64, error) {
+func (r *OrderRepository) GetByUserID(ctx context.Context, userID uint, page, pageSize int) ([]model.Order, int64, error) {
 	var orders []model.Order
 	var total int64
+	db := dbFrom(ctx, r.db)
 
-	if err := r.db.Model(&model.Order{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+	if err := db.Model(&model.Order{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	offset := (page - 1) * pageSize
-	err := r.db.Where("user_id = ?", userID).
-		Preload("Items").
+	err := db.Where("user_id = ?", userID).
+		Preload("Items").Preload("Discounts").
 		Offset(offset).
 		Limit(pageSize).
 		Find(&orders).Error
@@ -54,12 +56,60 @@ func (r *OrderRepository) GetByUserID(userID uint, page, pageSize int) ([]model.
 	return orders, total, nil
 }
 
-// UpdateStatus 更新订单状态
-func (r *OrderRepository) UpdateStatus(id uint, status int) error {
-	return r.db.Model(&model.Order{}).Where("id = ?", id).Update("status", status).Error
+// ListAllByUserID 获取用户的全部订单，用于导出个人数据
+func (r *OrderRepository) ListAllByUserID(ctx context.Context, userID uint) ([]model.Order, error) {
+	var orders []model.Order
+	err := dbFrom(ctx, r.db).Where("user_id = ?", userID).
+		Preload("Items").Preload("Discounts").
+		Order("id ASC").
+		Find(&orders).Error
+	return orders, err
+}
+
+// List 获取全部订单列表，按创建时间倒序
+func (r *OrderRepository) List(ctx context.Context, page, pageSize int) ([]model.Order, int64, error) {
+	var orders []model.Order
+	var total int64
+	db := dbFrom(ctx, r.db)
+
+	if err := db.Model(&model.Order{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := db.Preload("Items").Preload("Discounts").
+		Order("id DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&orders).Error
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return orders, total, nil
 }
 
-// GetDB 获取数据库连接
-func (r *OrderRepository) GetDB() *gorm.DB {
-	return r.db
+// ListAfter 按ID顺序获取ID大于afterID的订单及订单项，用于逐批遍历全部订单
+func (r *OrderRepository) ListAfter(ctx context.Context, afterID uint, limit int) ([]model.Order, error) {
+	var orders []model.Order
+	err := dbFrom(ctx, r.db).Where("id > ?", afterID).
+		Preload("Items").Preload("Discounts").
+		Order("id ASC").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
+
+// UpdateStatus 把订单状态从from改为to，订单当前不是from状态时返回ErrStatusChanged
+// 并发修改同一订单时只有一方成功，取消订单归还库存等副作用不会重复执行
+func (r *OrderRepository) UpdateStatus(ctx context.Context, id uint, from, to int) error {
+	result := dbFrom(ctx, r.db).Model(&model.Order{}).Where("id = ? AND status = ?", id, from).Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStatusChanged
+	}
+	return nil
 }
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/pkg/money"
+	"testing"
+)
+
+// TestOrderPayTotal 应付金额按支付币种的精度保存和读取，与本位币的精度无关
+func TestOrderPayTotal(t *testing.T) {
+	db := newTestDB(t)
+	orders := NewOrderRepository(db)
+	ctx := context.Background()
+
+	tests := []struct {
+		orderNo  string
+		currency string
+		payTotal money.Amount
+	}{
+		{"USD", "USD", money.MustParse("830.26", "USD")},
+		{"JPY", "JPY", money.MustParse("123879", "JPY")},
+		{"CNY", "CNY", money.MustParse("5999.00", "CNY")},
+	}
+	for _, tt := range tests {
+		order := &model.Order{OrderNo: tt.orderNo, UserID: 1, TotalPrice: money.MustParse("5999", "CNY"),
+			PayCurrency: tt.currency, ExchangeRate: "1", PayTotal: tt.payTotal}
+		if err := orders.Create(ctx, order); err != nil {
+			t.Fatal(err)
+		}
+		stored, err := orders.GetByID(ctx, order.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.PayTotal.Display() != tt.payTotal.Display() {
+			t.Errorf("%s: pay total = %s, want %s", tt.orderNo, stored.PayTotal.Display(), tt.payTotal.Display())
+		}
+	}
+
+	// 支持多币种之前的订单没有支付币种，按本位币读取
+	if err := db.Exec("INSERT INTO orders (order_no, user_id, total_price, pay_total) VALUES ('OLD', 1, 10.5, 10.5)").Error; err != nil {
+		t.Fatal(err)
+	}
+	var legacy model.Order
+	if err := db.Where("order_no = ?", "OLD").First(&legacy).Error; err != nil {
+		t.Fatal(err)
+	}
+	if legacy.PayCurrency != "CNY" || legacy.PayTotal.Display() != "CNY 10.50" {
+		t.Errorf("legacy order pay = %s %s", legacy.PayCurrency, legacy.PayTotal.Display())
+	}
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"myshop/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PrivacyRepository 账号注销与匿名化数据访问层
+// 注销和匿名化涉及多张表，需要在同一事务中完成
+type PrivacyRepository struct {
+	db *gorm.DB
+}
+
+// NewPrivacyRepository 创建账号注销仓储实例
+func NewPrivacyRepository(db *gorm.DB) *PrivacyRepository {
+	return &PrivacyRepository{db: db}
+}
+
+// SoftDeleteUser 软删除用户并吊销其全部API Key
+// 软删除后用户无法登录，个人信息保留到宽限期结束
+func (r *PrivacyRepository) SoftDeleteUser(ctx context.Context, userID uint) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.APIKey{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&model.User{}, userID).Error
+	})
+}
+
+// ListPendingAnonymization 查询注销时间早于before且尚未匿名化的用户
+func (r *PrivacyRepository) ListPendingAnonymization(ctx context.Context, before time.Time, limit int) ([]model.User, error) {
+	var users []model.User
+	err := dbFrom(ctx, r.db).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND anonymized_at IS NULL", before).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// Anonymize 匿名化已注销用户的个人信息
+// 用户行保留并改用假名，订单继续指向该行以便对账，但清除收件人、电话和详细地址；
+// 地址簿、第三方身份、令牌、会话等只与个人相关的数据直接删除
+func (r *PrivacyRepository) Anonymize(ctx context.Context, userID uint, pseudonym string) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"username":           pseudonym,
+			"password":           "",
+			"email":              "",
+			"email_verified_at":  nil,
+			"nickname":           "",
+			"phone":              "",
+			"avatar_url":         "",
+			"totp_secret":        "",
+			"two_factor_enabled": false,
+			"anonymized_at":      time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Unscoped().Model(&model.Order{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"ship_name":        "",
+			"ship_phone":       "",
+			"ship_detail":      "",
+			"ship_postal_code": "",
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&model.SecurityEvent{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"username": pseudonym,
+			"ip":       "",
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, m := range []interface{}{
+			&model.Address{},
+			&model.Identity{},
+			&model.RecoveryCode{},
+			&model.UserToken{},
+			&model.Session{},
+			&model.RefreshToken{},
+			&model.APIKey{},
+		} {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
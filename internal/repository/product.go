@@ -1,9 +1,11 @@
 package repository
 
 import (
+	"context"
 	"myshop/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ProductRepository 商品数据访问层
@@ -17,14 +19,14 @@ func NewProductRepository(db *gorm.DB) *ProductRepository {
 }
 
 // Create 创建新商品
-func (r *ProductRepository) Create(product *model.Product) error {
-	return r.db.Create(product).Error
+func (r *ProductRepository) Create(ctx context.Context, product *model.Product) error {
+	return dbFrom(ctx, r.db).Create(product).Error
 }
 
 // GetByID 根据ID获取商品
-func (r *ProductRepository) GetByID(id uint) (*model.Product, error) {
+func (r *ProductRepository) GetByID(ctx context.Context, id uint) (*model.Product, error) {
 	var product model.Product
-	err := r.db.First(&product, id).Error
+	err := dbFrom(ctx, r.db).First(&product, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -32,28 +34,29 @@ func (r *ProductRepository) GetByID(id uint) (*model.Product, error) {
 }
 
 // Update 更新商品信息
-func (r *ProductRepository) Update(product *model.Product) error {
-	return r.db.Save(product).Error
+func (r *ProductRepository) Update(ctx context.Context, product *model.Product) error {
+	return dbFrom(ctx, r.db).Save(product).Error
 }
 
 // Delete 删除商品（软删除）
-func (r *ProductRepository) Delete(id uint) error {
-	return r.db.Delete(&model.Product{}, id).Error
+func (r *ProductRepository) Delete(ctx context.Context, id uint) error {
+	return dbFrom(ctx, r.db).Delete(&model.Product{}, id).Error
 }
 
 // List 获取商品列表
-func (r *ProductRepository) List(page, pageSize int) ([]model.Product, int64, error) {
+func (r *ProductRepository) List(ctx context.Context, page, pageSize int) ([]model.Product, int64, error) {
 	var products []model.Product
 	var total int64
+	db := dbFrom(ctx, r.db)
 
 	// 获取总数
-	if err := r.db.Model(&model.Product{}).Count(&total).Error; err != nil {
+	if err := db.Model(&model.Product{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// 获取分页数据
 	offset := (page - 1) * pageSize
-	err := r.db.Offset(offset).Limit(pageSize).Find(&products).Error
+	err := db.Offset(offset).Limit(pageSize).Find(&products).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -61,9 +64,27 @@ func (r *ProductRepository) List(page, pageSize int) ([]model.Product, int64, er
 	return products, total, nil
 }
 
-// DeductStock 扣减库存
-func (r *ProductRepository) DeductStock(tx *gorm.DB, productID uint, quantity int) error {
-	result := tx.Model(&model.Product{}).
+// ListAfter 按ID顺序获取ID大于afterID的商品，用于逐批遍历全部商品
+func (r *ProductRepository) ListAfter(ctx context.Context, afterID uint, limit int) ([]model.Product, error) {
+	var products []model.Product
+	err := dbFrom(ctx, r.db).Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&products).Error
+	return products, err
+}
+
+// ListForUpdate 批量获取商品并加行锁，需在事务中调用，锁持续到事务结束
+// 按ID顺序加锁，避免并发下单时因加锁顺序不同而死锁；已删除的商品不返回
+func (r *ProductRepository) ListForUpdate(ctx context.Context, ids []uint) ([]model.Product, error) {
+	var products []model.Product
+	err := dbFrom(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id ASC").
+		Find(&products).Error
+	return products, err
+}
+
+// DeductStock 扣减库存，库存不足时返回ErrInsufficientStock
+func (r *ProductRepository) DeductStock(ctx context.Context, productID uint, quantity int) error {
+	result := dbFrom(ctx, r.db).Model(&model.Product{}).
 		Where("id = ? AND stock >= ?", productID, quantity).
 		UpdateColumn("stock", gorm.Expr("stock - ?", quantity))
 
@@ -77,3 +98,10 @@ func (r *ProductRepository) DeductStock(tx *gorm.DB, productID uint, quantity in
 
 	return nil
 }
+
+// RestoreStock 归还库存，取消订单时使用；商品已删除时不做处理
+func (r *ProductRepository) RestoreStock(ctx context.Context, productID uint, quantity int) error {
+	return dbFrom(ctx, r.db).Model(&model.Product{}).
+		Where("id = ?", productID).
+		UpdateColumn("stock", gorm.Expr("stock + ?", quantity)).Error
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"myshop/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// PromotionRepository 促销数据访问层
+type PromotionRepository struct {
+	db *gorm.DB
+}
+
+// NewPromotionRepository 创建促销仓储实例
+func NewPromotionRepository(db *gorm.DB) *PromotionRepository {
+	return &PromotionRepository{db: db}
+}
+
+// Create 创建促销及促销商品和满减档位
+func (r *PromotionRepository) Create(ctx context.Context, promotion *model.Promotion) error {
+	return dbFrom(ctx, r.db).Create(promotion).Error
+}
+
+// GetByID 根据ID获取促销及促销商品和满减档位
+func (r *PromotionRepository) GetByID(ctx context.Context, id uint) (*model.Promotion, error) {
+	var promotion model.Promotion
+	err := dbFrom(ctx, r.db).Preload("Items").Preload("Tiers").First(&promotion, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// List 获取促销列表，新创建的在前
+func (r *PromotionRepository) List(ctx context.Context, page, pageSize int) ([]model.Promotion, int64, error) {
+	var promotions []model.Promotion
+	var total int64
+	db := dbFrom(ctx, r.db)
+
+	if err := db.Model(&model.Promotion{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := db.Preload("Items").Preload("Tiers").Order("id DESC").Offset(offset).Limit(pageSize).Find(&promotions).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return promotions, total, nil
+}
+
+// ListEnabled 获取未停用的促销，按优先级从高到低、ID从小到大排序，不检查有效期
+func (r *PromotionRepository) ListEnabled(ctx context.Context) ([]model.Promotion, error) {
+	var promotions []model.Promotion
+	err := dbFrom(ctx, r.db).Preload("Items").Preload("Tiers").
+		Where("disabled = ?", false).Order("priority DESC, id ASC").Find(&promotions).Error
+	return promotions, err
+}
+
+// Update 更新促销并替换促销商品和满减档位
+func (r *PromotionRepository) Update(ctx context.Context, promotion *model.Promotion) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(promotion).Select("*").Omit("Items", "Tiers", "CreatedAt", "DeletedAt").Updates(promotion).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("promotion_id = ?", promotion.ID).Delete(&model.PromotionItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("promotion_id = ?", promotion.ID).Delete(&model.PromotionTier{}).Error; err != nil {
+			return err
+		}
+		for i := range promotion.Items {
+			promotion.Items[i].ID = 0
+			promotion.Items[i].PromotionID = promotion.ID
+		}
+		for i := range promotion.Tiers {
+			promotion.Tiers[i].ID = 0
+			promotion.Tiers[i].PromotionID = promotion.ID
+		}
+		if len(promotion.Items) > 0 {
+			if err := tx.Create(&promotion.Items).Error; err != nil {
+				return err
+			}
+		}
+		if len(promotion.Tiers) > 0 {
+			return tx.Create(&promotion.Tiers).Error
+		}
+		return nil
+	})
+}
+
+// Delete 删除促销（软删除），已下单的优惠明细保留
+func (r *PromotionRepository) Delete(ctx context.Context, id uint) error {
+	return dbFrom(ctx, r.db).Delete(&model.Promotion{}, id).Error
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"myshop/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeRepository 恢复码数据访问层
+type RecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoveryCodeRepository 创建恢复码仓储实例
+func NewRecoveryCodeRepository(db *gorm.DB) *RecoveryCodeRepository {
+	return &RecoveryCodeRepository{db: db}
+}
+
+// Replace 删除用户原有的恢复码并写入新的一组
+func (r *RecoveryCodeRepository) Replace(ctx context.Context, userID uint, hashes []string) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]model.RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = model.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Use 使用恢复码，已使用或不存在时返回false
+func (r *RecoveryCodeRepository) Use(ctx context.Context, userID uint, hash string) (bool, error) {
+	result := dbFrom(ctx, r.db).Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteByUserID 删除用户的全部恢复码
+func (r *RecoveryCodeRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return dbFrom(ctx, r.db).Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"myshop/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepository 刷新令牌数据访问层
+type RefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository 创建刷新令牌仓储实例
+func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// Create 保存刷新令牌
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	return dbFrom(ctx, r.db).Create(token).Error
+}
+
+// GetByHash 根据令牌摘要查询
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := dbFrom(ctx, r.db).Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Revoke 吊销单个令牌
+// 只有尚未吊销的令牌会被更新，返回false表示令牌已被并发请求抢先使用
+func (r *RefreshTokenRepository) Revoke(ctx context.Context, id uint) (bool, error) {
+	result := dbFrom(ctx, r.db).Model(&model.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily 吊销同一家族下的全部令牌
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return dbFrom(ctx, r.db).Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeByUserID 吊销用户的全部令牌
+func (r *RefreshTokenRepository) RevokeByUserID(ctx context.Context, userID uint) error {
+	return dbFrom(ctx, r.db).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"myshop/internal/model"
+	"time"
+)
+
+// 业务层依赖的数据访问接口
+// 各XxxRepository是基于GORM的实现，repotest包提供用于单元测试的内存实现。
+// 查询不到记录时返回gorm.ErrRecordNotFound，与GORM实现保持一致。
+// 各方法在ctx携带事务时加入该事务，见Transactor
+
+// UserStore 用户数据访问接口
+type UserStore interface {
+	Create(ctx context.Context, user *model.User) error
+	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetByID(ctx context.Context, id uint) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	Update(ctx context.Context, user *model.User) error
+}
+
+// ProductStore 商品数据访问接口
+type ProductStore interface {
+	Create(ctx context.Context, product *model.Product) error
+	GetByID(ctx context.Context, id uint) (*model.Product, error)
+	Update(ctx context.Context, product *model.Product) error
+	Delete(ctx context.Context, id uint) error
+	List(ctx context.Context, page, pageSize int) ([]model.Product, int64, error)
+	ListForUpdate(ctx context.Context, ids []uint) ([]model.Product, error)
+	DeductStock(ctx context.Context, productID uint, quantity int) error
+	RestoreStock(ctx context.Context, productID uint, quantity int) error
+}
+
+// OrderStore 订单数据访问接口
+type OrderStore interface {
+	Create(ctx context.Context, order *model.Order) error
+	GetByID(ctx context.Context, id uint) (*model.Order, error)
+	GetByUserID(ctx context.Context, userID uint, page, pageSize int) ([]model.Order, int64, error)
+	ListAllByUserID(ctx context.Context, userID uint) ([]model.Order, error)
+	List(ctx context.Context, page, pageSize int) ([]model.Order, int64, error)
+	UpdateStatus(ctx context.Context, id uint, from, to int) error
+}
+
+// CouponStore 优惠券数据访问接口
+type CouponStore interface {
+	Create(ctx context.Context, coupon *model.Coupon) error
+	GetByID(ctx context.Context, id uint) (*model.Coupon, error)
+	CodeExists(ctx context.Context, code string) (bool, error)
+	ListByCodes(ctx context.Context, codes []string) ([]model.Coupon, error)
+	List(ctx context.Context, page, pageSize int) ([]model.Coupon, int64, error)
+	Update(ctx context.Context, coupon *model.Coupon) error
+	Delete(ctx context.Context, id uint) error
+	IncrementUsage(ctx context.Context, id uint) error
+	CountRedemptions(ctx context.Context, couponID, userID uint) (int64, error)
+	CreateRedemption(ctx context.Context, redemption *model.CouponRedemption) error
+	ReleaseByOrder(ctx context.Context, orderID uint, at time.Time) (int, error)
+}
+
+// PromotionStore 促销数据访问接口
+type PromotionStore interface {
+	Create(ctx context.Context, promotion *model.Promotion) error
+	GetByID(ctx context.Context, id uint) (*model.Promotion, error)
+	List(ctx context.Context, page, pageSize int) ([]model.Promotion, int64, error)
+	ListEnabled(ctx context.Context) ([]model.Promotion, error)
+	Update(ctx context.Context, promotion *model.Promotion) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// AddressStore 收货地址数据访问接口
+type AddressStore interface {
+	Create(ctx context.Context, address *model.Address) error
+	GetByID(ctx context.Context, userID, id uint) (*model.Address, error)
+	GetDefault(ctx context.Context, userID uint) (*model.Address, error)
+	ListByUserID(ctx context.Context, userID uint) ([]model.Address, error)
+	CountByUserID(ctx context.Context, userID uint) (int64, error)
+	Update(ctx context.Context, address *model.Address) error
+	SetDefault(ctx context.Context, userID, id uint) error
+	Delete(ctx context.Context, address *model.Address) error
+}
+
+// SecurityEventStore 安全事件数据访问接口
+type SecurityEventStore interface {
+	Create(ctx context.Context, event *model.SecurityEvent) error
+	List(ctx context.Context, filter SecurityEventFilter, page, pageSize int) ([]model.SecurityEvent, int64, error)
+}
+
+// AuditLogStore 审计日志数据访问接口
+type AuditLogStore interface {
+	Create(ctx context.Context, log *model.AuditLog) error
+	List(ctx context.Context, filter AuditLogFilter, page, pageSize int) ([]model.AuditLog, int64, error)
+	Latest(ctx context.Context, resourceType string) (*model.AuditLog, error)
+}
+
+// RefreshTokenStore 刷新令牌数据访问接口
+type RefreshTokenStore interface {
+	Create(ctx context.Context, token *model.RefreshToken) error
+	GetByHash(ctx context.Context, hash string) (*model.RefreshToken, error)
+	Revoke(ctx context.Context, id uint) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeByUserID(ctx context.Context, userID uint) error
+}
+
+// SessionStore 登录会话数据访问接口
+type SessionStore interface {
+	Create(ctx context.Context, session *model.Session) error
+	GetByID(ctx context.Context, id uint) (*model.Session, error)
+	GetByFamilyID(ctx context.Context, familyID string) (*model.Session, error)
+	ListActive(ctx context.Context, userID uint) ([]model.Session, error)
+	Touch(ctx context.Context, id uint, at time.Time) error
+	Extend(ctx context.Context, id uint, expiresAt time.Time) error
+	Revoke(ctx context.Context, id uint) error
+}
+
+// RecoveryCodeStore 恢复码数据访问接口
+type RecoveryCodeStore interface {
+	Replace(ctx context.Context, userID uint, hashes []string) error
+	Use(ctx context.Context, userID uint, hash string) (bool, error)
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
+
+// UserTokenStore 一次性令牌数据访问接口
+type UserTokenStore interface {
+	Create(ctx context.Context, token *model.UserToken) error
+	Consume(ctx context.Context, nonceHash, purpose string) (*model.UserToken, error)
+	InvalidateByUser(ctx context.Context, userID uint, purpose string) error
+}
+
+// IdentityStore 第三方身份数据访问接口
+type IdentityStore interface {
+	Create(ctx context.Context, identity *model.Identity) error
+	CreateWithUser(ctx context.Context, user *model.User, identity *model.Identity) error
+	GetBySubject(ctx context.Context, provider, subject string) (*model.Identity, error)
+	ListByUserID(ctx context.Context, userID uint) ([]model.Identity, error)
+	TouchLogin(ctx context.Context, id uint, email string) error
+}
+
+// APIKeyStore API Key数据访问接口
+type APIKeyStore interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	GetByID(ctx context.Context, id uint) (*model.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	ListByUserID(ctx context.Context, userID uint) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id uint) error
+	TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error
+}
+
+// PrivacyStore 账号注销与匿名化数据访问接口
+type PrivacyStore interface {
+	SoftDeleteUser(ctx context.Context, userID uint) error
+	ListPendingAnonymization(ctx context.Context, before time.Time, limit int) ([]model.User, error)
+	Anonymize(ctx context.Context, userID uint, pseudonym string) error
+}
+
+var (
+	_ UserStore          = (*UserRepository)(nil)
+	_ ProductStore       = (*ProductRepository)(nil)
+	_ OrderStore         = (*OrderRepository)(nil)
+	_ CouponStore        = (*CouponRepository)(nil)
+	_ PromotionStore     = (*PromotionRepository)(nil)
+	_ AddressStore       = (*AddressRepository)(nil)
+	_ SecurityEventStore = (*SecurityEventRepository)(nil)
+	_ AuditLogStore      = (*AuditLogRepository)(nil)
+	_ RefreshTokenStore  = (*RefreshTokenRepository)(nil)
+	_ SessionStore       = (*SessionRepository)(nil)
+	_ RecoveryCodeStore  = (*RecoveryCodeRepository)(nil)
+	_ UserTokenStore     = (*UserTokenRepository)(nil)
+	_ IdentityStore      = (*IdentityRepository)(nil)
+	_ APIKeyStore        = (*APIKeyRepository)(nil)
+	_ PrivacyStore       = (*PrivacyRepository)(nil)
+)
@@ -0,0 +1,149 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ repository.AddressStore = (*AddressRepository)(nil)
+
+// AddressRepository 收货地址数据的内存实现，同一用户只有一个默认地址
+type AddressRepository struct {
+	mu        sync.Mutex
+	addresses map[uint]model.Address
+	nextID    uint
+}
+
+// NewAddressRepository 创建收货地址内存仓储
+func NewAddressRepository() *AddressRepository {
+	return &AddressRepository{addresses: make(map[uint]model.Address)}
+}
+
+// Create 创建收货地址，设为默认时取消用户其他地址的默认标记
+func (r *AddressRepository) Create(_ context.Context, address *model.Address) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if address.IsDefault {
+		r.clearDefault(address.UserID)
+	}
+	r.nextID++
+	address.ID = r.nextID
+	now := time.Now()
+	address.CreatedAt, address.UpdatedAt = now, now
+	r.addresses[address.ID] = *address
+	return nil
+}
+
+// GetByID 根据ID查询用户的收货地址
+func (r *AddressRepository) GetByID(_ context.Context, userID, id uint) (*model.Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	address, ok := r.addresses[id]
+	if !ok || address.UserID != userID {
+		return nil, errNotFound
+	}
+	return &address, nil
+}
+
+// GetDefault 查询用户的默认收货地址
+func (r *AddressRepository) GetDefault(_ context.Context, userID uint) (*model.Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range r.addresses {
+		if a.UserID == userID && a.IsDefault {
+			return &a, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+// ListByUserID 查询用户的全部收货地址，默认地址在前
+func (r *AddressRepository) ListByUserID(_ context.Context, userID uint) ([]model.Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.list(userID), nil
+}
+
+// CountByUserID 统计用户的收货地址数量
+func (r *AddressRepository) CountByUserID(_ context.Context, userID uint) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.list(userID))), nil
+}
+
+// Update 更新收货地址内容
+func (r *AddressRepository) Update(_ context.Context, address *model.Address) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.addresses[address.ID]
+	if !ok {
+		return nil
+	}
+	stored.ShippingAddress = address.ShippingAddress
+	stored.UpdatedAt = time.Now()
+	r.addresses[address.ID] = stored
+	return nil
+}
+
+// SetDefault 设为默认地址
+func (r *AddressRepository) SetDefault(_ context.Context, userID, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clearDefault(userID)
+	if address, ok := r.addresses[id]; ok && address.UserID == userID {
+		address.IsDefault = true
+		r.addresses[id] = address
+	}
+	return nil
+}
+
+// Delete 删除收货地址，删除的是默认地址时把最近添加的地址设为默认
+func (r *AddressRepository) Delete(_ context.Context, address *model.Address) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.addresses, address.ID)
+	if !address.IsDefault {
+		return nil
+	}
+	var next uint
+	for id, a := range r.addresses {
+		if a.UserID == address.UserID && id > next {
+			next = id
+		}
+	}
+	if next != 0 {
+		a := r.addresses[next]
+		a.IsDefault = true
+		r.addresses[next] = a
+	}
+	return nil
+}
+
+func (r *AddressRepository) list(userID uint) []model.Address {
+	addresses := []model.Address{}
+	for _, a := range r.addresses {
+		if a.UserID == userID {
+			addresses = append(addresses, a)
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		if addresses[i].IsDefault != addresses[j].IsDefault {
+			return addresses[i].IsDefault
+		}
+		return addresses[i].ID > addresses[j].ID
+	})
+	return addresses
+}
+
+func (r *AddressRepository) clearDefault(userID uint) {
+	for id, a := range r.addresses {
+		if a.UserID == userID && a.IsDefault {
+			a.IsDefault = false
+			r.addresses[id] = a
+		}
+	}
+}
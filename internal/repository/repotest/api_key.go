@@ -0,0 +1,91 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"sync"
+	"time"
+)
+
+var _ repository.APIKeyStore = (*APIKeyRepository)(nil)
+
+// APIKeyRepository API Key的内存实现
+type APIKeyRepository struct {
+	mu   sync.Mutex
+	keys []model.APIKey
+}
+
+// NewAPIKeyRepository 创建API Key内存仓储
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{}
+}
+
+// Create 保存API Key
+func (r *APIKeyRepository) Create(_ context.Context, key *model.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key.ID = uint(len(r.keys) + 1)
+	key.CreatedAt = time.Now()
+	r.keys = append(r.keys, *key)
+	return nil
+}
+
+// GetByID 根据ID查询
+func (r *APIKeyRepository) GetByID(_ context.Context, id uint) (*model.APIKey, error) {
+	return r.find(func(k *model.APIKey) bool { return k.ID == id })
+}
+
+// GetByHash 根据明文摘要查询
+func (r *APIKeyRepository) GetByHash(_ context.Context, hash string) (*model.APIKey, error) {
+	return r.find(func(k *model.APIKey) bool { return k.KeyHash == hash })
+}
+
+// ListByUserID 获取用户的全部API Key，按创建时间倒序
+func (r *APIKeyRepository) ListByUserID(_ context.Context, userID uint) ([]model.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := []model.APIKey{}
+	for i := len(r.keys) - 1; i >= 0; i-- {
+		if r.keys[i].UserID == userID {
+			keys = append(keys, r.keys[i])
+		}
+	}
+	return keys, nil
+}
+
+// Revoke 吊销API Key
+func (r *APIKeyRepository) Revoke(_ context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.keys {
+		if k := &r.keys[i]; k.ID == id && k.RevokedAt == nil {
+			now := time.Now()
+			k.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// TouchLastUsed 更新最近使用时间
+func (r *APIKeyRepository) TouchLastUsed(_ context.Context, id uint, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.keys {
+		if r.keys[i].ID == id {
+			r.keys[i].LastUsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) find(match func(k *model.APIKey) bool) (*model.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range r.keys {
+		if match(&k) {
+			return &k, nil
+		}
+	}
+	return nil, errNotFound
+}
@@ -0,0 +1,94 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"sync"
+	"time"
+)
+
+var _ repository.AuditLogStore = (*AuditLogRepository)(nil)
+
+// AuditLogRepository 审计日志的内存实现，只能追加
+type AuditLogRepository struct {
+	mu   sync.Mutex
+	logs []model.AuditLog
+}
+
+// NewAuditLogRepository 创建审计日志内存仓储
+func NewAuditLogRepository() *AuditLogRepository {
+	return &AuditLogRepository{}
+}
+
+// Create 记录审计日志
+func (r *AuditLogRepository) Create(_ context.Context, log *model.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	log.ID = uint(len(r.logs) + 1)
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+	r.logs = append(r.logs, *log)
+	return nil
+}
+
+// List 按条件分页查询审计日志，按时间倒序
+func (r *AuditLogRepository) List(_ context.Context, filter repository.AuditLogFilter, page, pageSize int) ([]model.AuditLog, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	logs := []model.AuditLog{}
+	for i := len(r.logs) - 1; i >= 0; i-- {
+		l := r.logs[i]
+		if (filter.ActorID != 0 && l.ActorID != filter.ActorID) ||
+			(filter.Action != "" && l.Action != filter.Action) ||
+			(filter.ResourceType != "" && l.ResourceType != filter.ResourceType) ||
+			(filter.ResourceID != "" && l.ResourceID != filter.ResourceID) ||
+			(filter.RequestID != "" && l.RequestID != filter.RequestID) ||
+			(!filter.Since.IsZero() && l.CreatedAt.Before(filter.Since)) ||
+			(!filter.Until.IsZero() && !l.CreatedAt.Before(filter.Until)) {
+			continue
+		}
+		logs = append(logs, l)
+	}
+	start, end := pageRange(len(logs), page, pageSize)
+	return logs[start:end], int64(len(logs)), nil
+}
+
+// Latest 查询某类资源最新的一条审计日志
+func (r *AuditLogRepository) Latest(_ context.Context, resourceType string) (*model.AuditLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.logs) - 1; i >= 0; i-- {
+		if r.logs[i].ResourceType == resourceType {
+			log := r.logs[i]
+			return &log, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+// ListByResourceType 按写入顺序查询某类资源的全部审计日志，供测试断言
+func (r *AuditLogRepository) ListByResourceType(_ context.Context, resourceType string) ([]model.AuditLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	logs := []model.AuditLog{}
+	for _, l := range r.logs {
+		if l.ResourceType == resourceType {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+// Snapshot 保存当前全部审计日志，返回恢复函数
+func (r *AuditLogRepository) Snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	logs := append([]model.AuditLog(nil), r.logs...)
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.logs = logs
+	}
+}
@@ -0,0 +1,214 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ repository.CouponStore = (*CouponRepository)(nil)
+
+// CouponRepository 优惠券数据的内存实现，兑换码唯一，删除后兑换码仍然占用
+type CouponRepository struct {
+	mu          sync.Mutex
+	coupons     map[uint]model.Coupon
+	deleted     map[uint]model.Coupon
+	redemptions []model.CouponRedemption
+	nextID      uint
+}
+
+// NewCouponRepository 创建优惠券内存仓储
+func NewCouponRepository() *CouponRepository {
+	return &CouponRepository{coupons: make(map[uint]model.Coupon), deleted: make(map[uint]model.Coupon)}
+}
+
+// Create 创建优惠券，兑换码不能重复
+func (r *CouponRepository) Create(_ context.Context, coupon *model.Coupon) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.codeExists(coupon.Code) {
+		return ErrDuplicate
+	}
+	r.nextID++
+	coupon.ID = r.nextID
+	now := time.Now()
+	coupon.CreatedAt, coupon.UpdatedAt = now, now
+	r.coupons[coupon.ID] = copyCoupon(*coupon)
+	return nil
+}
+
+// GetByID 根据ID获取优惠券
+func (r *CouponRepository) GetByID(_ context.Context, id uint) (*model.Coupon, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	coupon, ok := r.coupons[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	coupon = copyCoupon(coupon)
+	return &coupon, nil
+}
+
+// CodeExists 兑换码是否已被使用，包括已删除的优惠券
+func (r *CouponRepository) CodeExists(_ context.Context, code string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.codeExists(code), nil
+}
+
+func (r *CouponRepository) codeExists(code string) bool {
+	for _, set := range []map[uint]model.Coupon{r.coupons, r.deleted} {
+		for _, c := range set {
+			if c.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ListByCodes 根据兑换码批量获取优惠券，按ID顺序返回
+func (r *CouponRepository) ListByCodes(_ context.Context, codes []string) ([]model.Coupon, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	wanted := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		wanted[code] = true
+	}
+	coupons := []model.Coupon{}
+	for _, c := range r.coupons {
+		if wanted[c.Code] {
+			coupons = append(coupons, copyCoupon(c))
+		}
+	}
+	sort.Slice(coupons, func(i, j int) bool { return coupons[i].ID < coupons[j].ID })
+	return coupons, nil
+}
+
+// List 分页获取优惠券，新创建的在前
+func (r *CouponRepository) List(_ context.Context, page, pageSize int) ([]model.Coupon, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	coupons := make([]model.Coupon, 0, len(r.coupons))
+	for _, c := range r.coupons {
+		coupons = append(coupons, copyCoupon(c))
+	}
+	sort.Slice(coupons, func(i, j int) bool { return coupons[i].ID > coupons[j].ID })
+	start, end := pageRange(len(coupons), page, pageSize)
+	return coupons[start:end], int64(len(coupons)), nil
+}
+
+// Update 更新优惠券并替换适用范围，不修改已使用次数
+func (r *CouponRepository) Update(_ context.Context, coupon *model.Coupon) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stored, ok := r.coupons[coupon.ID]; ok {
+		coupon.UsedCount = stored.UsedCount
+		coupon.UpdatedAt = time.Now()
+		r.coupons[coupon.ID] = copyCoupon(*coupon)
+	}
+	return nil
+}
+
+// Delete 删除优惠券，兑换码仍然占用
+func (r *CouponRepository) Delete(_ context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.coupons[id]; ok {
+		r.deleted[id] = c
+		delete(r.coupons, id)
+	}
+	return nil
+}
+
+// IncrementUsage 已使用次数加1，达到总次数上限时返回ErrCouponExhausted
+func (r *CouponRepository) IncrementUsage(_ context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.coupons[id]
+	if !ok || c.TotalLimit > 0 && c.UsedCount >= c.TotalLimit {
+		return repository.ErrCouponExhausted
+	}
+	c.UsedCount++
+	r.coupons[id] = c
+	return nil
+}
+
+// CountRedemptions 统计用户使用某优惠券且未归还的次数，优惠券不存在时返回错误
+func (r *CouponRepository) CountRedemptions(_ context.Context, couponID, userID uint) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.coupons[couponID]; !ok {
+		return 0, errNotFound
+	}
+	var count int64
+	for _, rd := range r.redemptions {
+		if rd.CouponID == couponID && rd.UserID == userID && rd.ReleasedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CreateRedemption 创建优惠券使用记录
+func (r *CouponRepository) CreateRedemption(_ context.Context, redemption *model.CouponRedemption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	redemption.ID = uint(len(r.redemptions) + 1)
+	redemption.CreatedAt = time.Now()
+	r.redemptions = append(r.redemptions, *redemption)
+	return nil
+}
+
+// ReleaseByOrder 归还订单使用的优惠券，返回本次归还的使用记录数
+func (r *CouponRepository) ReleaseByOrder(_ context.Context, orderID uint, at time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	released := 0
+	for i := range r.redemptions {
+		rd := &r.redemptions[i]
+		if rd.OrderID != orderID || rd.ReleasedAt != nil {
+			continue
+		}
+		releasedAt := at
+		rd.ReleasedAt = &releasedAt
+		for _, set := range []map[uint]model.Coupon{r.coupons, r.deleted} {
+			if c, ok := set[rd.CouponID]; ok && c.UsedCount > 0 {
+				c.UsedCount--
+				set[rd.CouponID] = c
+			}
+		}
+		released++
+	}
+	return released, nil
+}
+
+// Snapshot 保存当前全部优惠券和使用记录，返回恢复函数
+func (r *CouponRepository) Snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	coupons := make(map[uint]model.Coupon, len(r.coupons))
+	for id, c := range r.coupons {
+		coupons[id] = copyCoupon(c)
+	}
+	deleted := make(map[uint]model.Coupon, len(r.deleted))
+	for id, c := range r.deleted {
+		deleted[id] = c
+	}
+	redemptions := append([]model.CouponRedemption(nil), r.redemptions...)
+	nextID := r.nextID
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.coupons, r.deleted, r.redemptions, r.nextID = coupons, deleted, redemptions, nextID
+	}
+}
+
+// copyCoupon 复制适用范围，避免调用方修改仓储中保存的数据
+func copyCoupon(coupon model.Coupon) model.Coupon {
+	coupon.Scopes = append([]model.CouponScope(nil), coupon.Scopes...)
+	return coupon
+}
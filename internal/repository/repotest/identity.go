@@ -0,0 +1,103 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"sync"
+	"time"
+)
+
+var _ repository.IdentityStore = (*IdentityRepository)(nil)
+
+// IdentityRepository 第三方身份的内存实现，同一提供方的用户标识唯一
+type IdentityRepository struct {
+	mu         sync.Mutex
+	users      *UserRepository
+	identities []model.Identity
+}
+
+// NewIdentityRepository 创建第三方身份内存仓储，CreateWithUser把用户写入users
+func NewIdentityRepository(users *UserRepository) *IdentityRepository {
+	return &IdentityRepository{users: users}
+}
+
+// Create 关联第三方身份
+func (r *IdentityRepository) Create(_ context.Context, identity *model.Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.create(identity)
+}
+
+// CreateWithUser 创建用户并关联第三方身份，身份已存在时不创建用户
+func (r *IdentityRepository) CreateWithUser(ctx context.Context, user *model.User, identity *model.Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.exists(identity.Provider, identity.Subject) {
+		return ErrDuplicate
+	}
+	if err := r.users.Create(ctx, user); err != nil {
+		return err
+	}
+	identity.UserID = user.ID
+	return r.create(identity)
+}
+
+func (r *IdentityRepository) exists(provider, subject string) bool {
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *IdentityRepository) create(identity *model.Identity) error {
+	for _, i := range r.identities {
+		if i.Provider == identity.Provider && i.Subject == identity.Subject {
+			return ErrDuplicate
+		}
+	}
+	identity.ID = uint(len(r.identities) + 1)
+	identity.CreatedAt = time.Now()
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+// GetBySubject 根据提供方和提供方内的用户标识查询
+func (r *IdentityRepository) GetBySubject(_ context.Context, provider, subject string) (*model.Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			return &i, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+// ListByUserID 查询用户关联的全部第三方身份
+func (r *IdentityRepository) ListByUserID(_ context.Context, userID uint) ([]model.Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	identities := []model.Identity{}
+	for _, i := range r.identities {
+		if i.UserID == userID {
+			identities = append(identities, i)
+		}
+	}
+	return identities, nil
+}
+
+// TouchLogin 更新最近登录时间和邮箱
+func (r *IdentityRepository) TouchLogin(_ context.Context, id uint, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.identities {
+		if r.identities[i].ID == id {
+			r.identities[i].LastLoginAt = time.Now()
+			r.identities[i].Email = email
+		}
+	}
+	return nil
+}
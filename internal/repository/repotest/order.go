@@ -0,0 +1,138 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ repository.OrderStore = (*OrderRepository)(nil)
+
+// OrderRepository 订单数据的内存实现
+type OrderRepository struct {
+	mu         sync.Mutex
+	orders     map[uint]model.Order
+	nextID     uint
+	nextItemID uint
+}
+
+// NewOrderRepository 创建订单内存仓储
+func NewOrderRepository() *OrderRepository {
+	return &OrderRepository{orders: make(map[uint]model.Order)}
+}
+
+// Create 创建订单及订单项，订单号不能重复
+func (r *OrderRepository) Create(_ context.Context, order *model.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, o := range r.orders {
+		if o.OrderNo == order.OrderNo {
+			return ErrDuplicate
+		}
+	}
+
+	r.nextID++
+	order.ID = r.nextID
+	for i := range order.Items {
+		r.nextItemID++
+		order.Items[i].ID = r.nextItemID
+		order.Items[i].OrderID = order.ID
+	}
+	for i := range order.Discounts {
+		order.Discounts[i].ID = uint(i + 1)
+		order.Discounts[i].OrderID = order.ID
+	}
+	now := time.Now()
+	order.CreatedAt, order.UpdatedAt = now, now
+	r.orders[order.ID] = copyOrder(*order)
+	return nil
+}
+
+// GetByID 根据ID获取订单
+func (r *OrderRepository) GetByID(_ context.Context, id uint) (*model.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order, ok := r.orders[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	order = copyOrder(order)
+	return &order, nil
+}
+
+// GetByUserID 按ID顺序分页获取用户的订单列表
+func (r *OrderRepository) GetByUserID(_ context.Context, userID uint, page, pageSize int) ([]model.Order, int64, error) {
+	orders := r.filter(func(o *model.Order) bool { return o.UserID == userID }, false)
+	start, end := pageRange(len(orders), page, pageSize)
+	return orders[start:end], int64(len(orders)), nil
+}
+
+// ListAllByUserID 获取用户的全部订单
+func (r *OrderRepository) ListAllByUserID(_ context.Context, userID uint) ([]model.Order, error) {
+	return r.filter(func(o *model.Order) bool { return o.UserID == userID }, false), nil
+}
+
+// List 分页获取全部订单，新订单在前
+func (r *OrderRepository) List(_ context.Context, page, pageSize int) ([]model.Order, int64, error) {
+	orders := r.filter(func(*model.Order) bool { return true }, true)
+	start, end := pageRange(len(orders), page, pageSize)
+	return orders[start:end], int64(len(orders)), nil
+}
+
+// UpdateStatus 把订单状态从from改为to，订单当前不是from状态时返回ErrStatusChanged
+func (r *OrderRepository) UpdateStatus(_ context.Context, id uint, from, to int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order, ok := r.orders[id]
+	if !ok || order.Status != from {
+		return repository.ErrStatusChanged
+	}
+	order.Status = to
+	order.UpdatedAt = time.Now()
+	r.orders[id] = order
+	return nil
+}
+
+// Snapshot 保存当前全部订单，返回恢复函数
+func (r *OrderRepository) Snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	orders := make(map[uint]model.Order, len(r.orders))
+	for id, o := range r.orders {
+		orders[id] = copyOrder(o)
+	}
+	nextID, nextItemID := r.nextID, r.nextItemID
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.orders, r.nextID, r.nextItemID = orders, nextID, nextItemID
+	}
+}
+
+func (r *OrderRepository) filter(match func(o *model.Order) bool, desc bool) []model.Order {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	orders := []model.Order{}
+	for _, o := range r.orders {
+		if match(&o) {
+			orders = append(orders, copyOrder(o))
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if desc {
+			return orders[i].ID > orders[j].ID
+		}
+		return orders[i].ID < orders[j].ID
+	})
+	return orders
+}
+
+// copyOrder 复制订单项和优惠明细，避免调用方修改仓储中保存的数据
+func copyOrder(order model.Order) model.Order {
+	order.Items = append([]model.OrderItem(nil), order.Items...)
+	order.Discounts = append([]model.OrderDiscount(nil), order.Discounts...)
+	return order
+}
@@ -0,0 +1,137 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ repository.ProductStore = (*ProductRepository)(nil)
+
+// ProductRepository 商品数据的内存实现
+type ProductRepository struct {
+	mu       sync.Mutex
+	products map[uint]model.Product
+	nextID   uint
+}
+
+// NewProductRepository 创建商品内存仓储
+func NewProductRepository() *ProductRepository {
+	return &ProductRepository{products: make(map[uint]model.Product)}
+}
+
+// Create 创建新商品，指定了ID时使用该ID
+func (r *ProductRepository) Create(_ context.Context, product *model.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if product.ID == 0 {
+		r.nextID++
+		product.ID = r.nextID
+	} else if _, ok := r.products[product.ID]; ok {
+		return ErrDuplicate
+	} else if product.ID > r.nextID {
+		r.nextID = product.ID
+	}
+	now := time.Now()
+	product.CreatedAt, product.UpdatedAt = now, now
+	r.products[product.ID] = *product
+	return nil
+}
+
+// GetByID 根据ID获取商品
+func (r *ProductRepository) GetByID(_ context.Context, id uint) (*model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, ok := r.products[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return &product, nil
+}
+
+// Update 更新商品信息
+func (r *ProductRepository) Update(_ context.Context, product *model.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product.UpdatedAt = time.Now()
+	r.products[product.ID] = *product
+	return nil
+}
+
+// Delete 删除商品
+func (r *ProductRepository) Delete(_ context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.products, id)
+	return nil
+}
+
+// List 按ID顺序分页获取商品列表
+func (r *ProductRepository) List(_ context.Context, page, pageSize int) ([]model.Product, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	products := make([]model.Product, 0, len(r.products))
+	for _, p := range r.products {
+		products = append(products, p)
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	start, end := pageRange(len(products), page, pageSize)
+	return products[start:end], int64(len(products)), nil
+}
+
+// ListForUpdate 按ID顺序批量获取商品，内存实现不加锁
+func (r *ProductRepository) ListForUpdate(_ context.Context, ids []uint) ([]model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	products := []model.Product{}
+	for _, id := range ids {
+		if p, ok := r.products[id]; ok {
+			products = append(products, p)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	return products, nil
+}
+
+// DeductStock 扣减库存，库存不足时返回ErrInsufficientStock
+func (r *ProductRepository) DeductStock(_ context.Context, productID uint, quantity int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[productID]
+	if !ok || p.Stock < quantity {
+		return repository.ErrInsufficientStock
+	}
+	p.Stock -= quantity
+	r.products[productID] = p
+	return nil
+}
+
+// RestoreStock 归还库存，商品不存在时不做处理
+func (r *ProductRepository) RestoreStock(_ context.Context, productID uint, quantity int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.products[productID]; ok {
+		p.Stock += quantity
+		r.products[productID] = p
+	}
+	return nil
+}
+
+// Snapshot 保存当前全部商品，返回恢复函数
+func (r *ProductRepository) Snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	products := make(map[uint]model.Product, len(r.products))
+	for id, p := range r.products {
+		products[id] = p
+	}
+	nextID := r.nextID
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.products, r.nextID = products, nextID
+	}
+}
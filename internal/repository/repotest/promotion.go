@@ -0,0 +1,106 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ repository.PromotionStore = (*PromotionRepository)(nil)
+
+// PromotionRepository 促销数据的内存实现
+type PromotionRepository struct {
+	mu         sync.Mutex
+	promotions map[uint]model.Promotion
+	nextID     uint
+}
+
+// NewPromotionRepository 创建促销内存仓储
+func NewPromotionRepository() *PromotionRepository {
+	return &PromotionRepository{promotions: make(map[uint]model.Promotion)}
+}
+
+// Create 创建促销
+func (r *PromotionRepository) Create(_ context.Context, promotion *model.Promotion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	promotion.ID = r.nextID
+	now := time.Now()
+	promotion.CreatedAt, promotion.UpdatedAt = now, now
+	r.promotions[promotion.ID] = copyPromotion(*promotion)
+	return nil
+}
+
+// GetByID 根据ID获取促销
+func (r *PromotionRepository) GetByID(_ context.Context, id uint) (*model.Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	promotion, ok := r.promotions[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	promotion = copyPromotion(promotion)
+	return &promotion, nil
+}
+
+// List 分页获取促销，新创建的在前
+func (r *PromotionRepository) List(_ context.Context, page, pageSize int) ([]model.Promotion, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	promotions := make([]model.Promotion, 0, len(r.promotions))
+	for _, p := range r.promotions {
+		promotions = append(promotions, copyPromotion(p))
+	}
+	sort.Slice(promotions, func(i, j int) bool { return promotions[i].ID > promotions[j].ID })
+	start, end := pageRange(len(promotions), page, pageSize)
+	return promotions[start:end], int64(len(promotions)), nil
+}
+
+// ListEnabled 获取未停用的促销，按优先级从高到低、ID从小到大排序
+func (r *PromotionRepository) ListEnabled(_ context.Context) ([]model.Promotion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	promotions := []model.Promotion{}
+	for _, p := range r.promotions {
+		if !p.Disabled {
+			promotions = append(promotions, copyPromotion(p))
+		}
+	}
+	sort.Slice(promotions, func(i, j int) bool {
+		if promotions[i].Priority != promotions[j].Priority {
+			return promotions[i].Priority > promotions[j].Priority
+		}
+		return promotions[i].ID < promotions[j].ID
+	})
+	return promotions, nil
+}
+
+// Update 更新促销并替换促销商品和满减档位
+func (r *PromotionRepository) Update(_ context.Context, promotion *model.Promotion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.promotions[promotion.ID]; ok {
+		promotion.UpdatedAt = time.Now()
+		r.promotions[promotion.ID] = copyPromotion(*promotion)
+	}
+	return nil
+}
+
+// Delete 删除促销
+func (r *PromotionRepository) Delete(_ context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.promotions, id)
+	return nil
+}
+
+// copyPromotion 复制促销商品和满减档位，避免调用方修改仓储中保存的数据
+func copyPromotion(promotion model.Promotion) model.Promotion {
+	promotion.Items = append([]model.PromotionItem(nil), promotion.Items...)
+	promotion.Tiers = append([]model.PromotionTier(nil), promotion.Tiers...)
+	return promotion
+}
@@ -0,0 +1,67 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"sync"
+	"time"
+)
+
+var _ repository.RecoveryCodeStore = (*RecoveryCodeRepository)(nil)
+
+// RecoveryCodeRepository 恢复码的内存实现
+type RecoveryCodeRepository struct {
+	mu     sync.Mutex
+	codes  []model.RecoveryCode
+	nextID uint
+}
+
+// NewRecoveryCodeRepository 创建恢复码内存仓储
+func NewRecoveryCodeRepository() *RecoveryCodeRepository {
+	return &RecoveryCodeRepository{}
+}
+
+// Replace 删除用户原有的恢复码并写入新的一组
+func (r *RecoveryCodeRepository) Replace(_ context.Context, userID uint, hashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleteByUserID(userID)
+	for _, hash := range hashes {
+		r.nextID++
+		r.codes = append(r.codes, model.RecoveryCode{ID: r.nextID, UserID: userID, CodeHash: hash, CreatedAt: time.Now()})
+	}
+	return nil
+}
+
+// Use 使用恢复码，已使用或不存在时返回false
+func (r *RecoveryCodeRepository) Use(_ context.Context, userID uint, hash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.codes {
+		if c := &r.codes[i]; c.UserID == userID && c.CodeHash == hash && c.UsedAt == nil {
+			now := time.Now()
+			c.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DeleteByUserID 删除用户的全部恢复码
+func (r *RecoveryCodeRepository) DeleteByUserID(_ context.Context, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleteByUserID(userID)
+	return nil
+}
+
+func (r *RecoveryCodeRepository) deleteByUserID(userID uint) {
+	kept := r.codes[:0]
+	for _, c := range r.codes {
+		if c.UserID != userID {
+			kept = append(kept, c)
+		}
+	}
+	r.codes = kept
+}
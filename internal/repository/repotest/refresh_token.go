@@ -0,0 +1,76 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"sync"
+	"time"
+)
+
+var _ repository.RefreshTokenStore = (*RefreshTokenRepository)(nil)
+
+// RefreshTokenRepository 刷新令牌的内存实现
+type RefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens []model.RefreshToken
+}
+
+// NewRefreshTokenRepository 创建刷新令牌内存仓储
+func NewRefreshTokenRepository() *RefreshTokenRepository {
+	return &RefreshTokenRepository{}
+}
+
+// Create 保存刷新令牌
+func (r *RefreshTokenRepository) Create(_ context.Context, token *model.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token.ID = uint(len(r.tokens) + 1)
+	token.CreatedAt = time.Now()
+	r.tokens = append(r.tokens, *token)
+	return nil
+}
+
+// GetByHash 根据令牌摘要查询
+func (r *RefreshTokenRepository) GetByHash(_ context.Context, hash string) (*model.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == hash {
+			return &t, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+// Revoke 吊销单个令牌，返回false表示令牌已被吊销
+func (r *RefreshTokenRepository) Revoke(_ context.Context, id uint) (bool, error) {
+	return r.revoke(func(t *model.RefreshToken) bool { return t.ID == id }) == 1, nil
+}
+
+// RevokeFamily 吊销同一家族下的全部令牌
+func (r *RefreshTokenRepository) RevokeFamily(_ context.Context, familyID string) error {
+	r.revoke(func(t *model.RefreshToken) bool { return t.FamilyID == familyID })
+	return nil
+}
+
+// RevokeByUserID 吊销用户的全部令牌
+func (r *RefreshTokenRepository) RevokeByUserID(_ context.Context, userID uint) error {
+	r.revoke(func(t *model.RefreshToken) bool { return t.UserID == userID })
+	return nil
+}
+
+// revoke 吊销符合条件且尚未吊销的令牌，返回吊销的数量
+func (r *RefreshTokenRepository) revoke(match func(t *model.RefreshToken) bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	n := 0
+	for i := range r.tokens {
+		if t := &r.tokens[i]; t.RevokedAt == nil && match(t) {
+			t.RevokedAt = &now
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,33 @@
+// Package repotest 提供repository包中数据访问接口的内存实现，用于业务层单元测试
+// PrivacyStore涉及多张表的联动删除，没有内存实现，相关测试使用SQLite
+// 只模拟业务层依赖的行为：自增ID、唯一约束、查询不到时返回gorm.ErrRecordNotFound，
+// 不支持软删除后的恢复等只在数据库中才有意义的操作
+package repotest
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrDuplicate 违反唯一约束
+var ErrDuplicate = errors.New("duplicate key")
+
+// errNotFound 与GORM实现一致，查询不到记录时返回
+var errNotFound = gorm.ErrRecordNotFound
+
+// pageRange 返回分页在长度为n的结果中的起止下标
+func pageRange(n, page, pageSize int) (int, int) {
+	start := (page - 1) * pageSize
+	if start < 0 {
+		start = 0
+	}
+	if start > n {
+		start = n
+	}
+	end := start + pageSize
+	if pageSize <= 0 || end > n {
+		end = n
+	}
+	return start, end
+}
@@ -0,0 +1,55 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"sync"
+	"time"
+)
+
+var _ repository.SecurityEventStore = (*SecurityEventRepository)(nil)
+
+// SecurityEventRepository 安全事件的内存实现
+type SecurityEventRepository struct {
+	mu     sync.Mutex
+	events []model.SecurityEvent
+}
+
+// NewSecurityEventRepository 创建安全事件内存仓储
+func NewSecurityEventRepository() *SecurityEventRepository {
+	return &SecurityEventRepository{}
+}
+
+// Create 记录安全事件
+func (r *SecurityEventRepository) Create(_ context.Context, event *model.SecurityEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event.ID = uint(len(r.events) + 1)
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	r.events = append(r.events, *event)
+	return nil
+}
+
+// List 按条件分页查询安全事件，按时间倒序
+func (r *SecurityEventRepository) List(_ context.Context, filter repository.SecurityEventFilter, page, pageSize int) ([]model.SecurityEvent, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := []model.SecurityEvent{}
+	for i := len(r.events) - 1; i >= 0; i-- {
+		e := r.events[i]
+		if (filter.UserID != 0 && e.UserID != filter.UserID) ||
+			(filter.Username != "" && e.Username != filter.Username) ||
+			(filter.IP != "" && e.IP != filter.IP) ||
+			(filter.Type != "" && e.Type != filter.Type) ||
+			(!filter.Since.IsZero() && e.CreatedAt.Before(filter.Since)) ||
+			(!filter.Until.IsZero() && !e.CreatedAt.Before(filter.Until)) {
+			continue
+		}
+		events = append(events, e)
+	}
+	start, end := pageRange(len(events), page, pageSize)
+	return events[start:end], int64(len(events)), nil
+}
@@ -0,0 +1,105 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ repository.SessionStore = (*SessionRepository)(nil)
+
+// SessionRepository 登录会话的内存实现
+type SessionRepository struct {
+	mu       sync.Mutex
+	sessions []model.Session
+}
+
+// NewSessionRepository 创建登录会话内存仓储
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{}
+}
+
+// Create 保存登录会话
+func (r *SessionRepository) Create(_ context.Context, session *model.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session.ID = uint(len(r.sessions) + 1)
+	session.CreatedAt = time.Now()
+	r.sessions = append(r.sessions, *session)
+	return nil
+}
+
+// GetByID 根据ID查询会话
+func (r *SessionRepository) GetByID(_ context.Context, id uint) (*model.Session, error) {
+	return r.find(func(s *model.Session) bool { return s.ID == id })
+}
+
+// GetByFamilyID 根据刷新令牌家族查询会话
+func (r *SessionRepository) GetByFamilyID(_ context.Context, familyID string) (*model.Session, error) {
+	return r.find(func(s *model.Session) bool { return s.FamilyID == familyID })
+}
+
+// ListActive 查询用户未吊销且未过期的会话，最近活跃的在前
+func (r *SessionRepository) ListActive(_ context.Context, userID uint) ([]model.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	sessions := []model.Session{}
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.RevokedAt == nil && s.ExpiresAt.After(now) {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].LastActiveAt.After(sessions[j].LastActiveAt) })
+	return sessions, nil
+}
+
+// Touch 更新最后活跃时间
+func (r *SessionRepository) Touch(_ context.Context, id uint, at time.Time) error {
+	r.update(id, func(s *model.Session) { s.LastActiveAt = at })
+	return nil
+}
+
+// Extend 顺延会话过期时间
+func (r *SessionRepository) Extend(_ context.Context, id uint, expiresAt time.Time) error {
+	r.update(id, func(s *model.Session) {
+		s.LastActiveAt = time.Now()
+		s.ExpiresAt = expiresAt
+	})
+	return nil
+}
+
+// Revoke 吊销会话
+func (r *SessionRepository) Revoke(_ context.Context, id uint) error {
+	r.update(id, func(s *model.Session) {
+		if s.RevokedAt == nil {
+			now := time.Now()
+			s.RevokedAt = &now
+		}
+	})
+	return nil
+}
+
+func (r *SessionRepository) find(match func(s *model.Session) bool) (*model.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.sessions {
+		if match(&s) {
+			return &s, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (r *SessionRepository) update(id uint, fn func(s *model.Session)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.sessions {
+		if r.sessions[i].ID == id {
+			fn(&r.sessions[i])
+		}
+	}
+}
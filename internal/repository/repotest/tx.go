@@ -0,0 +1,50 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/repository"
+)
+
+var _ repository.Transactor = (*TxManager)(nil)
+
+// Snapshotter 支持保存和恢复状态的内存仓储
+type Snapshotter interface {
+	// Snapshot 保存当前状态，返回恢复到该状态的函数
+	Snapshot() func()
+}
+
+// TxManager 事务管理器的内存实现
+// 进入事务时保存各仓储的状态，fn返回错误或panic时恢复，嵌套调用相当于保存点；
+// 不隔离并发事务，只用于顺序执行的单元测试
+type TxManager struct {
+	stores []Snapshotter
+}
+
+// NewTxManager 创建事务管理器，stores为参与回滚的仓储
+func NewTxManager(stores ...Snapshotter) *TxManager {
+	return &TxManager{stores: stores}
+}
+
+// WithinTx 执行fn，失败时恢复各仓储到执行前的状态
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	restores := make([]func(), len(m.stores))
+	for i, s := range m.stores {
+		restores[i] = s.Snapshot()
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			m.rollback(restores)
+			panic(p)
+		}
+		if err != nil {
+			m.rollback(restores)
+		}
+	}()
+	return fn(ctx)
+}
+
+func (m *TxManager) rollback(restores []func()) {
+	for _, restore := range restores {
+		restore()
+	}
+}
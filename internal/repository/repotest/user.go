@@ -0,0 +1,94 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"sync"
+	"time"
+)
+
+var _ repository.UserStore = (*UserRepository)(nil)
+
+// UserRepository 用户数据的内存实现，用户名唯一，非空邮箱唯一
+type UserRepository struct {
+	mu     sync.Mutex
+	users  map[uint]model.User
+	nextID uint
+}
+
+// NewUserRepository 创建用户内存仓储
+func NewUserRepository() *UserRepository {
+	return &UserRepository{users: make(map[uint]model.User)}
+}
+
+// Create 创建新用户
+func (r *UserRepository) Create(_ context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Username == user.Username {
+			return ErrDuplicate
+		}
+	}
+	if r.emailTaken(user) {
+		return repository.ErrEmailTaken
+	}
+	r.nextID++
+	user.ID = r.nextID
+	now := time.Now()
+	user.CreatedAt, user.UpdatedAt = now, now
+	r.users[user.ID] = *user
+	return nil
+}
+
+// GetByUsername 根据用户名查询用户
+func (r *UserRepository) GetByUsername(_ context.Context, username string) (*model.User, error) {
+	return r.find(func(u *model.User) bool { return u.Username == username })
+}
+
+// GetByID 根据ID查询用户
+func (r *UserRepository) GetByID(_ context.Context, id uint) (*model.User, error) {
+	return r.find(func(u *model.User) bool { return u.ID == id })
+}
+
+// GetByEmail 根据邮箱查询用户
+func (r *UserRepository) GetByEmail(_ context.Context, email string) (*model.User, error) {
+	return r.find(func(u *model.User) bool { return u.Email == email })
+}
+
+// Update 更新用户信息
+func (r *UserRepository) Update(_ context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.emailTaken(user) {
+		return repository.ErrEmailTaken
+	}
+	user.UpdatedAt = time.Now()
+	r.users[user.ID] = *user
+	return nil
+}
+
+// emailTaken 判断邮箱是否已被其他用户使用，调用方需持有锁
+func (r *UserRepository) emailTaken(user *model.User) bool {
+	if user.Email == "" {
+		return false
+	}
+	for _, u := range r.users {
+		if u.ID != user.ID && u.Email == user.Email {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *UserRepository) find(match func(u *model.User) bool) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if match(&u) {
+			return &u, nil
+		}
+	}
+	return nil, errNotFound
+}
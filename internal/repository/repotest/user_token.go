@@ -0,0 +1,60 @@
+package repotest
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"sync"
+	"time"
+)
+
+var _ repository.UserTokenStore = (*UserTokenRepository)(nil)
+
+// UserTokenRepository 一次性令牌的内存实现
+type UserTokenRepository struct {
+	mu     sync.Mutex
+	tokens []model.UserToken
+}
+
+// NewUserTokenRepository 创建一次性令牌内存仓储
+func NewUserTokenRepository() *UserTokenRepository {
+	return &UserTokenRepository{}
+}
+
+// Create 保存一次性令牌
+func (r *UserTokenRepository) Create(_ context.Context, token *model.UserToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token.ID = uint(len(r.tokens) + 1)
+	token.CreatedAt = time.Now()
+	r.tokens = append(r.tokens, *token)
+	return nil
+}
+
+// Consume 使用令牌，令牌不存在、用途不符或已被使用时返回repository.ErrRecordNotFound
+func (r *UserTokenRepository) Consume(_ context.Context, nonceHash, purpose string) (*model.UserToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.tokens {
+		if t := &r.tokens[i]; t.NonceHash == nonceHash && t.Purpose == purpose && t.UsedAt == nil {
+			now := time.Now()
+			t.UsedAt = &now
+			token := *t
+			return &token, nil
+		}
+	}
+	return nil, repository.ErrRecordNotFound
+}
+
+// InvalidateByUser 作废用户某一用途的全部未使用令牌
+func (r *UserTokenRepository) InvalidateByUser(_ context.Context, userID uint, purpose string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for i := range r.tokens {
+		if t := &r.tokens[i]; t.UserID == userID && t.Purpose == purpose && t.UsedAt == nil {
+			t.UsedAt = &now
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"myshop/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SecurityEventFilter 安全事件查询条件
+type SecurityEventFilter struct {
+	UserID   uint
+	Username string
+	IP       string
+	Type     string
+	Since    time.Time
+	Until    time.Time
+}
+
+// SecurityEventRepository 安全事件数据访问层
+type SecurityEventRepository struct {
+	db *gorm.DB
+}
+
+// NewSecurityEventRepository 创建安全事件仓储实例
+func NewSecurityEventRepository(db *gorm.DB) *SecurityEventRepository {
+	return &SecurityEventRepository{db: db}
+}
+
+// Create 记录安全事件
+func (r *SecurityEventRepository) Create(ctx context.Context, event *model.SecurityEvent) error {
+	return dbFrom(ctx, r.db).Create(event).Error
+}
+
+// List 按条件分页查询安全事件，按时间倒序
+func (r *SecurityEventRepository) List(ctx context.Context, filter SecurityEventFilter, page, pageSize int) ([]model.SecurityEvent, int64, error) {
+	var events []model.SecurityEvent
+	var total int64
+
+	query := dbFrom(ctx, r.db).Model(&model.SecurityEvent{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Username != "" {
+		query = query.Where("username = ?", filter.Username)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&events).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"myshop/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SessionRepository 登录会话数据访问层
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository 创建登录会话仓储实例
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create 保存登录会话
+func (r *SessionRepository) Create(ctx context.Context, session *model.Session) error {
+	return dbFrom(ctx, r.db).Create(session).Error
+}
+
+// GetByID 根据ID查询会话
+func (r *SessionRepository) GetByID(ctx context.Context, id uint) (*model.Session, error) {
+	var session model.Session
+	err := dbFrom(ctx, r.db).First(&session, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetByFamilyID 根据刷新令牌家族查询会话
+func (r *SessionRepository) GetByFamilyID(ctx context.Context, familyID string) (*model.Session, error) {
+	var session model.Session
+	err := dbFrom(ctx, r.db).Where("family_id = ?", familyID).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActive 查询用户未吊销且未过期的会话，最近活跃的在前
+func (r *SessionRepository) ListActive(ctx context.Context, userID uint) ([]model.Session, error) {
+	var sessions []model.Session
+	err := dbFrom(ctx, r.db).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_active_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Touch 更新最后活跃时间
+func (r *SessionRepository) Touch(ctx context.Context, id uint, at time.Time) error {
+	return dbFrom(ctx, r.db).Model(&model.Session{}).Where("id = ?", id).Update("last_active_at", at).Error
+}
+
+// Extend 刷新令牌轮换时顺延会话过期时间
+func (r *SessionRepository) Extend(ctx context.Context, id uint, expiresAt time.Time) error {
+	return dbFrom(ctx, r.db).Model(&model.Session{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_active_at": time.Now(),
+		"expires_at":     expiresAt,
+	}).Error
+}
+
+// Revoke 吊销会话
+func (r *SessionRepository) Revoke(ctx context.Context, id uint) error {
+	return dbFrom(ctx, r.db).Model(&model.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
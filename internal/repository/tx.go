@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Transactor 事务管理接口
+// 事务保存在context中，接收ctx的仓储方法通过dbFrom自动加入当前事务，业务层无需接触*gorm.DB
+type Transactor interface {
+	// WithinTx 在事务中执行fn，fn返回错误或panic时回滚；ctx中已有事务时以保存点嵌套执行，只回滚到保存点
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+var _ Transactor = (*TxManager)(nil)
+
+type txKey struct{}
+
+// TxManager 基于GORM的事务管理器
+type TxManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager 创建事务管理器实例
+func NewTxManager(db *gorm.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// WithinTx 在事务中执行fn，fn收到的ctx携带该事务
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	// 在已有事务上调用Transaction时GORM使用SAVEPOINT实现嵌套
+	return dbFrom(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// dbFrom 返回ctx中的当前事务，没有事务时返回db
+func dbFrom(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"myshop/internal/model"
+	"myshop/pkg/money"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&model.Product{}, &model.Order{}, &model.OrderItem{}, &model.OrderDiscount{},
+		&model.Coupon{}, &model.CouponScope{}, &model.CouponRedemption{}, &model.Promotion{}, &model.PromotionItem{}, &model.PromotionTier{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestTxManager(t *testing.T) {
+	db := newTestDB(t)
+	tx := NewTxManager(db)
+	products := NewProductRepository(db)
+	orders := NewOrderRepository(db)
+	ctx := context.Background()
+
+	product := &model.Product{Name: "iPhone", Price: money.MustParse("5999", "CNY"), Stock: 2, Status: 1}
+	if err := products.Create(ctx, product); err != nil {
+		t.Fatal(err)
+	}
+	place := func(ctx context.Context, orderNo string, quantity int) error {
+		order := &model.Order{OrderNo: orderNo, UserID: 1,
+			Items: []model.OrderItem{{ProductID: product.ID, Quantity: quantity}}}
+		if err := orders.Create(ctx, order); err != nil {
+			return err
+		}
+		return products.DeductStock(ctx, product.ID, quantity)
+	}
+
+	// 库存不足时订单与库存一起回滚
+	err := tx.WithinTx(ctx, func(ctx context.Context) error { return place(ctx, "A", 3) })
+	if !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("err = %v, want %v", err, ErrInsufficientStock)
+	}
+
+	// 内层失败只回滚到保存点，外层的修改正常提交
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := place(ctx, "B", 1); err != nil {
+			return err
+		}
+		inner := tx.WithinTx(ctx, func(ctx context.Context) error { return place(ctx, "C", 5) })
+		if !errors.Is(inner, ErrInsufficientStock) {
+			t.Errorf("inner err = %v", inner)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var orderNos []string
+	db.Model(&model.Order{}).Order("id").Pluck("order_no", &orderNos)
+	if len(orderNos) != 1 || orderNos[0] != "B" {
+		t.Fatalf("orders = %v, want [B]", orderNos)
+	}
+	stored, err := products.GetByID(ctx, product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Stock != 1 {
+		t.Fatalf("stock = %d, want 1", stored.Stock)
+	}
+}
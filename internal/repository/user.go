@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"myshop/internal/model"
 
 	"gorm.io/gorm"
@@ -17,15 +18,22 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-// Create 创建新用户
-func (r *UserRepository) Create(user *model.User) error {
-	return r.db.Create(user).Error
+// Create 创建新用户，邮箱与其他用户重复时返回ErrEmailTaken
+func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
+	db := dbFrom(ctx, r.db)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(user).Error
+	})
+	if err != nil {
+		return translateUserError(db, user, err)
+	}
+	return nil
 }
 
 // GetByUsername 根据用户名查询用户
-func (r *UserRepository) GetByUsername(username string) (*model.User, error) {
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := dbFrom(ctx, r.db).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -33,11 +41,48 @@ func (r *UserRepository) GetByUsername(username string) (*model.User, error) {
 }
 
 // GetByID 根据ID查询用户
-func (r *UserRepository) GetByID(id uint) (*model.User, error) {
+func (r *UserRepository) GetByID(ctx context.Context, id uint) (*model.User, error) {
 	var user model.User
-	err := r.db.First(&user, id).Error
+	err := dbFrom(ctx, r.db).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
+
+// Update 更新用户信息，邮箱与其他用户重复时返回ErrEmailTaken
+func (r *UserRepository) Update(ctx context.Context, user *model.User) error {
+	db := dbFrom(ctx, r.db)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.Save(user).Error
+	})
+	if err != nil {
+		return translateUserError(db, user, err)
+	}
+	return nil
+}
+
+// GetByEmail 根据邮箱查询用户
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	var user model.User
+	err := dbFrom(ctx, r.db).Where("email = ?", email).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// translateUserError 写入用户失败时判断是否违反了邮箱唯一索引
+// 各数据库的唯一约束错误格式不同，这里改为查询是否存在同邮箱的其他用户；
+// 写入放在保存点中，调用方处于事务中时只回滚到保存点，PostgreSQL的事务不会停在中止状态，db仍可继续查询；
+// 唯一索引覆盖已注销但尚未匿名化的用户，因此查询时包含软删除的记录
+func translateUserError(db *gorm.DB, user *model.User, err error) error {
+	if user.Email == "" {
+		return err
+	}
+	var count int64
+	if db.Unscoped().Model(&model.User{}).Where("email = ? AND id <> ?", user.Email, user.ID).Count(&count).Error == nil && count > 0 {
+		return ErrEmailTaken
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"myshop/internal/migrations"
+	"myshop/internal/model"
+	"myshop/pkg/migrate"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMigratedDB 创建执行过全部迁移的内存数据库，用于依赖迁移中索引的测试
+func newMigratedDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	m, err := migrate.New(db, migrations.All())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestUserEmailUnique(t *testing.T) {
+	ctx := context.Background()
+	db := newMigratedDB(t)
+	users := NewUserRepository(db)
+
+	alice := &model.User{Username: "alice", Email: "alice@example.com"}
+	if err := users.Create(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未填写邮箱的用户不参与唯一约束
+	for _, name := range []string{"bob", "carol"} {
+		if err := users.Create(ctx, &model.User{Username: name}); err != nil {
+			t.Fatalf("创建无邮箱用户%s失败: %v", name, err)
+		}
+	}
+
+	if err := users.Create(ctx, &model.User{Username: "mallory", Email: "alice@example.com"}); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("注册重复邮箱: err = %v, 期望 %v", err, ErrEmailTaken)
+	}
+
+	bob, _ := users.GetByUsername(ctx, "bob")
+	bob.Email = "alice@example.com"
+	if err := users.Update(ctx, bob); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("修改为重复邮箱: err = %v, 期望 %v", err, ErrEmailTaken)
+	}
+
+	// 已注销但尚未匿名化的用户仍占用邮箱
+	db.Delete(alice)
+	if err := users.Create(ctx, &model.User{Username: "dave", Email: "alice@example.com"}); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("注销宽限期内重用邮箱: err = %v, 期望 %v", err, ErrEmailTaken)
+	}
+
+	// 用户名重复不是邮箱冲突
+	if err := users.Create(ctx, &model.User{Username: "bob", Email: "bob@example.com"}); err == nil || errors.Is(err, ErrEmailTaken) {
+		t.Errorf("用户名重复: err = %v", err)
+	}
+}
+
+func TestUserStoresJoinTransaction(t *testing.T) {
+	db := newMigratedDB(t)
+	tx := NewTxManager(db)
+	users := NewUserRepository(db)
+	sessions := NewSessionRepository(db)
+	ctx := context.Background()
+
+	alice := &model.User{Username: "alice", Email: "alice@example.com"}
+	if err := users.Create(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只有一个连接，事务外的查询会一直等待，能执行完说明各仓储都加入了事务
+	errRollback := errors.New("rollback")
+	err := tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := sessions.Create(ctx, &model.Session{UserID: alice.ID, FamilyID: "f1"}); err != nil {
+			return err
+		}
+		// 违反唯一索引只回滚到保存点，事务仍可继续使用
+		if err := users.Create(ctx, &model.User{Username: "mallory", Email: "alice@example.com"}); !errors.Is(err, ErrEmailTaken) {
+			t.Errorf("事务中邮箱重复: err = %v, 期望 %v", err, ErrEmailTaken)
+		}
+		if err := NewPrivacyRepository(db).SoftDeleteUser(ctx, alice.ID); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("err = %v", err)
+	}
+
+	if _, err := users.GetByID(ctx, alice.ID); err != nil {
+		t.Errorf("回滚后用户应未被删除: %v", err)
+	}
+	if _, err := sessions.GetByFamilyID(ctx, "f1"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("回滚后会话应不存在: err = %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"myshop/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserTokenRepository 一次性令牌数据访问层
+type UserTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewUserTokenRepository 创建一次性令牌仓储实例
+func NewUserTokenRepository(db *gorm.DB) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+// Create 保存一次性令牌
+func (r *UserTokenRepository) Create(ctx context.Context, token *model.UserToken) error {
+	return dbFrom(ctx, r.db).Create(token).Error
+}
+
+// Consume 使用令牌，令牌不存在、用途不符或已被使用时返回ErrRecordNotFound
+func (r *UserTokenRepository) Consume(ctx context.Context, nonceHash, purpose string) (*model.UserToken, error) {
+	result := dbFrom(ctx, r.db).Model(&model.UserToken{}).
+		Where("nonce_hash = ? AND purpose = ? AND used_at IS NULL", nonceHash, purpose).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrRecordNotFound
+	}
+
+	var token model.UserToken
+	if err := dbFrom(ctx, r.db).Where("nonce_hash = ?", nonceHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// InvalidateByUser 作废用户某一用途的全部未使用令牌
+func (r *UserTokenRepository) InvalidateByUser(ctx context.Context, userID uint, purpose string) error {
+	return dbFrom(ctx, r.db).Model(&model.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
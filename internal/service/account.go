@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"myshop/internal/config"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/pkg/cache"
+	"myshop/pkg/mailer"
+	"myshop/pkg/utils"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultVerifyTTL = 24 * time.Hour
+	defaultResetTTL  = 30 * time.Minute
+)
+
+// AccountService 邮箱验证与找回密码业务逻辑层
+type AccountService struct {
+	tx        repository.Transactor
+	userRepo  repository.UserStore
+	tokenRepo repository.UserTokenStore
+	sessions  *SessionService
+	events    repository.SecurityEventStore
+	mailer    mailer.Mailer
+	limiter   *rateLimiter
+	secret    []byte
+	cfg       config.AccountConfig
+}
+
+// NewAccountService 创建账号服务实例
+func NewAccountService(tx repository.Transactor, userRepo repository.UserStore, tokenRepo repository.UserTokenStore,
+	sessions *SessionService, events repository.SecurityEventStore,
+	m mailer.Mailer, c cache.Cache, cfg config.AccountConfig) (*AccountService, error) {
+	if cfg.VerifyTTL <= 0 {
+		cfg.VerifyTTL = defaultVerifyTTL
+	}
+	if cfg.ResetTTL <= 0 {
+		cfg.ResetTTL = defaultResetTTL
+	}
+
+	secret := []byte(cfg.TokenSecret)
+	if len(secret) == 0 {
+		log.Println("未配置邮件链接令牌密钥，使用临时生成的密钥，重启后未使用的链接将失效")
+		random, err := utils.RandomToken(32)
+		if err != nil {
+			return nil, err
+		}
+		secret = []byte(random)
+	}
+
+	return &AccountService{
+		tx:        tx,
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		sessions:  sessions,
+		events:    events,
+		mailer:    m,
+		limiter:   newRateLimiter(c),
+		secret:    secret,
+		cfg:       cfg,
+	}, nil
+}
+
+// CheckEmailAvailable 检查邮箱是否已被其他用户使用
+func (s *AccountService) CheckEmailAvailable(ctx context.Context, email string, userID uint) error {
+	if existing, err := s.userRepo.GetByEmail(ctx, email); err == nil && existing.ID != userID {
+		return ErrEmailExists
+	}
+	return nil
+}
+
+// ChangeEmail 修改邮箱，新邮箱需要重新验证
+func (s *AccountService) ChangeEmail(ctx context.Context, userID uint, email string) error {
+	email = normalizeEmail(email)
+	if err := s.CheckEmailAvailable(ctx, email, userID); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.Email == email && user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	user.Email = email
+	user.EmailVerifiedAt = nil
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.tokenRepo.InvalidateByUser(ctx, user.ID, model.TokenPurposeVerifyEmail)
+	})
+	if errors.Is(err, repository.ErrEmailTaken) {
+		return ErrEmailExists
+	}
+	if err != nil {
+		return err
+	}
+	return s.SendVerification(ctx, user.ID)
+}
+
+// SendVerification 发送邮箱验证邮件，每个用户每分钟最多一封、每天最多十封
+func (s *AccountService) SendVerification(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.Email == "" {
+		return ErrEmailNotSet
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailVerified
+	}
+
+	if !s.limiter.Allow(fmt.Sprintf("verify:min:%d", user.ID), 1, time.Minute) ||
+		!s.limiter.Allow(fmt.Sprintf("verify:day:%d", user.ID), 10, 24*time.Hour) {
+		return ErrRateLimited
+	}
+
+	token, err := s.issue(ctx, user, model.TokenPurposeVerifyEmail, s.cfg.VerifyTTL)
+	if err != nil {
+		return err
+	}
+	s.send(mailer.Message{
+		To:      user.Email,
+		Subject: "请验证您的邮箱",
+		Body: fmt.Sprintf("%s，您好：\n\n请在%s内点击以下链接完成邮箱验证：\n%s\n\n如果这不是您本人的操作，请忽略本邮件。\n",
+			user.Username, s.cfg.VerifyTTL, s.link("/verify-email", token)),
+	})
+	return nil
+}
+
+// VerifyEmail 使用邮件中的令牌完成邮箱验证
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	stored, err := s.consume(ctx, token, model.TokenPurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	// 发送验证邮件之后修改过邮箱的，旧链接不能验证新邮箱
+	if user.Email != stored.Email {
+		return ErrInvalidToken
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	return s.userRepo.Update(ctx, user)
+}
+
+// ForgotPassword 发送重置密码邮件
+// 无论邮箱是否存在都返回成功，避免通过该接口枚举用户；只向已验证的邮箱发送
+func (s *AccountService) ForgotPassword(ctx context.Context, email, ip string) error {
+	email = normalizeEmail(email)
+	if !s.limiter.Allow("forgot:ip:"+ip, 10, time.Hour) ||
+		!s.limiter.Allow("forgot:email:"+email, 3, time.Hour) {
+		return ErrRateLimited
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil || user.EmailVerifiedAt == nil {
+		return nil
+	}
+
+	token, err := s.issue(ctx, user, model.TokenPurposeResetPassword, s.cfg.ResetTTL)
+	if err != nil {
+		return err
+	}
+	s.send(mailer.Message{
+		To:      user.Email,
+		Subject: "重置您的密码",
+		Body: fmt.Sprintf("%s，您好：\n\n请在%s内点击以下链接重置密码：\n%s\n\n如果这不是您本人的操作，请忽略本邮件，您的密码不会被修改。\n",
+			user.Username, s.cfg.ResetTTL, s.link("/reset-password", token)),
+	})
+	return nil
+}
+
+// ResetPassword 使用邮件中的令牌重置密码
+// 重置后作废其余重置链接，并吊销全部会话使所有设备重新登录；
+// 各步骤在同一事务中完成，任一步失败时令牌不会被消耗，可以重试
+func (s *AccountService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	var user *model.User
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		stored, err := s.consume(ctx, token, model.TokenPurposeResetPassword)
+		if err != nil {
+			return err
+		}
+		user, err = s.userRepo.GetByID(ctx, stored.UserID)
+		if err != nil {
+			return ErrInvalidToken
+		}
+		hashedPassword, err := utils.HashPassword(newPassword)
+		if err != nil {
+			return err
+		}
+		user.Password = hashedPassword
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		if err := s.tokenRepo.InvalidateByUser(ctx, user.ID, model.TokenPurposeResetPassword); err != nil {
+			return err
+		}
+		return s.sessions.RevokeAll(ctx, user.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.record(ctx, model.SecurityEventPasswordReset, user)
+	return nil
+}
+
+// ChangePassword 登录用户修改密码
+// 需要校验当前密码，每个用户15分钟内最多尝试5次；修改后吊销当前会话以外的全部会话
+func (s *AccountService) ChangePassword(ctx context.Context, userID, sessionID uint, oldPassword, newPassword string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if !s.limiter.Allow(fmt.Sprintf("password:%d", user.ID), 5, 15*time.Minute) {
+		return ErrRateLimited
+	}
+	if !utils.CheckPassword(oldPassword, user.Password) {
+		return ErrWrongPassword
+	}
+
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	user.Password = hashedPassword
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		if _, err := s.sessions.RevokeOthers(ctx, user.ID, sessionID); err != nil {
+			return err
+		}
+		return s.tokenRepo.InvalidateByUser(ctx, user.ID, model.TokenPurposeResetPassword)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.record(ctx, model.SecurityEventPasswordChange, user)
+	return nil
+}
+
+func (s *AccountService) record(ctx context.Context, eventType string, user *model.User) {
+	event := &model.SecurityEvent{Type: eventType, UserID: user.ID, Username: user.Username}
+	if err := s.events.Create(ctx, event); err != nil {
+		log.Printf("记录安全事件失败: %v", err)
+	}
+}
+
+// issue 签发一次性令牌并记录随机数摘要
+func (s *AccountService) issue(ctx context.Context, user *model.User, purpose string, ttl time.Duration) (string, error) {
+	nonce, err := utils.RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	err = s.tokenRepo.Create(ctx, &model.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		NonceHash: utils.HashToken(nonce),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return utils.SignToken(s.secret, utils.SignedToken{
+		Purpose:   purpose,
+		UserID:    user.ID,
+		Nonce:     nonce,
+		ExpiresAt: expiresAt.Unix(),
+	})
+}
+
+// consume 校验签名后使用令牌，每个令牌只能成功使用一次
+func (s *AccountService) consume(ctx context.Context, token, purpose string) (*model.UserToken, error) {
+	parsed, err := utils.ParseSignedToken(s.secret, token, purpose)
+	if err != nil {
+		if err == utils.ErrSignedTokenExpired {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrInvalidToken
+	}
+
+	stored, err := s.tokenRepo.Consume(ctx, utils.HashToken(parsed.Nonce), purpose)
+	if err != nil || stored.UserID != parsed.UserID {
+		return nil, ErrInvalidToken
+	}
+	return stored, nil
+}
+
+func (s *AccountService) link(path, token string) string {
+	return strings.TrimRight(s.cfg.BaseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// send 异步发送邮件，避免邮件服务的耗时暴露邮箱是否存在
+func (s *AccountService) send(msg mailer.Message) {
+	go func() {
+		if err := s.mailer.Send(msg); err != nil {
+			log.Printf("发送邮件到%s失败: %v", msg.To, err)
+		}
+	}()
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
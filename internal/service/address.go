@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+)
+
+// maxAddressesPerUser 每个用户最多保存的收货地址数量
+const maxAddressesPerUser = 20
+
+// AddressService 收货地址业务逻辑层
+type AddressService struct {
+	repo repository.AddressStore
+}
+
+// NewAddressService 创建收货地址服务实例
+func NewAddressService(repo repository.AddressStore) *AddressService {
+	return &AddressService{repo: repo}
+}
+
+// List 查询用户的收货地址
+func (s *AddressService) List(ctx context.Context, userID uint) ([]model.Address, error) {
+	return s.repo.ListByUserID(ctx, userID)
+}
+
+// Create 新增收货地址，用户的第一个地址自动设为默认
+func (s *AddressService) Create(ctx context.Context, userID uint, address *model.Address) error {
+	count, err := s.repo.CountByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if count >= maxAddressesPerUser {
+		return ErrAddressLimit
+	}
+
+	address.ID = 0
+	address.UserID = userID
+	if count == 0 {
+		address.IsDefault = true
+	}
+	return s.repo.Create(ctx, address)
+}
+
+// Update 修改收货地址内容，已创建订单中的地址快照不受影响
+func (s *AddressService) Update(ctx context.Context, userID, id uint, content model.ShippingAddress) (*model.Address, error) {
+	address, err := s.repo.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, ErrAddressNotFound
+	}
+
+	address.ShippingAddress = content
+	if err := s.repo.Update(ctx, address); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// SetDefault 设为默认地址
+func (s *AddressService) SetDefault(ctx context.Context, userID, id uint) error {
+	if _, err := s.repo.GetByID(ctx, userID, id); err != nil {
+		return ErrAddressNotFound
+	}
+	return s.repo.SetDefault(ctx, userID, id)
+}
+
+// Delete 删除收货地址
+func (s *AddressService) Delete(ctx context.Context, userID, id uint) error {
+	address, err := s.repo.GetByID(ctx, userID, id)
+	if err != nil {
+		return ErrAddressNotFound
+	}
+	return s.repo.Delete(ctx, address)
+}
+
+// Resolve 返回下单使用的收货地址，addressID为0时使用默认地址
+func (s *AddressService) Resolve(ctx context.Context, userID, addressID uint) (*model.Address, error) {
+	if addressID == 0 {
+		address, err := s.repo.GetDefault(ctx, userID)
+		if err != nil {
+			return nil, ErrAddressRequired
+		}
+		return address, nil
+	}
+
+	address, err := s.repo.GetByID(ctx, userID, addressID)
+	if err != nil {
+		return nil, ErrAddressNotFound
+	}
+	return address, nil
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"log"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/pkg/utils"
+	"strings"
+	"time"
+)
+
+// apiKeyPrefix API Key明文前缀，便于在日志和代码仓库中识别泄露的密钥
+const apiKeyPrefix = "msk_"
+
+// apiKeyDisplayLen 对外展示的明文前缀长度
+const apiKeyDisplayLen = 12
+
+// lastUsedResolution 最近使用时间的更新粒度，避免每个请求都写库
+const lastUsedResolution = time.Minute
+
+// APIKeyIdentity API Key校验通过后的身份信息
+type APIKeyIdentity struct {
+	UserID    uint      // 密钥所有者
+	Scopes    []string  // 生效的权限范围
+	ExpiresAt time.Time // 过期时间，永不过期时为零值
+}
+
+// APIKeyService API Key业务逻辑层
+type APIKeyService struct {
+	repo     repository.APIKeyStore
+	userRepo repository.UserStore
+}
+
+// NewAPIKeyService 创建API Key服务实例
+func NewAPIKeyService(repo repository.APIKeyStore, userRepo repository.UserStore) *APIKeyService {
+	return &APIKeyService{repo: repo, userRepo: userRepo}
+}
+
+// Create 为用户或服务账号创建API Key，返回的明文只有这一次机会获取
+// 管理员可以授予任意权限；普通用户只能授予自己角色已有的权限
+func (s *APIKeyService) Create(ctx context.Context, ownerID uint, name string, scopes []string, expiresAt *time.Time, byAdmin bool) (*model.APIKey, string, error) {
+	owner, err := s.userRepo.GetByID(ctx, ownerID)
+	if err != nil {
+		return nil, "", ErrUserNotFound
+	}
+	if len(scopes) == 0 {
+		return nil, "", ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !validScope(scope) {
+			return nil, "", ErrInvalidScope
+		}
+		if !byAdmin && !roleHasScope(owner.Role, scope) {
+			return nil, "", ErrInvalidScope
+		}
+	}
+	if expiresAt != nil && expiresAt.Before(time.Now()) {
+		return nil, "", ErrInvalidExpiry
+	}
+
+	secret, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	plain := apiKeyPrefix + secret
+
+	key := &model.APIKey{
+		UserID:    owner.ID,
+		Name:      name,
+		Prefix:    plain[:apiKeyDisplayLen],
+		KeyHash:   utils.HashToken(plain),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, plain, nil
+}
+
+// List 获取用户的API Key列表
+func (s *APIKeyService) List(ctx context.Context, ownerID uint) ([]model.APIKey, error) {
+	return s.repo.ListByUserID(ctx, ownerID)
+}
+
+// Revoke 吊销API Key，非管理员只能吊销自己的
+func (s *APIKeyService) Revoke(ctx context.Context, operatorID, id uint, byAdmin bool) error {
+	key, err := s.repo.GetByID(ctx, id)
+	if err != nil || (!byAdmin && key.UserID != operatorID) {
+		return ErrAPIKeyNotFound
+	}
+	return s.repo.Revoke(ctx, id)
+}
+
+// ValidateAPIKey 校验X-API-Key请求头携带的密钥，返回所有者和生效的权限范围
+// API Key只携带密钥本身的权限范围，不继承所有者的角色；
+// 非服务账号的密钥还要与所有者当前角色的权限取交集，角色降级后多出的权限随即失效
+func (s *APIKeyService) ValidateAPIKey(ctx context.Context, plain string) (*APIKeyIdentity, error) {
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetByHash(ctx, utils.HashToken(plain))
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+	owner, err := s.userRepo.GetByID(ctx, key.UserID)
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			log.Printf("更新API Key使用时间失败: %v", err)
+		}
+	}
+
+	identity := &APIKeyIdentity{
+		UserID: key.UserID,
+		Scopes: effectiveScopes(owner, key.Scopes),
+	}
+	if key.ExpiresAt != nil {
+		identity.ExpiresAt = *key.ExpiresAt
+	}
+	return identity, nil
+}
+
+func validScope(scope string) bool {
+	for _, s := range model.AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// effectiveScopes 计算密钥实际生效的权限范围
+// 服务账号没有登录角色，密钥权限由管理员直接授予，原样生效
+func effectiveScopes(owner *model.User, scopes []string) []string {
+	if owner.Role == model.RoleService {
+		return scopes
+	}
+	allowed := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if roleHasScope(owner.Role, scope) {
+			allowed = append(allowed, scope)
+		}
+	}
+	return allowed
+}
+
+func roleHasScope(role, scope string) bool {
+	for _, s := range model.RoleScopes[role] {
+		if s == scope || s == model.ScopeAll {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 非HTTP请求发起的操作使用的认证方式
+const (
+	AuthMethodSystem = "system" // 系统自身发起的操作，如启动时加载配置
+	AuthMethodCLI    = "cli"    // 运维人员通过命令行执行的操作，如导入商品
+)
+
+// Actor 操作者信息，由处理器从请求中提取，随context传入业务层用于审计
+type Actor struct {
+	UserID     uint
+	Role       string
+	AuthMethod string
+	IP         string
+	RequestID  string
+}
+
+type actorKey struct{}
+
+// WithActor 将操作者信息放入context
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// actorFrom 从context中取出操作者信息，没有时视为系统操作
+func actorFrom(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{AuthMethod: AuthMethodSystem}
+}
+
+// auditIgnoredFields 不计入变更的字段，由数据库自动维护
+var auditIgnoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"CreatedAt":  true,
+	"UpdatedAt":  true,
+}
+
+// AuditService 审计日志业务逻辑层
+type AuditService struct {
+	repo repository.AuditLogStore
+}
+
+// NewAuditService 创建审计日志服务实例
+func NewAuditService(repo repository.AuditLogStore) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Record 记录一次敏感操作，before和after为操作前后的资源，创建时before为nil，删除时after为nil
+// 调用方应在业务操作的同一事务中调用，写入失败时返回错误，由调用方回滚业务操作
+func (s *AuditService) Record(ctx context.Context, action, resourceType string, resourceID interface{}, before, after interface{}) error {
+	changes := diffFields(flattenFields(before), flattenFields(after))
+	if action == model.AuditActionUpdate && len(changes) == 0 {
+		return nil
+	}
+	return s.create(ctx, action, resourceType, fmt.Sprint(resourceID), changes, nil)
+}
+
+// RecordConfig 启动时记录运行配置的变化
+// 与最新一条配置审计日志保存的快照比较，有变化时写入一条审计日志并保存新的快照；
+// 密码、密钥类配置只记录以secret为密钥的HMAC摘要，secret为空时只记录是否设置
+func (s *AuditService) RecordConfig(ctx context.Context, source string, cfg interface{}, secret []byte) error {
+	var previous map[string]interface{}
+	latest, err := s.repo.Latest(ctx, model.AuditResourceConfig)
+	switch {
+	case err == nil:
+		previous = latest.Snapshot
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	current := flattenFields(cfg)
+	for field, value := range current {
+		if isSecretField(field) {
+			current[field] = fingerprint(secret, value)
+		}
+	}
+
+	changes := diffFields(previous, current)
+	if len(changes) == 0 {
+		return nil
+	}
+	return s.create(ctx, model.AuditActionUpdate, model.AuditResourceConfig, source, changes, current)
+}
+
+// NormalizeAuditPage 修正审计日志查询的分页参数，每页最多100条，超出范围时使用默认的20条
+func NormalizeAuditPage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return page, pageSize
+}
+
+// List 按条件分页查询审计日志，分页参数按NormalizeAuditPage修正
+func (s *AuditService) List(ctx context.Context, filter repository.AuditLogFilter, page, pageSize int) ([]model.AuditLog, int64, error) {
+	page, pageSize = NormalizeAuditPage(page, pageSize)
+	return s.repo.List(ctx, filter, page, pageSize)
+}
+
+func (s *AuditService) create(ctx context.Context, action, resourceType, resourceID string,
+	changes map[string]model.AuditChange, snapshot map[string]interface{}) error {
+	actor := actorFrom(ctx)
+	entry := &model.AuditLog{
+		ActorID:      actor.UserID,
+		ActorRole:    actor.Role,
+		AuthMethod:   actor.AuthMethod,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Changes:      changes,
+		Snapshot:     snapshot,
+		IP:           actor.IP,
+		RequestID:    actor.RequestID,
+	}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		log.Printf("记录审计日志失败(%s %s/%s): %v", action, resourceType, resourceID, err)
+		return err
+	}
+	return nil
+}
+
+// flattenFields 将资源按JSON序列化后展开为"字段路径→值"，嵌套结构用"."连接
+func flattenFields(v interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return fields
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return fields
+	}
+	flattenInto(fields, "", decoded)
+	return fields
+}
+
+func flattenInto(fields map[string]interface{}, prefix string, v interface{}) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			if prefix == "" && auditIgnoredFields[k] {
+				continue
+			}
+			flattenInto(fields, joinField(prefix, k), item)
+		}
+	case []interface{}:
+		for i, item := range value {
+			flattenInto(fields, joinField(prefix, strconv.Itoa(i)), item)
+		}
+	case nil:
+		// 空值与字段不存在等同，避免重放历史时产生虚假变更
+	default:
+		fields[prefix] = value
+	}
+}
+
+func joinField(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// diffFields 比较两组字段，返回值不同的字段
+func diffFields(before, after map[string]interface{}) map[string]model.AuditChange {
+	changes := make(map[string]model.AuditChange)
+	for field, b := range before {
+		a, ok := after[field]
+		if !ok {
+			changes[field] = model.AuditChange{Before: b}
+			continue
+		}
+		if !reflect.DeepEqual(a, b) {
+			changes[field] = model.AuditChange{Before: b, After: a}
+		}
+	}
+	for field, a := range after {
+		if _, ok := before[field]; !ok {
+			changes[field] = model.AuditChange{After: a}
+		}
+	}
+	return changes
+}
+
+// isSecretField 判断配置项是否为密码或密钥
+func isSecretField(field string) bool {
+	name := strings.ToLower(field[strings.LastIndex(field, ".")+1:])
+	return strings.Contains(name, "password") || strings.Contains(name, "secret")
+}
+
+// fingerprint 计算敏感配置的摘要，只用于判断是否变化
+// 使用服务端密钥的HMAC，能读取审计日志的人无法通过字典或彩虹表反推出弱密码
+func fingerprint(secret []byte, v interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return v
+	}
+	if len(secret) == 0 {
+		return "[已设置]"
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(s))
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
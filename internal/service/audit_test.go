@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"myshop/internal/repository/repotest"
+	"strings"
+	"testing"
+)
+
+// auditTestConfig 用于配置审计测试的简化配置
+type auditTestConfig struct {
+	Database struct {
+		Host     string
+		Password string
+	}
+}
+
+// recordedPassword 返回最近一条配置审计日志中密码字段的记录值
+func recordedPassword(t *testing.T, audits *repotest.AuditLogRepository) interface{} {
+	t.Helper()
+
+	history, err := audits.ListByResourceType(context.Background(), "config")
+	if err != nil || len(history) == 0 {
+		t.Fatalf("没有配置审计日志: %v", err)
+	}
+	for field, change := range history[len(history)-1].Changes {
+		if strings.HasSuffix(strings.ToLower(field), "password") {
+			return change.After
+		}
+	}
+	t.Fatal("审计日志中没有密码字段")
+	return nil
+}
+
+func TestAuditRecordConfigFingerprintsSecrets(t *testing.T) {
+	ctx := context.Background()
+	audits := repotest.NewAuditLogRepository()
+	svc := NewAuditService(audits)
+	secret := []byte("audit-secret")
+
+	var cfg auditTestConfig
+	cfg.Database.Host = "db"
+	cfg.Database.Password = "123456"
+	if err := svc.RecordConfig(ctx, "config.yaml", cfg, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := recordedPassword(t, audits).(string)
+	plain := sha256.Sum256([]byte("123456"))
+	if got == "" || strings.Contains(got, "123456") || strings.Contains(got, hex.EncodeToString(plain[:])[:12]) {
+		t.Fatalf("密码摘要 = %q, 不能是明文或不加密钥的SHA-256", got)
+	}
+
+	// 配置不变时不重复记录
+	if err := svc.RecordConfig(ctx, "config.yaml", cfg, secret); err != nil {
+		t.Fatal(err)
+	}
+	if history, _ := audits.ListByResourceType(ctx, "config"); len(history) != 1 {
+		t.Fatalf("配置未变化却写入了%d条审计日志", len(history))
+	}
+
+	cfg.Database.Password = "654321"
+	if err := svc.RecordConfig(ctx, "config.yaml", cfg, secret); err != nil {
+		t.Fatal(err)
+	}
+	if changed := recordedPassword(t, audits); changed == got {
+		t.Error("修改密码后摘要应变化")
+	}
+
+	// 不同密钥得到的摘要不同，无法跨部署比对
+	if fingerprint([]byte("other-secret"), "654321") == fingerprint(secret, "654321") {
+		t.Error("不同密钥得到了相同的摘要")
+	}
+}
+
+func TestAuditRecordConfigWithoutSecret(t *testing.T) {
+	audits := repotest.NewAuditLogRepository()
+	svc := NewAuditService(audits)
+
+	var cfg auditTestConfig
+	cfg.Database.Password = "123456"
+	if err := svc.RecordConfig(context.Background(), "config.yaml", cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := recordedPassword(t, audits); got != "[已设置]" {
+		t.Errorf("未配置密钥时密码记录为 %v, 期望只记录已设置", got)
+	}
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/pkg/money"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxCouponsPerOrder 一个订单最多同时使用的优惠券数量
+const maxCouponsPerOrder = 3
+
+// couponCodePattern 兑换码格式，大写字母、数字、下划线和连字符
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{1,32}$`)
+
+// CouponService 优惠券业务逻辑层
+type CouponService struct {
+	tx    repository.Transactor
+	repo  repository.CouponStore
+	audit *AuditService
+}
+
+// NewCouponService 创建优惠券服务实例
+func NewCouponService(tx repository.Transactor, repo repository.CouponStore, audit *AuditService) *CouponService {
+	return &CouponService{tx: tx, repo: repo, audit: audit}
+}
+
+// NormalizeCouponCode 去除首尾空白并转为大写
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Create 创建优惠券，兑换码不能与已有（包括已删除）的优惠券重复
+func (s *CouponService) Create(ctx context.Context, coupon *model.Coupon) error {
+	coupon.Code = NormalizeCouponCode(coupon.Code)
+	if err := validateCoupon(coupon); err != nil {
+		return err
+	}
+	exists, err := s.repo.CodeExists(ctx, coupon.Code)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrCouponCodeExists
+	}
+	coupon.ID = 0
+	coupon.UsedCount = 0
+	for i := range coupon.Scopes {
+		coupon.Scopes[i].ID = 0
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, coupon); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditActionCreate, model.AuditResourceCoupon, coupon.ID, nil, coupon)
+	})
+}
+
+// GetByID 根据ID获取优惠券，不存在时返回ErrCouponNotFound
+func (s *CouponService) GetByID(ctx context.Context, id uint) (*model.Coupon, error) {
+	coupon, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCouponNotFound
+	}
+	return coupon, err
+}
+
+// List 获取优惠券列表
+func (s *CouponService) List(ctx context.Context, page, pageSize int) ([]model.Coupon, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	return s.repo.List(ctx, page, pageSize)
+}
+
+// Update 修改优惠券，兑换码和已使用次数不能修改
+// 已下单的订单保存了优惠明细，修改优惠券不影响已有订单
+func (s *CouponService) Update(ctx context.Context, coupon *model.Coupon) error {
+	before, err := s.GetByID(ctx, coupon.ID)
+	if err != nil {
+		return err
+	}
+	coupon.Code = before.Code
+	coupon.UsedCount = before.UsedCount
+	coupon.CreatedAt = before.CreatedAt
+	if err := validateCoupon(coupon); err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, coupon); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditActionUpdate, model.AuditResourceCoupon, coupon.ID, before, coupon)
+	})
+}
+
+// Delete 删除优惠券，已使用的订单不受影响，兑换码不能再用于新的优惠券
+func (s *CouponService) Delete(ctx context.Context, id uint) error {
+	before, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditActionDelete, model.AuditResourceCoupon, id, before, nil)
+	})
+}
+
+// validateCoupon 校验优惠券的类型、金额、次数、有效期和适用范围
+func validateCoupon(c *model.Coupon) error {
+	if !couponCodePattern.MatchString(c.Code) || strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("%w: 兑换码只能包含大写字母、数字、下划线和连字符，名称不能为空", ErrInvalidCoupon)
+	}
+	for _, a := range []money.Amount{c.AmountOff, c.MaxDiscount, c.MinSpend} {
+		if a.IsNegative() || a.Currency() != money.DefaultCurrency() {
+			return fmt.Errorf("%w: 金额不能为负数且必须使用本位币", ErrInvalidCoupon)
+		}
+	}
+	switch c.Type {
+	case model.CouponTypePercent:
+		if c.PercentOff < 1 || c.PercentOff > 100 || !c.AmountOff.IsZero() {
+			return fmt.Errorf("%w: 按比例减免的比例应为1-100", ErrInvalidCoupon)
+		}
+	case model.CouponTypeFixed:
+		if c.PercentOff != 0 || !c.AmountOff.IsPositive() || !c.MaxDiscount.IsZero() {
+			return fmt.Errorf("%w: 固定金额减免的金额必须大于0", ErrInvalidCoupon)
+		}
+	default:
+		return fmt.Errorf("%w: 不支持的类型%q", ErrInvalidCoupon, c.Type)
+	}
+	if c.TotalLimit < 0 || c.PerUserLimit < 0 {
+		return fmt.Errorf("%w: 使用次数不能为负数", ErrInvalidCoupon)
+	}
+	if c.StartsAt != nil && c.EndsAt != nil && !c.EndsAt.After(*c.StartsAt) {
+		return fmt.Errorf("%w: 失效时间必须晚于生效时间", ErrInvalidCoupon)
+	}
+	for _, scope := range c.Scopes {
+		if (scope.ProductID == 0) == (scope.CategoryID == 0) {
+			return fmt.Errorf("%w: 适用范围需指定商品或分类之一", ErrInvalidCoupon)
+		}
+	}
+	return nil
+}
+
+// Apply 校验优惠券并应用到计价结果上，返回使用的优惠券，不修改使用次数
+// 叠加规则：最多同时使用maxCouponsPerOrder张，不可叠加的优惠券只能单独使用；
+// 先应用固定金额减免、再应用按比例减免，同类型按ID顺序，按比例减免以之前优惠后的剩余金额为基数
+// 最低消费按适用商品的原价合计计算
+func (s *CouponService) Apply(ctx context.Context, userID uint, quote *Quote, codes []string, now time.Time) ([]model.Coupon, error) {
+	codes = uniqueCouponCodes(codes)
+	if len(codes) == 0 {
+		return nil, nil
+	}
+	if len(codes) > maxCouponsPerOrder {
+		return nil, ErrCouponNotStackable
+	}
+
+	coupons, err := s.repo.ListByCodes(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(coupons))
+	for _, c := range coupons {
+		found[c.Code] = true
+	}
+	for _, code := range codes {
+		if !found[code] {
+			return nil, fmt.Errorf("%w: %s", ErrCouponNotFound, code)
+		}
+	}
+
+	for i := range coupons {
+		c := &coupons[i]
+		if len(coupons) > 1 && !c.Stackable {
+			return nil, fmt.Errorf("%w: %s", ErrCouponNotStackable, c.Code)
+		}
+		if !c.ActiveAt(now) {
+			return nil, fmt.Errorf("%w: %s", ErrCouponExpired, c.Code)
+		}
+		if c.TotalLimit > 0 && c.UsedCount >= c.TotalLimit {
+			return nil, fmt.Errorf("%w: %s", ErrCouponExhausted, c.Code)
+		}
+		if err := s.checkUserLimit(ctx, c, userID); err != nil {
+			return nil, err
+		}
+		eligible := quote.amount(couponMatcher(c))
+		if !eligible.IsPositive() {
+			return nil, fmt.Errorf("%w: %s", ErrCouponNotApplicable, c.Code)
+		}
+		if eligible.Cmp(c.MinSpend) < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrCouponMinSpend, c.Code)
+		}
+	}
+
+	sort.SliceStable(coupons, func(i, j int) bool {
+		fi, fj := coupons[i].Type == model.CouponTypeFixed, coupons[j].Type == model.CouponTypeFixed
+		if fi != fj {
+			return fi
+		}
+		return coupons[i].ID < coupons[j].ID
+	})
+	for i := range coupons {
+		c := &coupons[i]
+		match := couponMatcher(c)
+		amount := c.AmountOff
+		if c.Type == model.CouponTypePercent {
+			// 按比例减免向零舍入，减免金额不超过标示的比例
+			amount = quote.payable(match).MulFrac(int64(c.PercentOff), 100, money.RoundDown)
+			if c.MaxDiscount.IsPositive() {
+				amount = money.Min(amount, c.MaxDiscount)
+			}
+		}
+		quote.apply(model.OrderDiscount{
+			Source:      model.DiscountSourceCoupon,
+			SourceID:    c.ID,
+			Code:        c.Code,
+			Description: c.Name,
+			Amount:      amount,
+		}, match)
+	}
+	return coupons, nil
+}
+
+// Redeem 记录订单使用的优惠券，需在创建订单的事务中调用
+// 先原子递增已使用次数，达到总次数上限时返回ErrCouponExhausted；递增同时锁定优惠券行，
+// 同一用户并发下单时依次检查每人使用次数
+func (s *CouponService) Redeem(ctx context.Context, userID, orderID uint, coupons []model.Coupon) error {
+	for i := range coupons {
+		c := &coupons[i]
+		if err := s.repo.IncrementUsage(ctx, c.ID); err != nil {
+			if errors.Is(err, repository.ErrCouponExhausted) {
+				return fmt.Errorf("%w: %s", ErrCouponExhausted, c.Code)
+			}
+			return err
+		}
+		if err := s.checkUserLimit(ctx, c, userID); err != nil {
+			return err
+		}
+		redemption := &model.CouponRedemption{CouponID: c.ID, UserID: userID, OrderID: orderID}
+		if err := s.repo.CreateRedemption(ctx, redemption); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Release 归还订单使用的优惠券，返回归还的张数；重复调用不会重复归还
+func (s *CouponService) Release(ctx context.Context, orderID uint) (int, error) {
+	return s.repo.ReleaseByOrder(ctx, orderID, time.Now())
+}
+
+// checkUserLimit 检查用户使用优惠券的次数是否已达到上限
+func (s *CouponService) checkUserLimit(ctx context.Context, c *model.Coupon, userID uint) error {
+	if c.PerUserLimit == 0 {
+		return nil
+	}
+	used, err := s.repo.CountRedemptions(ctx, c.ID, userID)
+	if err != nil {
+		return err
+	}
+	if used >= int64(c.PerUserLimit) {
+		return fmt.Errorf("%w: %s", ErrCouponUserLimit, c.Code)
+	}
+	return nil
+}
+
+// couponMatcher 返回判断订单行是否在优惠券适用范围内的函数
+func couponMatcher(c *model.Coupon) func(PriceLine) bool {
+	return func(line PriceLine) bool {
+		return c.Applies(line.ProductID, line.CategoryID)
+	}
+}
+
+// uniqueCouponCodes 规范化兑换码并去除空值和重复值，保持原有顺序
+func uniqueCouponCodes(codes []string) []string {
+	seen := make(map[string]bool, len(codes))
+	unique := make([]string, 0, len(codes))
+	for _, code := range codes {
+		code = NormalizeCouponCode(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		unique = append(unique, code)
+	}
+	return unique
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"myshop/internal/model"
+	"myshop/internal/repository/repotest"
+	"myshop/pkg/money"
+	"testing"
+	"time"
+)
+
+func cny(s string) money.Amount {
+	return money.MustParse(s, "CNY")
+}
+
+func TestCouponApply(t *testing.T) {
+	svc := NewCouponService(repotest.NewTxManager(), repotest.NewCouponRepository(), NewAuditService(repotest.NewAuditLogRepository()))
+	ctx := context.Background()
+	now := time.Now()
+	tomorrow := now.Add(24 * time.Hour)
+	for _, c := range []model.Coupon{
+		{Code: "FIX20", Name: "满200减20", Type: model.CouponTypeFixed, AmountOff: cny("20"), MinSpend: cny("200"), Stackable: true},
+		{Code: "PCT10", Name: "九折", Type: model.CouponTypePercent, PercentOff: 10, Stackable: true},
+		{Code: "CAT2", Name: "分类2五折", Type: model.CouponTypePercent, PercentOff: 50, MaxDiscount: cny("10"), Stackable: true,
+			Scopes: []model.CouponScope{{CategoryID: 2}}},
+		{Code: "SOLO", Name: "立减30", Type: model.CouponTypeFixed, AmountOff: cny("30")},
+		{Code: "BIG", Name: "满300减10", Type: model.CouponTypeFixed, AmountOff: cny("10"), MinSpend: cny("300")},
+		{Code: "FUTURE", Name: "明天生效", Type: model.CouponTypeFixed, AmountOff: cny("10"), StartsAt: &tomorrow},
+		{Code: "PROD9", Name: "商品9专享", Type: model.CouponTypeFixed, AmountOff: cny("10"),
+			Scopes: []model.CouponScope{{ProductID: 9}}},
+	} {
+		if err := svc.Create(ctx, &c); err != nil {
+			t.Fatalf("create %s: %v", c.Code, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		codes     []string
+		want      error
+		wantTotal string
+		wantCodes []string // 优惠明细的应用顺序
+	}{
+		{name: "不使用优惠券", wantTotal: "250"},
+		{name: "固定金额减免", codes: []string{"FIX20"}, wantTotal: "230", wantCodes: []string{"FIX20"}},
+		{name: "兑换码不区分大小写并去重", codes: []string{" fix20 ", "FIX20"}, wantTotal: "230", wantCodes: []string{"FIX20"}},
+		{name: "先固定金额后按比例", codes: []string{"PCT10", "FIX20"}, wantTotal: "207", wantCodes: []string{"FIX20", "PCT10"}},
+		{name: "按分类减免并封顶", codes: []string{"CAT2"}, wantTotal: "240", wantCodes: []string{"CAT2"}},
+		{name: "不可叠加的优惠券单独使用", codes: []string{"SOLO"}, wantTotal: "220", wantCodes: []string{"SOLO"}},
+		{name: "不可叠加的优惠券不能同时使用", codes: []string{"SOLO", "FIX20"}, want: ErrCouponNotStackable},
+		{name: "超过张数上限", codes: []string{"FIX20", "PCT10", "CAT2", "PROD9"}, want: ErrCouponNotStackable},
+		{name: "未达到最低消费", codes: []string{"BIG"}, want: ErrCouponMinSpend},
+		{name: "未生效", codes: []string{"FUTURE"}, want: ErrCouponExpired},
+		{name: "不适用于所选商品", codes: []string{"PROD9"}, want: ErrCouponNotApplicable},
+		{name: "兑换码不存在", codes: []string{"NOPE"}, want: ErrCouponNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := newQuote([]PriceLine{
+				{ProductID: 1, CategoryID: 1, UnitPrice: cny("100"), Quantity: 2},
+				{ProductID: 2, CategoryID: 2, UnitPrice: cny("50"), Quantity: 1},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = svc.Apply(ctx, 1, quote, tt.codes, now)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if err != nil {
+				return
+			}
+			if quote.Total.Cmp(cny(tt.wantTotal)) != 0 {
+				t.Fatalf("total = %v, want %s", quote.Total, tt.wantTotal)
+			}
+			if len(quote.Discounts) != len(tt.wantCodes) {
+				t.Fatalf("discounts = %+v, want %v", quote.Discounts, tt.wantCodes)
+			}
+			for i, d := range quote.Discounts {
+				if d.Code != tt.wantCodes[i] {
+					t.Fatalf("discount #%d = %s, want %s", i, d.Code, tt.wantCodes[i])
+				}
+			}
+			// 分摊到各行的优惠之和等于优惠合计
+			var allocated money.Amount
+			for _, line := range quote.Lines {
+				allocated = allocated.Add(line.Discount)
+			}
+			if allocated.Cmp(quote.DiscountTotal) != 0 || quote.Subtotal.Sub(quote.DiscountTotal).Cmp(quote.Total) != 0 {
+				t.Fatalf("allocated %v, discount %v, total %v", allocated, quote.DiscountTotal, quote.Total)
+			}
+		})
+	}
+}
+
+func TestCouponValidate(t *testing.T) {
+	svc := NewCouponService(repotest.NewTxManager(), repotest.NewCouponRepository(), NewAuditService(repotest.NewAuditLogRepository()))
+	ctx := context.Background()
+	if err := svc.Create(ctx, &model.Coupon{Code: "dup", Name: "重复", Type: model.CouponTypeFixed, AmountOff: cny("1")}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		coupon model.Coupon
+		want   error
+	}{
+		{name: "兑换码重复", coupon: model.Coupon{Code: "DUP", Name: "重复", Type: model.CouponTypeFixed, AmountOff: cny("1")}, want: ErrCouponCodeExists},
+		{name: "兑换码格式错误", coupon: model.Coupon{Code: "A B", Name: "格式", Type: model.CouponTypeFixed, AmountOff: cny("1")}, want: ErrInvalidCoupon},
+		{name: "比例超过100", coupon: model.Coupon{Code: "P", Name: "比例", Type: model.CouponTypePercent, PercentOff: 101}, want: ErrInvalidCoupon},
+		{name: "固定金额为0", coupon: model.Coupon{Code: "F", Name: "金额", Type: model.CouponTypeFixed}, want: ErrInvalidCoupon},
+		{name: "适用范围为空", coupon: model.Coupon{Code: "S", Name: "范围", Type: model.CouponTypeFixed, AmountOff: cny("1"),
+			Scopes: []model.CouponScope{{}}}, want: ErrInvalidCoupon},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.Create(ctx, &tt.coupon); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"myshop/internal/repository"
+	"myshop/pkg/money"
+	"strings"
+)
+
+// CurrencyService 多币种价格展示
+// 商品以本位币定价和结算，按汇率换算为顾客选择的币种展示；下单时锁定换算使用的汇率
+type CurrencyService struct {
+	rates money.RateProvider
+	users repository.UserStore
+}
+
+// NewCurrencyService 创建币种服务实例
+func NewCurrencyService(rates money.RateProvider, users repository.UserStore) *CurrencyService {
+	return &CurrencyService{rates: rates, users: users}
+}
+
+// Supported 返回可以选择的币种，按代码排序
+func (s *CurrencyService) Supported() []string {
+	return s.rates.Currencies()
+}
+
+// Resolve 确定使用的币种：请求中指定的币种优先，其次是用户资料中的偏好币种，最后是本位币
+// 请求指定的币种没有汇率时返回ErrUnsupportedCurrency；偏好币种的汇率被移除时回退到本位币
+// userID为0表示匿名请求
+func (s *CurrencyService) Resolve(ctx context.Context, requested string, userID uint) (string, error) {
+	if strings.TrimSpace(requested) != "" {
+		currency, err := money.NormalizeCurrency(requested)
+		if err != nil || !s.supports(currency) {
+			return "", fmt.Errorf("%w: %s", ErrUnsupportedCurrency, requested)
+		}
+		return currency, nil
+	}
+	if userID != 0 {
+		if user, err := s.users.GetByID(ctx, userID); err == nil && user.Currency != "" && s.supports(user.Currency) {
+			return user.Currency, nil
+		}
+	}
+	return money.DefaultCurrency(), nil
+}
+
+// Quote 返回本位币到currency的汇率
+func (s *CurrencyService) Quote(currency string) (money.Rate, error) {
+	rate, err := s.rates.Rate(money.DefaultCurrency(), currency)
+	if errors.Is(err, money.ErrRateUnavailable) {
+		return money.Rate{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+	return rate, err
+}
+
+// Convert 按汇率换算本位币金额，使用银行家舍入避免大量换算时的舍入偏差
+func Convert(amount money.Amount, rate money.Rate) money.Amount {
+	return rate.Convert(amount, money.RoundHalfEven)
+}
+
+func (s *CurrencyService) supports(currency string) bool {
+	_, err := s.rates.Rate(money.DefaultCurrency(), currency)
+	return err == nil
+}
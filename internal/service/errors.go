@@ -6,4 +6,67 @@ var (
 	ErrInvalidCredentials = errors.New("invalid username or password")
 	ErrUserNotFound       = errors.New("user not found")
 	ErrUserExists         = errors.New("user already exists")
+	ErrAccountLocked      = errors.New("account temporarily locked")
+	ErrTooManyAttempts    = errors.New("too many login attempts")
+
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+
+	ErrInvalidAPIKey  = errors.New("invalid api key")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidScope   = errors.New("invalid scope")
+	ErrInvalidExpiry  = errors.New("invalid expiry")
+
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication not enrolled")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrInvalidChallenge     = errors.New("invalid or expired login challenge")
+
+	ErrEmailExists   = errors.New("email already in use")
+	ErrEmailNotSet   = errors.New("email not set")
+	ErrEmailVerified = errors.New("email already verified")
+	ErrInvalidToken  = errors.New("invalid token")
+	ErrTokenExpired  = errors.New("token expired")
+	ErrRateLimited   = errors.New("too many requests")
+
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionRevoked  = errors.New("session revoked")
+	ErrWrongPassword   = errors.New("current password is incorrect")
+	ErrReauthRequired  = errors.New("recent authentication required")
+
+	ErrOIDCProviderNotFound    = errors.New("identity provider not found")
+	ErrOIDCProviderUnavailable = errors.New("identity provider unavailable")
+	ErrInvalidOIDCState        = errors.New("invalid or expired oidc state")
+	ErrOIDCExchange            = errors.New("oidc code exchange failed")
+	ErrIdentityLinked          = errors.New("identity already linked to another user")
+
+	ErrAddressNotFound = errors.New("address not found")
+	ErrAddressRequired = errors.New("shipping address required")
+	ErrAddressLimit    = errors.New("too many addresses")
+
+	ErrProductNotFound     = errors.New("product not found")
+	ErrProductUnavailable  = errors.New("product unavailable")
+	ErrInvalidPrice        = errors.New("invalid price")
+	ErrInsufficientStock   = errors.New("insufficient stock")
+	ErrEmptyOrder          = errors.New("order has no items")
+	ErrInvalidQuantity     = errors.New("invalid quantity")
+	ErrOrderTooLarge       = errors.New("order amount too large")
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrInvalidOrderStatus  = errors.New("invalid order status")
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrInvalidRole         = errors.New("invalid role")
+	ErrChangeOwnRole       = errors.New("cannot change own role")
+
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrInvalidCoupon       = errors.New("invalid coupon")
+	ErrCouponCodeExists    = errors.New("coupon code already exists")
+	ErrCouponExpired       = errors.New("coupon not active")
+	ErrCouponExhausted     = errors.New("coupon usage limit reached")
+	ErrCouponUserLimit     = errors.New("coupon per-user limit reached")
+	ErrCouponNotApplicable = errors.New("coupon not applicable to order items")
+	ErrCouponMinSpend      = errors.New("order below coupon minimum spend")
+	ErrCouponNotStackable  = errors.New("coupons cannot be combined")
+
+	ErrPromotionNotFound = errors.New("promotion not found")
+	ErrInvalidPromotion  = errors.New("invalid promotion")
 )
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"myshop/internal/config"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/pkg/cache"
+	"time"
+)
+
+// 登录防护默认参数，配置缺省时使用
+const (
+	defaultMaxUserFailures = 5
+	defaultMaxIPFailures   = 20
+	defaultFailureWindow   = 15 * time.Minute
+	defaultLockoutDuration = 15 * time.Minute
+	defaultMaxDelay        = 5 * time.Second
+)
+
+// RetryAfterError 登录失败后要求客户端等待After再重试，Err为原始错误
+type RetryAfterError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryAfterError) Error() string { return e.Err.Error() }
+
+func (e *RetryAfterError) Unwrap() error { return e.Err }
+
+// withRetryAfter 需要等待时把err包装为RetryAfterError
+func withRetryAfter(err error, after time.Duration) error {
+	if after <= 0 {
+		return err
+	}
+	return &RetryAfterError{Err: err, After: after}
+}
+
+// LoginGuard 登录防暴力破解
+// 按用户名和IP分别统计失败次数，失败后渐进延迟，超过阈值临时锁定，并记录安全事件
+type LoginGuard struct {
+	cache  cache.Cache                   // 失败计数与锁定状态
+	events repository.SecurityEventStore // 安全事件仓储
+	cfg    config.LoginSecurityConfig
+}
+
+// NewLoginGuard 创建登录防护实例
+func NewLoginGuard(c cache.Cache, events repository.SecurityEventStore, cfg config.LoginSecurityConfig) *LoginGuard {
+	if cfg.MaxUserFailures <= 0 {
+		cfg.MaxUserFailures = defaultMaxUserFailures
+	}
+	if cfg.MaxIPFailures <= 0 {
+		cfg.MaxIPFailures = defaultMaxIPFailures
+	}
+	if cfg.FailureWindow <= 0 {
+		cfg.FailureWindow = defaultFailureWindow
+	}
+	if cfg.LockoutDuration <= 0 {
+		cfg.LockoutDuration = defaultLockoutDuration
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = defaultMaxDelay
+	}
+	return &LoginGuard{
+		cache:  c,
+		events: events,
+		cfg:    cfg,
+	}
+}
+
+func userFailKey(username string) string    { return "login:fail:user:" + username }
+func userLockKey(username string) string    { return "login:lock:user:" + username }
+func userBlockedKey(username string) string { return "login:blocked:user:" + username }
+func userWaitKey(username string) string    { return "login:wait:user:" + username }
+func ipFailKey(ip string) string            { return "login:fail:ip:" + ip }
+func ipLockKey(ip string) string            { return "login:lock:ip:" + ip }
+func ipBlockedKey(ip string) string         { return "login:blocked:ip:" + ip }
+
+// Check 检查用户名或IP是否处于锁定状态，以及是否还在上次失败后的延迟时间内
+// 同一锁定期间只在第一次被拦截时记录安全事件，延迟时间内的尝试返回RetryAfterError
+func (g *LoginGuard) Check(ctx context.Context, username, ip string) error {
+	if g.locked(userLockKey(username)) {
+		if g.firstBlocked(userBlockedKey(username)) {
+			g.record(ctx, model.SecurityEventLoginBlocked, 0, username, ip, "账号锁定期间尝试登录")
+		}
+		return ErrAccountLocked
+	}
+	if ip != "" && g.locked(ipLockKey(ip)) {
+		if g.firstBlocked(ipBlockedKey(ip)) {
+			g.record(ctx, model.SecurityEventLoginBlocked, 0, username, ip, "IP封禁期间尝试登录")
+		}
+		return ErrTooManyAttempts
+	}
+	if wait := g.wait(username); wait > 0 {
+		return &RetryAfterError{Err: ErrTooManyAttempts, After: wait}
+	}
+	return nil
+}
+
+// Fail 记录一次登录失败，返回客户端再次尝试前需要等待的时间
+// 累加用户名与IP的失败次数，达到阈值时锁定；按失败次数计算渐进延迟，延迟时间内Check拒绝该用户名的尝试
+func (g *LoginGuard) Fail(ctx context.Context, userID uint, username, ip string) time.Duration {
+	userFailures := g.incr(userFailKey(username))
+	ipFailures := 0
+	if ip != "" {
+		ipFailures = g.incr(ipFailKey(ip))
+	}
+
+	g.record(ctx, model.SecurityEventLoginFailed, userID, username, ip,
+		fmt.Sprintf("用户名连续失败%d次", userFailures))
+
+	if userFailures >= g.cfg.MaxUserFailures {
+		g.lock(userLockKey(username), userFailKey(username), userBlockedKey(username))
+		g.record(ctx, model.SecurityEventAccountLocked, userID, username, ip,
+			fmt.Sprintf("连续失败%d次，锁定%s", userFailures, g.cfg.LockoutDuration))
+	}
+	if ip != "" && ipFailures >= g.cfg.MaxIPFailures {
+		g.lock(ipLockKey(ip), ipFailKey(ip), ipBlockedKey(ip))
+		g.record(ctx, model.SecurityEventIPBlocked, userID, username, ip,
+			fmt.Sprintf("IP失败%d次，封禁%s", ipFailures, g.cfg.LockoutDuration))
+	}
+
+	delay := g.delay(userFailures)
+	if delay > 0 {
+		g.cache.Set(userWaitKey(username), time.Now().Add(delay).UnixNano(), delay)
+	}
+	return delay
+}
+
+// Succeed 登录成功后清除该用户名的失败计数
+// IP计数不清除，避免攻击者用自己的账号重置IP维度的计数
+func (g *LoginGuard) Succeed(ctx context.Context, userID uint, username, ip string) {
+	g.cache.Delete(userFailKey(username))
+	g.cache.Delete(userWaitKey(username))
+	g.record(ctx, model.SecurityEventLoginSuccess, userID, username, ip, "")
+}
+
+// Unlock 管理员解锁账号，同时清除失败计数
+func (g *LoginGuard) Unlock(ctx context.Context, user *model.User, operatorID uint) {
+	g.cache.Delete(userLockKey(user.Username))
+	g.cache.Delete(userFailKey(user.Username))
+	g.cache.Delete(userBlockedKey(user.Username))
+	g.cache.Delete(userWaitKey(user.Username))
+	g.record(ctx, model.SecurityEventUnlocked, user.ID, user.Username, "",
+		fmt.Sprintf("由管理员%d解锁", operatorID))
+}
+
+// ListEvents 查询安全事件
+func (g *LoginGuard) ListEvents(ctx context.Context, filter repository.SecurityEventFilter, page, pageSize int) ([]model.SecurityEvent, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	return g.events.List(ctx, filter, page, pageSize)
+}
+
+// delay 计算渐进延迟：基数 * 2^(失败次数-1)，不超过上限
+func (g *LoginGuard) delay(failures int) time.Duration {
+	if g.cfg.BaseDelay <= 0 || failures <= 0 {
+		return 0
+	}
+	d := g.cfg.BaseDelay
+	for i := 1; i < failures && d < g.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > g.cfg.MaxDelay {
+		d = g.cfg.MaxDelay
+	}
+	return d
+}
+
+// locked 判断锁定标记是否存在，缓存不可用时按已锁定处理
+func (g *LoginGuard) locked(key string) bool {
+	_, err := g.cache.Get(key)
+	if errors.Is(err, cache.ErrNotFound) {
+		return false
+	}
+	if err != nil {
+		log.Printf("查询登录锁定状态失败: %v", err)
+	}
+	return true
+}
+
+// incr 失败次数加一，计数窗口从第一次失败开始
+// 多个实例共享缓存时同样是原子的；缓存不可用时记录日志并按未失败处理
+func (g *LoginGuard) incr(key string) int {
+	count, err := g.cache.Incr(key, g.cfg.FailureWindow)
+	if err != nil {
+		log.Printf("登录失败计数失败: %v", err)
+	}
+	return int(count)
+}
+
+func (g *LoginGuard) lock(lockKey, failKey, blockedKey string) {
+	g.cache.Set(lockKey, time.Now(), g.cfg.LockoutDuration)
+	g.cache.Delete(failKey)
+	g.cache.Delete(blockedKey)
+}
+
+// firstBlocked 判断是否为本次锁定期间第一次被拦截的尝试，计数键在加锁时清除；缓存不可用时按第一次处理
+func (g *LoginGuard) firstBlocked(key string) bool {
+	count, err := g.cache.Incr(key, g.cfg.LockoutDuration)
+	return err != nil || count == 1
+}
+
+// wait 返回距离上次失败后的延迟结束还有多久，没有延迟时返回0
+func (g *LoginGuard) wait(username string) time.Duration {
+	until, err := cache.GetAs[int64](g.cache, userWaitKey(username))
+	if err != nil {
+		return 0
+	}
+	return time.Until(time.Unix(0, until))
+}
+
+// record 写入安全事件，写入失败只记录日志，不影响登录流程
+func (g *LoginGuard) record(ctx context.Context, eventType string, userID uint, username, ip, detail string) {
+	event := &model.SecurityEvent{
+		Type:     eventType,
+		UserID:   userID,
+		Username: truncate(username, 32),
+		IP:       ip,
+		Detail:   detail,
+	}
+	if err := g.events.Create(ctx, event); err != nil {
+		log.Printf("记录安全事件失败: %v", err)
+	}
+}
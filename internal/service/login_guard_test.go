@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"myshop/internal/config"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/internal/repository/repotest"
+	"myshop/pkg/cache"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// newLoginGuardTestEnv 创建登录防护和记录安全事件的内存仓储
+func newLoginGuardTestEnv(t *testing.T, cfg config.LoginSecurityConfig) (*LoginGuard, *repotest.SecurityEventRepository) {
+	t.Helper()
+
+	memCache := cache.NewMemoryCache(cache.MemoryOptions{})
+	t.Cleanup(func() { memCache.Close() })
+	events := repotest.NewSecurityEventRepository()
+	return NewLoginGuard(memCache, events, cfg), events
+}
+
+// countEvents 统计指定类型的安全事件数量
+func countEvents(t *testing.T, events *repotest.SecurityEventRepository, eventType string) int64 {
+	t.Helper()
+
+	_, total, err := events.List(context.Background(), repository.SecurityEventFilter{Type: eventType}, 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return total
+}
+
+func TestLoginGuardLocksUserAtThreshold(t *testing.T) {
+	ctx := context.Background()
+	guard, events := newLoginGuardTestEnv(t, config.LoginSecurityConfig{MaxUserFailures: 3, MaxIPFailures: 100})
+
+	for i := 1; i <= 3; i++ {
+		if err := guard.Check(ctx, "alice", "10.0.0.1"); err != nil {
+			t.Fatalf("第%d次尝试前不应锁定: %v", i, err)
+		}
+		guard.Fail(ctx, 1, "alice", "10.0.0.1")
+	}
+	for i := 0; i < 3; i++ {
+		if err := guard.Check(ctx, "alice", "10.0.0.2"); err != ErrAccountLocked {
+			t.Fatalf("达到阈值后换IP: err = %v, 期望 %v", err, ErrAccountLocked)
+		}
+	}
+	if err := guard.Check(ctx, "bob", "10.0.0.1"); err != nil {
+		t.Errorf("其他用户不应受影响: %v", err)
+	}
+
+	if n := countEvents(t, events, model.SecurityEventLoginFailed); n != 3 {
+		t.Errorf("登录失败事件 = %d, 期望 3", n)
+	}
+	if n := countEvents(t, events, model.SecurityEventAccountLocked); n != 1 {
+		t.Errorf("锁定事件 = %d, 期望 1", n)
+	}
+	if n := countEvents(t, events, model.SecurityEventLoginBlocked); n != 1 {
+		t.Errorf("同一锁定期间的尝试只记录一次事件, 实际 %d", n)
+	}
+}
+
+func TestLoginGuardBlocksIPAcrossUsernames(t *testing.T) {
+	ctx := context.Background()
+	guard, events := newLoginGuardTestEnv(t, config.LoginSecurityConfig{MaxUserFailures: 100, MaxIPFailures: 3})
+
+	for _, username := range []string{"alice", "bob", "carol"} {
+		guard.Fail(ctx, 0, username, "10.0.0.1")
+	}
+	if err := guard.Check(ctx, "dave", "10.0.0.1"); err != ErrTooManyAttempts {
+		t.Fatalf("IP达到阈值: err = %v, 期望 %v", err, ErrTooManyAttempts)
+	}
+	if err := guard.Check(ctx, "dave", "10.0.0.2"); err != nil {
+		t.Errorf("其他IP不应受影响: %v", err)
+	}
+	if n := countEvents(t, events, model.SecurityEventIPBlocked); n != 1 {
+		t.Errorf("IP封禁事件 = %d, 期望 1", n)
+	}
+}
+
+func TestLoginGuardSucceedResetsUserCount(t *testing.T) {
+	ctx := context.Background()
+	guard, _ := newLoginGuardTestEnv(t, config.LoginSecurityConfig{MaxUserFailures: 3, MaxIPFailures: 4})
+
+	guard.Fail(ctx, 1, "alice", "10.0.0.1")
+	guard.Fail(ctx, 1, "alice", "10.0.0.1")
+	guard.Succeed(ctx, 1, "alice", "10.0.0.1")
+	guard.Fail(ctx, 1, "alice", "10.0.0.1")
+	guard.Fail(ctx, 1, "alice", "10.0.0.1")
+	if err := guard.Check(ctx, "alice", ""); err != nil {
+		t.Fatalf("登录成功后应重新计数: %v", err)
+	}
+
+	// 成功登录不清除IP维度的计数，累计4次失败后IP被封禁
+	if err := guard.Check(ctx, "alice", "10.0.0.1"); err != ErrTooManyAttempts {
+		t.Errorf("err = %v, 期望 %v", err, ErrTooManyAttempts)
+	}
+}
+
+func TestLoginGuardLockExpiresAndUnlock(t *testing.T) {
+	ctx := context.Background()
+	guard, events := newLoginGuardTestEnv(t, config.LoginSecurityConfig{
+		MaxUserFailures: 1,
+		MaxIPFailures:   100,
+		LockoutDuration: 50 * time.Millisecond,
+	})
+
+	guard.Fail(ctx, 1, "alice", "")
+	if err := guard.Check(ctx, "alice", ""); err != ErrAccountLocked {
+		t.Fatalf("err = %v, 期望 %v", err, ErrAccountLocked)
+	}
+	time.Sleep(80 * time.Millisecond)
+	if err := guard.Check(ctx, "alice", ""); err != nil {
+		t.Fatalf("锁定到期后应自动解锁: %v", err)
+	}
+
+	guard.Fail(ctx, 1, "alice", "")
+	guard.Unlock(ctx, &model.User{ID: 1, Username: "alice"}, 99)
+	if err := guard.Check(ctx, "alice", ""); err != nil {
+		t.Fatalf("管理员解锁后仍被锁定: %v", err)
+	}
+	if n := countEvents(t, events, model.SecurityEventUnlocked); n != 1 {
+		t.Errorf("解锁事件 = %d, 期望 1", n)
+	}
+}
+
+func TestLoginGuardDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     time.Duration
+		failures int
+		want     time.Duration
+	}{
+		{name: "未配置基数", base: 0, failures: 3, want: 0},
+		{name: "没有失败", base: 100 * time.Millisecond, failures: 0, want: 0},
+		{name: "第1次失败", base: 100 * time.Millisecond, failures: 1, want: 100 * time.Millisecond},
+		{name: "第2次失败", base: 100 * time.Millisecond, failures: 2, want: 200 * time.Millisecond},
+		{name: "第4次失败", base: 100 * time.Millisecond, failures: 4, want: 800 * time.Millisecond},
+		{name: "超过上限", base: 100 * time.Millisecond, failures: 5, want: time.Second},
+		{name: "失败次数很大", base: 100 * time.Millisecond, failures: 1000, want: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, _ := newLoginGuardTestEnv(t, config.LoginSecurityConfig{BaseDelay: tt.base, MaxDelay: time.Second})
+			if got := guard.delay(tt.failures); got != tt.want {
+				t.Errorf("delay(%d) = %v, 期望 %v", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoginGuardFailReturnsDelay 失败后返回延迟而不阻塞，延迟时间内该用户名的尝试被拒绝
+func TestLoginGuardFailReturnsDelay(t *testing.T) {
+	ctx := context.Background()
+	guard, _ := newLoginGuardTestEnv(t, config.LoginSecurityConfig{BaseDelay: 20 * time.Millisecond, MaxDelay: time.Second})
+
+	start := time.Now()
+	if delay := guard.Fail(ctx, 1, "alice", ""); delay != 20*time.Millisecond {
+		t.Fatalf("第1次失败延迟 = %v, 期望20ms", delay)
+	}
+	if elapsed := time.Since(start); elapsed >= 20*time.Millisecond {
+		t.Errorf("Fail阻塞了%v", elapsed)
+	}
+
+	var retry *RetryAfterError
+	if err := guard.Check(ctx, "alice", ""); !errors.As(err, &retry) || !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("延迟时间内: err = %v, 期望RetryAfterError", err)
+	}
+	if retry.After <= 0 || retry.After > 20*time.Millisecond {
+		t.Errorf("Retry-After = %v", retry.After)
+	}
+	if err := guard.Check(ctx, "bob", ""); err != nil {
+		t.Errorf("其他用户不应受影响: %v", err)
+	}
+
+	time.Sleep(retry.After)
+	if err := guard.Check(ctx, "alice", ""); err != nil {
+		t.Errorf("延迟结束后: err = %v", err)
+	}
+}
+
+func TestLoginGuardTruncatesUsernameByRune(t *testing.T) {
+	guard, events := newLoginGuardTestEnv(t, config.LoginSecurityConfig{})
+
+	guard.Fail(context.Background(), 0, strings.Repeat("用户", 20), "")
+	list, _, _ := events.List(context.Background(), repository.SecurityEventFilter{}, 1, 10)
+	if len(list) != 1 {
+		t.Fatalf("事件数 = %d", len(list))
+	}
+	got := list[0].Username
+	if !utf8.ValidString(got) || utf8.RuneCountInString(got) != 32 {
+		t.Errorf("用户名截断为%q, 期望32个完整字符", got)
+	}
+}
+
+// brokenCache 模拟不可用的缓存，所有操作都返回连接错误
+type brokenCache struct{}
+
+var errCacheDown = errors.New("connection refused")
+
+func (brokenCache) Get(string) (interface{}, error)              { return nil, errCacheDown }
+func (brokenCache) Set(string, interface{}, time.Duration) error { return errCacheDown }
+func (brokenCache) Delete(string) error                          { return errCacheDown }
+func (brokenCache) Incr(string, time.Duration) (int64, error)    { return 0, errCacheDown }
+
+func TestLoginGuardFailsClosedWhenCacheDown(t *testing.T) {
+	guard := NewLoginGuard(brokenCache{}, repotest.NewSecurityEventRepository(), config.LoginSecurityConfig{})
+
+	if err := guard.Check(context.Background(), "alice", "10.0.0.1"); err != ErrAccountLocked {
+		t.Errorf("缓存不可用: err = %v, 期望 %v", err, ErrAccountLocked)
+	}
+	if newRateLimiter(brokenCache{}).Allow("forgot:ip:10.0.0.1", 10, time.Hour) {
+		t.Error("缓存不可用时限流应拒绝请求")
+	}
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"myshop/internal/config"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/pkg/cache"
+	"myshop/pkg/utils"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const (
+	defaultOIDCStateTTL = 10 * time.Minute // 跳转到身份提供方后完成登录的默认时限
+	oidcRequestTimeout  = 10 * time.Second // 访问身份提供方的超时时间
+)
+
+// oidcProvider 身份提供方，端点和公钥在第一次使用时自动发现
+type oidcProvider struct {
+	cfg      config.OIDCProviderConfig
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// OIDCProviderInfo 身份提供方信息，用于前端展示登录按钮
+type OIDCProviderInfo struct {
+	Name        string `json:"name" example:"google"`
+	DisplayName string `json:"display_name" example:"Google"`
+}
+
+// oidcState 授权请求的服务端状态，以state参数为键保存在缓存中
+type oidcState struct {
+	Provider   string
+	Verifier   string // PKCE code_verifier
+	Nonce      string
+	LinkUserID uint // 非0表示为已登录用户关联身份，否则为登录
+}
+
+func init() {
+	cache.Register(&oidcState{})
+}
+
+// OIDCAuthRequest 发起授权请求的结果
+// Binding需要保存在发起请求的浏览器中（HttpOnly Cookie），回调时原样带回，
+// 防止攻击者把自己发起的授权回调链接发给受害者完成登录或关联
+type OIDCAuthRequest struct {
+	URL     string
+	Binding string
+	TTL     time.Duration
+}
+
+// OIDCCallbackResult 回调处理结果，登录时返回Login，关联身份时返回LinkedUserID
+type OIDCCallbackResult struct {
+	Login        *LoginResult
+	LinkedUserID uint
+	Created      bool // 是否为首次登录自动创建的账号
+}
+
+// oidcClaims ID Token中使用的声明
+type oidcClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	AuthTime          int64  `json:"auth_time"`
+}
+
+// authenticatedAt 用户在提供方完成认证的时间
+// 提供方复用已有会话时auth_time早于本次回调，未返回auth_time时按回调时间计算
+func (c oidcClaims) authenticatedAt() time.Time {
+	if c.AuthTime > 0 {
+		return time.Unix(c.AuthTime, 0)
+	}
+	return time.Now()
+}
+
+// OIDCService 第三方登录业务逻辑层
+// 使用授权码模式加PKCE，state和nonce只保存在服务端缓存中且只能使用一次
+type OIDCService struct {
+	providers    map[string]*oidcProvider
+	names        []string
+	userRepo     repository.UserStore
+	identityRepo repository.IdentityStore
+	cache        cache.Cache
+	tokens       *TokenService
+	twoFactor    *TwoFactorService
+	stateTTL     time.Duration
+}
+
+// NewOIDCService 创建第三方登录服务实例
+func NewOIDCService(userRepo repository.UserStore, identityRepo repository.IdentityStore, c cache.Cache,
+	tokens *TokenService, twoFactor *TwoFactorService, cfg config.OIDCConfig) *OIDCService {
+	if cfg.StateTTL <= 0 {
+		cfg.StateTTL = defaultOIDCStateTTL
+	}
+
+	s := &OIDCService{
+		providers:    make(map[string]*oidcProvider),
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		cache:        c,
+		tokens:       tokens,
+		twoFactor:    twoFactor,
+		stateTTL:     cfg.StateTTL,
+	}
+	for _, p := range cfg.Providers {
+		if p.Name == "" || p.Issuer == "" || p.ClientID == "" {
+			log.Printf("跳过配置不完整的第三方登录提供方: %q", p.Name)
+			continue
+		}
+		s.providers[p.Name] = &oidcProvider{cfg: p}
+		s.names = append(s.names, p.Name)
+	}
+	return s
+}
+
+// Providers 返回已配置的身份提供方
+func (s *OIDCService) Providers() []OIDCProviderInfo {
+	infos := make([]OIDCProviderInfo, 0, len(s.names))
+	for _, name := range s.names {
+		p := s.providers[name]
+		display := p.cfg.DisplayName
+		if display == "" {
+			display = name
+		}
+		infos = append(infos, OIDCProviderInfo{Name: name, DisplayName: display})
+	}
+	return infos
+}
+
+// AuthURL 生成跳转到身份提供方的授权地址
+// linkUserID非0时，回调成功后把身份关联到该用户，而不是登录
+func (s *OIDCService) AuthURL(providerName string, linkUserID uint) (*OIDCAuthRequest, error) {
+	p, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := utils.RandomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := utils.RandomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	st := &oidcState{
+		Provider:   providerName,
+		Verifier:   oauth2.GenerateVerifier(),
+		Nonce:      nonce,
+		LinkUserID: linkUserID,
+	}
+	if err := s.cache.Set(oidcStateKey(state), st, s.stateTTL); err != nil {
+		return nil, err
+	}
+
+	return &OIDCAuthRequest{
+		URL:     p.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(st.Verifier)),
+		Binding: utils.HashToken(state),
+		TTL:     s.stateTTL,
+	}, nil
+}
+
+// Callback 处理身份提供方的回调
+// 1. 校验并作废state，确认与回调的提供方一致，且回调来自发起请求的浏览器（binding）
+// 2. 使用授权码和PKCE verifier换取令牌，校验ID Token的签名、受众和nonce
+// 3. 关联流程把身份关联到发起关联的用户；登录流程查找已关联的用户，首次登录时自动创建账号
+func (s *OIDCService) Callback(ctx context.Context, providerName, state, binding, code string, client ClientInfo) (*OIDCCallbackResult, error) {
+	p, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	key := oidcStateKey(state)
+	st, err := cache.GetAs[*oidcState](s.cache, key)
+	s.cache.Delete(key)
+	if err != nil || st.Provider != providerName {
+		return nil, ErrInvalidOIDCState
+	}
+	if subtle.ConstantTimeCompare([]byte(binding), []byte(utils.HashToken(state))) != 1 {
+		return nil, ErrInvalidOIDCState
+	}
+
+	exchangeCtx, cancel := context.WithTimeout(ctx, oidcRequestTimeout)
+	defer cancel()
+	token, err := p.oauth.Exchange(exchangeCtx, code, oauth2.VerifierOption(st.Verifier))
+	if err != nil {
+		log.Printf("第三方登录换取令牌失败(%s): %v", providerName, err)
+		return nil, ErrOIDCExchange
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrOIDCExchange
+	}
+	idToken, err := p.verifier.Verify(exchangeCtx, rawIDToken)
+	if err != nil || idToken.Nonce != st.Nonce {
+		return nil, ErrOIDCExchange
+	}
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, ErrOIDCExchange
+	}
+	claims.Email = normalizeEmail(claims.Email)
+
+	if st.LinkUserID != 0 {
+		if err := s.link(ctx, st.LinkUserID, providerName, idToken.Subject, claims.Email); err != nil {
+			return nil, err
+		}
+		return &OIDCCallbackResult{LinkedUserID: st.LinkUserID}, nil
+	}
+	return s.login(ctx, providerName, idToken.Subject, claims, client)
+}
+
+// ListIdentities 查询用户关联的第三方身份
+func (s *OIDCService) ListIdentities(ctx context.Context, userID uint) ([]model.Identity, error) {
+	return s.identityRepo.ListByUserID(ctx, userID)
+}
+
+func (s *OIDCService) link(ctx context.Context, userID uint, provider, subject, email string) error {
+	if existing, err := s.identityRepo.GetBySubject(ctx, provider, subject); err == nil {
+		if existing.UserID != userID {
+			return ErrIdentityLinked
+		}
+		return nil
+	}
+
+	return s.identityRepo.Create(ctx, &model.Identity{
+		UserID:      userID,
+		Provider:    provider,
+		Subject:     subject,
+		Email:       email,
+		LastLoginAt: time.Now(),
+	})
+}
+
+func (s *OIDCService) login(ctx context.Context, provider, subject string, claims oidcClaims, client ClientInfo) (*OIDCCallbackResult, error) {
+	result := &OIDCCallbackResult{}
+
+	var user *model.User
+	identity, err := s.identityRepo.GetBySubject(ctx, provider, subject)
+	if err == nil {
+		if user, err = s.userRepo.GetByID(ctx, identity.UserID); err != nil {
+			return nil, ErrUserNotFound
+		}
+		if err := s.identityRepo.TouchLogin(ctx, identity.ID, claims.Email); err != nil {
+			log.Printf("更新第三方身份登录时间失败: %v", err)
+		}
+	} else {
+		if user, err = s.signup(ctx, provider, subject, claims); err != nil {
+			return nil, err
+		}
+		result.Created = true
+	}
+
+	if user.Role == model.RoleService {
+		return nil, ErrInvalidCredentials
+	}
+
+	// 第三方登录只代替密码，启用了两步验证的用户仍需输入验证码
+	if user.TwoFactorEnabled {
+		challenge, err := s.twoFactor.Challenge(user)
+		if err != nil {
+			return nil, err
+		}
+		result.Login = &LoginResult{ChallengeToken: challenge}
+		return result, nil
+	}
+
+	pair, err := s.tokens.Issue(ctx, user, false, claims.authenticatedAt(), client)
+	if err != nil {
+		return nil, err
+	}
+	result.Login = &LoginResult{Tokens: pair}
+	return result, nil
+}
+
+// signup 首次登录时创建账号
+// 提供方确认过的邮箱已属于其他账号时不自动合并，避免被冒用，需要用户登录原账号后手动关联
+func (s *OIDCService) signup(ctx context.Context, provider, subject string, claims oidcClaims) (*model.User, error) {
+	email := ""
+	if claims.Email != "" && claims.EmailVerified {
+		if _, err := s.userRepo.GetByEmail(ctx, claims.Email); err == nil {
+			return nil, ErrEmailExists
+		}
+		email = claims.Email
+	}
+
+	username, err := s.uniqueUsername(ctx, provider, claims)
+	if err != nil {
+		return nil, err
+	}
+	// 第三方登录创建的账号没有可用密码，之后可通过找回密码设置
+	secret, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := utils.HashPassword(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Username: username,
+		Password: hashedPassword,
+		Role:     model.RoleUser,
+		Email:    email,
+	}
+	if email != "" {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	err = s.identityRepo.CreateWithUser(ctx, user, &model.Identity{
+		Provider:    provider,
+		Subject:     subject,
+		Email:       claims.Email,
+		LastLoginAt: time.Now(),
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrEmailTaken) {
+			return nil, ErrEmailExists
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// uniqueUsername 根据提供方返回的信息生成不重复的用户名
+func (s *OIDCService) uniqueUsername(ctx context.Context, provider string, claims oidcClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = usernameInvalidChars.ReplaceAllString(base, "")
+	if len(base) < 3 {
+		base = provider + "_" + base
+	}
+	if len(base) > 27 {
+		base = base[:27]
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		if _, err := s.userRepo.GetByUsername(ctx, candidate); err != nil {
+			return candidate, nil
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s_%04d", base, n.Int64())
+	}
+	return "", ErrUserExists
+}
+
+// provider 返回已完成端点发现的身份提供方
+func (s *OIDCService) provider(name string) (*oidcProvider, error) {
+	p, ok := s.providers[name]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth != nil {
+		return p, nil
+	}
+
+	// 提供方不可用时不影响服务启动，下次使用时重试
+	ctx, cancel := context.WithTimeout(context.Background(), oidcRequestTimeout)
+	defer cancel()
+	discovered, err := oidc.NewProvider(ctx, p.cfg.Issuer)
+	if err != nil {
+		log.Printf("发现第三方登录提供方%s失败: %v", name, err)
+		return nil, ErrOIDCProviderUnavailable
+	}
+	p.verifier = discovered.Verifier(&oidc.Config{ClientID: p.cfg.ClientID})
+	p.oauth = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Endpoint:     discovered.Endpoint(),
+		Scopes:       append([]string{oidc.ScopeOpenID}, p.cfg.Scopes...),
+	}
+	return p, nil
+}
+
+func oidcStateKey(state string) string {
+	return "oidc:state:" + state
+}
@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"myshop/internal/config"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/pkg/cache"
+	"myshop/pkg/utils"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeOIDCUser 假身份提供方上的用户
+type fakeOIDCUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	AuthTime      time.Time // 在提供方完成认证的时间，为空时不返回auth_time
+}
+
+// fakeAuthRequest 已授权、等待换取令牌的授权码
+type fakeAuthRequest struct {
+	user      fakeOIDCUser
+	clientID  string
+	nonce     string
+	challenge string
+}
+
+// fakeOIDCProvider 本地假身份提供方，实现发现、JWKS和令牌端点，
+// 授权端点由测试直接调用authorize代替浏览器跳转
+type fakeOIDCProvider struct {
+	*httptest.Server
+	ring  *utils.KeyRing
+	mu    sync.Mutex
+	codes map[string]fakeAuthRequest
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	t.Helper()
+
+	key, err := utils.GenerateEd25519Key("fake-idp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring := utils.NewKeyRing()
+	if err := ring.Add(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := ring.Use(key.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &fakeOIDCProvider{ring: ring, codes: make(map[string]fakeAuthRequest)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.URL,
+			"authorization_endpoint":                p.URL + "/authorize",
+			"token_endpoint":                        p.URL + "/token",
+			"jwks_uri":                              p.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"EdDSA"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(p.ring.JWKS())
+	})
+	mux.HandleFunc("/token", p.token)
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// authorize 模拟用户在身份提供方完成登录，返回回调中的state和code
+func (p *fakeOIDCProvider) authorize(t *testing.T, authURL string, user fakeOIDCUser) (state, code string) {
+	t.Helper()
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("授权请求缺少PKCE参数: %s", authURL)
+	}
+
+	code, err = utils.RandomToken(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.mu.Lock()
+	p.codes[code] = fakeAuthRequest{
+		user:      user,
+		clientID:  q.Get("client_id"),
+		nonce:     q.Get("nonce"),
+		challenge: q.Get("code_challenge"),
+	}
+	p.mu.Unlock()
+	return q.Get("state"), code
+}
+
+func (p *fakeOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	p.mu.Lock()
+	req, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != req.challenge {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	key, _ := p.ring.Signer()
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                p.URL,
+		"aud":                req.clientID,
+		"sub":                req.user.Subject,
+		"nonce":              req.nonce,
+		"email":              req.user.Email,
+		"email_verified":     req.user.EmailVerified,
+		"preferred_username": req.user.Username,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Minute).Unix(),
+	}
+	if !req.user.AuthTime.IsZero() {
+		claims["auth_time"] = req.user.AuthTime.Unix()
+	}
+	idToken := jwt.NewWithClaims(key.Method, claims)
+	idToken.Header["kid"] = key.ID
+	signed, _ := idToken.SignedString(key.Private)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "fake-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     signed,
+	})
+}
+
+// oidcTestEnv 第三方登录测试环境
+type oidcTestEnv struct {
+	service  *OIDCService
+	provider *fakeOIDCProvider
+	users    *repository.UserRepository
+	sessions *SessionService
+	db       *gorm.DB
+}
+
+func newOIDCTestEnv(t *testing.T) *oidcTestEnv {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.User{}, &model.SecurityEvent{}, &model.RefreshToken{},
+		&model.Session{}, &model.Identity{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	key, err := utils.GenerateEd25519Key("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring := utils.NewKeyRing()
+	ring.Add(key)
+	ring.Use(key.ID)
+	utils.InitJWT(utils.JWTOptions{KeyRing: ring})
+
+	provider := newFakeOIDCProvider(t)
+	memCache := cache.NewMemoryCache(cache.MemoryOptions{})
+	users := repository.NewUserRepository(db)
+	events := repository.NewSecurityEventRepository(db)
+	refreshTokens := repository.NewRefreshTokenRepository(db)
+	sessions := NewSessionService(repository.NewSessionRepository(db), refreshTokens, events, memCache)
+	tokens := NewTokenService(users, refreshTokens, sessions, events, utils.NewTokenDenylist(memCache), 0)
+
+	svc := NewOIDCService(users, repository.NewIdentityRepository(db), memCache, tokens, nil, config.OIDCConfig{
+		Providers: []config.OIDCProviderConfig{{
+			Name:        "fake",
+			Issuer:      provider.URL,
+			ClientID:    "myshop",
+			RedirectURL: "http://localhost:8080/api/auth/oidc/fake/callback",
+		}},
+	})
+	return &oidcTestEnv{service: svc, provider: provider, users: users, sessions: sessions, db: db}
+}
+
+// login 走完一次完整的登录流程
+func (e *oidcTestEnv) login(t *testing.T, user fakeOIDCUser, linkUserID uint) (*OIDCCallbackResult, error) {
+	t.Helper()
+
+	req, err := e.service.AuthURL("fake", linkUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, code := e.provider.authorize(t, req.URL, user)
+	return e.service.Callback(context.Background(), "fake", state, req.Binding, code, ClientInfo{IP: "127.0.0.1"})
+}
+
+func TestOIDCLoginCreatesAccountOnFirstLogin(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	alice := fakeOIDCUser{Subject: "alice-sub", Email: "Alice@Example.com", EmailVerified: true, Username: "alice"}
+
+	first, err := env.login(t, alice, 0)
+	if err != nil {
+		t.Fatalf("首次登录失败: %v", err)
+	}
+	if !first.Created || first.Login == nil || first.Login.Tokens == nil {
+		t.Fatalf("首次登录应创建账号并签发令牌: %+v", first)
+	}
+	claims, err := utils.ValidateToken(first.Login.Tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("签发的访问令牌无效: %v", err)
+	}
+
+	user, err := env.users.GetByID(context.Background(), claims.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "alice" || user.Email != "alice@example.com" || user.EmailVerifiedAt == nil {
+		t.Errorf("自动创建的账号信息不正确: %+v", user)
+	}
+
+	second, err := env.login(t, alice, 0)
+	if err != nil {
+		t.Fatalf("再次登录失败: %v", err)
+	}
+	if second.Created {
+		t.Error("再次登录不应重复创建账号")
+	}
+	claims2, _ := utils.ValidateToken(second.Login.Tokens.AccessToken)
+	if claims2.UserID != user.ID {
+		t.Errorf("再次登录的用户ID = %d, 期望 %d", claims2.UserID, user.ID)
+	}
+}
+
+func TestOIDCLoginRecordsProviderAuthTime(t *testing.T) {
+	env := newOIDCTestEnv(t)
+
+	tests := []struct {
+		name     string
+		authTime time.Time
+		want     bool
+	}{
+		{name: "刚在提供方登录", authTime: time.Now().Add(-time.Minute), want: true},
+		{name: "提供方复用了两小时前的会话", authTime: time.Now().Add(-2 * time.Hour), want: false},
+		{name: "未返回auth_time按回调时间计算", want: true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := fakeOIDCUser{Subject: fmt.Sprintf("sub-%d", i), Username: fmt.Sprintf("user%d", i), AuthTime: tt.authTime}
+			result, err := env.login(t, user, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, _ := utils.ValidateToken(result.Login.Tokens.AccessToken)
+			at, ok := env.sessions.AuthenticatedAt(claims.SessionID)
+			if ok != tt.want {
+				t.Fatalf("会话记录认证时间 = %v, 期望 %v", ok, tt.want)
+			}
+			if ok && !tt.authTime.IsZero() && at.Unix() != tt.authTime.Unix() {
+				t.Errorf("认证时间 = %v, 期望 %v", at, tt.authTime)
+			}
+		})
+	}
+}
+
+func TestOIDCStateIsSingleUse(t *testing.T) {
+	ctx := context.Background()
+	env := newOIDCTestEnv(t)
+	bob := fakeOIDCUser{Subject: "bob-sub", Username: "bob"}
+
+	req, _ := env.service.AuthURL("fake", 0)
+	state, code := env.provider.authorize(t, req.URL, bob)
+	if _, err := env.service.Callback(ctx, "fake", state, req.Binding, code, ClientInfo{}); err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+	if _, err := env.service.Callback(ctx, "fake", state, req.Binding, code, ClientInfo{}); err != ErrInvalidOIDCState {
+		t.Errorf("重复使用state: err = %v, 期望 %v", err, ErrInvalidOIDCState)
+	}
+	if _, err := env.service.Callback(ctx, "fake", "unknown", req.Binding, code, ClientInfo{}); err != ErrInvalidOIDCState {
+		t.Errorf("未知state: err = %v, 期望 %v", err, ErrInvalidOIDCState)
+	}
+}
+
+func TestOIDCCodeBoundToPKCEVerifier(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	carol := fakeOIDCUser{Subject: "carol-sub", Username: "carol"}
+
+	// 攻击者把为自己的授权请求签发的code注入到受害者的回调中
+	attackerReq, _ := env.service.AuthURL("fake", 0)
+	_, attackerCode := env.provider.authorize(t, attackerReq.URL, carol)
+	victimReq, _ := env.service.AuthURL("fake", 0)
+	victimState, _ := env.provider.authorize(t, victimReq.URL, carol)
+
+	if _, err := env.service.Callback(context.Background(), "fake", victimState, victimReq.Binding, attackerCode, ClientInfo{}); err != ErrOIDCExchange {
+		t.Errorf("code与verifier不匹配: err = %v, 期望 %v", err, ErrOIDCExchange)
+	}
+}
+
+func TestOIDCStateBoundToBrowser(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	mallory := fakeOIDCUser{Subject: "mallory-sub", Username: "mallory"}
+
+	// 攻击者发起授权后把回调链接发给受害者，受害者浏览器中没有对应的绑定值
+	victimReq, _ := env.service.AuthURL("fake", 0)
+	for name, binding := range map[string]string{"没有Cookie": "", "其他请求的Cookie": victimReq.Binding} {
+		attackerReq, _ := env.service.AuthURL("fake", 0)
+		state, code := env.provider.authorize(t, attackerReq.URL, mallory)
+		if _, err := env.service.Callback(context.Background(), "fake", state, binding, code, ClientInfo{}); err != ErrInvalidOIDCState {
+			t.Errorf("%s: err = %v, 期望 %v", name, err, ErrInvalidOIDCState)
+		}
+	}
+}
+
+func TestOIDCSignupIsAtomic(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	claims := oidcClaims{PreferredUsername: "frank"}
+
+	if _, err := env.service.signup(context.Background(), "fake", "frank-sub", claims); err != nil {
+		t.Fatalf("创建账号失败: %v", err)
+	}
+	// 并发的首次登录都未查到身份，后到的一方关联身份失败时不应留下孤立的账号
+	if _, err := env.service.signup(context.Background(), "fake", "frank-sub", claims); err == nil {
+		t.Fatal("重复关联同一身份应失败")
+	}
+	var count int64
+	env.db.Model(&model.User{}).Count(&count)
+	if count != 1 {
+		t.Errorf("用户数 = %d, 期望 1", count)
+	}
+}
+
+func TestOIDCLinkExistingAccount(t *testing.T) {
+	ctx := context.Background()
+	env := newOIDCTestEnv(t)
+	dave := &model.User{Username: "dave", Password: "x", Role: model.RoleUser}
+	erin := &model.User{Username: "erin", Password: "x", Role: model.RoleUser}
+	env.users.Create(ctx, dave)
+	env.users.Create(ctx, erin)
+	external := fakeOIDCUser{Subject: "dave-sub", Email: "dave@example.com", Username: "dave_ext"}
+
+	result, err := env.login(t, external, dave.ID)
+	if err != nil {
+		t.Fatalf("关联失败: %v", err)
+	}
+	if result.LinkedUserID != dave.ID || result.Login != nil {
+		t.Fatalf("关联流程不应登录: %+v", result)
+	}
+
+	identities, _ := env.service.ListIdentities(ctx, dave.ID)
+	if len(identities) != 1 || identities[0].Provider != "fake" {
+		t.Fatalf("关联的身份 = %+v", identities)
+	}
+
+	login, err := env.login(t, external, 0)
+	if err != nil {
+		t.Fatalf("使用关联身份登录失败: %v", err)
+	}
+	claims, _ := utils.ValidateToken(login.Login.Tokens.AccessToken)
+	if login.Created || claims.UserID != dave.ID {
+		t.Errorf("应登录到已关联的账号%d, 实际 %d", dave.ID, claims.UserID)
+	}
+
+	if _, err := env.login(t, external, erin.ID); err != ErrIdentityLinked {
+		t.Errorf("关联到第二个用户: err = %v, 期望 %v", err, ErrIdentityLinked)
+	}
+}
+
+func TestOIDCSignupDoesNotTakeOverVerifiedEmail(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	now := time.Now()
+	env.users.Create(context.Background(), &model.User{Username: "frank", Password: "x", Role: model.RoleUser,
+		Email: "frank@example.com", EmailVerifiedAt: &now})
+
+	_, err := env.login(t, fakeOIDCUser{Subject: "frank-sub", Email: "frank@example.com", EmailVerified: true}, 0)
+	if err != ErrEmailExists {
+		t.Errorf("邮箱已属于其他账号: err = %v, 期望 %v", err, ErrEmailExists)
+	}
+}
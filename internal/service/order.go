@@ -2,67 +2,256 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"myshop/internal/model"
 	"myshop/internal/repository"
+	"myshop/pkg/money"
 	"time"
-
-	"gorm.io/gorm"
 )
 
+// maxItemQuantity 单个商品一次最多购买的数量
+const maxItemQuantity = 999
+
 type OrderService struct {
-	orderRepo   *repository.OrderRepository
-	productRepo *repository.ProductRepository
+	tx          repository.Transactor // 组合订单与库存的写操作
+	orderRepo   repository.OrderStore
+	productRepo repository.ProductStore
+	catalog     *CachedProductService // 扣减库存后清除商品缓存
+	addresses   *AddressService
+	currency    *CurrencyService
+	promotions  *PromotionService
+	coupons     *CouponService
+	audit       *AuditService
 }
 
-func NewOrderService(orderRepo *repository.OrderRepository, productRepo *repository.ProductRepository) *OrderService {
+func NewOrderService(tx repository.Transactor, orderRepo repository.OrderStore, productRepo repository.ProductStore,
+	catalog *CachedProductService, addresses *AddressService, currency *CurrencyService, promotions *PromotionService,
+	coupons *CouponService, audit *AuditService) *OrderService {
 	return &OrderService{
+		tx:          tx,
 		orderRepo:   orderRepo,
 		productRepo: productRepo,
+		catalog:     catalog,
+		addresses:   addresses,
+		currency:    currency,
+		promotions:  promotions,
+		coupons:     coupons,
+		audit:       audit,
 	}
 }
 
-func (s *OrderService) Create(ctx context.Context, order *model.Order) error {
+// Create 创建订单
+// 1. 校验购买数量，合并同一商品的多个订单项
+// 2. 复制收货地址快照（调用方直接填写的地址或地址簿中的地址），确定支付币种并锁定汇率
+// 3. 在事务中一次查询锁定全部商品，检查上架状态和库存，记录商品名称和单价快照
+// 4. 应用自动促销和优惠券，按锁定的汇率把扣除优惠后的本位币总价换算为应付金额
+// 5. 创建订单、扣减库存并记录优惠券使用次数，任一步失败时整单回滚
+// order.PayCurrency为请求指定的币种，为空时使用用户的偏好币种；
+// order.ShippingAddress不为空时直接作为收货地址，否则按order.AddressID从地址簿中选择
+func (s *OrderService) Create(ctx context.Context, order *model.Order, couponCodes []string) error {
+	items, err := mergeOrderItems(order.Items)
+	if err != nil {
+		return err
+	}
+	order.Items = items
 	order.OrderNo = fmt.Sprintf("%d%d", time.Now().UnixNano(), order.UserID)
 	order.Status = model.OrderStatusPending
 
-	var totalPrice float64
-	for _, item := range order.Items {
-		product, err := s.productRepo.GetByID(item.ProductID)
+	// 复制收货地址快照，未填写也未指定地址时使用默认地址
+	if order.ShippingAddress == (model.ShippingAddress{}) {
+		address, err := s.addresses.Resolve(ctx, order.UserID, order.AddressID)
 		if err != nil {
-			return fmt.Errorf("获取商品信息失败: %w", err)
+			return err
 		}
-		item.Price = product.Price
-		totalPrice += product.Price * float64(item.Quantity)
+		order.AddressID = address.ID
+		order.ShippingAddress = address.ShippingAddress
+	} else {
+		order.AddressID = 0
 	}
-	order.TotalPrice = totalPrice
 
-	err := s.orderRepo.GetDB().Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(order).Error; err != nil {
-			return fmt.Errorf("创建订单失败: %w", err)
+	rate, err := s.lockRate(ctx, order)
+	if err != nil {
+		return err
+	}
+
+	productIDs := make([]uint, len(order.Items))
+	for i, item := range order.Items {
+		productIDs[i] = item.ProductID
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// 商品行锁持续到事务结束，快照的价格与扣减的库存对应同一时刻的商品
+		products, err := s.productRepo.ListForUpdate(ctx, productIDs)
+		if err != nil {
+			return fmt.Errorf("获取商品信息失败: %w", err)
+		}
+		lines, err := snapshotItems(order.Items, products)
+		if err != nil {
+			return err
+		}
+		_, coupons, err := s.price(ctx, order, lines, couponCodes, rate)
+		if err != nil {
+			return err
 		}
 
+		if err := s.orderRepo.Create(ctx, order); err != nil {
+			return fmt.Errorf("创建订单失败: %w", err)
+		}
 		for _, item := range order.Items {
-			if err := s.productRepo.DeductStock(tx, item.ProductID, item.Quantity); err != nil {
+			if err := s.productRepo.DeductStock(ctx, item.ProductID, item.Quantity); err != nil {
 				return fmt.Errorf("扣减库存失败: %w", err)
 			}
 		}
-
-		return nil
+		return s.coupons.Redeem(ctx, order.UserID, order.ID, coupons)
 	})
-
 	if err != nil {
 		return err
 	}
 
+	// 事务提交后再清除缓存，避免并发读取把提交前的库存重新写入缓存
+	s.catalog.Invalidate(productIDs...)
+
 	return nil
 }
 
-func (s *OrderService) GetByID(id uint) (*model.Order, error) {
-	return s.orderRepo.GetByID(id)
+// Preview 按与下单相同的规则试算订单金额，结果写入order，返回各行的优惠分摊
+// 不检查收货地址，不锁定商品、不扣减库存、不记录优惠券使用，实际下单时的金额以下单时的商品和促销为准
+func (s *OrderService) Preview(ctx context.Context, order *model.Order, couponCodes []string) (*Quote, error) {
+	items, err := mergeOrderItems(order.Items)
+	if err != nil {
+		return nil, err
+	}
+	order.Items = items
+
+	rate, err := s.lockRate(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]model.Product, 0, len(order.Items))
+	for _, item := range order.Items {
+		product, err := s.catalog.GetByID(item.ProductID)
+		if errors.Is(err, ErrProductNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("获取商品信息失败: %w", err)
+		}
+		products = append(products, *product)
+	}
+	lines, err := snapshotItems(order.Items, products)
+	if err != nil {
+		return nil, err
+	}
+	quote, _, err := s.price(ctx, order, lines, couponCodes, rate)
+	return quote, err
 }
 
-func (s *OrderService) GetUserOrders(userID uint, page, pageSize int) ([]model.Order, int64, error) {
+// lockRate 确定订单的支付币种并记录当前汇率，之后汇率变化不影响订单金额
+func (s *OrderService) lockRate(ctx context.Context, order *model.Order) (money.Rate, error) {
+	payCurrency, err := s.currency.Resolve(ctx, order.PayCurrency, order.UserID)
+	if err != nil {
+		return money.Rate{}, err
+	}
+	rate, err := s.currency.Quote(payCurrency)
+	if err != nil {
+		return money.Rate{}, err
+	}
+	order.PayCurrency = payCurrency
+	order.ExchangeRate = rate.String()
+	return rate, nil
+}
+
+// snapshotItems 检查商品上架状态和库存，记录订单项的商品名称和单价快照，返回计价行
+func snapshotItems(items []model.OrderItem, products []model.Product) ([]PriceLine, error) {
+	byID := make(map[uint]model.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+
+	lines := make([]PriceLine, len(items))
+	for i := range items {
+		item := &items[i]
+		product, ok := byID[item.ProductID]
+		if !ok || product.Status != model.ProductStatusOnSale {
+			return nil, fmt.Errorf("%w: %d", ErrProductUnavailable, item.ProductID)
+		}
+		if product.Stock < item.Quantity {
+			return nil, fmt.Errorf("%w: %d", ErrInsufficientStock, item.ProductID)
+		}
+		item.ProductName = product.Name
+		item.Price = product.Price
+		lines[i] = PriceLine{ProductID: product.ID, CategoryID: product.CategoryID, Name: product.Name,
+			UnitPrice: product.Price, Quantity: item.Quantity}
+	}
+	return lines, nil
+}
+
+// price 先应用自动促销再应用优惠券，把总价、优惠和应付金额写入order，返回计价结果和使用的优惠券
+func (s *OrderService) price(ctx context.Context, order *model.Order, lines []PriceLine, couponCodes []string,
+	rate money.Rate) (*Quote, []model.Coupon, error) {
+	now := time.Now()
+	quote, err := newQuote(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.promotions.Apply(ctx, quote, now); err != nil {
+		return nil, nil, err
+	}
+	coupons, err := s.coupons.Apply(ctx, order.UserID, quote, couponCodes, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	order.TotalPrice = quote.Total
+	order.DiscountTotal = quote.DiscountTotal
+	order.Discounts = quote.Discounts
+	// 按总价整体换算，避免逐项换算后累加产生的舍入误差
+	order.PayTotal = Convert(quote.Total, rate)
+	return quote, coupons, nil
+}
+
+// mergeOrderItems 校验购买数量并合并同一商品的订单项，按商品首次出现的顺序返回
+// 只保留商品ID和数量，名称和单价由下单时的商品信息决定
+func mergeOrderItems(items []model.OrderItem) ([]model.OrderItem, error) {
+	if len(items) == 0 {
+		return nil, ErrEmptyOrder
+	}
+	merged := make([]model.OrderItem, 0, len(items))
+	index := make(map[uint]int, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 || item.Quantity > maxItemQuantity {
+			return nil, ErrInvalidQuantity
+		}
+		if i, ok := index[item.ProductID]; ok {
+			merged[i].Quantity += item.Quantity
+			if merged[i].Quantity > maxItemQuantity {
+				return nil, ErrInvalidQuantity
+			}
+			continue
+		}
+		index[item.ProductID] = len(merged)
+		merged = append(merged, model.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	return merged, nil
+}
+
+func (s *OrderService) GetByID(ctx context.Context, id uint) (*model.Order, error) {
+	return s.orderRepo.GetByID(ctx, id)
+}
+
+func (s *OrderService) GetUserOrders(ctx context.Context, userID uint, page, pageSize int) ([]model.Order, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	return s.orderRepo.GetByUserID(ctx, userID, page, pageSize)
+}
+
+func (s *OrderService) List(ctx context.Context, page, pageSize int) ([]model.Order, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -70,9 +259,74 @@ func (s *OrderService) GetUserOrders(userID uint, page, pageSize int) ([]model.O
 		pageSize = 10
 	}
 
-	return s.orderRepo.GetByUserID(userID, page, pageSize)
+	return s.orderRepo.List(ctx, page, pageSize)
 }
 
-func (s *OrderService) UpdateStatus(id uint, status int) error {
-	return s.orderRepo.UpdateStatus(id, status)
+// orderTransitions 订单状态允许的变更，已完成和已取消的订单不能再修改
+var orderTransitions = map[int][]int{
+	model.OrderStatusPending: {model.OrderStatusPaid, model.OrderStatusCancelled},
+	model.OrderStatusPaid:    {model.OrderStatusShipped, model.OrderStatusCancelled},
+	model.OrderStatusShipped: {model.OrderStatusCompleted},
+}
+
+// canTransition 判断订单能否从from状态变更为to状态
+func canTransition(from, to int) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateStatus 修改订单状态并记录审计日志
+// 只允许orderTransitions中的变更；取消订单时归还库存和使用的优惠券，与审计日志在同一事务中写入，提交后清除商品缓存
+func (s *OrderService) UpdateStatus(ctx context.Context, id uint, status int) error {
+	if status < model.OrderStatusPending || status > model.OrderStatusCancelled {
+		return ErrInvalidOrderStatus
+	}
+	order, err := s.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return ErrOrderNotFound
+	}
+	if order.Status == status {
+		return nil
+	}
+	if !canTransition(order.Status, status) {
+		return ErrInvalidOrderStatus
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// 按读取时的状态做条件更新，并发修改同一订单时只有一方成功，库存不会重复归还
+		if err := s.orderRepo.UpdateStatus(ctx, id, order.Status, status); err != nil {
+			if errors.Is(err, repository.ErrStatusChanged) {
+				return ErrInvalidOrderStatus
+			}
+			return err
+		}
+		if status == model.OrderStatusCancelled {
+			for _, item := range order.Items {
+				if err := s.productRepo.RestoreStock(ctx, item.ProductID, item.Quantity); err != nil {
+					return fmt.Errorf("归还库存失败: %w", err)
+				}
+			}
+			if _, err := s.coupons.Release(ctx, id); err != nil {
+				return fmt.Errorf("归还优惠券失败: %w", err)
+			}
+		}
+		return s.audit.Record(ctx, model.AuditActionUpdate, model.AuditResourceOrder, id,
+			map[string]int{"status": order.Status}, map[string]int{"status": status})
+	})
+	if err != nil {
+		return err
+	}
+
+	if status == model.OrderStatusCancelled {
+		productIDs := make([]uint, len(order.Items))
+		for i, item := range order.Items {
+			productIDs[i] = item.ProductID
+		}
+		s.catalog.Invalidate(productIDs...)
+	}
+	return nil
 }
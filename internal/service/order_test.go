@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"myshop/internal/model"
+	"myshop/internal/repository/repotest"
+	"myshop/pkg/cache"
+	"myshop/pkg/money"
+	"testing"
+)
+
+// orderTestEnv 基于内存仓储的下单测试环境
+type orderTestEnv struct {
+	svc        *OrderService
+	products   *repotest.ProductRepository
+	addresses  *AddressService
+	users      *repotest.UserRepository
+	promotions *PromotionService
+	coupons    *CouponService
+	catalog    *CachedProductService
+	audits     *repotest.AuditLogRepository
+}
+
+func newOrderTestEnv(t *testing.T) *orderTestEnv {
+	t.Helper()
+	memCache := cache.NewMemoryCache(cache.MemoryOptions{})
+	t.Cleanup(func() { memCache.Close() })
+
+	products := repotest.NewProductRepository()
+	audits := repotest.NewAuditLogRepository()
+	audit := NewAuditService(audits)
+	orders := repotest.NewOrderRepository()
+	couponRepo := repotest.NewCouponRepository()
+	tx := repotest.NewTxManager(products, orders, couponRepo, audits)
+	catalog := NewCachedProductService(NewProductService(tx, products, audit), memCache)
+	addresses := NewAddressService(repotest.NewAddressRepository())
+	rates, err := money.NewStaticRates("CNY", map[string]string{"USD": "0.125", "JPY": "20"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := repotest.NewUserRepository()
+	currency := NewCurrencyService(rates, users)
+	promotions := NewPromotionService(tx, repotest.NewPromotionRepository(), audit)
+	coupons := NewCouponService(tx, couponRepo, audit)
+	svc := NewOrderService(tx, orders, products, catalog, addresses, currency,
+		promotions, coupons, audit)
+	return &orderTestEnv{svc: svc, products: products, addresses: addresses, users: users, promotions: promotions,
+		coupons: coupons, catalog: catalog, audits: audits}
+}
+
+// addProduct 添加一个上架商品
+func (e *orderTestEnv) addProduct(t *testing.T, price string, stock int) *model.Product {
+	t.Helper()
+	product := &model.Product{Name: "商品", Price: money.MustParse(price, "CNY"), Stock: stock, Status: 1}
+	if err := e.products.Create(context.Background(), product); err != nil {
+		t.Fatal(err)
+	}
+	return product
+}
+
+// addAddress 为用户添加收货地址，第一个地址为默认地址
+func (e *orderTestEnv) addAddress(t *testing.T, userID uint, name string) *model.Address {
+	t.Helper()
+	address := &model.Address{ShippingAddress: model.ShippingAddress{
+		Name: name, Phone: "13800138000", Province: "广东省", City: "深圳市", District: "南山区", Detail: "科技园",
+	}}
+	if err := e.addresses.Create(context.Background(), userID, address); err != nil {
+		t.Fatal(err)
+	}
+	return address
+}
+
+func (e *orderTestEnv) stock(t *testing.T, id uint) int {
+	t.Helper()
+	product, err := e.products.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return product.Stock
+}
+
+func TestOrderCreate(t *testing.T) {
+	env := newOrderTestEnv(t)
+	phone := env.addProduct(t, "5999.90", 10)
+	earbuds := env.addProduct(t, "1899.10", 1)
+	offShelf := env.addProduct(t, "2299", 10)
+	luxury := env.addProduct(t, "99999999", 10)
+	offShelf.Status = model.ProductStatusOffSale
+	if err := env.products.Update(context.Background(), offShelf); err != nil {
+		t.Fatal(err)
+	}
+	env.addAddress(t, 1, "张三")
+	office := env.addAddress(t, 1, "张三公司")
+
+	tests := []struct {
+		name        string
+		order       model.Order
+		want        error
+		wantTotal   string
+		wantAddress string
+		wantItems   int // 为0时与请求的订单项数量相同
+		wantStock   map[uint]int
+	}{
+		{
+			name: "使用默认地址下单并扣减库存",
+			order: model.Order{UserID: 1, Items: []model.OrderItem{
+				{ProductID: phone.ID, Quantity: 2}, {ProductID: earbuds.ID, Quantity: 1},
+			}},
+			wantTotal:   "13898.90",
+			wantAddress: "张三",
+			wantStock:   map[uint]int{phone.ID: 8, earbuds.ID: 0},
+		},
+		{
+			name:        "指定收货地址",
+			order:       model.Order{UserID: 1, AddressID: office.ID, Items: []model.OrderItem{{ProductID: phone.ID, Quantity: 1}}},
+			wantTotal:   "5999.90",
+			wantAddress: "张三公司",
+			wantStock:   map[uint]int{phone.ID: 7},
+		},
+		{
+			name: "库存不足时整单不扣减",
+			order: model.Order{UserID: 1, Items: []model.OrderItem{
+				{ProductID: phone.ID, Quantity: 1}, {ProductID: earbuds.ID, Quantity: 1},
+			}},
+			want:      ErrInsufficientStock,
+			wantStock: map[uint]int{phone.ID: 7, earbuds.ID: 0},
+		},
+		{
+			name: "合并同一商品的订单项",
+			order: model.Order{UserID: 1, Items: []model.OrderItem{
+				{ProductID: phone.ID, Quantity: 1}, {ProductID: phone.ID, Quantity: 2},
+			}},
+			wantTotal:   "17999.70",
+			wantAddress: "张三",
+			wantItems:   1,
+			wantStock:   map[uint]int{phone.ID: 4},
+		},
+		{
+			name:  "下架商品不能购买",
+			order: model.Order{UserID: 1, Items: []model.OrderItem{{ProductID: offShelf.ID, Quantity: 1}}},
+			want:  ErrProductUnavailable,
+		},
+		{
+			name:  "商品不存在",
+			order: model.Order{UserID: 1, Items: []model.OrderItem{{ProductID: 999, Quantity: 1}}},
+			want:  ErrProductUnavailable,
+		},
+		{
+			name:  "数量必须为正数",
+			order: model.Order{UserID: 1, Items: []model.OrderItem{{ProductID: phone.ID, Quantity: 0}}},
+			want:  ErrInvalidQuantity,
+		},
+		{
+			name:      "订单金额超出上限",
+			order:     model.Order{UserID: 1, Items: []model.OrderItem{{ProductID: luxury.ID, Quantity: 2}}},
+			want:      ErrOrderTooLarge,
+			wantStock: map[uint]int{luxury.ID: 10},
+		},
+		{
+			name:  "没有订单项",
+			order: model.Order{UserID: 1},
+			want:  ErrEmptyOrder,
+		},
+		{
+			name:  "没有收货地址",
+			order: model.Order{UserID: 2, Items: []model.OrderItem{{ProductID: phone.ID, Quantity: 1}}},
+			want:  ErrAddressRequired,
+		},
+		{
+			name:  "不能使用他人的地址",
+			order: model.Order{UserID: 2, AddressID: office.ID, Items: []model.OrderItem{{ProductID: phone.ID, Quantity: 1}}},
+			want:  ErrAddressNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := tt.order
+			err := env.svc.Create(context.Background(), &order, nil)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if err == nil {
+				if order.ID == 0 || order.Status != model.OrderStatusPending {
+					t.Fatalf("order = %+v", order)
+				}
+				if order.TotalPrice.String() != tt.wantTotal {
+					t.Fatalf("total = %v, want %v", order.TotalPrice, tt.wantTotal)
+				}
+				if order.ShippingAddress.Name != tt.wantAddress {
+					t.Fatalf("address = %q, want %q", order.ShippingAddress.Name, tt.wantAddress)
+				}
+				assertItemsMatchTotal(t, env, order.ID, tt.wantItems, len(tt.order.Items))
+			}
+			for id, want := range tt.wantStock {
+				if got := env.stock(t, id); got != want {
+					t.Fatalf("product %d stock = %d, want %d", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderCreateCurrency(t *testing.T) {
+	env := newOrderTestEnv(t)
+	product := env.addProduct(t, "99.90", 100)
+	env.addAddress(t, 1, "张三")
+	if err := env.users.Create(context.Background(), &model.User{ID: 1, Username: "zhangsan", Currency: "JPY"}); err != nil {
+		t.Fatal(err)
+	}
+	env.addAddress(t, 2, "李四")
+
+	tests := []struct {
+		name         string
+		userID       uint
+		header       string
+		want         error
+		wantCurrency string
+		wantRate     string
+		wantPayTotal string
+	}{
+		{name: "未指定时使用本位币", userID: 2, wantCurrency: "CNY", wantRate: "1", wantPayTotal: "299.70"},
+		{name: "请求指定币种", userID: 2, header: "usd", wantCurrency: "USD", wantRate: "0.125", wantPayTotal: "37.46"},
+		{name: "使用用户的偏好币种", userID: 1, wantCurrency: "JPY", wantRate: "20", wantPayTotal: "5994"},
+		{name: "请求指定的币种优先于偏好币种", userID: 1, header: "USD", wantCurrency: "USD", wantRate: "0.125", wantPayTotal: "37.46"},
+		{name: "没有汇率的币种", userID: 2, header: "EUR", want: ErrUnsupportedCurrency},
+		{name: "未知币种", userID: 2, header: "XXX", want: ErrUnsupportedCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := model.Order{UserID: tt.userID, PayCurrency: tt.header,
+				Items: []model.OrderItem{{ProductID: product.ID, Quantity: 3}}}
+			err := env.svc.Create(context.Background(), &order, nil)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if err != nil {
+				return
+			}
+			if order.TotalPrice.Display() != "CNY 299.70" {
+				t.Fatalf("total = %s, want CNY 299.70", order.TotalPrice.Display())
+			}
+			if order.PayCurrency != tt.wantCurrency || order.ExchangeRate != tt.wantRate ||
+				order.PayTotal.Display() != tt.wantCurrency+" "+tt.wantPayTotal {
+				t.Fatalf("pay = %s at %s (%s), want %s %s at %s",
+					order.PayTotal.Display(), order.ExchangeRate, order.PayCurrency, tt.wantCurrency, tt.wantPayTotal, tt.wantRate)
+			}
+		})
+	}
+}
+
+// assertItemsMatchTotal 检查保存的订单项带有商品快照，且单价乘数量之和减去优惠等于订单总价
+func assertItemsMatchTotal(t *testing.T, env *orderTestEnv, orderID uint, wantItems, requested int) {
+	t.Helper()
+	stored, err := env.svc.GetByID(context.Background(), orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wantItems == 0 {
+		wantItems = requested
+	}
+	if len(stored.Items) != wantItems {
+		t.Fatalf("got %d items, want %d", len(stored.Items), wantItems)
+	}
+	var total money.Amount
+	for _, item := range stored.Items {
+		if !item.Price.IsPositive() || item.ProductName == "" {
+			t.Fatalf("item without snapshot: %+v", item)
+		}
+		total = total.Add(item.Price.Mul(int64(item.Quantity)))
+	}
+	if total.Sub(stored.DiscountTotal).Cmp(stored.TotalPrice) != 0 {
+		t.Fatalf("items total %v - discount %v != order total %v", total, stored.DiscountTotal, stored.TotalPrice)
+	}
+}
+
+func TestOrderUpdateStatus(t *testing.T) {
+	env := newOrderTestEnv(t)
+	product := env.addProduct(t, "100", 10)
+	env.addAddress(t, 1, "张三")
+	order := &model.Order{UserID: 1, Items: []model.OrderItem{{ProductID: product.ID, Quantity: 1}}}
+	if err := env.svc.Create(context.Background(), order, nil); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithActor(context.Background(), Actor{UserID: 9, Role: model.RoleAdmin})
+
+	tests := []struct {
+		name   string
+		id     uint
+		status int
+		want   error
+	}{
+		{name: "非法状态", id: order.ID, status: 99, want: ErrInvalidOrderStatus},
+		{name: "订单不存在", id: 999, status: model.OrderStatusPaid, want: ErrOrderNotFound},
+		{name: "未支付不能发货", id: order.ID, status: model.OrderStatusShipped, want: ErrInvalidOrderStatus},
+		{name: "标记为已支付", id: order.ID, status: model.OrderStatusPaid},
+		{name: "状态未变化", id: order.ID, status: model.OrderStatusPaid},
+		{name: "已支付不能回到待支付", id: order.ID, status: model.OrderStatusPending, want: ErrInvalidOrderStatus},
+		{name: "未发货不能完成", id: order.ID, status: model.OrderStatusCompleted, want: ErrInvalidOrderStatus},
+		{name: "标记为已发货", id: order.ID, status: model.OrderStatusShipped},
+		{name: "已发货不能取消", id: order.ID, status: model.OrderStatusCancelled, want: ErrInvalidOrderStatus},
+		{name: "标记为已完成", id: order.ID, status: model.OrderStatusCompleted},
+		{name: "已完成不能再修改", id: order.ID, status: model.OrderStatusShipped, want: ErrInvalidOrderStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := env.svc.UpdateStatus(ctx, tt.id, tt.status); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	stored, _ := env.svc.GetByID(ctx, order.ID)
+	if stored.Status != model.OrderStatusCompleted {
+		t.Fatalf("status = %d, want %d", stored.Status, model.OrderStatusCompleted)
+	}
+	logs, _ := env.audits.ListByResourceType(context.Background(), model.AuditResourceOrder)
+	if len(logs) != 3 || logs[0].ActorID != 9 {
+		t.Fatalf("audit logs = %+v", logs)
+	}
+}
+
+// TestOrderCancelRestoresStock 取消订单归还库存并清除商品缓存，重复取消不会重复归还
+func TestOrderCancelRestoresStock(t *testing.T) {
+	env := newOrderTestEnv(t)
+	product := env.addProduct(t, "100", 10)
+	env.addAddress(t, 1, "张三")
+	ctx := WithActor(context.Background(), Actor{UserID: 9, Role: model.RoleAdmin})
+	order := &model.Order{UserID: 1, Items: []model.OrderItem{{ProductID: product.ID, Quantity: 3}}}
+	if err := env.svc.Create(ctx, order, nil); err != nil {
+		t.Fatal(err)
+	}
+	if cached, err := env.catalog.GetByID(product.ID); err != nil || cached.Stock != 7 {
+		t.Fatalf("cached product = %+v, %v", cached, err)
+	}
+
+	if err := env.svc.UpdateStatus(ctx, order.ID, model.OrderStatusPaid); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.svc.UpdateStatus(ctx, order.ID, model.OrderStatusCancelled); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.svc.UpdateStatus(ctx, order.ID, model.OrderStatusCancelled); err != nil {
+		t.Fatal(err)
+	}
+	if got := env.stock(t, product.ID); got != 10 {
+		t.Fatalf("stock = %d, want 10", got)
+	}
+	if cached, err := env.catalog.GetByID(product.ID); err != nil || cached.Stock != 10 {
+		t.Fatalf("取消后缓存的库存应刷新: %+v, %v", cached, err)
+	}
+}
+
+// TestOrderCreateCoupon 下单时使用优惠券，次数用完后不能再用，取消订单后归还
+func TestOrderCreateCoupon(t *testing.T) {
+	env := newOrderTestEnv(t)
+	product := env.addProduct(t, "99.99", 10)
+	env.addAddress(t, 1, "张三")
+	env.addAddress(t, 2, "李四")
+	ctx := context.Background()
+	coupon := &model.Coupon{Code: "ONCE", Name: "每人一次八折", Type: model.CouponTypePercent, PercentOff: 20,
+		TotalLimit: 1, PerUserLimit: 1}
+	if err := env.coupons.Create(ctx, coupon); err != nil {
+		t.Fatal(err)
+	}
+
+	order := &model.Order{UserID: 1, Items: []model.OrderItem{{ProductID: product.ID, Quantity: 3}}}
+	if err := env.svc.Create(ctx, order, []string{"once"}); err != nil {
+		t.Fatal(err)
+	}
+	// 299.97的20%向下取整为59.99
+	if order.DiscountTotal.String() != "59.99" || order.TotalPrice.String() != "239.98" || len(order.Discounts) != 1 {
+		t.Fatalf("discount = %v, total = %v, discounts = %+v", order.DiscountTotal, order.TotalPrice, order.Discounts)
+	}
+	assertItemsMatchTotal(t, env, order.ID, 1, 1)
+
+	second := model.Order{UserID: 2, Items: []model.OrderItem{{ProductID: product.ID, Quantity: 1}}}
+	if err := env.svc.Create(ctx, &second, []string{"ONCE"}); !errors.Is(err, ErrCouponExhausted) {
+		t.Fatalf("err = %v, want %v", err, ErrCouponExhausted)
+	}
+	if got := env.stock(t, product.ID); got != 7 {
+		t.Fatalf("stock = %d, want 7", got)
+	}
+
+	admin := WithActor(ctx, Actor{UserID: 9, Role: model.RoleAdmin})
+	if err := env.svc.UpdateStatus(admin, order.ID, model.OrderStatusCancelled); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.svc.UpdateStatus(admin, order.ID, model.OrderStatusPaid); !errors.Is(err, ErrInvalidOrderStatus) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidOrderStatus)
+	}
+	if err := env.svc.Create(ctx, &second, []string{"ONCE"}); err != nil {
+		t.Fatalf("coupon not released after cancel: %v", err)
+	}
+}
+
+// TestOrderPreview 试算与下单使用相同的促销和优惠券规则，试算不扣减库存也不占用优惠券
+func TestOrderPreview(t *testing.T) {
+	env := newOrderTestEnv(t)
+	earbuds := env.addProduct(t, "99.99", 10)
+	env.addAddress(t, 1, "张三")
+	ctx := context.Background()
+	promotion := &model.Promotion{Name: "买二送一", Type: model.PromotionTypeBuyXGetY, BuyQuantity: 2, FreeQuantity: 1}
+	if err := env.promotions.Create(ctx, promotion); err != nil {
+		t.Fatal(err)
+	}
+	coupon := &model.Coupon{Code: "TEN", Name: "立减10元", Type: model.CouponTypeFixed, AmountOff: money.MustParse("10", "CNY"), TotalLimit: 1}
+	if err := env.coupons.Create(ctx, coupon); err != nil {
+		t.Fatal(err)
+	}
+
+	items := func() []model.OrderItem { return []model.OrderItem{{ProductID: earbuds.ID, Quantity: 4}} }
+	preview := model.Order{UserID: 1, PayCurrency: "USD", Items: items()}
+	quote, err := env.svc.Preview(ctx, &preview, []string{"TEN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 399.96 - 买二送一99.99 - 优惠券10 = 289.97，按0.125换算为36.25
+	if preview.TotalPrice.String() != "289.97" || preview.PayTotal.String() != "36.25" || len(quote.Discounts) != 2 ||
+		quote.Discounts[0].SourceID != promotion.ID || quote.Lines[0].Payable().String() != "289.97" {
+		t.Fatalf("preview total = %v, pay = %v, discounts = %+v", preview.TotalPrice, preview.PayTotal, quote.Discounts)
+	}
+	if got := env.stock(t, earbuds.ID); got != 10 {
+		t.Fatalf("stock after preview = %d, want 10", got)
+	}
+
+	order := model.Order{UserID: 1, PayCurrency: "USD", Items: items()}
+	if err := env.svc.Create(ctx, &order, []string{"TEN"}); err != nil {
+		t.Fatal(err)
+	}
+	if order.TotalPrice.Cmp(preview.TotalPrice) != 0 || order.PayTotal.Cmp(preview.PayTotal) != 0 || len(order.Discounts) != 2 {
+		t.Fatalf("order total = %v, pay = %v, preview = %v", order.TotalPrice, order.PayTotal, preview.TotalPrice)
+	}
+	assertItemsMatchTotal(t, env, order.ID, 1, 1)
+
+	// 优惠券已用完，试算同样返回错误
+	if _, err := env.svc.Preview(ctx, &model.Order{UserID: 1, Items: items()}, []string{"TEN"}); !errors.Is(err, ErrCouponExhausted) {
+		t.Fatalf("err = %v, want %v", err, ErrCouponExhausted)
+	}
+}
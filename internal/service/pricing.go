@@ -0,0 +1,124 @@
+package service
+
+import (
+	"myshop/internal/model"
+	"myshop/pkg/money"
+)
+
+// PriceLine 参与计价的订单行
+type PriceLine struct {
+	ProductID  uint
+	CategoryID uint
+	Name       string
+	UnitPrice  money.Amount
+	Quantity   int
+	Amount     money.Amount // 原价小计，单价乘数量
+	Discount   money.Amount // 分摊到该行的优惠合计
+	claimed    int          // 已参与买赠或组合促销的件数，每件商品只参与一个按件计算的促销
+}
+
+// Payable 扣除已分摊优惠后的金额
+func (l PriceLine) Payable() money.Amount {
+	return l.Amount.Sub(l.Discount)
+}
+
+// available 尚未参与买赠或组合促销的件数
+func (l PriceLine) available() int {
+	return l.Quantity - l.claimed
+}
+
+// Quote 订单计价结果
+// 先应用自动促销再应用优惠券，每项优惠分摊到各行，任一行的优惠合计不会超过该行原价
+type Quote struct {
+	Lines         []PriceLine
+	Subtotal      money.Amount          // 原价合计
+	Discounts     []model.OrderDiscount // 优惠明细，按应用顺序
+	DiscountTotal money.Amount          // 优惠合计
+	Total         money.Amount          // 应付金额
+}
+
+// newQuote 按订单行创建计价结果，尚未应用任何优惠
+// 原价合计超出数据库金额列的范围时返回ErrOrderTooLarge，优惠只会减少金额，应用优惠后不会再超出
+func newQuote(lines []PriceLine) (*Quote, error) {
+	q := &Quote{Lines: lines}
+	for i := range q.Lines {
+		line := &q.Lines[i]
+		amount, err := line.UnitPrice.CheckedMul(int64(line.Quantity))
+		if err != nil {
+			return nil, ErrOrderTooLarge
+		}
+		subtotal, err := q.Subtotal.CheckedAdd(amount)
+		if err != nil || exceedsMaxAmount(subtotal) {
+			return nil, ErrOrderTooLarge
+		}
+		line.Amount = amount
+		line.Discount = money.Zero(amount.Currency())
+		q.Subtotal = subtotal
+	}
+	q.DiscountTotal = money.Zero(q.Subtotal.Currency())
+	q.Total = q.Subtotal
+	return q, nil
+}
+
+// amount 返回match为真的行的原价合计
+func (q *Quote) amount(match func(PriceLine) bool) money.Amount {
+	var sum money.Amount
+	for _, line := range q.Lines {
+		if match(line) {
+			sum = sum.Add(line.Amount)
+		}
+	}
+	return sum
+}
+
+// payable 返回match为真的行扣除已应用优惠后的金额合计
+func (q *Quote) payable(match func(PriceLine) bool) money.Amount {
+	var sum money.Amount
+	for _, line := range q.Lines {
+		if match(line) {
+			sum = sum.Add(line.Payable())
+		}
+	}
+	return sum
+}
+
+// apply 在match为真的行上应用一项优惠，按各行剩余金额的比例分摊
+// 优惠金额超过这些行的剩余金额时截断，返回实际的优惠金额；为0时不记录优惠明细
+func (q *Quote) apply(discount model.OrderDiscount, match func(PriceLine) bool) money.Amount {
+	var indexes []int
+	var weights []int64
+	for i, line := range q.Lines {
+		if match(line) {
+			indexes = append(indexes, i)
+			weights = append(weights, line.Payable().Minor())
+		}
+	}
+	amount := money.Min(discount.Amount, q.payable(match))
+	if !amount.IsPositive() {
+		return money.Zero(q.Subtotal.Currency())
+	}
+	return q.record(discount, indexes, amount.Allocate(weights))
+}
+
+// record 把一项优惠按parts分别计入indexes对应的行，每行不超过该行的剩余金额
+// 返回实际的优惠金额；为0时不记录优惠明细
+func (q *Quote) record(discount model.OrderDiscount, indexes []int, parts []money.Amount) money.Amount {
+	amount := money.Zero(q.Subtotal.Currency())
+	for i, part := range parts {
+		line := &q.Lines[indexes[i]]
+		part = money.Min(part, line.Payable())
+		if !part.IsPositive() {
+			continue
+		}
+		line.Discount = line.Discount.Add(part)
+		amount = amount.Add(part)
+	}
+	if !amount.IsPositive() {
+		return amount
+	}
+	discount.Amount = amount
+	q.Discounts = append(q.Discounts, discount)
+	q.DiscountTotal = q.DiscountTotal.Add(amount)
+	q.Total = q.Total.Sub(amount)
+	return amount
+}
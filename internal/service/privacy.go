@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"myshop/internal/config"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/pkg/cache"
+	"myshop/pkg/utils"
+	"time"
+)
+
+const (
+	defaultDeletionGrace     = 30 * 24 * time.Hour
+	defaultAnonymizeInterval = time.Hour
+	defaultReauthWindow      = 10 * time.Minute
+	anonymizeBatchSize       = 100 // 每轮最多匿名化的用户数
+)
+
+// UserExport 导出的个人数据
+type UserExport struct {
+	ExportedAt time.Time        `json:"exported_at"`
+	Profile    ProfileExport    `json:"profile"`
+	Addresses  []model.Address  `json:"addresses"`
+	Orders     []model.Order    `json:"orders"`
+	Identities []model.Identity `json:"identities"`
+}
+
+// ProfileExport 导出的个人资料
+type ProfileExport struct {
+	ID               uint       `json:"id"`
+	Username         string     `json:"username"`
+	Email            string     `json:"email"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"`
+	Nickname         string     `json:"nickname"`
+	Phone            string     `json:"phone"`
+	AvatarURL        string     `json:"avatar_url"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// PrivacyService 个人数据导出与账号注销业务逻辑层
+// 注销后先软删除，宽限期结束后由后台任务匿名化个人信息，订单保留用于对账
+type PrivacyService struct {
+	tx           repository.Transactor
+	userRepo     repository.UserStore
+	privacyRepo  repository.PrivacyStore
+	addressRepo  repository.AddressStore
+	orderRepo    repository.OrderStore
+	identityRepo repository.IdentityStore
+	sessions     *SessionService
+	twoFactor    *TwoFactorService
+	events       repository.SecurityEventStore
+	limiter      *rateLimiter
+	cfg          config.PrivacyConfig
+}
+
+// NewPrivacyService 创建个人数据服务实例
+func NewPrivacyService(tx repository.Transactor, userRepo repository.UserStore, privacyRepo repository.PrivacyStore,
+	addressRepo repository.AddressStore, orderRepo repository.OrderStore,
+	identityRepo repository.IdentityStore, sessions *SessionService, twoFactor *TwoFactorService,
+	events repository.SecurityEventStore, c cache.Cache, cfg config.PrivacyConfig) *PrivacyService {
+	if cfg.DeletionGrace <= 0 {
+		cfg.DeletionGrace = defaultDeletionGrace
+	}
+	if cfg.AnonymizeInterval <= 0 {
+		cfg.AnonymizeInterval = defaultAnonymizeInterval
+	}
+	if cfg.ReauthWindow <= 0 {
+		cfg.ReauthWindow = defaultReauthWindow
+	}
+
+	return &PrivacyService{
+		tx:           tx,
+		userRepo:     userRepo,
+		privacyRepo:  privacyRepo,
+		addressRepo:  addressRepo,
+		orderRepo:    orderRepo,
+		identityRepo: identityRepo,
+		sessions:     sessions,
+		twoFactor:    twoFactor,
+		events:       events,
+		limiter:      newRateLimiter(c),
+		cfg:          cfg,
+	}
+}
+
+// Export 导出用户的个人资料、收货地址、订单和关联的第三方账号
+func (s *PrivacyService) Export(ctx context.Context, userID uint) (*UserExport, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	addresses, err := s.addressRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.orderRepo.ListAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := s.identityRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &UserExport{
+		ExportedAt: time.Now(),
+		Profile: ProfileExport{
+			ID:               user.ID,
+			Username:         user.Username,
+			Email:            user.Email,
+			EmailVerifiedAt:  user.EmailVerifiedAt,
+			Nickname:         user.Nickname,
+			Phone:            user.Phone,
+			AvatarURL:        user.AvatarURL,
+			TwoFactorEnabled: user.TwoFactorEnabled,
+			CreatedAt:        user.CreatedAt,
+		},
+		Addresses:  addresses,
+		Orders:     orders,
+		Identities: identities,
+	}
+	if export.Addresses == nil {
+		export.Addresses = []model.Address{}
+	}
+	if export.Orders == nil {
+		export.Orders = []model.Order{}
+	}
+	if export.Identities == nil {
+		export.Identities = []model.Identity{}
+	}
+	return export, nil
+}
+
+// DeleteAccount 注销账号
+// 需要重新认证：校验密码、两步验证码，或当前会话在ReauthWindow内刚完成登录（第三方登录以提供方的auth_time为准）；
+// 每个用户15分钟内最多尝试5次；在同一事务中吊销全部会话和API Key并软删除用户
+func (s *PrivacyService) DeleteAccount(ctx context.Context, userID, sessionID uint, password, code string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if !s.limiter.Allow(fmt.Sprintf("delete_account:%d", user.ID), 5, 15*time.Minute) {
+		return ErrRateLimited
+	}
+	if err := s.reauthenticate(user, sessionID, password, code); err != nil {
+		return err
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.sessions.RevokeAll(ctx, user.ID); err != nil {
+			return err
+		}
+		return s.privacyRepo.SoftDeleteUser(ctx, user.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	event := &model.SecurityEvent{Type: model.SecurityEventAccountDeleted, UserID: user.ID, Username: user.Username}
+	if err := s.events.Create(ctx, event); err != nil {
+		log.Printf("记录安全事件失败: %v", err)
+	}
+	return nil
+}
+
+// reauthenticate 校验注销前的重新认证
+func (s *PrivacyService) reauthenticate(user *model.User, sessionID uint, password, code string) error {
+	switch {
+	case password != "":
+		if !utils.CheckPassword(password, user.Password) {
+			return ErrWrongPassword
+		}
+	case code != "":
+		if !user.TwoFactorEnabled || !s.twoFactor.checkCode(user, code) {
+			return ErrInvalidTwoFactorCode
+		}
+	default:
+		at, ok := s.sessions.AuthenticatedAt(sessionID)
+		if sessionID == 0 || !ok || time.Since(at) > s.cfg.ReauthWindow {
+			return ErrReauthRequired
+		}
+	}
+	return nil
+}
+
+// AnonymizeExpired 匿名化宽限期已结束的注销用户，返回处理的数量
+func (s *PrivacyService) AnonymizeExpired(ctx context.Context) (int, error) {
+	users, err := s.privacyRepo.ListPendingAnonymization(ctx, time.Now().Add(-s.cfg.DeletionGrace), anonymizeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, user := range users {
+		suffix, err := utils.RandomToken(9)
+		if err != nil {
+			return count, err
+		}
+		if err := s.privacyRepo.Anonymize(ctx, user.ID, "deleted_"+suffix); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// RunAnonymizer 按配置的间隔执行匿名化任务，直到ctx结束
+func (s *PrivacyService) RunAnonymizer(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.AnonymizeInterval)
+	defer ticker.Stop()
+
+	for {
+		if count, err := s.AnonymizeExpired(ctx); err != nil {
+			log.Printf("匿名化注销用户失败: %v", err)
+		} else if count > 0 {
+			log.Printf("已匿名化%d个注销用户", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
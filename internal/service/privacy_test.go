@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"myshop/internal/config"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/pkg/cache"
+	"myshop/pkg/money"
+	"myshop/pkg/utils"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// privacyTestEnv 个人数据测试环境
+// 注销和匿名化涉及多张表，使用真实的sqlite仓储
+type privacyTestEnv struct {
+	svc      *PrivacyService
+	tokens   *TokenService
+	sessions *SessionService
+	users    *repository.UserRepository
+	db       *gorm.DB
+}
+
+func newPrivacyTestEnv(t *testing.T) *privacyTestEnv {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.User{}, &model.Address{}, &model.Order{}, &model.OrderItem{},
+		&model.OrderDiscount{}, &model.Identity{}, &model.Session{}, &model.RefreshToken{},
+		&model.SecurityEvent{}, &model.APIKey{}, &model.RecoveryCode{}, &model.UserToken{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	key, err := utils.GenerateEd25519Key("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring := utils.NewKeyRing()
+	ring.Add(key)
+	ring.Use(key.ID)
+	utils.InitJWT(utils.JWTOptions{KeyRing: ring})
+
+	memCache := cache.NewMemoryCache(cache.MemoryOptions{})
+	t.Cleanup(func() { memCache.Close() })
+
+	users := repository.NewUserRepository(db)
+	events := repository.NewSecurityEventRepository(db)
+	refreshTokens := repository.NewRefreshTokenRepository(db)
+	sessions := NewSessionService(repository.NewSessionRepository(db), refreshTokens, events, memCache)
+	tokens := NewTokenService(users, refreshTokens, sessions, events, utils.NewTokenDenylist(memCache), 0)
+	guard := NewLoginGuard(memCache, events, config.LoginSecurityConfig{})
+	twoFactor := NewTwoFactorService(users, repository.NewRecoveryCodeRepository(db), memCache, tokens, guard, config.TwoFactorConfig{})
+
+	svc := NewPrivacyService(repository.NewTxManager(db), users, repository.NewPrivacyRepository(db), repository.NewAddressRepository(db),
+		repository.NewOrderRepository(db), repository.NewIdentityRepository(db), sessions, twoFactor, events,
+		memCache, config.PrivacyConfig{DeletionGrace: time.Hour})
+	return &privacyTestEnv{svc: svc, tokens: tokens, sessions: sessions, users: users, db: db}
+}
+
+// createUser 创建一个带收货地址、订单和第三方身份的用户
+func (e *privacyTestEnv) createUser(t *testing.T, username string) *model.User {
+	t.Helper()
+
+	hashed, err := utils.HashPassword("password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{Username: username, Password: hashed, Email: username + "@example.com", Role: model.RoleUser}
+	if err := e.users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+
+	ship := model.ShippingAddress{Name: "张三", Phone: "13800138000", Province: "广东省", City: "深圳市", Detail: "科技园"}
+	records := []interface{}{
+		&model.Address{UserID: user.ID, ShippingAddress: ship},
+		&model.Order{UserID: user.ID, OrderNo: "NO-" + username, ShippingAddress: ship,
+			TotalPrice: money.MustParse("100", "CNY"), DiscountTotal: money.Zero("CNY"),
+			PayCurrency: "CNY", PayTotal: money.MustParse("100", "CNY")},
+		&model.Identity{UserID: user.ID, Provider: "fake", Subject: username + "-sub", LastLoginAt: time.Now()},
+		&model.APIKey{UserID: user.ID, Name: "ci", Prefix: username, KeyHash: utils.HashToken(username)},
+	}
+	for _, r := range records {
+		if err := e.db.Create(r).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return user
+}
+
+// login 以指定的认证时间登录，返回会话ID
+func (e *privacyTestEnv) login(t *testing.T, user *model.User, authTime time.Time) uint {
+	t.Helper()
+
+	pair, err := e.tokens.Issue(context.Background(), user, false, authTime, ClientInfo{IP: "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := utils.ValidateToken(pair.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims.SessionID
+}
+
+func TestPrivacyExport(t *testing.T) {
+	env := newPrivacyTestEnv(t)
+	alice := env.createUser(t, "alice")
+	env.createUser(t, "bob")
+
+	export, err := env.svc.Export(context.Background(), alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.Profile.ID != alice.ID || export.Profile.Email != "alice@example.com" {
+		t.Errorf("个人资料 = %+v", export.Profile)
+	}
+	if len(export.Addresses) != 1 || len(export.Orders) != 1 || len(export.Identities) != 1 {
+		t.Errorf("导出的地址%d个、订单%d个、第三方身份%d个, 期望各1个",
+			len(export.Addresses), len(export.Orders), len(export.Identities))
+	}
+	if export.Orders[0].OrderNo != "NO-alice" {
+		t.Errorf("导出了其他用户的订单: %s", export.Orders[0].OrderNo)
+	}
+
+	if _, err := env.svc.Export(context.Background(), 404); err != ErrUserNotFound {
+		t.Errorf("不存在的用户: err = %v, 期望 %v", err, ErrUserNotFound)
+	}
+}
+
+func TestPrivacyDeleteAccountRequiresReauth(t *testing.T) {
+	ctx := context.Background()
+	env := newPrivacyTestEnv(t)
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		twoFA    bool
+		authTime time.Time
+		password string
+		code     string
+		want     error
+	}{
+		{name: "密码正确", authTime: time.Now().Add(-time.Hour), password: "password123"},
+		{name: "密码错误", authTime: time.Now(), password: "wrong", want: ErrWrongPassword},
+		{name: "两步验证码正确", twoFA: true, authTime: time.Now().Add(-time.Hour), code: code},
+		{name: "未启用两步验证时不接受验证码", authTime: time.Now().Add(-time.Hour), code: code, want: ErrInvalidTwoFactorCode},
+		{name: "刚登录过", authTime: time.Now().Add(-time.Minute)},
+		{name: "登录已超过重新认证时限", authTime: time.Now().Add(-30 * time.Minute), want: ErrReauthRequired},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := env.createUser(t, fmt.Sprintf("user%d", i))
+			if tt.twoFA {
+				env.db.Model(user).Updates(map[string]interface{}{"totp_secret": secret, "two_factor_enabled": true})
+			}
+			sessionID := env.login(t, user, tt.authTime)
+
+			err := env.svc.DeleteAccount(ctx, user.ID, sessionID, tt.password, tt.code)
+			if err != tt.want {
+				t.Fatalf("err = %v, 期望 %v", err, tt.want)
+			}
+
+			_, lookupErr := env.users.GetByID(ctx, user.ID)
+			if deleted := lookupErr != nil; deleted != (tt.want == nil) {
+				t.Fatalf("账号已注销 = %v", deleted)
+			}
+			if tt.want != nil {
+				return
+			}
+			if env.sessions.IsSessionActive(ctx, sessionID) {
+				t.Error("注销后会话仍然有效")
+			}
+			var active int64
+			env.db.Model(&model.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&active)
+			if active != 0 {
+				t.Errorf("注销后仍有%d个API Key有效", active)
+			}
+		})
+	}
+}
+
+func TestPrivacyDeleteAccountWithoutSession(t *testing.T) {
+	env := newPrivacyTestEnv(t)
+	user := env.createUser(t, "carol")
+
+	// API Key认证没有登录会话，只能通过密码或验证码重新认证
+	if err := env.svc.DeleteAccount(context.Background(), user.ID, 0, "", ""); err != ErrReauthRequired {
+		t.Fatalf("err = %v, 期望 %v", err, ErrReauthRequired)
+	}
+}
+
+func TestPrivacyAnonymizeExpired(t *testing.T) {
+	ctx := context.Background()
+	env := newPrivacyTestEnv(t)
+	expired := env.createUser(t, "dave")
+	recent := env.createUser(t, "erin")
+	kept := env.createUser(t, "frank")
+
+	for _, u := range []*model.User{expired, recent} {
+		if err := env.svc.DeleteAccount(ctx, u.ID, env.login(t, u, time.Now()), "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 把dave的注销时间移到宽限期之前
+	env.db.Unscoped().Model(&model.User{}).Where("id = ?", expired.ID).
+		Update("deleted_at", time.Now().Add(-2*time.Hour))
+
+	count, err := env.svc.AnonymizeExpired(ctx)
+	if err != nil || count != 1 {
+		t.Fatalf("AnonymizeExpired = %d, %v, 期望匿名化1个用户", count, err)
+	}
+
+	var got model.User
+	env.db.Unscoped().First(&got, expired.ID)
+	if got.AnonymizedAt == nil || got.Email != "" || got.Password != "" || got.Username == "dave" {
+		t.Errorf("个人信息未清除: %+v", got)
+	}
+	var order model.Order
+	if err := env.db.Unscoped().Where("user_id = ?", expired.ID).First(&order).Error; err != nil {
+		t.Fatalf("匿名化后订单应保留用于对账: %v", err)
+	}
+	if order.ShippingAddress.Name != "" || order.ShippingAddress.Phone != "" || order.ShippingAddress.Detail != "" {
+		t.Errorf("订单中的收件人信息未清除: %+v", order.ShippingAddress)
+	}
+	for _, m := range []interface{}{&model.Address{}, &model.Identity{}, &model.Session{}, &model.APIKey{}} {
+		var n int64
+		env.db.Unscoped().Model(m).Where("user_id = ?", expired.ID).Count(&n)
+		if n != 0 {
+			t.Errorf("%T 仍有%d条记录", m, n)
+		}
+	}
+
+	for _, u := range []*model.User{recent, kept} {
+		var other model.User
+		env.db.Unscoped().First(&other, u.ID)
+		if other.AnonymizedAt != nil || other.Username != u.Username {
+			t.Errorf("%s不应被匿名化", u.Username)
+		}
+	}
+	if count, _ := env.svc.AnonymizeExpired(ctx); count != 0 {
+		t.Errorf("重复执行又匿名化了%d个用户", count)
+	}
+}
+
+func TestPrivacyRunAnonymizerStopsWithContext(t *testing.T) {
+	env := newPrivacyTestEnv(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		env.svc.RunAnonymizer(ctx)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ctx取消后匿名化任务未退出")
+	}
+}
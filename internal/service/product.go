@@ -1,41 +1,99 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"myshop/internal/model"
 	"myshop/internal/repository"
+	"myshop/pkg/money"
+
+	"gorm.io/gorm"
 )
 
 // ProductService 商品业务逻辑层
 type ProductService struct {
-	repo *repository.ProductRepository // 商品仓储
+	tx    repository.Transactor   // 事务管理，商品变更与审计日志在同一事务中写入
+	repo  repository.ProductStore // 商品仓储
+	audit *AuditService           // 审计日志
 }
 
 // NewProductService 创建商品服务实例
-func NewProductService(repo *repository.ProductRepository) *ProductService {
-	return &ProductService{repo: repo}
+func NewProductService(tx repository.Transactor, repo repository.ProductStore, audit *AuditService) *ProductService {
+	return &ProductService{tx: tx, repo: repo, audit: audit}
 }
 
 // Create 创建新商品
-func (s *ProductService) Create(product *model.Product) error {
-	return s.repo.Create(product)
+func (s *ProductService) Create(ctx context.Context, product *model.Product) error {
+	if err := validatePrice(product.Price); err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, product); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditActionCreate, model.AuditResourceProduct, product.ID, nil, product)
+	})
 }
 
-// GetByID 根据ID获取商品
+// GetByID 根据ID获取商品，不存在时返回ErrProductNotFound
+// 查询结果会被缓存并由多个请求共享，因此不加入调用方的事务，只读取已提交的数据
 func (s *ProductService) GetByID(id uint) (*model.Product, error) {
-	return s.repo.GetByID(id)
+	product, err := s.repo.GetByID(context.Background(), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProductNotFound
+	}
+	return product, err
 }
 
 // Update 更新商品信息
-func (s *ProductService) Update(product *model.Product) error {
-	return s.repo.Update(product)
+func (s *ProductService) Update(ctx context.Context, product *model.Product) error {
+	if err := validatePrice(product.Price); err != nil {
+		return err
+	}
+	before, err := s.repo.GetByID(ctx, product.ID)
+	if err != nil {
+		return ErrProductNotFound
+	}
+	product.CreatedAt = before.CreatedAt
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, product); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditActionUpdate, model.AuditResourceProduct, product.ID, before, product)
+	})
 }
 
 // Delete 删除商品
-func (s *ProductService) Delete(id uint) error {
-	return s.repo.Delete(id)
+func (s *ProductService) Delete(ctx context.Context, id uint) error {
+	before, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return ErrProductNotFound
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditActionDelete, model.AuditResourceProduct, id, before, nil)
+	})
+}
+
+// maxAmount 商品价格和订单金额必须小于该值，与decimal(10,2)列可保存的整数部分一致
+const maxAmount = "100000000"
+
+// exceedsMaxAmount 判断金额是否超出数据库金额列的范围
+func exceedsMaxAmount(a money.Amount) bool {
+	return a.Cmp(money.MustParse(maxAmount, a.Currency())) >= 0
+}
+
+// validatePrice 商品以本位币定价，价格必须大于0且小于maxAmount
+func validatePrice(price money.Amount) error {
+	if !price.IsPositive() || price.Currency() != money.DefaultCurrency() || exceedsMaxAmount(price) {
+		return ErrInvalidPrice
+	}
+	return nil
 }
 
-// List 获取商品列表
+// List 获取商品列表，与GetByID相同不加入调用方的事务
 func (s *ProductService) List(page, pageSize int) ([]model.Product, int64, error) {
 	// 参数验证
 	if page < 1 {
@@ -45,5 +103,5 @@ func (s *ProductService) List(page, pageSize int) ([]model.Product, int64, error
 		pageSize = 10
 	}
 
-	return s.repo.List(page, pageSize)
+	return s.repo.List(context.Background(), page, pageSize)
 }
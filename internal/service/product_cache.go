@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"myshop/internal/model"
+	"myshop/pkg/cache"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	productCacheTTL    = 5 * time.Minute  // 商品详情缓存时间
+	productListTTL     = time.Minute      // 商品列表缓存时间
+	productNotFoundTTL = 30 * time.Second // 不存在的商品ID的缓存时间，抵御对不存在ID的扫描
+	productListVersion = "product:list:version"
+)
+
+// cachedProduct 缓存中的商品详情，Found为false表示商品不存在
+type cachedProduct struct {
+	Product *model.Product
+	Found   bool
+}
+
+// cachedProductPage 缓存中的一页商品列表
+type cachedProductPage struct {
+	Products []model.Product
+	Total    int64
+}
+
+func init() {
+	cache.Register(cachedProduct{})
+	cache.Register(cachedProductPage{})
+}
+
+// CachedProductService 带缓存的商品服务，装饰ProductService
+// 商品详情和列表按读穿透方式缓存，同一个键的并发未命中只查询一次数据库；
+// 商品增删改和库存变化时清除缓存，列表缓存通过版本号整体失效；
+// 详情缓存也带有按商品的版本号，读取数据库期间商品被清除过缓存时不保留读到的旧值
+type CachedProductService struct {
+	*ProductService
+	cache cache.Cache
+	group singleflight.Group
+}
+
+// NewCachedProductService 创建带缓存的商品服务实例
+func NewCachedProductService(products *ProductService, c cache.Cache) *CachedProductService {
+	return &CachedProductService{ProductService: products, cache: c}
+}
+
+// GetByID 根据ID获取商品，不存在时返回ErrProductNotFound
+func (s *CachedProductService) GetByID(id uint) (*model.Product, error) {
+	key := productCacheKey(id)
+	if cached, err := cache.GetAs[cachedProduct](s.cache, key); err == nil {
+		if !cached.Found {
+			return nil, ErrProductNotFound
+		}
+		product := *cached.Product
+		return &product, nil
+	}
+
+	v, err, _ := s.group.Do(key, func() (interface{}, error) {
+		version := s.version(id)
+		product, err := s.ProductService.GetByID(id)
+		if err != nil {
+			// 数据库错误不缓存，只有确认不存在时才缓存空结果
+			if err != ErrProductNotFound {
+				return nil, err
+			}
+			s.setDetail(id, version, cachedProduct{}, productNotFoundTTL)
+			return cachedProduct{}, nil
+		}
+		entry := cachedProduct{Product: product, Found: true}
+		s.setDetail(id, version, entry, productCacheTTL)
+		return entry, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entry := v.(cachedProduct)
+	if !entry.Found {
+		return nil, ErrProductNotFound
+	}
+	// 调用方各自拿到副本，避免修改缓存中或其他调用方共享的对象
+	product := *entry.Product
+	return &product, nil
+}
+
+// List 获取商品列表
+func (s *CachedProductService) List(page, pageSize int) ([]model.Product, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	version, _ := cache.GetAs[int64](s.cache, productListVersion)
+	key := fmt.Sprintf("product:list:%d:%d:%d", version, page, pageSize)
+	if cached, err := cache.GetAs[cachedProductPage](s.cache, key); err == nil {
+		return append([]model.Product(nil), cached.Products...), cached.Total, nil
+	}
+
+	v, err, _ := s.group.Do(key, func() (interface{}, error) {
+		products, total, err := s.ProductService.List(page, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		entry := cachedProductPage{Products: products, Total: total}
+		s.set(key, entry, productListTTL)
+		return entry, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entry := v.(cachedProductPage)
+	return append([]model.Product(nil), entry.Products...), entry.Total, nil
+}
+
+// Create 创建新商品并使列表缓存失效
+func (s *CachedProductService) Create(ctx context.Context, product *model.Product) error {
+	if err := s.ProductService.Create(ctx, product); err != nil {
+		return err
+	}
+	// 新ID可能已作为不存在的商品被缓存
+	s.Invalidate(product.ID)
+	return nil
+}
+
+// Update 更新商品信息并清除缓存
+func (s *CachedProductService) Update(ctx context.Context, product *model.Product) error {
+	if err := s.ProductService.Update(ctx, product); err != nil {
+		return err
+	}
+	s.Invalidate(product.ID)
+	return nil
+}
+
+// Delete 删除商品并清除缓存
+func (s *CachedProductService) Delete(ctx context.Context, id uint) error {
+	if err := s.ProductService.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.Invalidate(id)
+	return nil
+}
+
+// Invalidate 清除指定商品的详情缓存和全部列表缓存，库存变化后也需要调用
+// 先更新版本号再删除缓存，与setDetail配合保证正在进行的读取不会留下旧值
+func (s *CachedProductService) Invalidate(ids ...uint) {
+	for _, id := range ids {
+		// 详情版本号只需要比一次数据库读取存活更久
+		s.bumpVersion(productVersionKey(id), productCacheTTL)
+		if err := s.cache.Delete(productCacheKey(id)); err != nil {
+			log.Printf("清除商品缓存失败: %v", err)
+		}
+	}
+	s.bumpVersion(productListVersion, 0)
+}
+
+// version 返回商品详情缓存的版本号，没有版本号时为0
+func (s *CachedProductService) version(id uint) int64 {
+	version, _ := cache.GetAs[int64](s.cache, productVersionKey(id))
+	return version
+}
+
+// bumpVersion 更新版本号，使用递增的时间戳，版本号过期或被淘汰后也不会与之前的值重复
+// 列表缓存的版本号写在缓存键中，旧版本的列表缓存不再被读取，过期后自动清除
+func (s *CachedProductService) bumpVersion(key string, ttl time.Duration) {
+	version, _ := cache.GetAs[int64](s.cache, key)
+	next := time.Now().UnixNano()
+	if next <= version {
+		next = version + 1
+	}
+	if err := s.cache.Set(key, next, ttl); err != nil {
+		log.Printf("更新商品缓存版本失败: %v", err)
+	}
+}
+
+// setDetail 写入商品详情缓存，写入后版本号已变化说明读取期间商品被修改过，删除刚写入的旧值
+func (s *CachedProductService) setDetail(id uint, version int64, entry cachedProduct, ttl time.Duration) {
+	key := productCacheKey(id)
+	s.set(key, entry, ttl)
+	if s.version(id) != version {
+		if err := s.cache.Delete(key); err != nil {
+			log.Printf("清除商品缓存失败: %v", err)
+		}
+	}
+}
+
+// set 写入缓存，缓存不可用时只记录日志，读取仍然以数据库为准
+func (s *CachedProductService) set(key string, value interface{}, ttl time.Duration) {
+	if err := s.cache.Set(key, value, ttl); err != nil {
+		log.Printf("写入商品缓存失败: %v", err)
+	}
+}
+
+func productCacheKey(id uint) string {
+	return "product:" + strconv.FormatUint(uint64(id), 10)
+}
+
+func productVersionKey(id uint) string {
+	return "product:version:" + strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/pkg/cache"
+	"myshop/pkg/money"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newProductCacheTestEnv 创建带缓存的商品服务，返回查询商品表的次数计数器和数据库连接
+// 每次查询额外等待一段时间，使并发请求的未命中能够重叠
+func newProductCacheTestEnv(t *testing.T) (*CachedProductService, *int64, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.Product{}, &model.AuditLog{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	var queries int64
+	db.Callback().Query().Before("gorm:query").Register("test:count", func(tx *gorm.DB) {
+		if tx.Statement.Table == "products" {
+			atomic.AddInt64(&queries, 1)
+			time.Sleep(20 * time.Millisecond)
+		}
+	})
+
+	memCache := cache.NewMemoryCache(cache.MemoryOptions{})
+	t.Cleanup(func() { memCache.Close() })
+	audit := NewAuditService(repository.NewAuditLogRepository(db))
+	products := NewProductService(repository.NewTxManager(db), repository.NewProductRepository(db), audit)
+	return NewCachedProductService(products, memCache), &queries, db
+}
+
+func TestCachedProductSingleFlight(t *testing.T) {
+	svc, queries, _ := newProductCacheTestEnv(t)
+	ctx := context.Background()
+	product := &model.Product{Name: "iPhone", Price: money.MustParse("6999", "CNY"), Stock: 10}
+	if err := svc.Create(ctx, product); err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt64(queries, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := svc.GetByID(product.ID)
+			if err != nil || got.Name != "iPhone" {
+				t.Errorf("GetByID = %v, %v", got, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt64(queries); n != 1 {
+		t.Fatalf("并发未命中应只查询一次数据库, 实际%d次", n)
+	}
+	if _, err := svc.GetByID(product.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt64(queries); n != 1 {
+		t.Fatalf("命中缓存时不应查询数据库, 实际%d次", n)
+	}
+}
+
+func TestCachedProductNegativeLookup(t *testing.T) {
+	svc, queries, _ := newProductCacheTestEnv(t)
+
+	for i := 0; i < 3; i++ {
+		if _, err := svc.GetByID(404); err != ErrProductNotFound {
+			t.Fatalf("err = %v", err)
+		}
+	}
+	if n := atomic.LoadInt64(queries); n != 1 {
+		t.Fatalf("不存在的商品应缓存空结果, 实际查询%d次", n)
+	}
+}
+
+func TestCachedProductInvalidation(t *testing.T) {
+	svc, _, _ := newProductCacheTestEnv(t)
+	ctx := context.Background()
+	product := &model.Product{Name: "iPhone", Price: money.MustParse("6999", "CNY"), Stock: 10}
+	if err := svc.Create(ctx, product); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, total, _ := svc.List(1, 10); total != 1 {
+		t.Fatalf("total = %d", total)
+	}
+	if _, err := svc.GetByID(product.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	updated := *product
+	updated.Price = money.MustParse("5999", "CNY")
+	if err := svc.Update(ctx, &updated); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := svc.GetByID(product.ID); got.Price.String() != "5999.00" {
+		t.Fatalf("更新后读到旧价格: %v", got.Price)
+	}
+	if list, _, _ := svc.List(1, 10); list[0].Price.String() != "5999.00" {
+		t.Fatalf("更新后列表读到旧价格: %v", list[0].Price)
+	}
+
+	if err := svc.Create(ctx, &model.Product{Name: "iPad", Price: money.MustParse("3999", "CNY")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := svc.List(1, 10); total != 2 {
+		t.Fatalf("新增后列表未失效, total = %d", total)
+	}
+
+	if err := svc.Delete(ctx, product.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetByID(product.ID); err != ErrProductNotFound {
+		t.Fatalf("删除后仍能读到商品, err = %v", err)
+	}
+}
+
+// TestCachedProductLoadRacesInvalidate 读取数据库后、写入缓存前商品被修改，不能把读到的旧值留在缓存中
+func TestCachedProductLoadRacesInvalidate(t *testing.T) {
+	svc, _, db := newProductCacheTestEnv(t)
+	ctx := context.Background()
+	product := &model.Product{Name: "iPhone", Price: money.MustParse("6999", "CNY"), Stock: 10}
+	if err := svc.Create(ctx, product); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一次查询商品表后暂停，等待商品被修改
+	loaded, resume := make(chan struct{}), make(chan struct{})
+	var paused int32
+	db.Callback().Query().After("gorm:query").Register("test:pause", func(tx *gorm.DB) {
+		if tx.Statement.Table == "products" && atomic.CompareAndSwapInt32(&paused, 0, 1) {
+			close(loaded)
+			<-resume
+		}
+	})
+
+	done := make(chan *model.Product)
+	go func() {
+		got, err := svc.GetByID(product.ID)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- got
+	}()
+	<-loaded
+
+	updated := *product
+	updated.Price = money.MustParse("5999", "CNY")
+	if err := svc.Update(ctx, &updated); err != nil {
+		t.Fatal(err)
+	}
+	close(resume)
+	if stale := <-done; stale.Price.String() != "6999.00" {
+		t.Fatalf("暂停的读取应返回修改前的值: %v", stale.Price)
+	}
+
+	if got, _ := svc.GetByID(product.ID); got.Price.String() != "5999.00" {
+		t.Fatalf("缓存中留下了旧价格: %v", got.Price)
+	}
+}
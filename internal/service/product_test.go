@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"myshop/internal/model"
+	"myshop/internal/repository/repotest"
+	"myshop/pkg/money"
+	"testing"
+)
+
+func TestProductServiceAudit(t *testing.T) {
+	audits := repotest.NewAuditLogRepository()
+	svc := NewProductService(repotest.NewTxManager(), repotest.NewProductRepository(), NewAuditService(audits))
+	ctx := WithActor(context.Background(), Actor{UserID: 1, Role: model.RoleAdmin})
+
+	product := &model.Product{Name: "iPhone", Price: money.MustParse("5999", "CNY"), Stock: 10, Status: 1}
+	if err := svc.Create(ctx, product); err != nil {
+		t.Fatal(err)
+	}
+	updated := *product
+	updated.Price = money.MustParse("4999", "CNY")
+	if err := svc.Update(ctx, &updated); err != nil {
+		t.Fatal(err)
+	}
+	// 内容未变化的修改不记录审计日志
+	if err := svc.Update(ctx, &updated); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Delete(ctx, product.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	logs, _ := audits.ListByResourceType(context.Background(), model.AuditResourceProduct)
+	wantActions := []string{model.AuditActionCreate, model.AuditActionUpdate, model.AuditActionDelete}
+	if len(logs) != len(wantActions) {
+		t.Fatalf("got %d audit logs, want %d", len(logs), len(wantActions))
+	}
+	for i, action := range wantActions {
+		if logs[i].Action != action || logs[i].ActorID != 1 {
+			t.Fatalf("log %d = %+v, want action %s", i, logs[i], action)
+		}
+	}
+	if change := logs[1].Changes["price"]; change.Before != "5999.00" || change.After != "4999.00" {
+		t.Fatalf("price change = %+v", change)
+	}
+}
+
+func TestProductServiceNotFound(t *testing.T) {
+	svc := NewProductService(repotest.NewTxManager(), repotest.NewProductRepository(), NewAuditService(repotest.NewAuditLogRepository()))
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"查询", func() error { _, err := svc.GetByID(42); return err }},
+		{"修改", func() error {
+			return svc.Update(ctx, &model.Product{ID: 42, Name: "x", Price: money.MustParse("1", "CNY")})
+		}},
+		{"删除", func() error { return svc.Delete(ctx, 42) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, ErrProductNotFound) {
+				t.Fatalf("err = %v, want %v", err, ErrProductNotFound)
+			}
+		})
+	}
+}
+
+func TestProductServiceRejectsInvalidPrice(t *testing.T) {
+	svc := NewProductService(repotest.NewTxManager(), repotest.NewProductRepository(), NewAuditService(repotest.NewAuditLogRepository()))
+	for _, price := range []money.Amount{{}, money.MustParse("-1", "CNY"), money.MustParse("1", "USD"), money.MustParse("100000000", "CNY")} {
+		err := svc.Create(context.Background(), &model.Product{Name: "iPhone", Price: price})
+		if !errors.Is(err, ErrInvalidPrice) {
+			t.Fatalf("price %s: err = %v, want %v", price.Display(), err, ErrInvalidPrice)
+		}
+	}
+}
+
+// failingAuditLogs 写入总是失败的审计日志仓储
+type failingAuditLogs struct {
+	*repotest.AuditLogRepository
+}
+
+func (failingAuditLogs) Create(context.Context, *model.AuditLog) error {
+	return errors.New("audit store down")
+}
+
+func TestProductServiceRollsBackWhenAuditFails(t *testing.T) {
+	products := repotest.NewProductRepository()
+	svc := NewProductService(repotest.NewTxManager(products), products,
+		NewAuditService(failingAuditLogs{repotest.NewAuditLogRepository()}))
+
+	product := &model.Product{Name: "iPhone", Price: money.MustParse("5999", "CNY"), Stock: 10, Status: 1}
+	if err := svc.Create(context.Background(), product); err == nil {
+		t.Fatal("审计日志写入失败时创建商品应返回错误")
+	}
+	if list, _, _ := products.List(context.Background(), 1, 10); len(list) != 0 {
+		t.Fatalf("审计日志写入失败后商品未回滚: %+v", list)
+	}
+}
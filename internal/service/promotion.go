@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/pkg/money"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxPromotionGroup 买赠每组最多的件数
+const maxPromotionGroup = 100
+
+// PromotionService 自动促销业务逻辑层
+type PromotionService struct {
+	tx    repository.Transactor
+	repo  repository.PromotionStore
+	audit *AuditService
+}
+
+// NewPromotionService 创建促销服务实例
+func NewPromotionService(tx repository.Transactor, repo repository.PromotionStore, audit *AuditService) *PromotionService {
+	return &PromotionService{tx: tx, repo: repo, audit: audit}
+}
+
+// Create 创建促销
+func (s *PromotionService) Create(ctx context.Context, promotion *model.Promotion) error {
+	if err := validatePromotion(promotion); err != nil {
+		return err
+	}
+	promotion.ID = 0
+	for i := range promotion.Items {
+		promotion.Items[i].ID = 0
+	}
+	for i := range promotion.Tiers {
+		promotion.Tiers[i].ID = 0
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, promotion); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditActionCreate, model.AuditResourcePromotion, promotion.ID, nil, promotion)
+	})
+}
+
+// GetByID 根据ID获取促销，不存在时返回ErrPromotionNotFound
+func (s *PromotionService) GetByID(ctx context.Context, id uint) (*model.Promotion, error) {
+	promotion, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPromotionNotFound
+	}
+	return promotion, err
+}
+
+// List 获取促销列表
+func (s *PromotionService) List(ctx context.Context, page, pageSize int) ([]model.Promotion, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	return s.repo.List(ctx, page, pageSize)
+}
+
+// Update 修改促销，已下单的订单保存了优惠明细，不受影响
+func (s *PromotionService) Update(ctx context.Context, promotion *model.Promotion) error {
+	before, err := s.GetByID(ctx, promotion.ID)
+	if err != nil {
+		return err
+	}
+	promotion.CreatedAt = before.CreatedAt
+	if err := validatePromotion(promotion); err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, promotion); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditActionUpdate, model.AuditResourcePromotion, promotion.ID, before, promotion)
+	})
+}
+
+// Delete 删除促销
+func (s *PromotionService) Delete(ctx context.Context, id uint) error {
+	before, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditActionDelete, model.AuditResourcePromotion, id, before, nil)
+	})
+}
+
+// validatePromotion 校验促销的类型、参数、有效期和促销商品，满减档位按门槛从低到高排序
+func validatePromotion(p *model.Promotion) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return fmt.Errorf("%w: 名称不能为空", ErrInvalidPromotion)
+	}
+	amounts := []money.Amount{p.BundlePrice}
+	for _, tier := range p.Tiers {
+		amounts = append(amounts, tier.Threshold, tier.AmountOff)
+	}
+	for _, a := range amounts {
+		if a.IsNegative() || a.Currency() != money.DefaultCurrency() {
+			return fmt.Errorf("%w: 金额不能为负数且必须使用本位币", ErrInvalidPromotion)
+		}
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return fmt.Errorf("%w: 失效时间必须晚于生效时间", ErrInvalidPromotion)
+	}
+
+	switch p.Type {
+	case model.PromotionTypeBuyXGetY:
+		if p.BuyQuantity < 1 || p.FreeQuantity < 1 || p.BuyQuantity+p.FreeQuantity > maxPromotionGroup {
+			return fmt.Errorf("%w: 买赠的购买件数和免费件数至少为1，合计不超过%d", ErrInvalidPromotion, maxPromotionGroup)
+		}
+		if len(p.Tiers) > 0 || !p.BundlePrice.IsZero() {
+			return fmt.Errorf("%w: 买赠不能设置满减档位或组合价", ErrInvalidPromotion)
+		}
+		return validatePromotionScope(p.Items)
+	case model.PromotionTypeTiered:
+		if len(p.Tiers) == 0 || p.BuyQuantity != 0 || p.FreeQuantity != 0 || !p.BundlePrice.IsZero() {
+			return fmt.Errorf("%w: 满减需设置档位，不能设置买赠件数或组合价", ErrInvalidPromotion)
+		}
+		sort.SliceStable(p.Tiers, func(i, j int) bool { return p.Tiers[i].Threshold.Cmp(p.Tiers[j].Threshold) < 0 })
+		for i, tier := range p.Tiers {
+			if !tier.AmountOff.IsPositive() || tier.AmountOff.Cmp(tier.Threshold) > 0 {
+				return fmt.Errorf("%w: 减免金额必须大于0且不超过门槛金额", ErrInvalidPromotion)
+			}
+			if i > 0 && tier.Threshold.Cmp(p.Tiers[i-1].Threshold) == 0 {
+				return fmt.Errorf("%w: 满减档位的门槛金额不能重复", ErrInvalidPromotion)
+			}
+		}
+		return validatePromotionScope(p.Items)
+	case model.PromotionTypeBundle:
+		if len(p.Items) == 0 || !p.BundlePrice.IsPositive() || len(p.Tiers) > 0 || p.BuyQuantity != 0 || p.FreeQuantity != 0 {
+			return fmt.Errorf("%w: 组合需设置商品和大于0的组合价，不能设置满减档位或买赠件数", ErrInvalidPromotion)
+		}
+		seen := make(map[uint]bool, len(p.Items))
+		for _, item := range p.Items {
+			if item.ProductID == 0 || item.CategoryID != 0 || item.Quantity < 1 || item.Quantity > maxItemQuantity || seen[item.ProductID] {
+				return fmt.Errorf("%w: 组合商品需指定不重复的商品和数量", ErrInvalidPromotion)
+			}
+			seen[item.ProductID] = true
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: 不支持的类型%q", ErrInvalidPromotion, p.Type)
+	}
+}
+
+// validatePromotionScope 校验买赠和满减的适用范围
+func validatePromotionScope(items []model.PromotionItem) error {
+	for _, item := range items {
+		if (item.ProductID == 0) == (item.CategoryID == 0) || item.Quantity != 0 {
+			return fmt.Errorf("%w: 适用范围需指定商品或分类之一，不能设置数量", ErrInvalidPromotion)
+		}
+	}
+	return nil
+}
+
+// Apply 在计价结果上应用now时刻生效的全部促销
+func (s *PromotionService) Apply(ctx context.Context, quote *Quote, now time.Time) error {
+	promotions, err := s.repo.ListEnabled(ctx)
+	if err != nil {
+		return fmt.Errorf("获取促销失败: %w", err)
+	}
+	applyPromotions(quote, promotions, now)
+	return nil
+}
+
+// applyPromotions 依次应用促销，promotions已按优先级从高到低、ID从小到大排序
+// 重叠的促销按以下规则确定结果：
+//  1. 买赠和组合按件计算，参与其中一个的商品件数不再参与其他买赠或组合
+//  2. 满减以适用商品扣除之前优惠后的金额计算门槛
+//  3. 独占促销只在之前没有促销生效时参与，生效后不再计算后续促销
+func applyPromotions(quote *Quote, promotions []model.Promotion, now time.Time) {
+	applied := false
+	for i := range promotions {
+		p := &promotions[i]
+		if !p.ActiveAt(now) || p.Exclusive && applied {
+			continue
+		}
+		discount := model.OrderDiscount{Source: model.DiscountSourcePromotion, SourceID: p.ID, Description: p.Name}
+		var amount money.Amount
+		switch p.Type {
+		case model.PromotionTypeBuyXGetY:
+			amount = applyBuyXGetY(quote, p, discount)
+		case model.PromotionTypeTiered:
+			amount = applyTiered(quote, p, discount)
+		case model.PromotionTypeBundle:
+			amount = applyBundle(quote, p, discount)
+		}
+		if amount.IsPositive() {
+			applied = true
+			if p.Exclusive {
+				return
+			}
+		}
+	}
+}
+
+// applyBuyXGetY 适用商品按单价从高到低排列，每BuyQuantity+FreeQuantity件为一组，每组中最便宜的FreeQuantity件免费
+// 凑不满一组的商品不参与，可以继续参与其他促销
+func applyBuyXGetY(q *Quote, p *model.Promotion, discount model.OrderDiscount) money.Amount {
+	type unit struct {
+		line  int
+		price money.Amount
+	}
+	var units []unit
+	for i, line := range q.Lines {
+		if !p.Applies(line.ProductID, line.CategoryID) {
+			continue
+		}
+		for n := 0; n < line.available(); n++ {
+			units = append(units, unit{line: i, price: line.UnitPrice})
+		}
+	}
+	group := p.BuyQuantity + p.FreeQuantity
+	count := len(units) / group * group
+	if count == 0 {
+		return money.Zero(q.Subtotal.Currency())
+	}
+	sort.SliceStable(units, func(i, j int) bool { return units[i].price.Cmp(units[j].price) > 0 })
+
+	indexes := make([]int, len(q.Lines))
+	parts := make([]money.Amount, len(q.Lines))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	for n, u := range units[:count] {
+		q.Lines[u.line].claimed++
+		if n%group >= p.BuyQuantity {
+			parts[u.line] = parts[u.line].Add(u.price)
+		}
+	}
+	return q.record(discount, indexes, parts)
+}
+
+// applyTiered 按适用商品的剩余金额达到的最高一档减免，减免金额按各行剩余金额分摊
+func applyTiered(q *Quote, p *model.Promotion, discount model.OrderDiscount) money.Amount {
+	match := func(line PriceLine) bool { return p.Applies(line.ProductID, line.CategoryID) }
+	base := q.payable(match)
+	var best *model.PromotionTier
+	for i := range p.Tiers {
+		tier := &p.Tiers[i]
+		if base.Cmp(tier.Threshold) >= 0 && (best == nil || tier.Threshold.Cmp(best.Threshold) > 0) {
+			best = tier
+		}
+	}
+	if best == nil {
+		return money.Zero(q.Subtotal.Currency())
+	}
+	discount.Amount = best.AmountOff
+	return q.apply(discount, match)
+}
+
+// applyBundle 按组合内各商品的可用件数计算能凑成的组数，每组按组合价计算
+// 节省的金额按各商品在组合中的原价分摊；组合价不低于原价时不生效，也不占用商品件数
+func applyBundle(q *Quote, p *model.Promotion, discount model.OrderDiscount) money.Amount {
+	zero := money.Zero(q.Subtotal.Currency())
+	lineOf := make(map[uint]int, len(q.Lines))
+	for i, line := range q.Lines {
+		lineOf[line.ProductID] = i
+	}
+	sets := -1
+	for _, item := range p.Items {
+		i, ok := lineOf[item.ProductID]
+		if !ok {
+			return zero
+		}
+		if n := q.Lines[i].available() / item.Quantity; sets < 0 || n < sets {
+			sets = n
+		}
+	}
+	if sets <= 0 {
+		return zero
+	}
+
+	var list money.Amount
+	indexes := make([]int, len(p.Items))
+	weights := make([]int64, len(p.Items))
+	for k, item := range p.Items {
+		i := lineOf[item.ProductID]
+		amount := q.Lines[i].UnitPrice.Mul(int64(item.Quantity * sets))
+		list = list.Add(amount)
+		indexes[k], weights[k] = i, amount.Minor()
+	}
+	saving := list.Sub(p.BundlePrice.Mul(int64(sets)))
+	if !saving.IsPositive() {
+		return zero
+	}
+	for k, item := range p.Items {
+		q.Lines[indexes[k]].claimed += item.Quantity * sets
+	}
+	return q.record(discount, indexes, saving.Allocate(weights))
+}
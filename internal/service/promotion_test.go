@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"myshop/internal/model"
+	"myshop/internal/repository/repotest"
+	"testing"
+	"time"
+)
+
+func TestApplyPromotions(t *testing.T) {
+	now := time.Now()
+	tomorrow := now.Add(24 * time.Hour)
+	buy2get1 := model.Promotion{ID: 1, Name: "配件买二送一", Type: model.PromotionTypeBuyXGetY, BuyQuantity: 2, FreeQuantity: 1,
+		Items: []model.PromotionItem{{CategoryID: 1}}}
+	buy1get1 := model.Promotion{ID: 2, Name: "配件买一送一", Type: model.PromotionTypeBuyXGetY, BuyQuantity: 1, FreeQuantity: 1,
+		Items: []model.PromotionItem{{CategoryID: 1}}}
+	tiered := model.Promotion{ID: 3, Name: "满500减50，满1000减120", Type: model.PromotionTypeTiered,
+		Tiers: []model.PromotionTier{{Threshold: cny("500"), AmountOff: cny("50")}, {Threshold: cny("1000"), AmountOff: cny("120")}}}
+	accessoryTiered := model.Promotion{ID: 4, Name: "配件满300减30", Type: model.PromotionTypeTiered,
+		Items: []model.PromotionItem{{CategoryID: 1}}, Tiers: []model.PromotionTier{{Threshold: cny("300"), AmountOff: cny("30")}}}
+	bundle := model.Promotion{ID: 5, Name: "手机加保护壳1000元", Type: model.PromotionTypeBundle, BundlePrice: cny("1000"),
+		Items: []model.PromotionItem{{ProductID: 2, Quantity: 1}, {ProductID: 3, Quantity: 1}}}
+	exclusive := tiered
+	exclusive.ID, exclusive.Exclusive = 6, true
+	expensiveBundle := bundle
+	expensiveBundle.ID, expensiveBundle.BundlePrice = 7, cny("1100")
+	future := buy2get1
+	future.ID, future.StartsAt = 8, &tomorrow
+	disabled := tiered
+	disabled.ID, disabled.Disabled = 9, true
+
+	tests := []struct {
+		name       string
+		promotions []model.Promotion // 已按优先级排序
+		want       []string          // 促销ID=优惠金额，按应用顺序
+	}{
+		{name: "买二送一，每组最便宜的一件免费", promotions: []model.Promotion{buy2get1}, want: []string{"1=100.00"}},
+		{name: "满减按达到的最高一档", promotions: []model.Promotion{tiered}, want: []string{"3=120.00"}},
+		{name: "满减按之前优惠后的金额计算门槛", promotions: []model.Promotion{buy2get1, accessoryTiered}, want: []string{"1=100.00"}},
+		{name: "优先级高的先计算", promotions: []model.Promotion{accessoryTiered, buy2get1}, want: []string{"4=30.00", "1=100.00"}},
+		{name: "买赠占用的商品不再参与组合", promotions: []model.Promotion{buy1get1, bundle}, want: []string{"2=130.00"}},
+		{name: "组合占用的商品不再参与买赠", promotions: []model.Promotion{bundle, buy1get1}, want: []string{"5=30.00", "2=100.00"}},
+		{name: "独占促销生效后不再计算其他促销", promotions: []model.Promotion{exclusive, buy2get1}, want: []string{"6=120.00"}},
+		{name: "已有促销生效时独占促销不参与", promotions: []model.Promotion{buy2get1, exclusive}, want: []string{"1=100.00"}},
+		{name: "组合价不低于原价时不生效", promotions: []model.Promotion{expensiveBundle}},
+		{name: "未生效或停用的促销不参与", promotions: []model.Promotion{future, disabled}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := newQuote([]PriceLine{
+				{ProductID: 1, CategoryID: 1, Name: "耳机", UnitPrice: cny("100"), Quantity: 3},
+				{ProductID: 2, CategoryID: 1, Name: "保护壳", UnitPrice: cny("30"), Quantity: 1},
+				{ProductID: 3, CategoryID: 2, Name: "手机", UnitPrice: cny("1000"), Quantity: 1},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			applyPromotions(quote, tt.promotions, now)
+
+			var got []string
+			for _, d := range quote.Discounts {
+				if d.Source != model.DiscountSourcePromotion {
+					t.Fatalf("discount source = %q", d.Source)
+				}
+				got = append(got, fmt.Sprintf("%d=%s", d.SourceID, d.Amount))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("discounts = %v, want %v", got, tt.want)
+			}
+			for _, line := range quote.Lines {
+				if line.Discount.IsNegative() || line.Payable().IsNegative() {
+					t.Fatalf("line %s: discount %v of %v", line.Name, line.Discount, line.Amount)
+				}
+			}
+			if quote.Subtotal.Sub(quote.DiscountTotal).Cmp(quote.Total) != 0 {
+				t.Fatalf("subtotal %v - discount %v != total %v", quote.Subtotal, quote.DiscountTotal, quote.Total)
+			}
+		})
+	}
+}
+
+func TestPromotionValidate(t *testing.T) {
+	svc := NewPromotionService(repotest.NewTxManager(), repotest.NewPromotionRepository(), NewAuditService(repotest.NewAuditLogRepository()))
+
+	tests := []struct {
+		name      string
+		promotion model.Promotion
+		want      error
+	}{
+		{name: "买赠", promotion: model.Promotion{Name: "买二送一", Type: model.PromotionTypeBuyXGetY, BuyQuantity: 2, FreeQuantity: 1}},
+		{name: "买赠缺少免费件数", promotion: model.Promotion{Name: "买二", Type: model.PromotionTypeBuyXGetY, BuyQuantity: 2}, want: ErrInvalidPromotion},
+		{name: "满减档位门槛重复", promotion: model.Promotion{Name: "满减", Type: model.PromotionTypeTiered, Tiers: []model.PromotionTier{
+			{Threshold: cny("100"), AmountOff: cny("10")}, {Threshold: cny("100"), AmountOff: cny("20")}}}, want: ErrInvalidPromotion},
+		{name: "减免金额超过门槛", promotion: model.Promotion{Name: "满减", Type: model.PromotionTypeTiered, Tiers: []model.PromotionTier{
+			{Threshold: cny("10"), AmountOff: cny("20")}}}, want: ErrInvalidPromotion},
+		{name: "组合按分类指定", promotion: model.Promotion{Name: "组合", Type: model.PromotionTypeBundle, BundlePrice: cny("10"),
+			Items: []model.PromotionItem{{CategoryID: 1, Quantity: 1}}}, want: ErrInvalidPromotion},
+		{name: "组合商品重复", promotion: model.Promotion{Name: "组合", Type: model.PromotionTypeBundle, BundlePrice: cny("10"),
+			Items: []model.PromotionItem{{ProductID: 1, Quantity: 1}, {ProductID: 1, Quantity: 2}}}, want: ErrInvalidPromotion},
+		{name: "不支持的类型", promotion: model.Promotion{Name: "秒杀", Type: "flash"}, want: ErrInvalidPromotion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.Create(context.Background(), &tt.promotion); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"log"
+	"myshop/pkg/cache"
+	"time"
+)
+
+// rateLimiter 基于缓存的固定窗口计数限流
+type rateLimiter struct {
+	cache cache.Cache
+}
+
+func newRateLimiter(c cache.Cache) *rateLimiter {
+	return &rateLimiter{cache: c}
+}
+
+// Allow 窗口内计数未超过limit时返回true，每次调用都计数
+// 窗口从第一次计数开始，后续计数不会延长窗口；缓存不可用时按超限处理
+func (l *rateLimiter) Allow(key string, limit int, window time.Duration) bool {
+	count, err := l.cache.Incr("ratelimit:"+key, window)
+	if err != nil {
+		log.Printf("限流计数失败: %v", err)
+		return false
+	}
+	return count <= int64(limit)
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/pkg/cache"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval 最后活跃时间的更新间隔
+// 间隔内的请求只检查缓存，避免每个请求都写数据库
+const sessionTouchInterval = time.Minute
+
+// authTimeRetention 会话认证时间在缓存中的保留时长，超过后不再视为近期认证
+const authTimeRetention = time.Hour
+
+// ClientInfo 发起登录的客户端信息
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// SessionService 登录会话业务逻辑层
+type SessionService struct {
+	repo        repository.SessionStore
+	refreshRepo repository.RefreshTokenStore
+	events      repository.SecurityEventStore
+	cache       cache.Cache
+}
+
+// NewSessionService 创建登录会话服务实例
+func NewSessionService(repo repository.SessionStore, refreshRepo repository.RefreshTokenStore,
+	events repository.SecurityEventStore, c cache.Cache) *SessionService {
+	return &SessionService{repo: repo, refreshRepo: refreshRepo, events: events, cache: c}
+}
+
+// Start 为一次登录创建会话
+func (s *SessionService) Start(ctx context.Context, userID uint, familyID string, client ClientInfo, expiresAt time.Time) (*model.Session, error) {
+	now := time.Now()
+	session := &model.Session{
+		UserID:       userID,
+		FamilyID:     familyID,
+		Device:       describeDevice(client.UserAgent),
+		IP:           client.IP,
+		UserAgent:    truncate(client.UserAgent, 255),
+		LastActiveAt: now,
+		ExpiresAt:    expiresAt,
+	}
+	if err := s.repo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Extend 刷新令牌轮换时顺延会话，刷新令牌家族没有对应会话时返回ErrSessionNotFound
+func (s *SessionService) Extend(ctx context.Context, familyID string, expiresAt time.Time) (*model.Session, error) {
+	session, err := s.repo.GetByFamilyID(ctx, familyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.RevokedAt != nil {
+		return nil, ErrSessionRevoked
+	}
+	if err := s.repo.Extend(ctx, session.ID, expiresAt); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// IsSessionActive 判断会话是否仍然有效，供认证中间件调用
+// 有效时顺便更新最后活跃时间，每个会话每分钟最多写一次数据库
+func (s *SessionService) IsSessionActive(ctx context.Context, sessionID uint) bool {
+	key := sessionActiveKey(sessionID)
+	if _, err := s.cache.Get(key); err == nil {
+		return true
+	}
+
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil || session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return false
+	}
+
+	if err := s.repo.Touch(ctx, session.ID, time.Now()); err != nil {
+		log.Printf("更新会话活跃时间失败: %v", err)
+	}
+	s.cache.Set(key, true, sessionTouchInterval)
+	return true
+}
+
+// MarkAuthenticated 记录会话的认证时间，供注销账号等敏感操作判断是否近期认证过
+func (s *SessionService) MarkAuthenticated(sessionID uint, at time.Time) {
+	ttl := authTimeRetention - time.Since(at)
+	if ttl <= 0 {
+		return
+	}
+	s.cache.Set(sessionAuthKey(sessionID), at.Unix(), ttl)
+}
+
+// AuthenticatedAt 查询会话最近一次认证的时间，没有记录时返回false
+func (s *SessionService) AuthenticatedAt(sessionID uint) (time.Time, bool) {
+	at, err := cache.GetAs[int64](s.cache, sessionAuthKey(sessionID))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(at, 0), true
+}
+
+// List 查询用户的有效会话
+func (s *SessionService) List(ctx context.Context, userID uint) ([]model.Session, error) {
+	return s.repo.ListActive(ctx, userID)
+}
+
+// Revoke 吊销用户的指定会话
+func (s *SessionService) Revoke(ctx context.Context, userID, sessionID uint) error {
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil || session.UserID != userID || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	if err := s.revoke(ctx, session); err != nil {
+		return err
+	}
+	s.record(ctx, userID, fmt.Sprintf("吊销会话%d(%s)", session.ID, session.Device))
+	return nil
+}
+
+// RevokeOthers 吊销除当前会话以外的全部会话，返回吊销的数量
+func (s *SessionService) RevokeOthers(ctx context.Context, userID, currentID uint) (int, error) {
+	sessions, err := s.repo.ListActive(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range sessions {
+		if sessions[i].ID == currentID {
+			continue
+		}
+		if err := s.revoke(ctx, &sessions[i]); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if count > 0 {
+		s.record(ctx, userID, fmt.Sprintf("吊销其他%d个会话", count))
+	}
+	return count, nil
+}
+
+// RevokeAll 吊销用户的全部会话和刷新令牌，用于重置密码等场景
+func (s *SessionService) RevokeAll(ctx context.Context, userID uint) error {
+	if _, err := s.RevokeOthers(ctx, userID, 0); err != nil {
+		return err
+	}
+	// 兼容没有对应会话的旧刷新令牌
+	return s.refreshRepo.RevokeByUserID(ctx, userID)
+}
+
+// RevokeFamily 刷新令牌家族被吊销时同步吊销对应会话
+func (s *SessionService) RevokeFamily(ctx context.Context, familyID string) error {
+	session, err := s.repo.GetByFamilyID(ctx, familyID)
+	if err != nil {
+		return s.refreshRepo.RevokeFamily(ctx, familyID)
+	}
+	return s.revoke(ctx, session)
+}
+
+// revoke 吊销会话及其刷新令牌家族，并清除活跃缓存使访问令牌立即失效
+func (s *SessionService) revoke(ctx context.Context, session *model.Session) error {
+	if err := s.repo.Revoke(ctx, session.ID); err != nil {
+		return err
+	}
+	s.cache.Delete(sessionActiveKey(session.ID))
+	s.cache.Delete(sessionAuthKey(session.ID))
+	return s.refreshRepo.RevokeFamily(ctx, session.FamilyID)
+}
+
+func (s *SessionService) record(ctx context.Context, userID uint, detail string) {
+	event := &model.SecurityEvent{Type: model.SecurityEventSessionRevoked, UserID: userID, Detail: detail}
+	if err := s.events.Create(ctx, event); err != nil {
+		log.Printf("记录安全事件失败: %v", err)
+	}
+}
+
+func sessionActiveKey(sessionID uint) string {
+	return fmt.Sprintf("session:active:%d", sessionID)
+}
+
+func sessionAuthKey(sessionID uint) string {
+	return fmt.Sprintf("session:auth:%d", sessionID)
+}
+
+// describeDevice 从User-Agent中粗略解析浏览器和操作系统，仅用于会话列表展示
+func describeDevice(ua string) string {
+	if ua == "" {
+		return "未知设备"
+	}
+
+	browser := "未知浏览器"
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+		{"PostmanRuntime/", "Postman"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	os := ""
+	for _, o := range []struct{ token, name string }{
+		{"Windows", "Windows"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			os = o.name
+			break
+		}
+	}
+
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
+
+// truncate 截断为最多n个字符
+// 按字符而不是字节截断，避免切断多字节字符产生非法UTF-8；数据库varchar的长度也按字符计算
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
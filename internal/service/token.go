@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"log"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/pkg/utils"
+	"time"
+)
+
+// defaultRefreshTTL 刷新令牌默认有效期
+const defaultRefreshTTL = 30 * 24 * time.Hour
+
+// TokenPair 登录或刷新后下发的令牌对
+type TokenPair struct {
+	AccessToken  string // 短期访问令牌
+	RefreshToken string // 刷新令牌，仅可使用一次
+	ExpiresIn    int64  // 访问令牌有效秒数
+}
+
+// TokenService 令牌业务逻辑层
+// 负责签发访问令牌、轮换刷新令牌以及登出吊销
+type TokenService struct {
+	userRepo    repository.UserStore
+	refreshRepo repository.RefreshTokenStore
+	sessions    *SessionService
+	events      repository.SecurityEventStore
+	denylist    *utils.TokenDenylist
+	refreshTTL  time.Duration
+}
+
+// NewTokenService 创建令牌服务实例
+func NewTokenService(userRepo repository.UserStore, refreshRepo repository.RefreshTokenStore,
+	sessions *SessionService, events repository.SecurityEventStore, denylist *utils.TokenDenylist,
+	refreshTTL time.Duration) *TokenService {
+	if refreshTTL <= 0 {
+		refreshTTL = defaultRefreshTTL
+	}
+	return &TokenService{
+		userRepo:    userRepo,
+		refreshRepo: refreshRepo,
+		sessions:    sessions,
+		events:      events,
+		denylist:    denylist,
+		refreshTTL:  refreshTTL,
+	}
+}
+
+// Issue 为登录成功的用户签发令牌对，开启新的刷新令牌家族和登录会话
+// mfa表示本次登录是否通过了两步验证，authTime为用户实际完成认证的时间
+func (s *TokenService) Issue(ctx context.Context, user *model.User, mfa bool, authTime time.Time, client ClientInfo) (*TokenPair, error) {
+	familyID, err := utils.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.sessions.Start(ctx, user.ID, familyID, client, time.Now().Add(s.refreshTTL))
+	if err != nil {
+		return nil, err
+	}
+	s.sessions.MarkAuthenticated(session.ID, authTime)
+	return s.issue(ctx, user, familyID, session.ID, mfa)
+}
+
+// Refresh 使用刷新令牌换取新的令牌对
+// 1. 校验刷新令牌存在且未过期
+// 2. 已吊销的令牌再次出现视为泄露，吊销整个家族
+// 3. 吊销当前令牌并在同一家族下签发新令牌，顺延登录会话
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	stored, err := s.refreshRepo.GetByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt != nil {
+		s.revokeFamily(ctx, stored, "已吊销的刷新令牌被再次使用")
+		return nil, ErrRefreshTokenReused
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// 条件更新保证同一个令牌只能被成功使用一次
+	ok, err := s.refreshRepo.Revoke(ctx, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.revokeFamily(ctx, stored, "刷新令牌被并发重复使用")
+		return nil, ErrRefreshTokenReused
+	}
+
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	session, err := s.sessions.Extend(ctx, stored.FamilyID, time.Now().Add(s.refreshTTL))
+	if err != nil {
+		if err == ErrSessionRevoked || err == ErrSessionNotFound {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	return s.issue(ctx, user, stored.FamilyID, session.ID, stored.MFA)
+}
+
+// Logout 登出
+// 将当前访问令牌加入黑名单并吊销当前会话；
+// 没有会话的旧令牌若提供了刷新令牌，则吊销其所在家族
+func (s *TokenService) Logout(ctx context.Context, userID, sessionID uint, tokenID string, expiresAt time.Time, refreshToken string) error {
+	if err := s.denylist.Revoke(tokenID, expiresAt); err != nil {
+		return err
+	}
+
+	if sessionID != 0 {
+		if err := s.sessions.Revoke(ctx, userID, sessionID); err != nil && err != ErrSessionNotFound {
+			return err
+		}
+	}
+	if refreshToken != "" {
+		stored, err := s.refreshRepo.GetByHash(ctx, utils.HashToken(refreshToken))
+		if err == nil && stored.UserID == userID {
+			if err := s.sessions.RevokeFamily(ctx, stored.FamilyID); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.record(ctx, model.SecurityEventLogout, userID, "")
+	return nil
+}
+
+func (s *TokenService) issue(ctx context.Context, user *model.User, familyID string, sessionID uint, mfa bool) (*TokenPair, error) {
+	accessToken, err := utils.GenerateToken(user.ID, sessionID, user.Role, mfa)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	err = s.refreshRepo.Create(ctx, &model.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(refreshToken),
+		MFA:       mfa,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(utils.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+func (s *TokenService) revokeFamily(ctx context.Context, stored *model.RefreshToken, detail string) {
+	if err := s.sessions.RevokeFamily(ctx, stored.FamilyID); err != nil {
+		log.Printf("吊销刷新令牌家族失败: %v", err)
+	}
+	s.record(ctx, model.SecurityEventTokenReuse, stored.UserID, detail)
+}
+
+func (s *TokenService) record(ctx context.Context, eventType string, userID uint, detail string) {
+	event := &model.SecurityEvent{Type: eventType, UserID: userID, Detail: detail}
+	if err := s.events.Create(ctx, event); err != nil {
+		log.Printf("记录安全事件失败: %v", err)
+	}
+}
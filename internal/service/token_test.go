@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/pkg/utils"
+	"testing"
+	"time"
+)
+
+func TestTokenRefreshRotates(t *testing.T) {
+	ctx := context.Background()
+	env := newUserTestEnv(t)
+	alice := env.register(t, "alice", "")
+
+	first, err := env.tokens.Issue(ctx, alice, false, time.Now(), ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := env.tokens.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Fatal("刷新后应下发新的令牌对")
+	}
+
+	before, _ := utils.ValidateToken(first.AccessToken)
+	after, _ := utils.ValidateToken(second.AccessToken)
+	if before.SessionID != after.SessionID {
+		t.Errorf("刷新后会话ID = %d, 期望沿用 %d", after.SessionID, before.SessionID)
+	}
+	if _, err := env.tokens.Refresh(ctx, second.RefreshToken); err != nil {
+		t.Errorf("新的刷新令牌应可继续使用: %v", err)
+	}
+}
+
+func TestTokenReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	env := newUserTestEnv(t)
+	alice := env.register(t, "alice", "")
+
+	stolen, err := env.tokens.Issue(ctx, alice, false, time.Now(), ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := env.tokens.Issue(ctx, alice, false, time.Now(), ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 合法用户先完成轮换，攻击者随后重放已使用过的刷新令牌
+	rotated, err := env.tokens.Refresh(ctx, stolen.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.tokens.Refresh(ctx, stolen.RefreshToken); err != ErrRefreshTokenReused {
+		t.Fatalf("重放已使用的刷新令牌: err = %v, 期望 %v", err, ErrRefreshTokenReused)
+	}
+	events, _, _ := env.events.List(ctx, repository.SecurityEventFilter{Type: model.SecurityEventTokenReuse}, 1, 10)
+	if len(events) != 1 || events[0].UserID != alice.ID {
+		t.Errorf("应记录一次刷新令牌重放事件: %+v", events)
+	}
+
+	// 整个家族被吊销：轮换出的新令牌和同一会话的访问令牌都失效
+	if _, err := env.tokens.Refresh(ctx, rotated.RefreshToken); err == nil {
+		t.Error("家族被吊销后，轮换出的刷新令牌仍可使用")
+	}
+	claims, _ := utils.ValidateToken(rotated.AccessToken)
+	if env.sessions.IsSessionActive(ctx, claims.SessionID) {
+		t.Error("家族被吊销后会话仍然有效")
+	}
+
+	// 其他登录会话不受影响
+	if _, err := env.tokens.Refresh(ctx, other.RefreshToken); err != nil {
+		t.Errorf("其他会话的刷新令牌不应被吊销: %v", err)
+	}
+}
+
+func TestTokenRefreshRejectsUnknown(t *testing.T) {
+	env := newUserTestEnv(t)
+
+	if _, err := env.tokens.Refresh(context.Background(), "not-a-refresh-token"); err != ErrInvalidRefreshToken {
+		t.Errorf("err = %v, 期望 %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+// TestTokenRefreshRequiresSession 没有对应登录会话的刷新令牌不能换取新令牌，也不会补建会话
+func TestTokenRefreshRequiresSession(t *testing.T) {
+	ctx := context.Background()
+	env := newUserTestEnv(t)
+	alice := env.register(t, "alice", "")
+
+	plain := "orphan-refresh-token"
+	orphan := &model.RefreshToken{UserID: alice.ID, FamilyID: "orphan", TokenHash: utils.HashToken(plain),
+		ExpiresAt: time.Now().Add(time.Hour)}
+	if err := env.refreshTokens.Create(ctx, orphan); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.tokens.Refresh(ctx, plain); err != ErrInvalidRefreshToken {
+		t.Fatalf("err = %v, 期望 %v", err, ErrInvalidRefreshToken)
+	}
+	if sessions, _ := env.sessions.List(ctx, alice.ID); len(sessions) != 0 {
+		t.Errorf("不应补建会话: %+v", sessions)
+	}
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"myshop/internal/config"
+	"myshop/internal/model"
+	"myshop/internal/repository"
+	"myshop/pkg/cache"
+	"myshop/pkg/utils"
+	"strings"
+	"time"
+)
+
+const (
+	defaultChallengeTTL    = 5 * time.Minute
+	maxChallengeAttempts   = 5  // 同一挑战允许的验证码错误次数
+	recoveryCodeCount      = 10 // 每次生成的恢复码数量
+	recoveryCodeHalfLength = 5  // 恢复码格式为 xxxxx-xxxxx
+)
+
+// loginChallenge 两步登录的挑战，密码验证通过后写入缓存
+// 错误次数单独用计数键记录，并发提交时也能准确累计
+type loginChallenge struct {
+	UserID    uint
+	ExpiresAt time.Time
+}
+
+func init() {
+	cache.Register(&loginChallenge{})
+}
+
+// TwoFactorService 两步验证业务逻辑层
+// 负责TOTP开通、关闭、恢复码以及两步登录的第二步
+type TwoFactorService struct {
+	userRepo     repository.UserStore
+	recoveryRepo repository.RecoveryCodeStore
+	cache        cache.Cache
+	tokens       *TokenService
+	guard        *LoginGuard
+	cfg          config.TwoFactorConfig
+}
+
+// NewTwoFactorService 创建两步验证服务实例
+func NewTwoFactorService(userRepo repository.UserStore, recoveryRepo repository.RecoveryCodeStore,
+	c cache.Cache, tokens *TokenService, guard *LoginGuard, cfg config.TwoFactorConfig) *TwoFactorService {
+	if cfg.Issuer == "" {
+		cfg.Issuer = "MyShop"
+	}
+	if cfg.ChallengeTTL <= 0 {
+		cfg.ChallengeTTL = defaultChallengeTTL
+	}
+	return &TwoFactorService{
+		userRepo:     userRepo,
+		recoveryRepo: recoveryRepo,
+		cache:        c,
+		tokens:       tokens,
+		guard:        guard,
+		cfg:          cfg,
+	}
+}
+
+func challengeKey(token string) string         { return "2fa:challenge:" + utils.HashToken(token) }
+func challengeAttemptsKey(token string) string { return "2fa:attempts:" + utils.HashToken(token) }
+func usedStepKey(userID uint, step int64) string {
+	return fmt.Sprintf("2fa:step:%d:%d", userID, step)
+}
+
+// Enroll 开始开通两步验证，生成密钥并返回otpauth URI
+// 密钥在验证通过之前不会生效
+func (s *TwoFactorService) Enroll(ctx context.Context, userID uint) (secret, uri string, err error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", "", ErrUserNotFound
+	}
+	if user.TwoFactorEnabled {
+		return "", "", ErrTwoFactorEnabled
+	}
+
+	secret, err = utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	user.TOTPSecret = secret
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return "", "", err
+	}
+
+	return secret, utils.TOTPURI(s.cfg.Issuer, user.Username, secret), nil
+}
+
+// Activate 校验验证码后启用两步验证，返回一次性恢复码明文
+func (s *TwoFactorService) Activate(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if !s.checkCode(user, code) {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, err := s.newRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	user.TwoFactorEnabled = true
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable 校验验证码后关闭两步验证，同时作废恢复码
+func (s *TwoFactorService) Disable(ctx context.Context, userID uint, code string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnrolled
+	}
+	if !s.checkCode(user, code) {
+		return ErrInvalidTwoFactorCode
+	}
+
+	user.TwoFactorEnabled = false
+	user.TOTPSecret = ""
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	return s.recoveryRepo.DeleteByUserID(ctx, user.ID)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，原有恢复码全部作废
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if !user.TwoFactorEnabled {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if !s.checkCode(user, code) {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	return s.newRecoveryCodes(ctx, user.ID)
+}
+
+// Challenge 密码验证通过后创建登录挑战，返回挑战令牌
+func (s *TwoFactorService) Challenge(user *model.User) (string, error) {
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	ch := &loginChallenge{UserID: user.ID, ExpiresAt: time.Now().Add(s.cfg.ChallengeTTL)}
+	if err := s.cache.Set(challengeKey(token), ch, s.cfg.ChallengeTTL); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// VerifyLogin 两步登录的第二步：用挑战令牌加验证码（或恢复码）换取正式令牌
+// 错误的验证码计入登录失败次数，同一挑战错误过多后作废
+func (s *TwoFactorService) VerifyLogin(ctx context.Context, challengeToken, code, recoveryCode string, client ClientInfo) (*TokenPair, error) {
+	key := challengeKey(challengeToken)
+	ch, err := cache.GetAs[*loginChallenge](s.cache, key)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := s.userRepo.GetByID(ctx, ch.UserID)
+	if err != nil || !user.TwoFactorEnabled {
+		s.cache.Delete(key)
+		return nil, ErrInvalidChallenge
+	}
+	if err := s.guard.Check(ctx, user.Username, client.IP); err != nil {
+		return nil, err
+	}
+
+	passed := false
+	if code != "" {
+		passed = s.checkCode(user, code)
+	} else if recoveryCode != "" {
+		passed, err = s.recoveryRepo.Use(ctx, user.ID, hashRecoveryCode(recoveryCode))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !passed {
+		// 计数失败时无法确认剩余次数，按次数用尽处理
+		attempts, err := s.cache.Incr(challengeAttemptsKey(challengeToken), time.Until(ch.ExpiresAt))
+		if err != nil || attempts >= maxChallengeAttempts {
+			s.cache.Delete(key)
+		}
+		return nil, withRetryAfter(ErrInvalidTwoFactorCode, s.guard.Fail(ctx, user.ID, user.Username, client.IP))
+	}
+
+	s.cache.Delete(key)
+	s.cache.Delete(challengeAttemptsKey(challengeToken))
+	s.guard.Succeed(ctx, user.ID, user.Username, client.IP)
+	return s.tokens.Issue(ctx, user, true, time.Now(), client)
+}
+
+// checkCode 校验TOTP验证码，同一时间步的验证码只能使用一次，早于已使用时间步的验证码也不再接受
+// 每个时间步一个计数键，并发提交同一验证码时只有第一个加一的请求通过；
+// 通过后把仍在允许偏差内的更早时间步一并计数，缓存不可用时按已使用处理
+func (s *TwoFactorService) checkCode(user *model.User, code string) bool {
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false
+	}
+
+	used, err := s.cache.Incr(usedStepKey(user.ID, step), 2*time.Minute)
+	if err != nil || used != 1 {
+		return false
+	}
+	for earlier := step - 2*utils.TOTPSkew; earlier < step; earlier++ {
+		s.cache.Incr(usedStepKey(user.ID, earlier), 2*time.Minute)
+	}
+	return true
+}
+
+// newRecoveryCodes 生成一组新的恢复码并保存摘要
+func (s *TwoFactorService) newRecoveryCodes(ctx context.Context, userID uint) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		secret, err := utils.GenerateTOTPSecret()
+		if err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(secret[:recoveryCodeHalfLength*2])
+		codes[i] = raw[:recoveryCodeHalfLength] + "-" + raw[recoveryCodeHalfLength:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	if err := s.recoveryRepo.Replace(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode 规范化后计算恢复码摘要，忽略大小写、空格和连字符
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return utils.HashToken(code)
+}
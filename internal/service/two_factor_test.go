@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"myshop/internal/model"
+	"myshop/pkg/utils"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// enableTwoFactor 为用户开通两步验证，返回密钥、开通时使用的时间步和恢复码
+func (e *userTestEnv) enableTwoFactor(t *testing.T, user *model.User) (string, int64, []string) {
+	t.Helper()
+
+	secret, _, err := e.twoFactor.Enroll(context.Background(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := utils.TOTPStep(time.Now())
+	code, _ := utils.TOTPCode(secret, step)
+	recovery, err := e.twoFactor.Activate(context.Background(), user.ID, code)
+	if err != nil {
+		t.Fatalf("开通两步验证失败: %v", err)
+	}
+	return secret, step, recovery
+}
+
+func TestTwoFactorCodeIsSingleUse(t *testing.T) {
+	env := newUserTestEnv(t)
+	alice := env.register(t, "alice", "")
+	secret, step, _ := env.enableTwoFactor(t, alice)
+	client := ClientInfo{IP: "127.0.0.1"}
+
+	codeAt := func(s int64) string {
+		code, _ := utils.TOTPCode(secret, s)
+		return code
+	}
+
+	tests := []struct {
+		name string
+		code string
+		want error
+	}{
+		{name: "开通时用过的验证码", code: codeAt(step), want: ErrInvalidTwoFactorCode},
+		{name: "早于已使用时间步的验证码", code: codeAt(step - 1), want: ErrInvalidTwoFactorCode},
+		{name: "下一个时间步的验证码", code: codeAt(step + 1)},
+		{name: "同一验证码再次使用", code: codeAt(step + 1), want: ErrInvalidTwoFactorCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			challenge, err := env.twoFactor.Challenge(alice)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = env.twoFactor.VerifyLogin(context.Background(), challenge, tt.code, "", client)
+			if err != tt.want {
+				t.Errorf("err = %v, 期望 %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestTwoFactorCodeConcurrentReplay 并发提交同一验证码时只有一个请求通过
+func TestTwoFactorCodeConcurrentReplay(t *testing.T) {
+	env := newUserTestEnv(t)
+	alice := env.register(t, "alice", "")
+	secret, step, _ := env.enableTwoFactor(t, alice)
+	code, _ := utils.TOTPCode(secret, step+1)
+
+	var passed int64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		challenge, err := env.twoFactor.Challenge(alice)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := env.twoFactor.VerifyLogin(context.Background(), challenge, code, "", ClientInfo{IP: "127.0.0.1"}); err == nil {
+				atomic.AddInt64(&passed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if passed != 1 {
+		t.Fatalf("%d个请求使用同一验证码登录成功, 期望1个", passed)
+	}
+}
+
+func TestTwoFactorRecoveryCodeIsSingleUse(t *testing.T) {
+	env := newUserTestEnv(t)
+	alice := env.register(t, "alice", "")
+	_, _, recovery := env.enableTwoFactor(t, alice)
+	client := ClientInfo{IP: "127.0.0.1"}
+
+	for i, want := range []error{nil, ErrInvalidTwoFactorCode} {
+		challenge, err := env.twoFactor.Challenge(alice)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.twoFactor.VerifyLogin(context.Background(), challenge, "", recovery[0], client); err != want {
+			t.Errorf("第%d次使用恢复码: err = %v, 期望 %v", i+1, err, want)
+		}
+	}
+}
+
+func TestTwoFactorChallengeIsSingleUse(t *testing.T) {
+	env := newUserTestEnv(t)
+	alice := env.register(t, "alice", "")
+	secret, step, _ := env.enableTwoFactor(t, alice)
+
+	challenge, err := env.twoFactor.Challenge(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := utils.TOTPCode(secret, step+1)
+	if _, err := env.twoFactor.VerifyLogin(context.Background(), challenge, code, "", ClientInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.twoFactor.VerifyLogin(context.Background(), challenge, code, "", ClientInfo{}); err != ErrInvalidChallenge {
+		t.Errorf("挑战令牌再次使用: err = %v, 期望 %v", err, ErrInvalidChallenge)
+	}
+}
@@ -1,64 +1,287 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"log"
 	"myshop/internal/model"
 	"myshop/internal/repository"
+	"myshop/pkg/money"
 	"myshop/pkg/utils"
+	"strings"
+	"sync"
+	"time"
 )
 
 // UserService 用户业务逻辑层
 type UserService struct {
-	repo *repository.UserRepository // 用户数据仓储
+	tx        repository.Transactor // 事务管理
+	repo      repository.UserStore  // 用户数据仓储
+	guard     *LoginGuard           // 登录防暴力破解
+	tokens    *TokenService         // 令牌签发
+	twoFactor *TwoFactorService     // 两步验证
+	account   *AccountService       // 邮箱验证与找回密码
+	sessions  *SessionService       // 登录会话
+	audit     *AuditService         // 审计日志
 }
 
 // NewUserService 创建用户服务实例
-func NewUserService(repo *repository.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(tx repository.Transactor, repo repository.UserStore, guard *LoginGuard, tokens *TokenService,
+	twoFactor *TwoFactorService, account *AccountService, sessions *SessionService, audit *AuditService) *UserService {
+	return &UserService{
+		tx:        tx,
+		repo:      repo,
+		guard:     guard,
+		tokens:    tokens,
+		twoFactor: twoFactor,
+		account:   account,
+		sessions:  sessions,
+		audit:     audit,
+	}
+}
+
+// LoginResult 登录结果
+// 启用了两步验证的用户只返回挑战令牌，需要再提交验证码换取正式令牌
+type LoginResult struct {
+	Tokens         *TokenPair
+	ChallengeToken string
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// getDummyHash 返回一个固定的bcrypt哈希
+// 用户名不存在时仍然执行一次密码校验，使响应耗时与用户存在时一致，防止通过时间差枚举用户名
+func getDummyHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = utils.HashPassword("myshop-dummy-password")
+	})
+	return dummyHash
 }
 
 // Register 用户注册
-// 1. 检查用户名是否已存在
+// 1. 检查用户名和邮箱是否已存在
 // 2. 对密码进行加密
-// 3. 创建新用户
-func (s *UserService) Register(user *model.User) error {
+// 3. 创建新用户，填写了邮箱的发送验证邮件
+func (s *UserService) Register(ctx context.Context, user *model.User) error {
 	// 检查用户名是否已存在
-	existingUser, err := s.repo.GetByUsername(user.Username)
+	existingUser, err := s.repo.GetByUsername(ctx, user.Username)
 	if err == nil && existingUser != nil {
 		return ErrUserExists
 	}
 
+	// 邮箱可选，填写时不能与其他用户重复
+	user.Email = normalizeEmail(user.Email)
+	user.EmailVerifiedAt = nil
+	if user.Email != "" {
+		if err := s.account.CheckEmailAvailable(ctx, user.Email, 0); err != nil {
+			return err
+		}
+	}
+
 	// 密码加密
 	hashedPassword, err := utils.HashPassword(user.Password)
 	if err != nil {
 		return err
 	}
 	user.Password = hashedPassword
+	user.Role = model.RoleUser
+
+	// 创建用户，并发注册同一邮箱时由数据库唯一索引兜底
+	if err := s.repo.Create(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrEmailTaken) {
+			return ErrEmailExists
+		}
+		return err
+	}
 
-	// 创建用户
-	return s.repo.Create(user)
+	// 验证邮件发送失败不影响注册，用户可以稍后重新发送
+	if user.Email != "" {
+		if err := s.account.SendVerification(ctx, user.ID); err != nil {
+			log.Printf("发送验证邮件失败: %v", err)
+		}
+	}
+	return nil
 }
 
 // Login 用户登录
-// 1. 根据用户名查找用户
-// 2. 验证密码
-// 3. 生成JWT token
-func (s *UserService) Login(username, password string) (string, error) {
-	// 查找用户
-	user, err := s.repo.GetByUsername(username)
+// 1. 检查用户名和IP是否被锁定
+// 2. 根据用户名查找用户
+// 3. 验证密码，失败时累计失败次数
+// 4. 启用两步验证的用户返回挑战令牌，否则签发访问令牌和刷新令牌
+func (s *UserService) Login(ctx context.Context, username, password string, client ClientInfo) (*LoginResult, error) {
+	// 检查锁定状态
+	if err := s.guard.Check(ctx, username, client.IP); err != nil {
+		return nil, err
+	}
+
+	// 查找用户，不存在时也执行一次密码校验
+	user, err := s.repo.GetByUsername(ctx, username)
+	if err != nil {
+		utils.CheckPassword(password, getDummyHash())
+		return nil, withRetryAfter(ErrInvalidCredentials, s.guard.Fail(ctx, 0, username, client.IP))
+	}
+
+	// 验证密码，服务账号不允许使用密码登录
+	if !utils.CheckPassword(password, user.Password) || user.Role == model.RoleService {
+		return nil, withRetryAfter(ErrInvalidCredentials, s.guard.Fail(ctx, user.ID, username, client.IP))
+	}
+
+	// 两步验证通过之前不清除失败计数，避免已知密码的攻击者借此无限尝试验证码
+	if user.TwoFactorEnabled {
+		challenge, err := s.twoFactor.Challenge(user)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{ChallengeToken: challenge}, nil
+	}
+	s.guard.Succeed(ctx, user.ID, username, client.IP)
+
+	// 签发令牌
+	pair, err := s.tokens.Issue(ctx, user, false, time.Now(), client)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Tokens: pair}, nil
+}
+
+// CreateServiceAccount 创建服务账号
+// 服务账号使用随机密码且禁止密码登录，只能通过API Key访问
+func (s *UserService) CreateServiceAccount(ctx context.Context, username string) (*model.User, error) {
+	if existingUser, err := s.repo.GetByUsername(ctx, username); err == nil && existingUser != nil {
+		return nil, ErrUserExists
+	}
+
+	secret, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := utils.HashPassword(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Username: username,
+		Password: hashedPassword,
+		Role:     model.RoleService,
+	}
+	if err := s.repo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ProfileUpdate 个人资料修改内容，为nil的字段保持不变
+type ProfileUpdate struct {
+	Nickname  *string
+	Phone     *string
+	AvatarURL *string
+	Email     *string // 邮箱修改后需要重新验证
+	Currency  *string // 偏好币种，空字符串表示使用本位币
+}
+
+// UpdateProfile 修改个人资料
+func (s *UserService) UpdateProfile(ctx context.Context, userID uint, update ProfileUpdate) (*model.User, error) {
+	user, err := s.repo.GetByID(ctx, userID)
 	if err != nil {
-		return "", ErrInvalidCredentials
+		return nil, ErrUserNotFound
 	}
 
-	// 验证密码
-	if !utils.CheckPassword(password, user.Password) {
-		return "", ErrInvalidCredentials
+	// 先检查邮箱和币种，避免校验失败时其他字段已被修改
+	// 偏好币种只校验代码，没有汇率的币种在使用时回退到本位币
+	var currency string
+	if update.Currency != nil && strings.TrimSpace(*update.Currency) != "" {
+		if currency, err = money.NormalizeCurrency(*update.Currency); err != nil {
+			return nil, ErrUnsupportedCurrency
+		}
+	}
+	changeEmail := update.Email != nil && normalizeEmail(*update.Email) != user.Email
+	if changeEmail {
+		if err := s.account.CheckEmailAvailable(ctx, normalizeEmail(*update.Email), user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if update.Nickname != nil {
+		user.Nickname = strings.TrimSpace(*update.Nickname)
+	}
+	if update.Phone != nil {
+		user.Phone = strings.TrimSpace(*update.Phone)
+	}
+	if update.AvatarURL != nil {
+		user.AvatarURL = strings.TrimSpace(*update.AvatarURL)
+	}
+	if update.Currency != nil {
+		user.Currency = currency
+	}
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, err
 	}
 
-	// 生成token
-	return utils.GenerateToken(user.ID)
+	if changeEmail {
+		if err := s.account.ChangeEmail(ctx, user.ID, *update.Email); err != nil {
+			return nil, err
+		}
+		return s.repo.GetByID(ctx, userID)
+	}
+	return user, nil
 }
 
 // GetByID 根据ID获取用户信息
-func (s *UserService) GetByID(id uint) (*model.User, error) {
-	return s.repo.GetByID(id)
+func (s *UserService) GetByID(ctx context.Context, id uint) (*model.User, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ChangeRole 管理员修改用户角色
+// 只能在普通用户和管理员之间切换，不能修改自己和服务账号的角色；
+// 修改角色、吊销该用户的全部会话和审计日志在同一事务中完成，新角色在重新登录后生效；
+// 已有API Key的权限在校验时与新角色取交集，降级后无需重新签发
+func (s *UserService) ChangeRole(ctx context.Context, id uint, role string) error {
+	if role != model.RoleUser && role != model.RoleAdmin {
+		return ErrInvalidRole
+	}
+	if id == actorFrom(ctx).UserID {
+		return ErrChangeOwnRole
+	}
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.Role == model.RoleService {
+		return ErrInvalidRole
+	}
+	if user.Role == role {
+		return nil
+	}
+
+	before := user.Role
+	user.Role = role
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, user); err != nil {
+			return err
+		}
+		if err := s.sessions.RevokeAll(ctx, user.ID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, model.AuditActionUpdate, model.AuditResourceUser, user.ID,
+			map[string]string{"role": before}, map[string]string{"role": role})
+	})
+}
+
+// Unlock 管理员解锁被临时锁定的账号
+func (s *UserService) Unlock(ctx context.Context, id, operatorID uint) error {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	s.guard.Unlock(ctx, user, operatorID)
+	return nil
+}
+
+// ListSecurityEvents 查询安全事件
+func (s *UserService) ListSecurityEvents(ctx context.Context, filter repository.SecurityEventFilter, page, pageSize int) ([]model.SecurityEvent, int64, error) {
+	return s.guard.ListEvents(ctx, filter, page, pageSize)
 }
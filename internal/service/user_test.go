@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"myshop/internal/config"
+	"myshop/internal/model"
+	"myshop/internal/repository/repotest"
+	"myshop/pkg/cache"
+	"myshop/pkg/mailer"
+	"myshop/pkg/utils"
+	"slices"
+	"testing"
+)
+
+// userTestEnv 基于内存仓储的用户服务测试环境
+type userTestEnv struct {
+	svc           *UserService
+	users         *repotest.UserRepository
+	audits        *repotest.AuditLogRepository
+	events        *repotest.SecurityEventRepository
+	refreshTokens *repotest.RefreshTokenRepository
+	tokens        *TokenService
+	sessions      *SessionService
+	twoFactor     *TwoFactorService
+}
+
+func newUserTestEnv(t *testing.T) *userTestEnv {
+	t.Helper()
+
+	key, err := utils.GenerateEd25519Key("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring := utils.NewKeyRing()
+	ring.Add(key)
+	ring.Use(key.ID)
+	utils.InitJWT(utils.JWTOptions{KeyRing: ring})
+
+	memCache := cache.NewMemoryCache(cache.MemoryOptions{})
+	t.Cleanup(func() { memCache.Close() })
+
+	users := repotest.NewUserRepository()
+	events := repotest.NewSecurityEventRepository()
+	refreshTokens := repotest.NewRefreshTokenRepository()
+	audits := repotest.NewAuditLogRepository()
+
+	guard := NewLoginGuard(memCache, events, config.LoginSecurityConfig{MaxUserFailures: 3})
+	sessions := NewSessionService(repotest.NewSessionRepository(), refreshTokens, events, memCache)
+	tokens := NewTokenService(users, refreshTokens, sessions, events, utils.NewTokenDenylist(memCache), 0)
+	twoFactor := NewTwoFactorService(users, repotest.NewRecoveryCodeRepository(), memCache, tokens, guard, config.TwoFactorConfig{})
+	account, err := NewAccountService(repotest.NewTxManager(), users, repotest.NewUserTokenRepository(), sessions, events,
+		mailer.NewMemoryOutbox(), memCache, config.AccountConfig{TokenSecret: "test-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewUserService(repotest.NewTxManager(), users, guard, tokens, twoFactor, account, sessions, NewAuditService(audits))
+	return &userTestEnv{svc: svc, users: users, audits: audits, events: events, refreshTokens: refreshTokens,
+		tokens: tokens, sessions: sessions, twoFactor: twoFactor}
+}
+
+// register 注册一个普通用户
+func (e *userTestEnv) register(t *testing.T, username, email string) *model.User {
+	t.Helper()
+	user := &model.User{Username: username, Password: "password123", Email: email}
+	if err := e.svc.Register(context.Background(), user); err != nil {
+		t.Fatalf("注册%s失败: %v", username, err)
+	}
+	return user
+}
+
+func TestUserRegister(t *testing.T) {
+	env := newUserTestEnv(t)
+	env.register(t, "alice", "alice@example.com")
+
+	tests := []struct {
+		name  string
+		user  model.User
+		want  error
+		check func(t *testing.T, user *model.User)
+	}{
+		{
+			name: "用户名已存在",
+			user: model.User{Username: "alice", Password: "password123"},
+			want: ErrUserExists,
+		},
+		{
+			name: "邮箱忽略大小写后重复",
+			user: model.User{Username: "alice2", Password: "password123", Email: " Alice@Example.com "},
+			want: ErrEmailExists,
+		},
+		{
+			name: "不能通过注册指定角色",
+			user: model.User{Username: "mallory", Password: "password123", Role: model.RoleAdmin},
+			check: func(t *testing.T, user *model.User) {
+				if user.Role != model.RoleUser {
+					t.Fatalf("role = %q, want %q", user.Role, model.RoleUser)
+				}
+			},
+		},
+		{
+			name: "密码加密保存",
+			user: model.User{Username: "bob", Password: "password123", Email: "Bob@Example.com"},
+			check: func(t *testing.T, user *model.User) {
+				if user.Password == "password123" || !utils.CheckPassword("password123", user.Password) {
+					t.Fatal("password not hashed")
+				}
+				if user.Email != "bob@example.com" {
+					t.Fatalf("email = %q", user.Email)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := tt.user
+			err := env.svc.Register(context.Background(), &user)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if tt.check == nil {
+				return
+			}
+			stored, err := env.users.GetByUsername(context.Background(), user.Username)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, stored)
+		})
+	}
+}
+
+func TestUserLogin(t *testing.T) {
+	env := newUserTestEnv(t)
+	env.register(t, "alice", "")
+	if _, err := env.svc.CreateServiceAccount(context.Background(), "robot"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		want     error
+	}{
+		{name: "登录成功", username: "alice", password: "password123"},
+		{name: "密码错误", username: "alice", password: "wrong", want: ErrInvalidCredentials},
+		{name: "用户不存在", username: "nobody", password: "password123", want: ErrInvalidCredentials},
+		{name: "服务账号不能密码登录", username: "robot", password: "", want: ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := env.svc.Login(context.Background(), tt.username, tt.password, ClientInfo{IP: "127.0.0.1"})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if err == nil && (result.Tokens == nil || result.Tokens.AccessToken == "") {
+				t.Fatalf("result = %+v, want tokens", result)
+			}
+		})
+	}
+}
+
+func TestUserLoginLocksAfterFailures(t *testing.T) {
+	env := newUserTestEnv(t)
+	env.register(t, "alice", "")
+
+	client := ClientInfo{IP: "127.0.0.1"}
+	for i := 0; i < 3; i++ {
+		if _, err := env.svc.Login(context.Background(), "alice", "wrong", client); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("第%d次失败返回 %v", i+1, err)
+		}
+	}
+	// 锁定期间正确的密码也不能登录
+	if _, err := env.svc.Login(context.Background(), "alice", "password123", client); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("err = %v, want %v", err, ErrAccountLocked)
+	}
+}
+
+func TestUserChangeRole(t *testing.T) {
+	env := newUserTestEnv(t)
+	admin := env.register(t, "admin", "")
+	alice := env.register(t, "alice", "")
+	robot, err := env.svc.CreateServiceAccount(context.Background(), "robot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithActor(context.Background(), Actor{UserID: admin.ID, Role: model.RoleAdmin})
+
+	tests := []struct {
+		name string
+		id   uint
+		role string
+		want error
+	}{
+		{name: "未知角色", id: alice.ID, role: "root", want: ErrInvalidRole},
+		{name: "不能修改自己的角色", id: admin.ID, role: model.RoleUser, want: ErrChangeOwnRole},
+		{name: "用户不存在", id: 999, role: model.RoleAdmin, want: ErrUserNotFound},
+		{name: "不能修改服务账号", id: robot.ID, role: model.RoleAdmin, want: ErrInvalidRole},
+		{name: "提升为管理员", id: alice.ID, role: model.RoleAdmin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := env.svc.ChangeRole(ctx, tt.id, tt.role); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	stored, _ := env.users.GetByID(context.Background(), alice.ID)
+	if stored.Role != model.RoleAdmin {
+		t.Fatalf("role = %q, want %q", stored.Role, model.RoleAdmin)
+	}
+	logs, _ := env.audits.ListByResourceType(context.Background(), model.AuditResourceUser)
+	if len(logs) != 1 || logs[0].ActorID != admin.ID || logs[0].Changes["role"].After != model.RoleAdmin {
+		t.Fatalf("audit logs = %+v", logs)
+	}
+}
+
+func TestAPIKeyScopesFollowOwnerRole(t *testing.T) {
+	env := newUserTestEnv(t)
+	admin := env.register(t, "admin", "")
+	alice := env.register(t, "alice", "")
+	robot, err := env.svc.CreateServiceAccount(context.Background(), "robot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithActor(context.Background(), Actor{UserID: admin.ID, Role: model.RoleAdmin})
+	if err := env.svc.ChangeRole(ctx, alice.ID, model.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := NewAPIKeyService(repotest.NewAPIKeyRepository(), env.users)
+	scopes := []string{model.ScopeOrdersRead, model.ScopeOrdersReadAll, model.ScopeProductsWrite}
+	_, alicePlain, err := keys.Create(context.Background(), alice.ID, "alice", scopes, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, robotPlain, err := keys.Create(context.Background(), robot.ID, "robot", scopes, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.svc.ChangeRole(ctx, alice.ID, model.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := keys.ValidateAPIKey(context.Background(), alicePlain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(identity.Scopes, model.ScopeOrdersRead) {
+		t.Fatal("降级后仍应保留orders:read")
+	}
+	for _, scope := range []string{model.ScopeOrdersReadAll, model.ScopeProductsWrite} {
+		if slices.Contains(identity.Scopes, scope) {
+			t.Fatalf("降级后不应再有%s", scope)
+		}
+	}
+
+	identity, err = keys.ValidateAPIKey(context.Background(), robotPlain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identity.Scopes) != len(scopes) {
+		t.Fatalf("服务账号密钥权限 = %v, want %v", identity.Scopes, scopes)
+	}
+}
@@ -1,9 +1,25 @@
 package cache
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
+var (
+	// ErrNotFound 键不存在或已过期
+	// 其他错误表示缓存本身不可用，如Redis连接失败
+	ErrNotFound = errors.New("cache: key not found")
+	// ErrTypeMismatch 缓存中的值不是期望的类型
+	ErrTypeMismatch = errors.New("cache: type mismatch")
+)
+
+// Cache 缓存接口
+// Get在键不存在或已过期时返回ErrNotFound；expiration<=0表示永不过期
+// Incr原子地把计数加一并返回加一后的值，键不存在时从0开始并设置过期时间，已存在时不改变过期时间；
+// 计数键只能通过Incr和Delete访问
 type Cache interface {
 	Get(key string) (interface{}, error)
 	Set(key string, value interface{}, expiration time.Duration) error
 	Delete(key string) error
+	Incr(key string, expiration time.Duration) (int64, error)
 }
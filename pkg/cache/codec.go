@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"time"
+)
+
+func init() {
+	Register(time.Time{})
+}
+
+// Register 注册需要存入分布式缓存的自定义类型
+// 同一类型只能注册值或指针其中一种，存入时应使用注册时的形式，取出时还原为该形式；
+// 基本类型已由encoding/gob预先注册
+func Register(value interface{}) {
+	gob.Register(value)
+}
+
+// envelope 序列化时包一层接口，保留值的具体类型
+type envelope struct {
+	Value interface{}
+}
+
+func encode(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(envelope{Value: value}); err != nil {
+		return nil, fmt.Errorf("cache: encode %T: %w", value, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func decode(data []byte) (interface{}, error) {
+	var e envelope
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&e); err != nil {
+		return nil, fmt.Errorf("cache: decode: %w", err)
+	}
+	return e.Value, nil
+}
@@ -1,41 +1,120 @@
 package cache
 
 import (
-	"errors"
+	"container/list"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
 
+const (
+	defaultMaxEntries      = 100000
+	defaultCleanupInterval = time.Minute
+)
+
+// MemoryOptions 内存缓存配置
+type MemoryOptions struct {
+	MaxEntries      int           // 最大条目数，超出时淘汰最久未使用的条目，<=0时使用默认值
+	CleanupInterval time.Duration // 后台清理过期条目的间隔，<=0时使用默认值
+	// PinnedPrefixes 以这些前缀开头的键不计入容量、不会被淘汰，只在过期或删除时清除
+	// 用于吊销列表、登录锁定、验证码防重放等一旦丢失就会削弱安全性的状态，这些键应设置过期时间
+	PinnedPrefixes []string
+}
+
+// Stats 缓存统计
+type Stats struct {
+	Hits        uint64 `json:"hits"`        // 命中次数
+	Misses      uint64 `json:"misses"`      // 未命中次数，包括已过期
+	Evictions   uint64 `json:"evictions"`   // 因容量不足被淘汰的条目数
+	Expirations uint64 `json:"expirations"` // 因过期被清除的条目数
+	Entries     int    `json:"entries"`     // 当前条目数
+	Pinned      int    `json:"pinned"`      // 其中不会被淘汰的条目数
+}
+
 type item struct {
+	key        string
 	value      interface{}
 	expiration int64
 }
 
+func (i *item) expired(now int64) bool {
+	return i.expiration > 0 && i.expiration < now
+}
+
+// MemoryCache 进程内缓存
+// 容量有上限，按LRU淘汰；过期条目在读取时或由后台清理协程删除，Close后停止清理
+// 带固定前缀的键单独保存，不参与LRU淘汰
 type MemoryCache struct {
-	items map[string]item
-	mu    sync.RWMutex
+	mu             sync.Mutex
+	items          map[string]*list.Element
+	lru            *list.List // 链表头部为最近使用的条目
+	pinned         map[string]*item
+	pinnedPrefixes []string
+	maxEntries     int
+	stats          Stats
+	stop           chan struct{}
+	closeOnce      sync.Once
 }
 
-func NewMemoryCache() *MemoryCache {
-	return &MemoryCache{
-		items: make(map[string]item),
+// NewMemoryCache 创建内存缓存并启动后台清理
+func NewMemoryCache(opts MemoryOptions) *MemoryCache {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = defaultMaxEntries
 	}
+	if opts.CleanupInterval <= 0 {
+		opts.CleanupInterval = defaultCleanupInterval
+	}
+
+	c := &MemoryCache{
+		items:          make(map[string]*list.Element),
+		lru:            list.New(),
+		pinned:         make(map[string]*item),
+		pinnedPrefixes: opts.PinnedPrefixes,
+		maxEntries:     opts.MaxEntries,
+		stop:           make(chan struct{}),
+	}
+	go c.janitor(opts.CleanupInterval)
+	return c
 }
 
 func (c *MemoryCache) Get(key string) (interface{}, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isPinned(key) {
+		it, found := c.pinned[key]
+		if !found {
+			c.stats.Misses++
+			return nil, ErrNotFound
+		}
+		if it.expired(time.Now().UnixNano()) {
+			delete(c.pinned, key)
+			c.stats.Expirations++
+			c.stats.Misses++
+			return nil, ErrNotFound
+		}
+		c.stats.Hits++
+		return it.value, nil
+	}
 
-	item, found := c.items[key]
+	elem, found := c.items[key]
 	if !found {
-		return nil, errors.New("key not found")
+		c.stats.Misses++
+		return nil, ErrNotFound
 	}
 
-	if item.expiration > 0 && item.expiration < time.Now().UnixNano() {
-		return nil, errors.New("key expired")
+	it := elem.Value.(*item)
+	if it.expired(time.Now().UnixNano()) {
+		c.removeElement(elem)
+		c.stats.Expirations++
+		c.stats.Misses++
+		return nil, ErrNotFound
 	}
 
-	return item.value, nil
+	c.lru.MoveToFront(elem)
+	c.stats.Hits++
+	return it.value, nil
 }
 
 func (c *MemoryCache) Set(key string, value interface{}, expiration time.Duration) error {
@@ -47,17 +126,143 @@ func (c *MemoryCache) Set(key string, value interface{}, expiration time.Duratio
 		exp = time.Now().Add(expiration).UnixNano()
 	}
 
-	c.items[key] = item{
-		value:      value,
-		expiration: exp,
+	if c.isPinned(key) {
+		c.pinned[key] = &item{key: key, value: value, expiration: exp}
+		return nil
+	}
+
+	if elem, found := c.items[key]; found {
+		it := elem.Value.(*item)
+		it.value = value
+		it.expiration = exp
+		c.lru.MoveToFront(elem)
+		return nil
+	}
+
+	c.items[key] = c.lru.PushFront(&item{key: key, value: value, expiration: exp})
+	for c.lru.Len() > c.maxEntries {
+		c.removeElement(c.lru.Back())
+		c.stats.Evictions++
 	}
 	return nil
 }
 
+func (c *MemoryCache) Incr(key string, expiration time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	var it *item
+	if c.isPinned(key) {
+		it = c.pinned[key]
+	} else if elem, found := c.items[key]; found {
+		it = elem.Value.(*item)
+		c.lru.MoveToFront(elem)
+	}
+
+	if it != nil && !it.expired(now.UnixNano()) {
+		count, ok := it.value.(int64)
+		if !ok {
+			return 0, fmt.Errorf("%w: %s is %T, want int64", ErrTypeMismatch, key, it.value)
+		}
+		it.value = count + 1
+		return count + 1, nil
+	}
+
+	var exp int64
+	if expiration > 0 {
+		exp = now.Add(expiration).UnixNano()
+	}
+	if it != nil {
+		it.value, it.expiration = int64(1), exp
+		return 1, nil
+	}
+	if c.isPinned(key) {
+		c.pinned[key] = &item{key: key, value: int64(1), expiration: exp}
+		return 1, nil
+	}
+	c.items[key] = c.lru.PushFront(&item{key: key, value: int64(1), expiration: exp})
+	for c.lru.Len() > c.maxEntries {
+		c.removeElement(c.lru.Back())
+		c.stats.Evictions++
+	}
+	return 1, nil
+}
+
 func (c *MemoryCache) Delete(key string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	delete(c.items, key)
+	if elem, found := c.items[key]; found {
+		c.removeElement(elem)
+	}
+	delete(c.pinned, key)
 	return nil
 }
+
+// Stats 返回缓存统计
+func (c *MemoryCache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Pinned = len(c.pinned)
+	stats.Entries = c.lru.Len() + stats.Pinned
+	return stats
+}
+
+// Close 停止后台清理协程，可重复调用
+func (c *MemoryCache) Close() error {
+	c.closeOnce.Do(func() { close(c.stop) })
+	return nil
+}
+
+// DeleteExpired 删除全部已过期的条目
+func (c *MemoryCache) DeleteExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	for elem := c.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if elem.Value.(*item).expired(now) {
+			c.removeElement(elem)
+			c.stats.Expirations++
+		}
+		elem = prev
+	}
+	for key, it := range c.pinned {
+		if it.expired(now) {
+			delete(c.pinned, key)
+			c.stats.Expirations++
+		}
+	}
+}
+
+func (c *MemoryCache) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.DeleteExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *MemoryCache) isPinned(key string) bool {
+	for _, prefix := range c.pinnedPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *MemoryCache) removeElement(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.items, elem.Value.(*item).key)
+}
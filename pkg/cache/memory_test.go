@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheNotFound(t *testing.T) {
+	c := NewMemoryCache(MemoryOptions{})
+	defer c.Close()
+
+	if _, err := c.Get("missing"); err != ErrNotFound {
+		t.Fatalf("missing: err = %v", err)
+	}
+
+	c.Set("short", true, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, err := c.Get("short"); err != ErrNotFound {
+		t.Fatalf("expired: err = %v", err)
+	}
+
+	c.Set("forever", 1, 0)
+	if v, err := c.Get("forever"); err != nil || v != 1 {
+		t.Fatalf("forever = %v, %v", v, err)
+	}
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewMemoryCache(MemoryOptions{MaxEntries: 2})
+	defer c.Close()
+
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	c.Get("a") // a变为最近使用
+	c.Set("c", 3, 0)
+
+	if _, err := c.Get("b"); err != ErrNotFound {
+		t.Fatalf("b应该被淘汰, err = %v", err)
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, err := c.Get(key); err != nil {
+			t.Fatalf("%s: err = %v", key, err)
+		}
+	}
+
+	stats := c.Stats()
+	if stats.Evictions != 1 || stats.Entries != 2 || stats.Hits != 3 || stats.Misses != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestMemoryCacheJanitorPurgesExpired(t *testing.T) {
+	c := NewMemoryCache(MemoryOptions{CleanupInterval: 5 * time.Millisecond})
+	defer c.Close()
+
+	c.Set("short", true, time.Millisecond)
+	c.Set("long", true, time.Hour)
+
+	deadline := time.Now().Add(time.Second)
+	for c.Stats().Entries != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("过期条目没有被清理, stats = %+v", c.Stats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := c.Stats(); stats.Expirations != 1 || stats.Misses != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestMemoryCacheCloseStopsJanitor(t *testing.T) {
+	c := NewMemoryCache(MemoryOptions{CleanupInterval: time.Millisecond})
+	c.Close()
+	c.Close()
+
+	c.Set("short", true, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	if stats := c.Stats(); stats.Entries != 1 {
+		t.Fatalf("Close后不应再清理, stats = %+v", stats)
+	}
+}
+
+func TestGetAs(t *testing.T) {
+	c := NewMemoryCache(MemoryOptions{})
+	defer c.Close()
+	c.Set("count", 3, 0)
+
+	if v, err := GetAs[int](c, "count"); err != nil || v != 3 {
+		t.Fatalf("GetAs[int] = %v, %v", v, err)
+	}
+	if _, err := GetAs[string](c, "count"); !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("类型不符: err = %v", err)
+	}
+	if _, err := GetAs[int](c, "missing"); err != ErrNotFound {
+		t.Fatalf("missing: err = %v", err)
+	}
+}
+
+func TestMemoryCacheNeverEvictsPinned(t *testing.T) {
+	c := NewMemoryCache(MemoryOptions{MaxEntries: 2, PinnedPrefixes: []string{"login:lock:"}})
+	defer c.Close()
+
+	c.Set("login:lock:user:alice", true, time.Hour)
+	for i := 0; i < 10; i++ {
+		c.Set(fmt.Sprintf("product:%d", i), i, 0)
+	}
+
+	if _, err := c.Get("login:lock:user:alice"); err != nil {
+		t.Fatalf("锁定状态不应被淘汰, err = %v", err)
+	}
+	stats := c.Stats()
+	if stats.Entries != 3 || stats.Pinned != 1 || stats.Evictions != 8 {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	c.Set("login:lock:user:bob", true, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	c.DeleteExpired()
+	if _, err := c.Get("login:lock:user:bob"); err != ErrNotFound {
+		t.Fatalf("过期的固定条目应被清除, err = %v", err)
+	}
+	c.Delete("login:lock:user:alice")
+	if _, err := c.Get("login:lock:user:alice"); err != ErrNotFound {
+		t.Fatalf("删除后: err = %v", err)
+	}
+}
+
+func TestMemoryCacheIncrIsAtomic(t *testing.T) {
+	c := NewMemoryCache(MemoryOptions{})
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Incr("counter", time.Hour)
+		}()
+	}
+	wg.Wait()
+	if n, err := c.Incr("counter", time.Hour); err != nil || n != 51 {
+		t.Fatalf("counter = %d, %v", n, err)
+	}
+
+	// 窗口从第一次计数开始，过期后重新计数
+	c.Incr("window", 5*time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	if n, _ := c.Incr("window", time.Hour); n != 1 {
+		t.Fatalf("过期后应重新计数, n = %d", n)
+	}
+
+	c.Set("plain", "x", 0)
+	if _, err := c.Incr("plain", 0); !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("非计数键: err = %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisOptions Redis连接配置
+type RedisOptions struct {
+	Addr         string
+	Password     string
+	DB           int
+	PoolSize     int
+	MinIdleConns int
+	Prefix       string // 键前缀，多个应用共用同一Redis时避免冲突
+}
+
+// RedisCache 基于Redis的缓存，多个实例共享状态
+// 值经过序列化后保存，自定义类型需要先调用Register注册
+type RedisCache struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisCache 连接Redis并创建缓存，连接失败时返回错误
+func NewRedisCache(opts RedisOptions) (*RedisCache, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:         opts.Addr,
+		Password:     opts.Password,
+		DB:           opts.DB,
+		PoolSize:     opts.PoolSize,
+		MinIdleConns: opts.MinIdleConns,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("cache: connect redis %s: %w", opts.Addr, err)
+	}
+
+	return &RedisCache{client: client, prefix: opts.Prefix}, nil
+}
+
+func (c *RedisCache) Get(key string) (interface{}, error) {
+	data, err := c.client.Get(context.Background(), c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cache: redis get %s: %w", key, err)
+	}
+	return decode(data)
+}
+
+func (c *RedisCache) Set(key string, value interface{}, expiration time.Duration) error {
+	data, err := encode(value)
+	if err != nil {
+		return err
+	}
+	// 与MemoryCache一致，expiration<=0表示永不过期；go-redis中负数有特殊含义，统一换成0
+	if expiration < 0 {
+		expiration = 0
+	}
+	if err := c.client.Set(context.Background(), c.prefix+key, data, expiration).Err(); err != nil {
+		return fmt.Errorf("cache: redis set %s: %w", key, err)
+	}
+	return nil
+}
+
+// incrScript 计数加一，新建的键设置过期时间，INCR与PEXPIRE在同一脚本中执行保证原子性
+var incrScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 and tonumber(ARGV[1]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+func (c *RedisCache) Incr(key string, expiration time.Duration) (int64, error) {
+	count, err := incrScript.Run(context.Background(), c.client, []string{c.prefix + key}, expiration.Milliseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("cache: redis incr %s: %w", key, err)
+	}
+	return count, nil
+}
+
+func (c *RedisCache) Delete(key string) error {
+	if err := c.client.Del(context.Background(), c.prefix+key).Err(); err != nil {
+		return fmt.Errorf("cache: redis delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// Close 关闭连接池
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+type testSession struct {
+	UserID    uint
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+type testChallenge struct {
+	Attempts int
+}
+
+func init() {
+	Register(testSession{})
+	Register(&testChallenge{})
+}
+
+func newTestRedisCache(t *testing.T) (*RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	c, err := NewRedisCache(RedisOptions{Addr: server.Addr(), Prefix: "test:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, server
+}
+
+func TestRedisCacheRoundTripKeepsType(t *testing.T) {
+	c, server := newTestRedisCache(t)
+	now := time.Now().Truncate(time.Second)
+
+	values := map[string]interface{}{
+		"int":     42,
+		"int64":   int64(7),
+		"bool":    true,
+		"string":  "hello",
+		"time":    now,
+		"struct":  testSession{UserID: 1, Scopes: []string{"a"}, ExpiresAt: now},
+		"pointer": &testChallenge{Attempts: 2},
+	}
+	for key, value := range values {
+		if err := c.Set(key, value, time.Minute); err != nil {
+			t.Fatalf("Set(%s): %v", key, err)
+		}
+	}
+	if !server.Exists("test:int") {
+		t.Fatal("键没有加上前缀")
+	}
+
+	if v, _ := c.Get("int"); v != 42 {
+		t.Errorf("int = %#v", v)
+	}
+	if v, _ := c.Get("int64"); v != int64(7) {
+		t.Errorf("int64 = %#v", v)
+	}
+	if v, _ := c.Get("bool"); v != true {
+		t.Errorf("bool = %#v", v)
+	}
+	if v, _ := c.Get("string"); v != "hello" {
+		t.Errorf("string = %#v", v)
+	}
+	if v, _ := c.Get("time"); !v.(time.Time).Equal(now) {
+		t.Errorf("time = %#v", v)
+	}
+	if v, _ := c.Get("struct"); v.(testSession).UserID != 1 || v.(testSession).Scopes[0] != "a" {
+		t.Errorf("struct = %#v", v)
+	}
+	if v, _ := c.Get("pointer"); v.(*testChallenge).Attempts != 2 {
+		t.Errorf("pointer = %#v", v)
+	}
+}
+
+func TestRedisCacheUnregisteredType(t *testing.T) {
+	c, _ := newTestRedisCache(t)
+	type unregistered struct{ A int }
+
+	if err := c.Set("k", unregistered{A: 1}, time.Minute); err == nil {
+		t.Fatal("未注册的类型应该返回错误")
+	}
+}
+
+func TestRedisCacheNotFound(t *testing.T) {
+	c, server := newTestRedisCache(t)
+
+	if _, err := c.Get("missing"); err != ErrNotFound {
+		t.Fatalf("missing: err = %v", err)
+	}
+
+	if err := c.Set("short", true, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	server.FastForward(2 * time.Second)
+	if _, err := c.Get("short"); err != ErrNotFound {
+		t.Fatalf("expired: err = %v", err)
+	}
+
+	if err := c.Set("deleted", true, 0); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := server.TTL("test:deleted"); ttl != 0 {
+		t.Fatalf("expiration为0时不应过期, ttl = %v", ttl)
+	}
+	if err := c.Delete("deleted"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("deleted"); err != ErrNotFound {
+		t.Fatalf("deleted: err = %v", err)
+	}
+}
+
+func TestRedisCacheIncr(t *testing.T) {
+	c, server := newTestRedisCache(t)
+
+	for want := int64(1); want <= 3; want++ {
+		if n, err := c.Incr("counter", time.Minute); err != nil || n != want {
+			t.Fatalf("counter = %d, %v, want %d", n, err, want)
+		}
+	}
+	// 只有第一次计数设置过期时间，后续计数不延长窗口
+	server.FastForward(30 * time.Second)
+	c.Incr("counter", time.Minute)
+	if ttl := server.TTL("test:counter"); ttl != 30*time.Second {
+		t.Fatalf("ttl = %v, want 30s", ttl)
+	}
+	server.FastForward(time.Minute)
+	if n, _ := c.Incr("counter", time.Minute); n != 1 {
+		t.Fatalf("过期后应重新计数, n = %d", n)
+	}
+
+	c.Incr("forever", 0)
+	if ttl := server.TTL("test:forever"); ttl != 0 {
+		t.Fatalf("expiration为0时不应过期, ttl = %v", ttl)
+	}
+}
+
+func TestRedisCacheConnectionErrorIsNotNotFound(t *testing.T) {
+	c, server := newTestRedisCache(t)
+	server.Close()
+
+	_, err := c.Get("any")
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("连接失败应返回非ErrNotFound的错误, err = %v", err)
+	}
+}
+
+func TestNewRedisCacheUnreachable(t *testing.T) {
+	server := miniredis.RunT(t)
+	addr := server.Addr()
+	server.Close()
+
+	if _, err := NewRedisCache(RedisOptions{Addr: addr}); err == nil {
+		t.Fatal("无法连接时应返回错误")
+	}
+}
@@ -0,0 +1,18 @@
+package cache
+
+import "fmt"
+
+// GetAs 读取缓存并转换为指定类型
+// 键不存在时返回ErrNotFound，类型不符时返回ErrTypeMismatch
+func GetAs[T any](c Cache, key string) (T, error) {
+	var zero T
+	v, err := c.Get(key)
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := v.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s is %T, want %T", ErrTypeMismatch, key, v, zero)
+	}
+	return typed, nil
+}
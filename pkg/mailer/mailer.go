@@ -0,0 +1,17 @@
+package mailer
+
+import "time"
+
+// Message 邮件内容
+type Message struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"` // 纯文本正文
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// Mailer 邮件发送接口
+// 生产环境使用SMTPMailer，开发和测试环境使用MemoryOutbox或FileOutbox
+type Mailer interface {
+	Send(msg Message) error
+}
@@ -1,28 +1,84 @@
 package middleware
 
 import (
-	"myshop/pkg/utils"
-
 	"github.com/gin-gonic/gin"
 )
 
-func Auth() gin.HandlerFunc {
+// Auth 依次尝试各认证器
+// 第一个在请求中找到凭证的认证器决定结果，凭证无效时不再尝试后续认证器
+func Auth(authenticators ...Authenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("Authorization")
-		if token == "" {
-			c.JSON(401, gin.H{"error": "未授权"})
-			c.Abort()
+		for _, a := range authenticators {
+			principal, err := a.Authenticate(c)
+			if err == ErrNoCredentials {
+				continue
+			}
+			if err != nil {
+				c.JSON(401, gin.H{"error": "无效的凭证"})
+				c.Abort()
+				return
+			}
+
+			c.Set(principalKey, principal)
+			c.Next()
 			return
 		}
 
-		userID, err := utils.ValidateToken(token)
-		if err != nil {
-			c.JSON(401, gin.H{"error": "无效的token"})
+		c.JSON(401, gin.H{"error": "未授权"})
+		c.Abort()
+	}
+}
+
+// RequireRole 要求当前用户具备指定角色之一，需在Auth之后使用
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := GetPrincipal(c); ok {
+			for _, r := range roles {
+				if principal.HasRole(r) {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.JSON(403, gin.H{"error": "权限不足"})
+		c.Abort()
+	}
+}
+
+// RequireMFAForRole 要求具备指定角色的主体登录时通过了两步验证，其他主体不受限制
+func RequireMFAForRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := GetPrincipal(c); ok && principal.HasRole(role) && !principal.MFA {
+			c.JSON(403, gin.H{"error": "该操作需要启用两步验证后重新登录"})
 			c.Abort()
 			return
 		}
+		c.Next()
+	}
+}
 
-		c.Set("userID", userID)
+// RequireScope 要求当前主体具备指定权限，登录用户与API Key使用同一套检查
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := GetPrincipal(c); ok && principal.HasScope(scope) {
+			c.Next()
+			return
+		}
+
+		c.JSON(403, gin.H{"error": "权限不足"})
+		c.Abort()
+	}
+}
+
+// DenyAPIKey 拒绝API Key主体，用于只允许登录用户本人操作的接口
+func DenyAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := GetPrincipal(c); ok && principal.Method == AuthMethodAPIKey {
+			c.JSON(403, gin.H{"error": "该接口不支持API Key访问"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"myshop/pkg/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrNoCredentials 请求中没有该认证器处理的凭证，交给下一个认证器
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials 凭证存在但无效
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator 认证器，从请求中解析一种凭证
+type Authenticator interface {
+	Authenticate(c *gin.Context) (*Principal, error)
+}
+
+// SessionChecker 校验访问令牌所属的登录会话是否仍然有效
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, sessionID uint) bool
+}
+
+// BearerAuthenticator 解析 Authorization: Bearer {token}
+type BearerAuthenticator struct {
+	denylist *utils.TokenDenylist
+	sessions SessionChecker
+	scopes   RoleScopes
+}
+
+// NewBearerAuthenticator 创建Bearer令牌认证器
+func NewBearerAuthenticator(denylist *utils.TokenDenylist, sessions SessionChecker, scopes RoleScopes) *BearerAuthenticator {
+	return &BearerAuthenticator{denylist: denylist, sessions: sessions, scopes: scopes}
+}
+
+func (a *BearerAuthenticator) Authenticate(c *gin.Context) (*Principal, error) {
+	header := c.GetHeader("Authorization")
+	if header == "" {
+		return nil, ErrNoCredentials
+	}
+
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrInvalidCredentials
+	}
+
+	return tokenPrincipal(c.Request.Context(), strings.TrimSpace(token), a.denylist, a.sessions, a.scopes, AuthMethodBearer)
+}
+
+// CookieAuthenticator 从HTTP-only Cookie中读取访问令牌
+type CookieAuthenticator struct {
+	name     string
+	denylist *utils.TokenDenylist
+	sessions SessionChecker
+	scopes   RoleScopes
+}
+
+// NewCookieAuthenticator 创建Cookie认证器
+func NewCookieAuthenticator(name string, denylist *utils.TokenDenylist, sessions SessionChecker, scopes RoleScopes) *CookieAuthenticator {
+	return &CookieAuthenticator{name: name, denylist: denylist, sessions: sessions, scopes: scopes}
+}
+
+func (a *CookieAuthenticator) Authenticate(c *gin.Context) (*Principal, error) {
+	token, err := c.Cookie(a.name)
+	if err != nil || token == "" {
+		return nil, ErrNoCredentials
+	}
+
+	return tokenPrincipal(c.Request.Context(), token, a.denylist, a.sessions, a.scopes, AuthMethodCookie)
+}
+
+// APIKeyValidator 校验API Key并返回对应的认证主体
+type APIKeyValidator interface {
+	ValidateAPIKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// APIKeyAuthenticator 解析 X-API-Key 请求头
+type APIKeyAuthenticator struct {
+	validator APIKeyValidator
+}
+
+// NewAPIKeyAuthenticator 创建API Key认证器
+func NewAPIKeyAuthenticator(validator APIKeyValidator) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{validator: validator}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(c *gin.Context) (*Principal, error) {
+	key := c.GetHeader("X-API-Key")
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	principal, err := a.validator.ValidateAPIKey(c.Request.Context(), key)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	principal.Method = AuthMethodAPIKey
+	return principal, nil
+}
+
+// tokenPrincipal 校验访问令牌，已加入黑名单或所属会话已吊销的令牌视为无效
+func tokenPrincipal(ctx context.Context, token string, denylist *utils.TokenDenylist, sessions SessionChecker, scopes RoleScopes, method AuthMethod) (*Principal, error) {
+	claims, err := utils.ValidateToken(token)
+	if err != nil || denylist.IsRevoked(claims.ID) {
+		return nil, ErrInvalidCredentials
+	}
+	if claims.SessionID != 0 && !sessions.IsSessionActive(ctx, claims.SessionID) {
+		return nil, ErrInvalidCredentials
+	}
+
+	roles := []string{claims.Role}
+	return &Principal{
+		UserID:    claims.UserID,
+		Roles:     roles,
+		Scopes:    scopes.forRoles(roles),
+		Method:    method,
+		MFA:       claims.MFA,
+		TokenID:   claims.ID,
+		SessionID: claims.SessionID,
+		ExpiresAt: claims.ExpiresAt,
+	}, nil
+}
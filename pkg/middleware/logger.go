@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -29,7 +30,7 @@ func Logger() gin.HandlerFunc {
 		gin.DefaultWriter.Write([]byte(
 			"method=" + c.Request.Method + " " +
 				"path=" + path + " " +
-				"status=" + string(c.Writer.Status()) + " " +
+				"status=" + strconv.Itoa(c.Writer.Status()) + " " +
 				"latency=" + latency.String() + "\n"))
 	}
 }
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthMethod 认证方式
+type AuthMethod string
+
+const (
+	AuthMethodBearer AuthMethod = "bearer"  // Authorization: Bearer {token}
+	AuthMethodCookie AuthMethod = "cookie"  // HTTP-only Cookie，供浏览器使用
+	AuthMethodAPIKey AuthMethod = "api_key" // X-API-Key，供服务账号使用
+)
+
+// principalKey 认证主体在gin上下文中的键
+const principalKey = "principal"
+
+// Principal 当前请求的认证主体
+type Principal struct {
+	UserID    uint       // 用户ID
+	Roles     []string   // 角色
+	Scopes    []string   // 权限范围
+	Method    AuthMethod // 认证方式
+	MFA       bool       // 登录时是否通过了两步验证
+	TokenID   string     // 访问令牌jti，API Key认证时为空
+	SessionID uint       // 登录会话ID，API Key认证时为0
+	ExpiresAt time.Time  // 凭证过期时间
+}
+
+// HasRole 判断主体是否具备指定角色
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope 判断主体是否具备指定权限，"*"表示全部权限
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == scopeAll {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeAll 全部权限
+const scopeAll = "*"
+
+// RoleScopes 角色到权限范围的映射，登录令牌主体的权限由角色推导
+type RoleScopes map[string][]string
+
+// forRoles 汇总多个角色的权限范围
+func (m RoleScopes) forRoles(roles []string) []string {
+	var scopes []string
+	for _, role := range roles {
+		scopes = append(scopes, m[role]...)
+	}
+	return scopes
+}
+
+// GetPrincipal 获取当前请求的认证主体，未经Auth中间件的请求返回false
+func GetPrincipal(c *gin.Context) (*Principal, bool) {
+	v, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	p, ok := v.(*Principal)
+	return p, ok
+}
+
+// CurrentUserID 获取当前登录用户ID，未认证时返回0
+func CurrentUserID(c *gin.Context) uint {
+	if p, ok := GetPrincipal(c); ok {
+		return p.UserID
+	}
+	return 0
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"myshop/pkg/utils"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 请求ID的请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey 请求ID在gin上下文中的键
+const requestIDKey = "request_id"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID 为每个请求分配请求ID
+// 沿用上游网关传入的合法请求ID，否则随机生成，并在响应头中返回
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id, _ = utils.RandomToken(12)
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetRequestID 获取当前请求的请求ID，未经RequestID中间件时返回空
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
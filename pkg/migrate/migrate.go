@@ -0,0 +1,226 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lockName 迁移锁名称，多个实例同时启动迁移时只有一个能执行
+const lockName = "myshop_schema_migrations"
+
+// lockTimeout 等待迁移锁的最长时间
+const lockTimeout = time.Minute
+
+var (
+	// ErrDirty 数据库中存在代码里没有的迁移版本，通常是用旧版本程序连接了新版本的数据库
+	ErrDirty = errors.New("migrate: database has migrations unknown to this binary")
+	// ErrLocked 等待迁移锁超时
+	ErrLocked = errors.New("migrate: timed out waiting for migration lock")
+	// ErrNoDown 迁移不支持回滚
+	ErrNoDown = errors.New("migrate: migration cannot be rolled back")
+)
+
+// Migration 一个版本的数据库结构变更
+// Version按顺序递增，发布后不能修改；已执行的迁移不能再修改内容，需要调整时新增迁移
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // 为空表示不支持回滚
+}
+
+// SchemaMigration 已执行的迁移记录
+type SchemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:128"`
+	AppliedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName 迁移记录表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status 迁移状态
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// Migrator 执行迁移
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New 创建迁移执行器，迁移按版本号排序，版本号重复时返回错误
+func New(db *gorm.DB, migrations []Migration) (*Migrator, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Version == sorted[i-1].Version {
+			return nil, fmt.Errorf("migrate: duplicate version %d", sorted[i].Version)
+		}
+	}
+	return &Migrator{db: db, migrations: sorted}, nil
+}
+
+// Up 执行全部未执行的迁移，返回执行的数量
+func (m *Migrator) Up() (int, error) {
+	count := 0
+	err := m.withLock(func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := mig.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&SchemaMigration{Version: mig.Version, Name: mig.Name}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migrate: up %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down 按版本倒序回滚最近执行的steps个迁移，返回回滚的数量
+func (m *Migrator) Down(steps int) (int, error) {
+	count := 0
+	err := m.withLock(func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == nil {
+				return fmt.Errorf("%w: %d_%s", ErrNoDown, mig.Version, mig.Name)
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := mig.Down(tx); err != nil {
+					return err
+				}
+				return tx.Delete(&SchemaMigration{}, mig.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migrate: down %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Status 返回全部迁移的执行状态
+func (m *Migrator) Status() ([]Status, error) {
+	applied := map[int64]SchemaMigration{}
+	if m.db.Migrator().HasTable(&SchemaMigration{}) {
+		var err error
+		if applied, err = m.applied(m.db); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if record, ok := applied[mig.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Check 检查数据库结构是否为最新，有未执行的迁移时返回未执行的数量，
+// 数据库中有未知版本时返回ErrDirty
+func (m *Migrator) Check() (int, error) {
+	if !m.db.Migrator().HasTable(&SchemaMigration{}) {
+		return len(m.migrations), nil
+	}
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return 0, err
+	}
+
+	known := make(map[int64]bool, len(m.migrations))
+	pending := 0
+	for _, mig := range m.migrations {
+		known[mig.Version] = true
+		if _, ok := applied[mig.Version]; !ok {
+			pending++
+		}
+	}
+	for version := range applied {
+		if !known[version] {
+			return pending, fmt.Errorf("%w: version %d", ErrDirty, version)
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) applied(db *gorm.DB) (map[int64]SchemaMigration, error) {
+	var records []SchemaMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]SchemaMigration, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// withLock 在同一个数据库连接上持有迁移锁执行fn
+// MySQL和PostgreSQL使用会话级的咨询锁，进程异常退出时随连接自动释放；
+// SQLite只允许单个写入者，不需要额外加锁
+func (m *Migrator) withLock(fn func(db *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		switch conn.Dialector.Name() {
+		case "mysql":
+			var got int
+			if err := conn.Raw("SELECT GET_LOCK(?, ?)", lockName, int(lockTimeout.Seconds())).Scan(&got).Error; err != nil {
+				return err
+			}
+			if got != 1 {
+				return ErrLocked
+			}
+			defer conn.Exec("SELECT RELEASE_LOCK(?)", lockName)
+		case "postgres":
+			ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+			defer cancel()
+			if err := conn.WithContext(ctx).Exec("SELECT pg_advisory_lock(hashtext(?))", lockName).Error; err != nil {
+				return fmt.Errorf("%w: %v", ErrLocked, err)
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(hashtext(?))", lockName)
+		}
+
+		if err := conn.AutoMigrate(&SchemaMigration{}); err != nil {
+			return err
+		}
+		// 新会话不继承上面语句中的表名等状态
+		return fn(conn.Session(&gorm.Session{NewDB: true}))
+	})
+}
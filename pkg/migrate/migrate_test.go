@@ -0,0 +1,108 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func createTable(name string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		return tx.Exec("CREATE TABLE " + name + " (id integer primary key)").Error
+	}
+}
+
+func TestMigratorRunsInVersionOrder(t *testing.T) {
+	db := newTestDB(t)
+	var order []int64
+	step := func(v int64) Migration {
+		return Migration{Version: v, Name: "step", Up: func(tx *gorm.DB) error {
+			order = append(order, v)
+			return nil
+		}}
+	}
+
+	m, err := New(db, []Migration{step(3), step(1), step(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 3 || order[0] != 1 || order[1] != 2 || order[2] != 3 {
+		t.Fatalf("执行顺序 = %v", order)
+	}
+
+	if _, err := New(db, []Migration{step(1), step(1)}); err == nil {
+		t.Fatal("版本号重复时应返回错误")
+	}
+}
+
+func TestMigratorFailedMigrationIsNotRecorded(t *testing.T) {
+	db := newTestDB(t)
+	m, _ := New(db, []Migration{
+		{Version: 1, Name: "a", Up: createTable("a")},
+		{Version: 2, Name: "broken", Up: func(tx *gorm.DB) error { return errors.New("boom") }},
+	})
+
+	if n, err := m.Up(); err == nil || n != 1 {
+		t.Fatalf("Up() = %d, %v", n, err)
+	}
+	if pending, err := m.Check(); err != nil || pending != 1 {
+		t.Fatalf("Check() = %d, %v", pending, err)
+	}
+}
+
+func TestMigratorDown(t *testing.T) {
+	db := newTestDB(t)
+	m, _ := New(db, []Migration{
+		{Version: 1, Name: "a", Up: createTable("a")},
+		{Version: 2, Name: "b", Up: createTable("b"), Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable("b")
+		}},
+	})
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := m.Down(1); err != nil || n != 1 {
+		t.Fatalf("Down(1) = %d, %v", n, err)
+	}
+	if db.Migrator().HasTable("b") {
+		t.Fatal("b应该已被删除")
+	}
+	if _, err := m.Down(1); !errors.Is(err, ErrNoDown) {
+		t.Fatalf("不支持回滚的迁移应返回ErrNoDown, err = %v", err)
+	}
+}
+
+func TestMigratorCheckDetectsUnknownVersion(t *testing.T) {
+	db := newTestDB(t)
+	newer, _ := New(db, []Migration{
+		{Version: 1, Name: "a", Up: createTable("a")},
+		{Version: 2, Name: "b", Up: createTable("b")},
+	})
+	if _, err := newer.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	older, _ := New(db, []Migration{{Version: 1, Name: "a", Up: createTable("a")}})
+	if _, err := older.Check(); !errors.Is(err, ErrDirty) {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// currencyDigits 各币种最小货币单位的小数位数，如人民币的最小单位是分，保留2位
+var currencyDigits = map[string]int{
+	"CNY": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"HKD": 2,
+	"SGD": 2,
+	"AUD": 2,
+	"CAD": 2,
+	"JPY": 0,
+	"KRW": 0,
+}
+
+var (
+	currencyMu      sync.RWMutex
+	defaultCurrency = "CNY"
+)
+
+// RegisterCurrency 注册币种及其小数位数，已存在时覆盖
+func RegisterCurrency(code string, digits int) error {
+	code = strings.ToUpper(code)
+	if len(code) != 3 || digits < 0 || digits > 4 {
+		return fmt.Errorf("%w: %s", ErrUnknownCurrency, code)
+	}
+	currencyMu.Lock()
+	defer currencyMu.Unlock()
+	currencyDigits[code] = digits
+	return nil
+}
+
+// Digits 返回币种的小数位数
+func Digits(code string) (int, bool) {
+	currencyMu.RLock()
+	defer currencyMu.RUnlock()
+	digits, ok := currencyDigits[code]
+	return digits, ok
+}
+
+// NormalizeCurrency 校验币种代码并转为大写
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if _, ok := Digits(code); !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownCurrency, code)
+	}
+	return code, nil
+}
+
+// SetDefaultCurrency 设置默认币种，即商品定价使用的本位币
+// 从数据库和JSON读取的金额不含币种，使用默认币种；应在启动时设置一次
+func SetDefaultCurrency(code string) error {
+	code, err := NormalizeCurrency(code)
+	if err != nil {
+		return err
+	}
+	currencyMu.Lock()
+	defer currencyMu.Unlock()
+	defaultCurrency = code
+	return nil
+}
+
+// DefaultCurrency 返回默认币种
+func DefaultCurrency() string {
+	currencyMu.RLock()
+	defer currencyMu.RUnlock()
+	return defaultCurrency
+}
+
+// digitsOf 返回币种的小数位数，币种未注册时panic
+func digitsOf(code string) int {
+	digits, ok := Digits(code)
+	if !ok {
+		panic(fmt.Errorf("%w: %s", ErrUnknownCurrency, code))
+	}
+	return digits
+}
@@ -0,0 +1,400 @@
+// Package money 精确的金额计算
+// 金额以最小货币单位（如分）的整数保存并携带币种，避免浮点数累加和乘法产生的误差；
+// 不同币种的金额不能直接运算，需先按汇率换算
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnknownCurrency 未注册的币种
+	ErrUnknownCurrency = errors.New("money: unknown currency")
+	// ErrInvalidAmount 金额格式错误，或小数位数超过币种的精度
+	ErrInvalidAmount = errors.New("money: invalid amount")
+	// ErrCurrencyMismatch 不同币种的金额之间运算
+	ErrCurrencyMismatch = errors.New("money: currency mismatch")
+	// ErrOverflow 金额超出可表示的范围
+	ErrOverflow = errors.New("money: amount overflow")
+)
+
+// RoundingMode 舍入方式
+type RoundingMode int
+
+const (
+	RoundHalfUp   RoundingMode = iota // 四舍五入，.5远离零舍入，价格计算默认使用
+	RoundHalfEven                     // 银行家舍入，.5舍入到偶数，用于汇率换算等大量累计的场景
+	RoundDown                         // 向零舍入
+	RoundUp                           // 远离零舍入
+)
+
+// Amount 金额
+// 零值表示默认币种的0，可以直接用作累加的初始值
+type Amount struct {
+	minor    int64  // 最小货币单位的数量
+	currency string // ISO 4217币种代码，为空时表示默认币种
+}
+
+// New 以最小货币单位创建金额，如New(599900, "CNY")表示5999.00元
+func New(minor int64, currency string) Amount {
+	digitsOf(currency)
+	return Amount{minor: minor, currency: currency}
+}
+
+// Zero 返回币种的0
+func Zero(currency string) Amount {
+	return New(0, currency)
+}
+
+// Parse 解析十进制字符串表示的金额，如"5999.00"
+// 小数位数超过币种精度时返回ErrInvalidAmount，末尾多余的0除外
+func Parse(s, currency string) (Amount, error) {
+	digits, ok := Digits(currency)
+	if !ok {
+		return Amount{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, currency)
+	}
+	minor, err := parseMinor(strings.TrimSpace(s), digits)
+	if err != nil {
+		return Amount{}, fmt.Errorf("%w: %q", err, s)
+	}
+	return Amount{minor: minor, currency: currency}, nil
+}
+
+// MustParse 与Parse相同，出错时panic，用于常量和测试
+func MustParse(s, currency string) Amount {
+	a, err := Parse(s, currency)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func parseMinor(s string, digits int) (int64, error) {
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg, s = true, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, ErrInvalidAmount
+	}
+	if len(fracPart) > digits {
+		if strings.Trim(fracPart[digits:], "0") != "" {
+			return 0, ErrInvalidAmount
+		}
+		fracPart = fracPart[:digits]
+	}
+	fracPart += strings.Repeat("0", digits-len(fracPart))
+
+	v, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		if intPart+fracPart == "" {
+			return 0, nil
+		}
+		return 0, ErrOverflow
+	}
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Minor 返回最小货币单位的数量
+func (a Amount) Minor() int64 { return a.minor }
+
+// Currency 返回币种
+func (a Amount) Currency() string {
+	if a.currency == "" {
+		return DefaultCurrency()
+	}
+	return a.currency
+}
+
+// In 返回数值相同、币种为currency的金额，用于给从数据库读取的金额标记实际币种
+func (a Amount) In(currency string) Amount {
+	return New(a.minor, currency)
+}
+
+// IsZero 是否为0
+func (a Amount) IsZero() bool { return a.minor == 0 }
+
+// IsPositive 是否大于0
+func (a Amount) IsPositive() bool { return a.minor > 0 }
+
+// IsNegative 是否小于0
+func (a Amount) IsNegative() bool { return a.minor < 0 }
+
+// Add 加法，币种不同或结果溢出时panic
+func (a Amount) Add(b Amount) Amount {
+	sum, err := a.CheckedAdd(b)
+	if err != nil {
+		panic(err)
+	}
+	return sum
+}
+
+// CheckedAdd 加法，结果超出可表示的范围时返回ErrOverflow，用于金额来自外部输入的场景；币种不同时panic
+func (a Amount) CheckedAdd(b Amount) (Amount, error) {
+	cur := a.match(b)
+	sum := a.minor + b.minor
+	if (sum > a.minor) != (b.minor > 0) {
+		return Amount{}, ErrOverflow
+	}
+	return Amount{minor: sum, currency: cur}, nil
+}
+
+// Sub 减法，币种不同时panic
+func (a Amount) Sub(b Amount) Amount {
+	return a.Add(b.Neg())
+}
+
+// Neg 取相反数
+func (a Amount) Neg() Amount {
+	if a.minor == math.MinInt64 {
+		panic(ErrOverflow)
+	}
+	return Amount{minor: -a.minor, currency: a.currency}
+}
+
+// Mul 乘以整数，如单价乘数量；结果溢出时panic
+func (a Amount) Mul(n int64) Amount {
+	product, err := a.CheckedMul(n)
+	if err != nil {
+		panic(err)
+	}
+	return product
+}
+
+// CheckedMul 乘以整数，结果超出可表示的范围时返回ErrOverflow
+func (a Amount) CheckedMul(n int64) (Amount, error) {
+	product := new(big.Int).Mul(big.NewInt(a.minor), big.NewInt(n))
+	if !product.IsInt64() {
+		return Amount{}, ErrOverflow
+	}
+	return Amount{minor: product.Int64(), currency: a.currency}, nil
+}
+
+// MulFrac 乘以分数num/den并按mode舍入到最小货币单位，如打八五折为MulFrac(85, 100, RoundHalfUp)
+func (a Amount) MulFrac(num, den int64, mode RoundingMode) Amount {
+	if den == 0 {
+		panic("money: zero denominator")
+	}
+	product := new(big.Int).Mul(big.NewInt(a.minor), big.NewInt(num))
+	return Amount{minor: toInt64(divRound(product, big.NewInt(den), mode)), currency: a.currency}
+}
+
+// Cmp 比较大小，a<b返回-1，相等返回0，a>b返回1；币种不同时panic
+func (a Amount) Cmp(b Amount) int {
+	a.match(b)
+	switch {
+	case a.minor < b.minor:
+		return -1
+	case a.minor > b.minor:
+		return 1
+	}
+	return 0
+}
+
+// Min 返回较小的金额
+func Min(a, b Amount) Amount {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+// Allocate 按权重把金额分摊为多份，各份之和严格等于原金额
+// 先按比例向零取整，余下的最小单位依次分给舍去部分最大的份额，舍去部分相同时靠前的优先
+func (a Amount) Allocate(weights []int64) []Amount {
+	parts := make([]Amount, len(weights))
+	var total int64
+	for _, w := range weights {
+		if w < 0 {
+			panic("money: negative weight")
+		}
+		total += w
+	}
+	if total == 0 {
+		for i := range parts {
+			parts[i] = Amount{currency: a.currency}
+		}
+		return parts
+	}
+
+	abs := new(big.Int).Abs(big.NewInt(a.minor))
+	remainders := make([]*big.Int, len(weights))
+	allocated := new(big.Int)
+	for i, w := range weights {
+		share, rem := new(big.Int).QuoRem(new(big.Int).Mul(abs, big.NewInt(w)), big.NewInt(total), new(big.Int))
+		parts[i] = Amount{minor: share.Int64(), currency: a.currency}
+		remainders[i] = rem
+		allocated.Add(allocated, share)
+	}
+
+	left := new(big.Int).Sub(abs, allocated).Int64()
+	for ; left > 0; left-- {
+		best := -1
+		for i, rem := range remainders {
+			if weights[i] > 0 && (best < 0 || rem.Cmp(remainders[best]) > 0) {
+				best = i
+			}
+		}
+		parts[best].minor++
+		remainders[best] = new(big.Int)
+	}
+	if a.minor < 0 {
+		for i := range parts {
+			parts[i].minor = -parts[i].minor
+		}
+	}
+	return parts
+}
+
+// String 返回十进制字符串，小数位数等于币种精度，如"5999.00"
+func (a Amount) String() string {
+	digits := digitsOf(a.Currency())
+	s := strconv.FormatInt(a.minor, 10)
+	neg := a.minor < 0
+	if neg {
+		s = s[1:]
+	}
+	if digits > 0 {
+		if len(s) <= digits {
+			s = strings.Repeat("0", digits-len(s)+1) + s
+		}
+		s = s[:len(s)-digits] + "." + s[len(s)-digits:]
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// Display 返回带币种的字符串，如"CNY 5999.00"
+func (a Amount) Display() string {
+	return a.Currency() + " " + a.String()
+}
+
+// match 检查两个金额的币种是否相同，返回运算结果的币种
+// 零值金额与任意币种兼容
+func (a Amount) match(b Amount) string {
+	switch {
+	case a.currency == b.currency:
+		return a.currency
+	case a.currency == "" && a.minor == 0:
+		return b.currency
+	case b.currency == "" && b.minor == 0:
+		return a.currency
+	case a.Currency() == b.Currency():
+		return a.Currency()
+	}
+	panic(fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency(), b.Currency()))
+}
+
+// divRound 计算x/y并按mode舍入
+func divRound(x, y *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(x, y, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	// 结果的符号，余数不为0时商向零截断，需要远离零调整时按该符号加减1
+	sign := int64(x.Sign() * y.Sign())
+	away := false
+	switch mode {
+	case RoundUp:
+		away = true
+	case RoundHalfUp, RoundHalfEven:
+		// 比较2|r|与|y|判断舍去部分与0.5的大小
+		cmp := new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(new(big.Int).Abs(y))
+		away = cmp > 0 || cmp == 0 && (mode == RoundHalfUp || q.Bit(0) == 1)
+	}
+	if away {
+		q.Add(q, big.NewInt(sign))
+	}
+	return q
+}
+
+func toInt64(v *big.Int) int64 {
+	if !v.IsInt64() {
+		panic(ErrOverflow)
+	}
+	return v.Int64()
+}
+
+// MarshalJSON 编码为十进制字符串，避免客户端按浮点数解析
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON 接受十进制字符串或数字，数字按字面值解析，不经过浮点数；币种为默认币种
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	s := string(data)
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	parsed, err := Parse(s, DefaultCurrency())
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value 实现driver.Valuer，以十进制字符串写入decimal列
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan 实现sql.Scanner，读取decimal列，币种为默认币种
+// 币种不同的金额（如订单金额）由模型在读取后用In标记实际币种
+func (a *Amount) Scan(src interface{}) error {
+	currency := DefaultCurrency()
+	digits := digitsOf(currency)
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*a = Amount{currency: currency}
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		// SQLite没有定点小数类型，decimal列以浮点数保存，按币种精度舍入还原
+		s = strconv.FormatFloat(v, 'f', digits, 64)
+	default:
+		return fmt.Errorf("%w: unsupported type %T", ErrInvalidAmount, src)
+	}
+	parsed, err := Parse(s, currency)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
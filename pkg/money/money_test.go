@@ -0,0 +1,184 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     int64
+		err      error
+	}{
+		{"5999", "CNY", 599900, nil},
+		{"5999.9", "CNY", 599990, nil},
+		{"0.01", "CNY", 1, nil},
+		{".5", "CNY", 50, nil},
+		{"-12.30", "CNY", -1230, nil},
+		{"12.3400", "CNY", 1234, nil},
+		{"1000", "JPY", 1000, nil},
+		{"12.345", "CNY", 0, ErrInvalidAmount},
+		{"12.5", "JPY", 0, ErrInvalidAmount},
+		{"1e3", "CNY", 0, ErrInvalidAmount},
+		{"", "CNY", 0, ErrInvalidAmount},
+		{"99999999999999999999", "CNY", 0, ErrOverflow},
+		{"1", "XXX", 0, ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, tt.currency)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Parse(%q, %s) err = %v, want %v", tt.in, tt.currency, err, tt.err)
+			continue
+		}
+		if err == nil && got.Minor() != tt.want {
+			t.Errorf("Parse(%q, %s) = %d, want %d", tt.in, tt.currency, got.Minor(), tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		amount Amount
+		want   string
+	}{
+		{New(599900, "CNY"), "5999.00"},
+		{New(5, "CNY"), "0.05"},
+		{New(-5, "CNY"), "-0.05"},
+		{New(-1230, "USD"), "-12.30"},
+		{New(1000, "JPY"), "1000"},
+		{Amount{}, "0.00"},
+	}
+	for _, tt := range tests {
+		if got := tt.amount.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	// 0.1 + 0.2 使用浮点数时不等于0.3
+	sum := MustParse("0.1", "CNY").Add(MustParse("0.2", "CNY"))
+	if sum.Cmp(MustParse("0.3", "CNY")) != 0 {
+		t.Fatalf("0.1 + 0.2 = %s", sum)
+	}
+	if got := MustParse("19.99", "CNY").Mul(3); got.String() != "59.97" {
+		t.Fatalf("19.99 * 3 = %s", got)
+	}
+	var total Amount
+	total = total.Add(MustParse("5", "USD"))
+	if total.Currency() != "USD" || total.String() != "5.00" {
+		t.Fatalf("zero value + 5 USD = %s", total.Display())
+	}
+	if _, err := New(math.MaxInt64, "CNY").CheckedAdd(New(1, "CNY")); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("CheckedAdd overflow err = %v, want %v", err, ErrOverflow)
+	}
+	if _, err := New(math.MaxInt64/2+1, "CNY").CheckedMul(2); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("CheckedMul overflow err = %v, want %v", err, ErrOverflow)
+	}
+
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrCurrencyMismatch) {
+			t.Fatalf("recover() = %v, want %v", err, ErrCurrencyMismatch)
+		}
+	}()
+	MustParse("1", "CNY").Add(MustParse("1", "USD"))
+}
+
+func TestMulFrac(t *testing.T) {
+	tests := []struct {
+		amount   string
+		num, den int64
+		mode     RoundingMode
+		want     string
+	}{
+		{"0.05", 1, 2, RoundHalfUp, "0.03"},
+		{"0.05", 1, 2, RoundHalfEven, "0.02"},
+		{"0.07", 1, 2, RoundHalfEven, "0.04"},
+		{"-0.05", 1, 2, RoundHalfUp, "-0.03"},
+		{"-0.05", 1, 2, RoundHalfEven, "-0.02"},
+		{"10.00", 1, 3, RoundDown, "3.33"},
+		{"10.00", 1, 3, RoundUp, "3.34"},
+		{"-10.00", 1, 3, RoundUp, "-3.34"},
+		{"99.99", 85, 100, RoundHalfUp, "84.99"},
+	}
+	for _, tt := range tests {
+		got := MustParse(tt.amount, "CNY").MulFrac(tt.num, tt.den, tt.mode)
+		if got.String() != tt.want {
+			t.Errorf("%s * %d/%d (mode %d) = %s, want %s", tt.amount, tt.num, tt.den, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		amount  string
+		weights []int64
+		want    []string
+	}{
+		{"10.00", []int64{1, 1, 1}, []string{"3.34", "3.33", "3.33"}},
+		{"0.05", []int64{3, 7}, []string{"0.02", "0.03"}},
+		{"0.10", []int64{1, 2}, []string{"0.03", "0.07"}},
+		{"-10.00", []int64{1, 1, 1}, []string{"-3.34", "-3.33", "-3.33"}},
+		{"1.00", []int64{0, 1}, []string{"0.00", "1.00"}},
+		{"1.00", []int64{0, 0}, []string{"0.00", "0.00"}},
+	}
+	for _, tt := range tests {
+		parts := MustParse(tt.amount, "CNY").Allocate(tt.weights)
+		for i, p := range parts {
+			if p.String() != tt.want[i] {
+				t.Errorf("Allocate(%s, %v) = %v, want %v", tt.amount, tt.weights, parts, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(struct{ Price Amount }{MustParse("5999", "CNY")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"Price":"5999.00"}` {
+		t.Fatalf("marshal = %s", data)
+	}
+
+	for _, in := range []string{`"0.30"`, `0.3`, `0.30`} {
+		var a Amount
+		if err := json.Unmarshal([]byte(in), &a); err != nil {
+			t.Fatalf("unmarshal %s: %v", in, err)
+		}
+		if a.Minor() != 30 {
+			t.Fatalf("unmarshal %s = %d", in, a.Minor())
+		}
+	}
+	var a Amount
+	if err := json.Unmarshal([]byte(`"0.001"`), &a); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidAmount)
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want int64
+	}{
+		{[]byte("5999.00"), 599900},
+		{"0.10", 10},
+		{int64(5999), 599900},
+		{0.1 + 0.2, 30},
+		{nil, 0},
+	}
+	for _, tt := range tests {
+		var a Amount
+		if err := a.Scan(tt.src); err != nil {
+			t.Fatalf("Scan(%v): %v", tt.src, err)
+		}
+		if a.Minor() != tt.want || a.Currency() != DefaultCurrency() {
+			t.Fatalf("Scan(%v) = %s", tt.src, a.Display())
+		}
+	}
+}
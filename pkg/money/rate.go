@@ -0,0 +1,228 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRateUnavailable 没有两个币种之间的汇率
+var ErrRateUnavailable = errors.New("money: exchange rate unavailable")
+
+// RateDigits 汇率保留的小数位数，超出的部分按银行家舍入
+const RateDigits = 8
+
+// rateScale 汇率的定点分母，即10^RateDigits
+var rateScale = big.NewInt(100000000)
+
+// Rate 汇率，1单位From币种兑换Value单位To币种
+// 以RateDigits位定点小数保存，换算结果可以由String返回的汇率重现
+type Rate struct {
+	From  string
+	To    string
+	value int64 // 汇率乘以10^RateDigits
+}
+
+// Identity 返回币种到自身的汇率1
+func Identity(currency string) Rate {
+	return Rate{From: currency, To: currency, value: rateScale.Int64()}
+}
+
+// ParseRate 解析十进制字符串表示的汇率，如ParseRate("CNY", "USD", "0.1384")
+// 汇率必须大于0，小数位数不能超过RateDigits
+func ParseRate(from, to, s string) (Rate, error) {
+	for _, code := range []string{from, to} {
+		if _, ok := Digits(code); !ok {
+			return Rate{}, fmt.Errorf("%w: %s", ErrUnknownCurrency, code)
+		}
+	}
+	value, err := parseMinor(strings.TrimSpace(s), RateDigits)
+	if err != nil || value <= 0 {
+		return Rate{}, fmt.Errorf("%w: rate %q", ErrInvalidAmount, s)
+	}
+	return Rate{From: from, To: to, value: value}, nil
+}
+
+// Convert 按汇率把From币种的金额换算为To币种，按mode舍入到To币种的最小货币单位
+// 金额币种与From不同时panic
+func (r Rate) Convert(a Amount, mode RoundingMode) Amount {
+	if a.Currency() != r.From {
+		panic(fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, a.Currency(), r.From))
+	}
+	// minor_to = minor_from × rate × 10^digits(To) ÷ 10^digits(From)
+	num := new(big.Int).Mul(big.NewInt(a.minor), big.NewInt(r.value))
+	num.Mul(num, pow10(digitsOf(r.To)))
+	den := new(big.Int).Mul(rateScale, pow10(digitsOf(r.From)))
+	return Amount{minor: toInt64(divRound(num, den, mode)), currency: r.To}
+}
+
+// Inverse 返回反向汇率，按银行家舍入保留RateDigits位小数
+func (r Rate) Inverse() Rate {
+	value := divRound(new(big.Int).Mul(rateScale, rateScale), big.NewInt(r.value), RoundHalfEven)
+	return Rate{From: r.To, To: r.From, value: toInt64(value)}
+}
+
+// String 返回十进制字符串，省略末尾的0，如"0.1384"
+func (r Rate) String() string {
+	s := strconv.FormatInt(r.value, 10)
+	if len(s) <= RateDigits {
+		s = strings.Repeat("0", RateDigits-len(s)+1) + s
+	}
+	s = s[:len(s)-RateDigits] + "." + s[len(s)-RateDigits:]
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// RateProvider 汇率来源
+type RateProvider interface {
+	// Rate 返回from到to的汇率，没有汇率时返回ErrRateUnavailable
+	Rate(from, to string) (Rate, error)
+	// Currencies 返回有汇率的全部币种，按代码排序
+	Currencies() []string
+}
+
+// StaticRates 固定汇率表，各币种的汇率都相对同一个基准币种报价
+// 两个非基准币种之间的汇率经基准币种交叉换算
+type StaticRates struct {
+	base  string
+	rates map[string]Rate // 基准币种到各币种的汇率
+}
+
+// NewStaticRates 创建固定汇率表，rates的键为币种代码，值为1基准币种可兑换的数量
+// 如NewStaticRates("CNY", map[string]string{"USD": "0.1384"})
+func NewStaticRates(base string, rates map[string]string) (*StaticRates, error) {
+	base, err := NormalizeCurrency(base)
+	if err != nil {
+		return nil, err
+	}
+	s := &StaticRates{base: base, rates: map[string]Rate{base: Identity(base)}}
+	for code, value := range rates {
+		code, err := NormalizeCurrency(code)
+		if err != nil {
+			return nil, err
+		}
+		rate, err := ParseRate(base, code, value)
+		if err != nil {
+			return nil, err
+		}
+		if code == base && rate.value != rateScale.Int64() {
+			return nil, fmt.Errorf("%w: base currency %s must have rate 1", ErrInvalidAmount, base)
+		}
+		s.rates[code] = rate
+	}
+	return s, nil
+}
+
+// Rate 实现RateProvider
+func (s *StaticRates) Rate(from, to string) (Rate, error) {
+	fromRate, ok := s.rates[from]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w: %s to %s", ErrRateUnavailable, from, to)
+	}
+	toRate, ok := s.rates[to]
+	if !ok {
+		return Rate{}, fmt.Errorf("%w: %s to %s", ErrRateUnavailable, from, to)
+	}
+	switch {
+	case from == to:
+		return Identity(from), nil
+	case from == s.base:
+		return toRate, nil
+	case to == s.base:
+		return fromRate.Inverse(), nil
+	}
+	// 交叉汇率 from→to = (base→to) ÷ (base→from)
+	value := divRound(new(big.Int).Mul(big.NewInt(toRate.value), rateScale), big.NewInt(fromRate.value), RoundHalfEven)
+	return Rate{From: from, To: to, value: toInt64(value)}, nil
+}
+
+// Currencies 实现RateProvider
+func (s *StaticRates) Currencies() []string {
+	codes := make([]string, 0, len(s.rates))
+	for code := range s.rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// rateFile 汇率文件格式
+// {"base": "CNY", "rates": {"USD": "0.1384", "JPY": "20.65"}}
+type rateFile struct {
+	Base  string            `json:"base"`
+	Rates map[string]string `json:"rates"`
+}
+
+// FileRates 从JSON文件读取的汇率表，文件修改后在下次查询时重新读取
+// 重新读取失败时继续使用上一次成功读取的汇率，便于在运行中更新汇率文件
+type FileRates struct {
+	path string
+
+	mu      sync.Mutex
+	rates   *StaticRates
+	modTime time.Time
+}
+
+// NewFileRates 读取汇率文件，文件不存在或格式错误时返回错误
+func NewFileRates(path string) (*FileRates, error) {
+	f := &FileRates{path: path}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Reload 文件修改时间变化后重新读取汇率文件
+func (f *FileRates) Reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.rates != nil && info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	var file rateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("parse rate file %s: %w", f.path, err)
+	}
+	rates, err := NewStaticRates(file.Base, file.Rates)
+	if err != nil {
+		return fmt.Errorf("parse rate file %s: %w", f.path, err)
+	}
+	f.rates, f.modTime = rates, info.ModTime()
+	return nil
+}
+
+func (f *FileRates) current() *StaticRates {
+	_ = f.Reload()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rates
+}
+
+// Rate 实现RateProvider
+func (f *FileRates) Rate(from, to string) (Rate, error) {
+	return f.current().Rate(from, to)
+}
+
+// Currencies 实现RateProvider
+func (f *FileRates) Currencies() []string {
+	return f.current().Currencies()
+}
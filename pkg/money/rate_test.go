@@ -0,0 +1,114 @@
+package money
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRateConvert(t *testing.T) {
+	tests := []struct {
+		from, to, rate string
+		amount         Amount
+		want           string
+	}{
+		{"CNY", "USD", "0.1384", MustParse("5999", "CNY"), "830.26"},
+		{"CNY", "JPY", "20.65", MustParse("5999", "CNY"), "123879"},
+		{"JPY", "CNY", "0.0484", MustParse("1000", "JPY"), "48.40"},
+		// 0.125 × 0.01 = 0.00125，银行家舍入到偶数
+		{"CNY", "USD", "0.125", MustParse("0.02", "CNY"), "0.00"},
+		{"CNY", "USD", "0.125", MustParse("0.06", "CNY"), "0.01"},
+	}
+	for _, tt := range tests {
+		rate, err := ParseRate(tt.from, tt.to, tt.rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := rate.Convert(tt.amount, RoundHalfEven)
+		if got.String() != tt.want || got.Currency() != tt.to {
+			t.Errorf("Convert(%s) at %s = %s, want %s %s", tt.amount.Display(), tt.rate, got.Display(), tt.to, tt.want)
+		}
+	}
+
+	for _, s := range []string{"0", "-1", "0.123456789", "abc"} {
+		if _, err := ParseRate("CNY", "USD", s); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("ParseRate(%q) err = %v, want ErrInvalidAmount", s, err)
+		}
+	}
+}
+
+func TestStaticRates(t *testing.T) {
+	rates, err := NewStaticRates("CNY", map[string]string{"usd": "0.125", "JPY": "20"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		from, to, want string
+	}{
+		{"CNY", "USD", "0.125"},
+		{"USD", "CNY", "8"},
+		{"USD", "JPY", "160"},
+		{"JPY", "USD", "0.00625"},
+		{"JPY", "JPY", "1"},
+	}
+	for _, tt := range tests {
+		rate, err := rates.Rate(tt.from, tt.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rate.String() != tt.want || rate.From != tt.from || rate.To != tt.to {
+			t.Errorf("Rate(%s, %s) = %s, want %s", tt.from, tt.to, rate, tt.want)
+		}
+	}
+
+	if _, err := rates.Rate("CNY", "EUR"); !errors.Is(err, ErrRateUnavailable) {
+		t.Errorf("Rate(CNY, EUR) err = %v, want ErrRateUnavailable", err)
+	}
+	if got := rates.Currencies(); len(got) != 3 || got[0] != "CNY" || got[2] != "USD" {
+		t.Errorf("Currencies() = %v", got)
+	}
+	if _, err := NewStaticRates("CNY", map[string]string{"XXX": "1"}); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("unknown currency err = %v", err)
+	}
+}
+
+func TestFileRatesReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	write := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	write(`{"base": "CNY", "rates": {"USD": "0.14"}}`, now.Add(-time.Hour))
+
+	rates, err := NewFileRates(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rate, err := rates.Rate("CNY", "USD")
+	if err != nil || rate.String() != "0.14" {
+		t.Fatalf("Rate = %s, %v", rate, err)
+	}
+
+	// 文件修改后使用新汇率
+	write(`{"base": "CNY", "rates": {"USD": "0.15"}}`, now)
+	if rate, _ := rates.Rate("CNY", "USD"); rate.String() != "0.15" {
+		t.Errorf("after update Rate = %s, want 0.15", rate)
+	}
+
+	// 新文件格式错误时保留上一次的汇率
+	write(`{"base": "CNY", "rates": {"USD": "bad"}}`, now.Add(time.Hour))
+	if rate, _ := rates.Rate("CNY", "USD"); rate.String() != "0.15" {
+		t.Errorf("after bad update Rate = %s, want 0.15", rate)
+	}
+	if err := rates.Reload(); err == nil {
+		t.Error("Reload() with bad file should fail")
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"errors"
+	"log"
+	"myshop/pkg/cache"
+	"time"
+)
+
+// TokenDenylist 访问令牌黑名单
+// 按jti记录已吊销的令牌，缓存过期时间与令牌自身的过期时间一致
+type TokenDenylist struct {
+	cache cache.Cache
+}
+
+// NewTokenDenylist 创建令牌黑名单
+func NewTokenDenylist(c cache.Cache) *TokenDenylist {
+	return &TokenDenylist{cache: c}
+}
+
+// Revoke 吊销令牌直到其过期
+func (d *TokenDenylist) Revoke(jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	return d.cache.Set("jwt:deny:"+jti, true, ttl)
+}
+
+// IsRevoked 判断令牌是否已被吊销
+// 缓存不可用时无法确认令牌未被吊销，按已吊销处理
+func (d *TokenDenylist) IsRevoked(jti string) bool {
+	if jti == "" {
+		return false
+	}
+	_, err := d.cache.Get("jwt:deny:" + jti)
+	if errors.Is(err, cache.ErrNotFound) {
+		return false
+	}
+	if err != nil {
+		log.Printf("查询令牌黑名单失败: %v", err)
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"errors"
+	"myshop/pkg/cache"
+	"testing"
+	"time"
+)
+
+// downCache 模拟连接失败的缓存
+type downCache struct{ cache.Cache }
+
+func (downCache) Get(string) (interface{}, error) { return nil, errors.New("connection refused") }
+
+func TestTokenDenylistIsRevoked(t *testing.T) {
+	memCache := cache.NewMemoryCache(cache.MemoryOptions{})
+	defer memCache.Close()
+	denylist := NewTokenDenylist(memCache)
+	denylist.Revoke("revoked", time.Now().Add(time.Minute))
+
+	tests := []struct {
+		name     string
+		denylist *TokenDenylist
+		jti      string
+		want     bool
+	}{
+		{name: "已吊销", denylist: denylist, jti: "revoked", want: true},
+		{name: "未吊销", denylist: denylist, jti: "active"},
+		{name: "缓存不可用按已吊销处理", denylist: NewTokenDenylist(downCache{}), jti: "active", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.denylist.IsRevoked(tt.jti); got != tt.want {
+				t.Errorf("IsRevoked(%s) = %v, 期望 %v", tt.jti, got, tt.want)
+			}
+		})
+	}
+}
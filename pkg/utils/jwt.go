@@ -1,35 +1,151 @@
 package utils
 
 import (
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
-var jwtSecret = []byte("your-secret-key")
+// clockSkew 校验exp/nbf时允许的时钟偏差
+const clockSkew = 30 * time.Second
 
-func GenerateToken(userID uint) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+var (
+	ErrTokenMissingKid = errors.New("token missing kid header")
+	ErrTokenClaims     = errors.New("token claims invalid")
+)
+
+// JWTOptions 令牌签发与校验参数
+type JWTOptions struct {
+	KeyRing   *KeyRing      // 签名与验签密钥环
+	Issuer    string        // 签发者(iss)
+	Audience  string        // 受众(aud)
+	AccessTTL time.Duration // 访问令牌有效期
+}
+
+// jwtOptions 访问令牌默认参数，访问令牌短期有效，过期后使用刷新令牌换取
+var jwtOptions = JWTOptions{
+	KeyRing:   NewKeyRing(),
+	Issuer:    "myshop",
+	Audience:  "myshop-api",
+	AccessTTL: 15 * time.Minute,
+}
+
+// InitJWT 设置令牌参数，未设置的字段保留默认值
+func InitJWT(opts JWTOptions) {
+	if opts.KeyRing != nil {
+		jwtOptions.KeyRing = opts.KeyRing
+	}
+	if opts.Issuer != "" {
+		jwtOptions.Issuer = opts.Issuer
+	}
+	if opts.Audience != "" {
+		jwtOptions.Audience = opts.Audience
+	}
+	if opts.AccessTTL > 0 {
+		jwtOptions.AccessTTL = opts.AccessTTL
+	}
+}
+
+// AccessTokenTTL 返回访问令牌有效期
+func AccessTokenTTL() time.Duration {
+	return jwtOptions.AccessTTL
+}
+
+// Claims token中携带的用户身份信息
+type Claims struct {
+	ID        string // 令牌唯一标识(jti)，用于吊销
+	UserID    uint
+	SessionID uint // 登录会话ID(sid)，会话吊销后令牌随之失效
+	Role      string
+	MFA       bool // 是否通过了两步验证
+	ExpiresAt time.Time
+}
+
+// GenerateToken 使用当前签名密钥签发访问令牌，头部携带kid以便验签方选择公钥
+func GenerateToken(userID, sessionID uint, role string, mfa bool) (string, error) {
+	key, err := jwtOptions.KeyRing.Signer()
+	if err != nil {
+		return "", err
+	}
+
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(key.Method, jwt.MapClaims{
+		"jti":     jti,
+		"iss":     jwtOptions.Issuer,
+		"aud":     jwtOptions.Audience,
 		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
+		"sid":     sessionID,
+		"role":    role,
+		"mfa":     mfa,
+		"iat":     now.Unix(),
+		"nbf":     now.Unix(),
+		"exp":     now.Add(jwtOptions.AccessTTL).Unix(),
 	})
+	token.Header["kid"] = key.ID
 
-	return token.SignedString(jwtSecret)
+	return token.SignedString(key.Private)
 }
 
-func ValidateToken(tokenString string) (uint, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+// ValidateToken 严格校验访问令牌
+// 1. 算法必须是密钥环中的算法，且与kid对应密钥的算法一致
+// 2. exp、nbf必须存在且在有效期内
+// 3. iss、aud必须与本服务一致
+func ValidateToken(tokenString string) (*Claims, error) {
+	ring := jwtOptions.KeyRing
+	parser := &jwt.Parser{
+		ValidMethods:         ring.Methods(),
+		SkipClaimsValidation: true,
+	}
 
+	token, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, ErrTokenMissingKid
+		}
+		key, err := ring.Get(kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return key.Public, nil
+	})
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrInvalidKey
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := uint(claims["user_id"].(float64))
-		return userID, nil
+	now := time.Now()
+	if !claims.VerifyExpiresAt(now.Add(-clockSkew).Unix(), true) ||
+		!claims.VerifyNotBefore(now.Add(clockSkew).Unix(), true) ||
+		!claims.VerifyIssuer(jwtOptions.Issuer, true) ||
+		!claims.VerifyAudience(jwtOptions.Audience, true) {
+		return nil, ErrTokenClaims
 	}
 
-	return 0, jwt.ErrInvalidKey
+	userID, _ := claims["user_id"].(float64)
+	sessionID, _ := claims["sid"].(float64)
+	role, _ := claims["role"].(string)
+	mfa, _ := claims["mfa"].(bool)
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+	return &Claims{
+		ID:        jti,
+		UserID:    uint(userID),
+		SessionID: uint(sessionID),
+		Role:      role,
+		MFA:       mfa,
+		ExpiresAt: time.Unix(int64(exp), 0),
+	}, nil
 }
@@ -0,0 +1,281 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// useTestJWT 使用测试密钥环，测试结束后恢复全局参数
+func useTestJWT(t *testing.T, ring *KeyRing) {
+	t.Helper()
+
+	saved := jwtOptions
+	t.Cleanup(func() { jwtOptions = saved })
+	InitJWT(JWTOptions{KeyRing: ring, Issuer: "myshop", Audience: "myshop-api", AccessTTL: 15 * time.Minute})
+}
+
+// newTestRSAKey 生成测试用的RSA密钥
+func newTestRSAKey(t *testing.T, kid string) *SigningKey {
+	t.Helper()
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, Private: priv, Public: &priv.PublicKey}
+}
+
+// newTestEdKey 生成测试用的Ed25519密钥
+func newTestEdKey(t *testing.T, kid string) *SigningKey {
+	t.Helper()
+
+	key, err := GenerateEd25519Key(kid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// signClaims 用指定密钥和kid签发任意声明，用于构造各种异常令牌
+func signClaims(t *testing.T, key *SigningKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// validClaims 返回一组能通过校验的声明，overrides中值为nil的键会被删除
+func validClaims(overrides jwt.MapClaims) jwt.MapClaims {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"jti":     "test-jti",
+		"iss":     "myshop",
+		"aud":     "myshop-api",
+		"user_id": 7,
+		"sid":     3,
+		"role":    "user",
+		"mfa":     true,
+		"iat":     now.Unix(),
+		"nbf":     now.Unix(),
+		"exp":     now.Add(time.Minute).Unix(),
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(claims, k)
+		} else {
+			claims[k] = v
+		}
+	}
+	return claims
+}
+
+func TestGenerateAndValidateToken(t *testing.T) {
+	ring := NewKeyRing()
+	ring.Add(newTestEdKey(t, "ed"))
+	ring.Use("ed")
+	useTestJWT(t, ring)
+
+	token, err := GenerateToken(7, 3, "admin", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if claims.UserID != 7 || claims.SessionID != 3 || claims.Role != "admin" || !claims.MFA || claims.ID == "" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if d := time.Until(claims.ExpiresAt); d <= 14*time.Minute || d > 15*time.Minute {
+		t.Errorf("过期时间 = %v后, 期望约15分钟", d)
+	}
+}
+
+func TestValidateTokenRejects(t *testing.T) {
+	ed := newTestEdKey(t, "ed")
+	rs := newTestRSAKey(t, "rs")
+	outsider := newTestEdKey(t, "ed")
+
+	ring := NewKeyRing()
+	ring.Add(ed)
+	ring.Add(rs)
+	ring.Use("ed")
+	useTestJWT(t, ring)
+
+	now := time.Now()
+	tests := []struct {
+		name  string
+		token func() string
+		ok    bool
+	}{
+		{
+			name:  "有效令牌",
+			token: func() string { return signClaims(t, ed, "ed", validClaims(nil)) },
+			ok:    true,
+		},
+		{
+			name: "alg为none",
+			token: func() string {
+				token := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims(nil))
+				token.Header["kid"] = "ed"
+				signed, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+				return signed
+			},
+		},
+		{
+			name: "HS256用公钥作为密钥",
+			token: func() string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims(nil))
+				token.Header["kid"] = "ed"
+				signed, _ := token.SignedString([]byte(ed.Public.(ed25519.PublicKey)))
+				return signed
+			},
+		},
+		{
+			name:  "算法与kid对应密钥不一致",
+			token: func() string { return signClaims(t, rs, "ed", validClaims(nil)) },
+		},
+		{
+			name:  "缺少kid",
+			token: func() string { return signClaims(t, ed, "", validClaims(nil)) },
+		},
+		{
+			name:  "未知kid",
+			token: func() string { return signClaims(t, ed, "unknown", validClaims(nil)) },
+		},
+		{
+			name:  "其他密钥冒用kid",
+			token: func() string { return signClaims(t, outsider, "ed", validClaims(nil)) },
+		},
+		{
+			name: "篡改载荷",
+			token: func() string {
+				parts := strings.Split(signClaims(t, ed, "ed", validClaims(nil)), ".")
+				forged := signClaims(t, outsider, "ed", validClaims(jwt.MapClaims{"role": "admin"}))
+				parts[1] = strings.Split(forged, ".")[1]
+				return strings.Join(parts, ".")
+			},
+		},
+		{
+			name:  "签发者错误",
+			token: func() string { return signClaims(t, ed, "ed", validClaims(jwt.MapClaims{"iss": "evil"})) },
+		},
+		{
+			name:  "缺少签发者",
+			token: func() string { return signClaims(t, ed, "ed", validClaims(jwt.MapClaims{"iss": nil})) },
+		},
+		{
+			name:  "受众错误",
+			token: func() string { return signClaims(t, ed, "ed", validClaims(jwt.MapClaims{"aud": "other-api"})) },
+		},
+		{
+			name:  "缺少受众",
+			token: func() string { return signClaims(t, ed, "ed", validClaims(jwt.MapClaims{"aud": nil})) },
+		},
+		{
+			name: "已过期且超出时钟偏差",
+			token: func() string {
+				return signClaims(t, ed, "ed", validClaims(jwt.MapClaims{"exp": now.Add(-clockSkew - time.Second).Unix()}))
+			},
+		},
+		{
+			name: "已过期但在时钟偏差内",
+			token: func() string {
+				return signClaims(t, ed, "ed", validClaims(jwt.MapClaims{"exp": now.Add(-clockSkew / 2).Unix()}))
+			},
+			ok: true,
+		},
+		{
+			name:  "缺少exp",
+			token: func() string { return signClaims(t, ed, "ed", validClaims(jwt.MapClaims{"exp": nil})) },
+		},
+		{
+			name: "尚未生效且超出时钟偏差",
+			token: func() string {
+				return signClaims(t, ed, "ed", validClaims(jwt.MapClaims{"nbf": now.Add(clockSkew + 5*time.Second).Unix()}))
+			},
+		},
+		{
+			name: "尚未生效但在时钟偏差内",
+			token: func() string {
+				return signClaims(t, ed, "ed", validClaims(jwt.MapClaims{"nbf": now.Add(clockSkew / 2).Unix()}))
+			},
+			ok: true,
+		},
+		{
+			name:  "缺少nbf",
+			token: func() string { return signClaims(t, ed, "ed", validClaims(jwt.MapClaims{"nbf": nil})) },
+		},
+		{
+			name:  "格式错误",
+			token: func() string { return "not.a.jwt" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateToken(tt.token())
+			if (err == nil) != tt.ok {
+				t.Errorf("ValidateToken err = %v, 期望通过 = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestValidateTokenKeyRotation(t *testing.T) {
+	oldKey := newTestEdKey(t, "2024")
+	newKey := newTestRSAKey(t, "2025")
+
+	ring := NewKeyRing()
+	ring.Add(oldKey)
+	ring.Use("2024")
+	useTestJWT(t, ring)
+
+	oldToken, err := GenerateToken(1, 1, "user", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 轮换：新密钥负责签名，旧密钥只保留公钥用于验签
+	ring.Add(newKey)
+	if err := ring.Use("2025"); err != nil {
+		t.Fatal(err)
+	}
+	ring.Add(&SigningKey{ID: "2024", Method: oldKey.Method, Public: oldKey.Public})
+
+	newToken, err := GenerateToken(2, 2, "user", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, _ := new(jwt.Parser).ParseUnverified(newToken, jwt.MapClaims{})
+	if parsed.Header["kid"] != "2025" || parsed.Method.Alg() != "RS256" {
+		t.Errorf("轮换后签发的令牌 kid = %v, alg = %s", parsed.Header["kid"], parsed.Method.Alg())
+	}
+
+	for name, token := range map[string]string{"旧密钥签发": oldToken, "新密钥签发": newToken} {
+		if _, err := ValidateToken(token); err != nil {
+			t.Errorf("%s的令牌应继续有效: %v", name, err)
+		}
+	}
+
+	// 旧令牌全部过期后移除旧密钥
+	ring.Remove("2024")
+	if _, err := ValidateToken(oldToken); err == nil {
+		t.Error("移除旧密钥后旧令牌仍能通过校验")
+	}
+	if _, err := ValidateToken(newToken); err != nil {
+		t.Errorf("移除旧密钥不应影响新令牌: %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt"
+)
+
+var (
+	ErrUnknownKey        = errors.New("unknown signing key")
+	ErrNoSigningKey      = errors.New("no signing key configured")
+	ErrUnsupportedKeyAlg = errors.New("unsupported signing algorithm")
+)
+
+// SigningKey 令牌签名密钥
+// Private为空的密钥只用于验签，通常是已轮换下来、等待旧令牌自然过期的密钥
+type SigningKey struct {
+	ID      string            // 密钥ID，写入令牌头部的kid
+	Method  jwt.SigningMethod // 签名算法，RS256或EdDSA
+	Private crypto.PrivateKey // 私钥
+	Public  crypto.PublicKey  // 公钥
+}
+
+// KeyRing 密钥环
+// 当前密钥负责签名，环中所有密钥都可用于验签，从而支持密钥轮换
+type KeyRing struct {
+	mu      sync.RWMutex
+	keys    map[string]*SigningKey
+	current string
+}
+
+// NewKeyRing 创建空的密钥环
+func NewKeyRing() *KeyRing {
+	return &KeyRing{keys: make(map[string]*SigningKey)}
+}
+
+// Add 添加密钥，已存在的同名密钥会被替换
+func (r *KeyRing) Add(key *SigningKey) error {
+	switch key.Method {
+	case jwt.SigningMethodRS256:
+		if _, ok := key.Public.(*rsa.PublicKey); !ok {
+			return fmt.Errorf("密钥%s不是RSA公钥", key.ID)
+		}
+	case jwt.SigningMethodEdDSA:
+		if _, ok := key.Public.(ed25519.PublicKey); !ok {
+			return fmt.Errorf("密钥%s不是Ed25519公钥", key.ID)
+		}
+	default:
+		return ErrUnsupportedKeyAlg
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[key.ID] = key
+	return nil
+}
+
+// Use 切换签名密钥，新签发的令牌使用该密钥，旧密钥继续用于验签
+func (r *KeyRing) Use(kid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[kid]
+	if !ok {
+		return ErrUnknownKey
+	}
+	if key.Private == nil {
+		return fmt.Errorf("密钥%s没有私钥，不能用于签名", kid)
+	}
+	r.current = kid
+	return nil
+}
+
+// Remove 移除密钥，使用该密钥签发的令牌将无法通过校验
+func (r *KeyRing) Remove(kid string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.keys, kid)
+	if r.current == kid {
+		r.current = ""
+	}
+}
+
+// Signer 返回当前签名密钥
+func (r *KeyRing) Signer() (*SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[r.current]
+	if !ok {
+		return nil, ErrNoSigningKey
+	}
+	return key, nil
+}
+
+// Get 根据kid获取验签密钥
+func (r *KeyRing) Get(kid string) (*SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// Methods 返回环中密钥使用的全部签名算法
+func (r *KeyRing) Methods() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range r.keys {
+		alg := key.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// JWK JSON Web Key，只包含公钥部分
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 导出全部验签公钥，供其他服务校验本服务签发的令牌
+func (r *KeyRing) JWKS() JWKS {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set := JWKS{Keys: make([]JWK, 0, len(r.keys))}
+	for _, key := range r.keys {
+		jwk := JWK{Use: "sig", Alg: key.Method.Alg(), Kid: key.ID}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// LoadSigningKey 从PEM文件加载密钥
+// 提供私钥文件时公钥由私钥推导；只提供公钥文件时该密钥仅用于验签
+func LoadSigningKey(kid, alg, privateKeyFile, publicKeyFile string) (*SigningKey, error) {
+	key := &SigningKey{ID: kid}
+
+	var pemBytes []byte
+	var err error
+	private := privateKeyFile != ""
+	if private {
+		pemBytes, err = os.ReadFile(privateKeyFile)
+	} else {
+		pemBytes, err = os.ReadFile(publicKeyFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥%s失败: %w", kid, err)
+	}
+
+	switch alg {
+	case "RS256":
+		key.Method = jwt.SigningMethodRS256
+		if private {
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+			if err != nil {
+				return nil, fmt.Errorf("解析密钥%s失败: %w", kid, err)
+			}
+			key.Private, key.Public = priv, &priv.PublicKey
+		} else {
+			pub, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+			if err != nil {
+				return nil, fmt.Errorf("解析密钥%s失败: %w", kid, err)
+			}
+			key.Public = pub
+		}
+	case "EdDSA":
+		key.Method = jwt.SigningMethodEdDSA
+		if private {
+			priv, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+			if err != nil {
+				return nil, fmt.Errorf("解析密钥%s失败: %w", kid, err)
+			}
+			edKey := priv.(ed25519.PrivateKey)
+			key.Private, key.Public = edKey, edKey.Public()
+		} else {
+			pub, err := jwt.ParseEdPublicKeyFromPEM(pemBytes)
+			if err != nil {
+				return nil, fmt.Errorf("解析密钥%s失败: %w", kid, err)
+			}
+			key.Public = pub
+		}
+	default:
+		return nil, ErrUnsupportedKeyAlg
+	}
+
+	return key, nil
+}
+
+// GenerateEd25519Key 生成临时Ed25519密钥，仅用于未配置密钥的开发环境
+func GenerateEd25519Key(kid string) (*SigningKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &SigningKey{
+		ID:      kid,
+		Method:  jwt.SigningMethodEdDSA,
+		Private: priv,
+		Public:  pub,
+	}, nil
+}
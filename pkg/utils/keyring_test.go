@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestKeyRingAdd(t *testing.T) {
+	ed := newTestEdKey(t, "ed")
+	rs := newTestRSAKey(t, "rs")
+
+	tests := []struct {
+		name    string
+		key     *SigningKey
+		wantErr bool
+	}{
+		{name: "Ed25519", key: ed},
+		{name: "RS256", key: rs},
+		{name: "RS256配Ed25519公钥", key: &SigningKey{ID: "x", Method: jwt.SigningMethodRS256, Public: ed.Public}, wantErr: true},
+		{name: "EdDSA配RSA公钥", key: &SigningKey{ID: "x", Method: jwt.SigningMethodEdDSA, Public: rs.Public}, wantErr: true},
+		{name: "不支持的算法", key: &SigningKey{ID: "x", Method: jwt.SigningMethodHS256, Public: []byte("secret")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := NewKeyRing()
+			err := ring.Add(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Add err = %v, 期望出错 = %v", err, tt.wantErr)
+			}
+			if _, getErr := ring.Get(tt.key.ID); (getErr == nil) == tt.wantErr {
+				t.Errorf("添加后Get err = %v", getErr)
+			}
+		})
+	}
+}
+
+func TestKeyRingUseAndRemove(t *testing.T) {
+	ring := NewKeyRing()
+	if _, err := ring.Signer(); err != ErrNoSigningKey {
+		t.Fatalf("空密钥环 Signer err = %v, 期望 %v", err, ErrNoSigningKey)
+	}
+
+	active := newTestEdKey(t, "active")
+	ring.Add(active)
+	ring.Add(&SigningKey{ID: "verify-only", Method: active.Method, Public: active.Public})
+
+	tests := []struct {
+		name    string
+		kid     string
+		wantErr bool
+	}{
+		{name: "未知密钥", kid: "unknown", wantErr: true},
+		{name: "只有公钥的密钥不能签名", kid: "verify-only", wantErr: true},
+		{name: "带私钥的密钥", kid: "active"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ring.Use(tt.kid); (err != nil) != tt.wantErr {
+				t.Errorf("Use(%s) err = %v, 期望出错 = %v", tt.kid, err, tt.wantErr)
+			}
+		})
+	}
+
+	if key, err := ring.Signer(); err != nil || key.ID != "active" {
+		t.Fatalf("Signer = %v, %v", key, err)
+	}
+	ring.Remove("active")
+	if _, err := ring.Signer(); err != ErrNoSigningKey {
+		t.Errorf("移除当前密钥后 Signer err = %v, 期望 %v", err, ErrNoSigningKey)
+	}
+	if _, err := ring.Get("active"); err != ErrUnknownKey {
+		t.Errorf("移除后 Get err = %v, 期望 %v", err, ErrUnknownKey)
+	}
+}
+
+func TestKeyRingMethodsAndJWKS(t *testing.T) {
+	ring := NewKeyRing()
+	ring.Add(newTestEdKey(t, "b"))
+	ring.Add(newTestEdKey(t, "a"))
+	ring.Add(newTestRSAKey(t, "c"))
+
+	if methods := ring.Methods(); len(methods) != 2 {
+		t.Errorf("Methods = %v, 期望去重后2种算法", methods)
+	}
+
+	set := ring.JWKS()
+	want := []struct{ kid, kty, alg string }{{"a", "OKP", "EdDSA"}, {"b", "OKP", "EdDSA"}, {"c", "RSA", "RS256"}}
+	if len(set.Keys) != len(want) {
+		t.Fatalf("JWKS = %+v", set)
+	}
+	for i, w := range want {
+		k := set.Keys[i]
+		if k.Kid != w.kid || k.Kty != w.kty || k.Alg != w.alg || k.Use != "sig" {
+			t.Errorf("Keys[%d] = %+v, 期望 %+v", i, k, w)
+		}
+	}
+	if set.Keys[0].Crv != "Ed25519" || set.Keys[0].X == "" || set.Keys[2].N == "" || set.Keys[2].E != "AQAB" {
+		t.Errorf("公钥参数不完整: %+v", set.Keys)
+	}
+}
+
+// writePEM 把DER编码的密钥写入临时PEM文件
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSigningKey(t *testing.T) {
+	ed := newTestEdKey(t, "ed")
+	edDER, err := x509.MarshalPKCS8PrivateKey(ed.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsPubDER, err := x509.MarshalPKIXPublicKey(&rsPriv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edPrivate := writePEM(t, "ed.pem", "PRIVATE KEY", edDER)
+	rsPrivate := writePEM(t, "rs.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsPriv))
+	rsPublic := writePEM(t, "rs.pub.pem", "PUBLIC KEY", rsPubDER)
+
+	tests := []struct {
+		name        string
+		alg         string
+		private     string
+		public      string
+		wantPrivate bool
+		wantErr     bool
+		errIs       error // 期望错误链中包含的错误，为空时不检查
+	}{
+		{name: "EdDSA私钥", alg: "EdDSA", private: edPrivate, wantPrivate: true},
+		{name: "RS256私钥", alg: "RS256", private: rsPrivate, wantPrivate: true},
+		{name: "RS256只有公钥", alg: "RS256", public: rsPublic},
+		{name: "算法与密钥不符", alg: "RS256", private: edPrivate, wantErr: true},
+		{name: "不支持的算法", alg: "HS256", private: edPrivate, wantErr: true, errIs: ErrUnsupportedKeyAlg},
+		{name: "文件不存在", alg: "EdDSA", private: filepath.Join(t.TempDir(), "missing.pem"), wantErr: true, errIs: os.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := LoadSigningKey("kid", tt.alg, tt.private, tt.public)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("期望加载失败")
+				}
+				if tt.errIs != nil && !errors.Is(err, tt.errIs) {
+					t.Errorf("err = %v, 期望 %v", err, tt.errIs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key.Method.Alg() != tt.alg || (key.Private != nil) != tt.wantPrivate || key.Public == nil {
+				t.Errorf("key = %+v", key)
+			}
+			if err := NewKeyRing().Add(key); err != nil {
+				t.Errorf("加载的密钥不能加入密钥环: %v", err)
+			}
+		})
+	}
+}
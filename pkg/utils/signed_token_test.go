@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSignedToken(t *testing.T) {
+	secret := []byte("test-secret")
+	valid := SignedToken{Purpose: "verify_email", UserID: 7, Nonce: "n1", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	issue := func(secret []byte, st SignedToken) string {
+		token, err := SignToken(secret, st)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	good := issue(secret, valid)
+	payload, sig, _ := strings.Cut(good, ".")
+
+	// tamper 修改载荷中的用户ID但保留原签名
+	tamper := func() string {
+		raw, _ := base64.RawURLEncoding.DecodeString(payload)
+		var st SignedToken
+		json.Unmarshal(raw, &st)
+		st.UserID = 1
+		forged, _ := json.Marshal(st)
+		return base64.RawURLEncoding.EncodeToString(forged) + "." + sig
+	}
+
+	expired := valid
+	expired.ExpiresAt = time.Now().Add(-time.Second).Unix()
+
+	tests := []struct {
+		name    string
+		token   string
+		purpose string
+		want    error
+	}{
+		{name: "有效令牌", token: good, purpose: "verify_email"},
+		{name: "已过期", token: issue(secret, expired), purpose: "verify_email", want: ErrSignedTokenExpired},
+		{name: "篡改载荷", token: tamper(), purpose: "verify_email", want: ErrSignedTokenInvalid},
+		{name: "篡改签名", token: payload + "." + strings.Repeat("A", len(sig)), purpose: "verify_email", want: ErrSignedTokenInvalid},
+		{name: "其他密钥签发", token: issue([]byte("other-secret"), valid), purpose: "verify_email", want: ErrSignedTokenInvalid},
+		{name: "用途不符", token: good, purpose: "reset_password", want: ErrSignedTokenInvalid},
+		{name: "缺少签名", token: payload, purpose: "verify_email", want: ErrSignedTokenInvalid},
+		{name: "签名正确但载荷不是JSON", token: "bm90LWpzb24." + sign(secret, "bm90LWpzb24"), purpose: "verify_email", want: ErrSignedTokenInvalid},
+		{name: "空令牌", token: "", purpose: "verify_email", want: ErrSignedTokenInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSignedToken(secret, tt.token, tt.purpose)
+			if err != tt.want {
+				t.Fatalf("err = %v, 期望 %v", err, tt.want)
+			}
+			if err == nil && *got != valid {
+				t.Errorf("解析结果 = %+v, 期望 %+v", got, valid)
+			}
+		})
+	}
+}
+
+func TestParseSignedTokenRejectsForgedExpiry(t *testing.T) {
+	secret := []byte("test-secret")
+	token, err := SignToken(secret, SignedToken{Purpose: "reset_password", UserID: 7, ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 延长过期时间后签名不再匹配，不能借此复活过期令牌
+	_, sig, _ := strings.Cut(token, ".")
+	extended, _ := json.Marshal(SignedToken{Purpose: "reset_password", UserID: 7, ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	forged := base64.RawURLEncoding.EncodeToString(extended) + "." + sig
+	if _, err := ParseSignedToken(secret, forged, "reset_password"); err != ErrSignedTokenInvalid {
+		t.Errorf("err = %v, 期望 %v", err, ErrSignedTokenInvalid)
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret RFC 6238附录B中SHA1测试向量的密钥"12345678901234567890"
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238给出的是8位验证码，取末6位
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil || got != tt.want {
+			t.Errorf("TOTPCode(T=%d) = %s, %v, 期望 %s", tt.unix, got, err, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	step := TOTPStep(now)
+	code := func(s int64) string {
+		c, err := TOTPCode(rfc6238Secret, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		ok       bool
+	}{
+		{name: "当前时间步", code: code(step), wantStep: step, ok: true},
+		{name: "前一个时间步", code: code(step - 1), wantStep: step - 1, ok: true},
+		{name: "后一个时间步", code: code(step + 1), wantStep: step + 1, ok: true},
+		{name: "超出时钟偏差", code: code(step - 2)},
+		{name: "首尾空白", code: " " + code(step) + " ", wantStep: step, ok: true},
+		{name: "位数不对", code: code(step)[:5]},
+		{name: "错误的验证码", code: "000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ValidateTOTP(rfc6238Secret, tt.code, now)
+			if ok != tt.ok || (ok && got != tt.wantStep) {
+				t.Errorf("ValidateTOTP = %d, %v, 期望 %d, %v", got, ok, tt.wantStep, tt.ok)
+			}
+		})
+	}
+
+	if _, ok := ValidateTOTP("not base32!", code(step), now); ok {
+		t.Error("非法密钥不应通过校验")
+	}
+}